@@ -1,35 +1,189 @@
 package api
 
 import (
-	"encoding/base64"
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
-	"gonum.org/v1/gonum/mat"
 
 	statsanal "github.com/yodeman/analyses-api/stats-analyses"
 )
 
-// Response format for regression request
+// Response format for regression request.
+// `Coeffs` and `Tstats` are only set when a formatted rendering is requested.
 type regressionResp struct {
-	Coeffs string `json:"regression_coefficients"`
-	Tstats string `json:"t-test statistics"`
-	Error  string `json:"error"`
+	Result *statsanal.RegressionResult `json:"result"`
+	Coeffs string                      `json:"regression_coefficients,omitempty"`
+	Tstats string                      `json:"t-test statistics,omitempty"`
+	// ModelID is the id of the saved model, only set when `save` is requested.
+	ModelID int64 `json:"model_id,omitempty"`
+	// Rows holds the index in the file of each observation, only set when
+	// `influence` is requested.
+	Rows  []int  `json:"rows,omitempty"`
+	Error string `json:"error"`
 }
 
 // Request format for regression queries.
 type regressionRequest struct {
-	Username string `json:"username" binding:"required,alphanum"`
+	Username   string  `json:"username" binding:"required,alphanum"`
+	FileID     int64   `json:"file_id" binding:"required,min=1"`
+	Version    int32   `json:"version" binding:"omitempty,min=1"`
+	Formatted  bool    `json:"formatted"`
+	Confidence float64 `json:"confidence" binding:"omitempty,gt=0,lt=1"`
+	// fall back to the pseudo-inverse solution for rank deficient data.
+	AllowRankDeficient bool        `json:"allow_rank_deficient"`
+	Target             *columnRef  `json:"target"`
+	Predictors         []columnRef `json:"predictors"`
+	NoIntercept        bool        `json:"no_intercept"`
+	Influence          bool        `json:"influence"`
+	Missing            string      `json:"missing" binding:"omitempty,oneof=listwise mean median ffill"`
+	Save               bool        `json:"save"`
 }
 
+/*
+linearRegression performs multivariable linear regression on one of the
+user's files. The endpoint expects a GET request with a json body with the
+following key:
+
+	`username`   - alphanumeric user's username
+	`file_id`    - id of the user's file to analyse.
+	`version`    - optional, version of the file to analyse, defaults to the
+	               file's current version.
+	`formatted`  - optional, also render coefficients and t-statistics as
+	               python formatted strings.
+	`confidence` - optional, confidence level of the coefficients' intervals
+	               in (0, 1), defaults to 0.95.
+	`allow_rank_deficient` - optional, use the pseudo-inverse solution when
+	               the predictors are collinear instead of failing.
+	`target`     - optional, index or name of the target column, defaults to
+	               the last column.
+	`predictors` - optional, list of indices or names of the predictor
+	               columns, defaults to every column but the target.
+	`no_intercept` - optional, fit the model without an intercept.
+	`influence`  - optional, also compute the influence of each observation.
+	`missing`    - optional, how missing values in the selected columns are
+	               handled: `listwise` (default) drops rows with a missing
+	               value, `mean` and `median` impute the column's mean or
+	               median, `ffill` carries the last observed value forward.
+	`save`       - optional, save the fitted model for predictions at
+	               /models/:id/predict.
+
+With `influence`, the residual, leverage, studentized residual and Cook's
+distance of each observation are ordered like `rows`, the indices in the file
+of the observations, which skip the rows dropped for missing values.
+`influential` holds the observations, as positions in `rows`, whose leverage
+exceeds 2p/n or whose Cook's distance exceeds 4/n, for p coefficients and n
+observations. Studentized residuals and Cook's distances are null for
+observations with a leverage of one.
+
+The request returns response with the following http status codes:
+
+200 - status OK:
+
+	with response body:
+	    {
+	        "result": {
+	            "names": ["intercept", "*****", ...],
+	            "coefficients": [*****],
+	            "standard_errors": [*****],
+	            "t_statistics": [*****],
+	            "p_values": [*****],
+	            "conf_lower": [*****],
+	            "conf_upper": [*****],
+	            "confidence": *****,
+	            "observations": *****,
+	            "residual_df": *****,
+	            "residual_std_error": *****,
+	            "r_squared": *****,
+	            "adj_r_squared": *****,
+	            "f_statistic": *****,
+	            "f_p_value": *****,
+	            "rank": *****,
+	            "rank_deficient": *****,
+	            "condition_number": *****,
+	            "influence": {
+	                "residuals": [*****],
+	                "leverage": [*****],
+	                "studentized_residuals": [*****],
+	                "cooks_distance": [*****],
+	                "leverage_cutoff": *****,
+	                "cooks_cutoff": *****,
+	                "influential": [*****]
+	            }
+	        },
+	        "model_id": *****,
+	        "rows": [*****],
+	        "error":""
+	     }
+
+400 - status Bad Request:
+
+	Error parsing request body, or invalid target or predictor columns.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+401 - status Unauthorized:
+
+	If access token has expired or username in request body don't match username
+	in token payload.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+404 - status Not Found:
+
+	If the user has no file with id `file_id`, or the file has no such
+	version.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+422 - status Unprocessable Entity:
+
+	If the predictors are collinear and `allow_rank_deficient` is not set, the
+	target is constant or fitted exactly, or no data is left after handling
+	missing values.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+501 - status Internal Server Error:
+
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+*/
 func (server *Server) linearRegression(ctx *gin.Context) {
-	var resp regressionResp
-	var req regressionRequest
+	server.serveAnalysis(ctx, &regressionRequest{})
+}
+
+// Response format for a failed analysis.
+type analysisErrResp struct {
+	Result any    `json:"result"`
+	Error  string `json:"error"`
+}
+
+// serveAnalysis binds the request body into `req`, checks it against the
+// authenticated user and responds with the outcome of the analysis.
+func (server *Server) serveAnalysis(ctx *gin.Context, req analysisRequest) {
+	var resp analysisErrResp
 
-	if err := ctx.ShouldBindJSON(&req); err != nil {
+	if err := ctx.ShouldBindJSON(req); err != nil {
 		resp.Error = errResponse(fmt.Errorf(
-			"Error parsing `username` in request body.\n%w", err))
+			"Error parsing request body.\n%w", err))
 		ctx.JSON(http.StatusBadRequest, resp)
 		return
 	}
@@ -42,43 +196,87 @@ func (server *Server) linearRegression(ctx *gin.Context) {
 		return
 	}
 
-	if req.Username != authPayload.Username {
+	if req.owner() != authPayload.Username {
 		resp.Error = errResponse(
 			fmt.Errorf("request `username` and auth `username` don't match."))
 		ctx.JSON(http.StatusUnauthorized, resp)
 		return
 	}
 
-	userFile, err := server.querier.GetFile(ctx, authPayload.Username)
+	body, status, err := req.run(ctx, server, authPayload.Username)
 	if err != nil {
-		resp.Error = errResponse(fmt.Errorf("Error fetching user's file\n%w", err))
-		ctx.JSON(http.StatusInternalServerError, resp)
+		resp.Error = errResponse(err)
+		ctx.JSON(status, resp)
 		return
 	}
+	ctx.JSON(http.StatusOK, body)
+}
+
+func (req *regressionRequest) owner() string {
+	return req.Username
+}
+
+// run performs the regression on the file of user `username` and returns the
+// response body.
+//
+// Returns a non-nil error along with the http status code to respond with if
+// the regression can't be performed.
+func (req *regressionRequest) run(
+	ctx context.Context, server *Server, username string,
+) (any, int, error) {
+	var resp regressionResp
 
-	decoded, err := base64.StdEncoding.DecodeString(userFile.Data)
+	ds, status, err := server.loadDataset(ctx, username, req.FileID, req.Version)
 	if err != nil {
-		resp.Error = errResponse(fmt.Errorf("Error decoding user's file\n%w", err))
-		ctx.JSON(http.StatusInternalServerError, resp)
-		return
+		return nil, status, err
 	}
 
-	var data mat.Dense
-	err = data.UnmarshalBinary(decoded)
+	columns, err := resolveModelColumns(ds.names, req.Target, req.Predictors)
 	if err != nil {
-		resp.Error = errResponse(fmt.Errorf("Error decoding user's file\n%w", err))
-		ctx.JSON(http.StatusInternalServerError, resp)
-		return
+		return nil, http.StatusBadRequest, err
 	}
 
-	coeffs, tstats, err := statsanal.LinearRegression(&data)
+	modelData, rows, err := statsanal.HandleMissing(
+		statsanal.SelectColumns(ds.data, columns),
+		statsanal.MissingStrategy(req.Missing),
+	)
 	if err != nil {
-		resp.Error = errResponse(fmt.Errorf("Error during regression analysis\n%w", err))
-		ctx.JSON(http.StatusInternalServerError, resp)
-		return
+		return nil, http.StatusUnprocessableEntity,
+			fmt.Errorf("Error handling missing values.\n%w", err)
+	}
+
+	result, err := statsanal.LinearRegression(
+		modelData,
+		statsanal.RegressionOptions{
+			Confidence:         req.Confidence,
+			AllowRankDeficient: req.AllowRankDeficient,
+			NoIntercept:        req.NoIntercept,
+			Influence:          req.Influence,
+			Names:              ds.selectNames(columns),
+		},
+	)
+	if err != nil {
+		if errors.Is(err, statsanal.ErrRankDeficient) ||
+			errors.Is(err, statsanal.ErrNoVariance) {
+			return nil, http.StatusUnprocessableEntity, err
+		}
+		return nil, http.StatusInternalServerError,
+			fmt.Errorf("Error during regression analysis\n%w", err)
 	}
 
-	resp.Coeffs = coeffs
-	resp.Tstats = tstats
-	ctx.JSON(http.StatusOK, resp)
+	resp.Result = &result
+	if req.Influence {
+		resp.Rows = rows
+	}
+	if req.Formatted {
+		resp.Coeffs, resp.Tstats = result.Formatted()
+	}
+	if req.Save {
+		resp.ModelID, err = server.saveModel(
+			ctx, username, req.FileID, ds, columns, result)
+		if err != nil {
+			return nil, http.StatusInternalServerError, err
+		}
+	}
+	return resp, http.StatusOK, nil
 }
@@ -2,10 +2,11 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
-	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"math"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -18,6 +19,7 @@ import (
 
 	mockdb "github.com/yodeman/analyses-api/dbase/mock"
 	db "github.com/yodeman/analyses-api/dbase/sqlc"
+	statsanal "github.com/yodeman/analyses-api/stats-analyses"
 	"github.com/yodeman/analyses-api/token"
 	"github.com/yodeman/analyses-api/util"
 )
@@ -30,21 +32,64 @@ func TestLinearRegression(t *testing.T) {
 	matrix := mat.NewDense(rows, cols, data)
 	byteData, err := matrix.MarshalBinary()
 	require.NoError(t, err)
-	encoded := base64.StdEncoding.EncodeToString(byteData)
+	matData := byteData
 
+	collinear := mat.DenseCopyOf(matrix)
+	for i := 0; i < rows; i++ {
+		collinear.Set(i, 1, 2*collinear.At(i, 0))
+	}
+	byteData, err = collinear.MarshalBinary()
+	require.NoError(t, err)
+	collinearResp := db.File{
+		ID:       util.RandomInt(1, 1000),
+		Username: user.Username,
+		Data:     byteData,
+	}
+
+	fileID := util.RandomInt(1, 1000)
+	getFileParams := db.GetFileParams{ID: fileID, Username: user.Username}
 	regReq := regressionRequest{
 		Username: user.Username,
+		FileID:   fileID,
 	}
 	regResp := db.File{
+		ID:       fileID,
+		Username: user.Username,
+		Data:     matData,
+	}
+
+	withMissing := mat.DenseCopyOf(matrix)
+	withMissing.Set(0, 0, math.NaN())
+	withMissing.Set(5, cols-1, math.NaN())
+	byteData, err = withMissing.MarshalBinary()
+	require.NoError(t, err)
+	missingResp := db.File{
 		ID:       util.RandomInt(1, 1000),
 		Username: user.Username,
-		Data:     encoded,
+		Data:     byteData,
 	}
 
+	constant := mat.DenseCopyOf(matrix)
+	for i := 0; i < rows; i++ {
+		constant.Set(i, cols-1, 5)
+	}
+	byteData, err = constant.MarshalBinary()
+	require.NoError(t, err)
+	constantResp := db.File{
+		ID:       fileID,
+		Username: user.Username,
+		Data:     byteData,
+	}
+
+	namedResp := regResp
+	namedResp.ColumnNames = strings.Split("a,b,c,d,e,f,g,h,i,j", ",")
+
+	targetCol := columnIndex(0)
+
 	testCases := []struct {
 		name       string
 		params     regressionRequest
-		buildStubs func(querier *mockdb.MockQuerier)
+		buildStubs func(store *mockdb.MockStore)
 		setupAuth  func(
 			t *testing.T, request *http.Request, tokenMaker *token.PasetoMaker,
 		)
@@ -53,9 +98,9 @@ func TestLinearRegression(t *testing.T) {
 		{
 			name:   "OK",
 			params: regReq,
-			buildStubs: func(querier *mockdb.MockQuerier) {
-				querier.EXPECT().
-					GetFile(gomock.Any(), gomock.Eq(regReq.Username)).
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetFile(gomock.Any(), gomock.Eq(getFileParams)).
 					Times(1).
 					Return(regResp, nil)
 			},
@@ -68,14 +113,429 @@ func TestLinearRegression(t *testing.T) {
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recorder.Code)
+				resp := requireBodyMatchRegression(t, recorder.Body, rows, cols)
+				require.Empty(t, resp.Coeffs)
+				require.Empty(t, resp.Tstats)
+				require.Nil(t, resp.Result.Influence)
+				require.Empty(t, resp.Rows)
+			},
+		},
+		{
+			name:   "FORMATTED",
+			params: regressionRequest{Username: user.Username, FileID: fileID, Formatted: true},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetFile(gomock.Any(), gomock.Eq(getFileParams)).
+					Times(1).
+					Return(regResp, nil)
+			},
+			setupAuth: func(
+				t *testing.T, request *http.Request, tokenMaker *token.PasetoMaker,
+			) {
+				addAuthorization(
+					t, request, tokenMaker, authorizationTypeToken, user.Username,
+					time.Minute)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				resp := requireBodyMatchRegression(t, recorder.Body, rows, cols)
+				require.NotEmpty(t, resp.Coeffs)
+				require.NotEmpty(t, resp.Tstats)
+			},
+		},
+		{
+			name:   "SAVE",
+			params: regressionRequest{Username: user.Username, FileID: fileID, Save: true},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetFile(gomock.Any(), gomock.Eq(getFileParams)).
+					Times(1).
+					Return(namedResp, nil)
+				store.EXPECT().
+					CreateModel(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(
+						_ context.Context, arg db.CreateModelParams,
+					) (db.Model, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.Equal(t, "linear", arg.Kind)
+						require.Equal(t, fileID, arg.FileID.Int64)
+						require.Equal(t, "j", arg.Target)
+						require.Equal(t, namedResp.ColumnNames[:cols-1], arg.Predictors)
+
+						var model statsanal.Model
+						require.NoError(t, json.Unmarshal(arg.Model, &model))
+						require.True(t, model.Intercept)
+						require.Len(t, model.Coefficients, cols)
+						return db.Model{ID: 7}, nil
+					})
+			},
+			setupAuth: func(
+				t *testing.T, request *http.Request, tokenMaker *token.PasetoMaker,
+			) {
+				addAuthorization(
+					t, request, tokenMaker, authorizationTypeToken, user.Username,
+					time.Minute)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				resp := requireBodyMatchRegression(t, recorder.Body, rows, cols)
+				require.Equal(t, int64(7), resp.ModelID)
+			},
+		},
+		{
+			name:   "SAVE ERROR",
+			params: regressionRequest{Username: user.Username, FileID: fileID, Save: true},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetFile(gomock.Any(), gomock.Eq(getFileParams)).
+					Times(1).
+					Return(namedResp, nil)
+				store.EXPECT().
+					CreateModel(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Model{}, sql.ErrConnDone)
+			},
+			setupAuth: func(
+				t *testing.T, request *http.Request, tokenMaker *token.PasetoMaker,
+			) {
+				addAuthorization(
+					t, request, tokenMaker, authorizationTypeToken, user.Username,
+					time.Minute)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name:   "VERSION",
+			params: regressionRequest{Username: user.Username, FileID: fileID, Version: 2},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetFile(gomock.Any(), gomock.Any()).
+					Times(0)
+
+				store.EXPECT().
+					GetFileVersion(gomock.Any(), gomock.Eq(db.GetFileVersionParams{
+						FileID:   fileID,
+						Version:  2,
+						Username: user.Username,
+					})).
+					Times(1).
+					Return(db.FileVersion{FileID: fileID, Version: 2, Data: matData}, nil)
+			},
+			setupAuth: func(
+				t *testing.T, request *http.Request, tokenMaker *token.PasetoMaker,
+			) {
+				addAuthorization(
+					t, request, tokenMaker, authorizationTypeToken, user.Username,
+					time.Minute)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchRegression(t, recorder.Body, rows, cols)
+			},
+		},
+		{
+			name:   "VERSION NOT FOUND",
+			params: regressionRequest{Username: user.Username, FileID: fileID, Version: 7},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetFileVersion(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.FileVersion{}, sql.ErrNoRows)
+			},
+			setupAuth: func(
+				t *testing.T, request *http.Request, tokenMaker *token.PasetoMaker,
+			) {
+				addAuthorization(
+					t, request, tokenMaker, authorizationTypeToken, user.Username,
+					time.Minute)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:   "RANK DEFICIENT",
+			params: regReq,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetFile(gomock.Any(), gomock.Eq(getFileParams)).
+					Times(1).
+					Return(collinearResp, nil)
+			},
+			setupAuth: func(
+				t *testing.T, request *http.Request, tokenMaker *token.PasetoMaker,
+			) {
+				addAuthorization(
+					t, request, tokenMaker, authorizationTypeToken, user.Username,
+					time.Minute)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name:   "CONSTANT TARGET",
+			params: regReq,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetFile(gomock.Any(), gomock.Eq(getFileParams)).
+					Times(1).
+					Return(constantResp, nil)
+			},
+			setupAuth: func(
+				t *testing.T, request *http.Request, tokenMaker *token.PasetoMaker,
+			) {
+				addAuthorization(
+					t, request, tokenMaker, authorizationTypeToken, user.Username,
+					time.Minute)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name: "RANK DEFICIENT PINV",
+			params: regressionRequest{
+				Username:           user.Username,
+				FileID:             fileID,
+				AllowRankDeficient: true,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetFile(gomock.Any(), gomock.Eq(getFileParams)).
+					Times(1).
+					Return(collinearResp, nil)
+			},
+			setupAuth: func(
+				t *testing.T, request *http.Request, tokenMaker *token.PasetoMaker,
+			) {
+				addAuthorization(
+					t, request, tokenMaker, authorizationTypeToken, user.Username,
+					time.Minute)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var resp regressionResp
+				err := json.NewDecoder(recorder.Body).Decode(&resp)
+				require.NoError(t, err)
+				require.True(t, resp.Result.RankDeficient)
+				require.Equal(t, cols-1, resp.Result.Rank)
+			},
+		},
+		{
+			name: "SELECTED COLUMNS",
+			params: regressionRequest{
+				Username:    user.Username,
+				FileID:      fileID,
+				Target:      &targetCol,
+				Predictors:  []columnRef{columnIndex(1), columnName("col_4")},
+				NoIntercept: true,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetFile(gomock.Any(), gomock.Eq(getFileParams)).
+					Times(1).
+					Return(regResp, nil)
+			},
+			setupAuth: func(
+				t *testing.T, request *http.Request, tokenMaker *token.PasetoMaker,
+			) {
+				addAuthorization(
+					t, request, tokenMaker, authorizationTypeToken, user.Username,
+					time.Minute)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var resp regressionResp
+				err := json.NewDecoder(recorder.Body).Decode(&resp)
+				require.NoError(t, err)
+				require.Equal(t, []string{"col_1", "col_4"}, resp.Result.Names)
+			},
+		},
+		{
+			name: "NAMED COLUMNS",
+			params: regressionRequest{
+				Username:   user.Username,
+				FileID:     fileID,
+				Predictors: []columnRef{columnName("c"), columnIndex(3)},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetFile(gomock.Any(), gomock.Eq(getFileParams)).
+					Times(1).
+					Return(namedResp, nil)
+			},
+			setupAuth: func(
+				t *testing.T, request *http.Request, tokenMaker *token.PasetoMaker,
+			) {
+				addAuthorization(
+					t, request, tokenMaker, authorizationTypeToken, user.Username,
+					time.Minute)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var resp regressionResp
+				err := json.NewDecoder(recorder.Body).Decode(&resp)
+				require.NoError(t, err)
+				require.Equal(t, []string{"intercept", "c", "d"}, resp.Result.Names)
+			},
+		},
+		{
+			name: "INVALID COLUMN",
+			params: regressionRequest{
+				Username:   user.Username,
+				FileID:     fileID,
+				Predictors: []columnRef{columnName("unknown")},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetFile(gomock.Any(), gomock.Eq(getFileParams)).
+					Times(1).
+					Return(regResp, nil)
+			},
+			setupAuth: func(
+				t *testing.T, request *http.Request, tokenMaker *token.PasetoMaker,
+			) {
+				addAuthorization(
+					t, request, tokenMaker, authorizationTypeToken, user.Username,
+					time.Minute)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:   "MISSING LISTWISE",
+			params: regReq,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetFile(gomock.Any(), gomock.Eq(getFileParams)).
+					Times(1).
+					Return(missingResp, nil)
+			},
+			setupAuth: func(
+				t *testing.T, request *http.Request, tokenMaker *token.PasetoMaker,
+			) {
+				addAuthorization(
+					t, request, tokenMaker, authorizationTypeToken, user.Username,
+					time.Minute)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				resp := requireBodyMatchRegression(t, recorder.Body, rows-2, cols)
+				require.Equal(t, rows-2, resp.Result.Observations)
+			},
+		},
+		{
+			name:   "MISSING MEAN",
+			params: regressionRequest{Username: user.Username, FileID: fileID, Missing: "mean"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetFile(gomock.Any(), gomock.Eq(getFileParams)).
+					Times(1).
+					Return(missingResp, nil)
+			},
+			setupAuth: func(
+				t *testing.T, request *http.Request, tokenMaker *token.PasetoMaker,
+			) {
+				addAuthorization(
+					t, request, tokenMaker, authorizationTypeToken, user.Username,
+					time.Minute)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				resp := requireBodyMatchRegression(t, recorder.Body, rows, cols)
+				require.Equal(t, rows, resp.Result.Observations)
+			},
+		},
+		{
+			name: "INFLUENCE",
+			params: regressionRequest{
+				Username:  user.Username,
+				FileID:    fileID,
+				Influence: true,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetFile(gomock.Any(), gomock.Eq(getFileParams)).
+					Times(1).
+					Return(missingResp, nil)
+			},
+			setupAuth: func(
+				t *testing.T, request *http.Request, tokenMaker *token.PasetoMaker,
+			) {
+				addAuthorization(
+					t, request, tokenMaker, authorizationTypeToken, user.Username,
+					time.Minute)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				resp := requireBodyMatchRegression(t, recorder.Body, rows-2, cols)
+				// the rows with a missing value are dropped.
+				require.Len(t, resp.Rows, rows-2)
+				require.Equal(t, 1, resp.Rows[0])
+				require.NotContains(t, resp.Rows, 5)
+
+				inf := resp.Result.Influence
+				require.NotNil(t, inf)
+				require.Len(t, inf.Residuals, rows-2)
+				require.Len(t, inf.CooksDistance, rows-2)
+				var total float64
+				for _, h := range inf.Leverage {
+					total += h
+				}
+				// the leverages sum to the number of coefficients.
+				require.InDelta(t, float64(cols), total, 1e-8)
+				require.InDelta(t, 2*float64(cols)/float64(rows-2), inf.LeverageCutoff, 1e-12)
+			},
+		},
+		{
+			name:   "INVALID MISSING STRATEGY",
+			params: regressionRequest{Username: user.Username, FileID: fileID, Missing: "drop"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetFile(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			setupAuth: func(
+				t *testing.T, request *http.Request, tokenMaker *token.PasetoMaker,
+			) {
+				addAuthorization(
+					t, request, tokenMaker, authorizationTypeToken, user.Username,
+					time.Minute)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:   "INVALID CONFIDENCE",
+			params: regressionRequest{Username: user.Username, FileID: fileID, Confidence: 1.2},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetFile(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			setupAuth: func(
+				t *testing.T, request *http.Request, tokenMaker *token.PasetoMaker,
+			) {
+				addAuthorization(
+					t, request, tokenMaker, authorizationTypeToken, user.Username,
+					time.Minute)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
 			},
 		},
 		{
 			name:   "BAD REQUEST",
-			params: regressionRequest{Username: "1@2"},
-			buildStubs: func(querier *mockdb.MockQuerier) {
-				querier.EXPECT().
-					GetFile(gomock.Any(), gomock.Eq(regReq.Username)).
+			params: regressionRequest{Username: "1@2", FileID: fileID},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetFile(gomock.Any(), gomock.Eq(getFileParams)).
 					Times(0)
 			},
 			setupAuth: func(
@@ -92,9 +552,9 @@ func TestLinearRegression(t *testing.T) {
 		{
 			name:   "UNAUTHORIZED",
 			params: regReq,
-			buildStubs: func(querier *mockdb.MockQuerier) {
-				querier.EXPECT().
-					GetFile(gomock.Any(), gomock.Eq(regReq.Username)).
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetFile(gomock.Any(), gomock.Eq(getFileParams)).
 					Times(0)
 			},
 			setupAuth: func(
@@ -109,11 +569,11 @@ func TestLinearRegression(t *testing.T) {
 			},
 		},
 		{
-			name:   "INTERNAL ERROR",
+			name:   "NOT FOUND",
 			params: regReq,
-			buildStubs: func(querier *mockdb.MockQuerier) {
-				querier.EXPECT().
-					GetFile(gomock.Any(), gomock.Eq(regReq.Username)).
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetFile(gomock.Any(), gomock.Eq(getFileParams)).
 					Times(1).
 					Return(db.File{}, sql.ErrNoRows)
 			},
@@ -124,6 +584,45 @@ func TestLinearRegression(t *testing.T) {
 					t, request, tokenMaker, authorizationTypeToken, user.Username,
 					time.Minute)
 			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:   "MISSING FILE ID",
+			params: regressionRequest{Username: user.Username},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetFile(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			setupAuth: func(
+				t *testing.T, request *http.Request, tokenMaker *token.PasetoMaker,
+			) {
+				addAuthorization(
+					t, request, tokenMaker, authorizationTypeToken, user.Username,
+					time.Minute)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:   "INTERNAL ERROR",
+			params: regReq,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetFile(gomock.Any(), gomock.Eq(getFileParams)).
+					Times(1).
+					Return(db.File{}, sql.ErrConnDone)
+			},
+			setupAuth: func(
+				t *testing.T, request *http.Request, tokenMaker *token.PasetoMaker,
+			) {
+				addAuthorization(
+					t, request, tokenMaker, authorizationTypeToken, user.Username,
+					time.Minute)
+			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusInternalServerError, recorder.Code)
 			},
@@ -131,15 +630,15 @@ func TestLinearRegression(t *testing.T) {
 		{
 			name:   "CORRUPTED FILE",
 			params: regReq,
-			buildStubs: func(querier *mockdb.MockQuerier) {
-				querier.EXPECT().
-					GetFile(gomock.Any(), gomock.Eq(regReq.Username)).
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetFile(gomock.Any(), gomock.Eq(getFileParams)).
 					Times(1).
 					Return(
 						db.File{
 							ID:       util.RandomInt(1, 1000),
 							Username: user.Username,
-							Data:     "ADIEDRYE=@$",
+							Data:     []byte("ADIEDRYE=@$"),
 						},
 						nil)
 			},
@@ -163,11 +662,11 @@ func TestLinearRegression(t *testing.T) {
 			ctrl := gomock.NewController(t)
 			defer ctrl.Finish()
 
-			querier := mockdb.NewMockQuerier(ctrl)
+			store := mockdb.NewMockStore(ctrl)
 			// build stubs
-			tc.buildStubs(querier)
+			tc.buildStubs(store)
 			// start test server and send request
-			server := newTestServer(t, querier)
+			server := newTestServer(t, store)
 			recorder := httptest.NewRecorder()
 
 			url := fmt.Sprint("/analyses/regression")
@@ -184,3 +683,25 @@ func TestLinearRegression(t *testing.T) {
 		})
 	}
 }
+
+func requireBodyMatchRegression(
+	t *testing.T, responseBody *bytes.Buffer, rows, cols int,
+) regressionResp {
+	var serverResp regressionResp
+
+	err := json.NewDecoder(responseBody).Decode(&serverResp)
+	require.NoError(t, err)
+
+	require.Empty(t, serverResp.Error)
+	require.NotNil(t, serverResp.Result)
+	require.Len(t, serverResp.Result.Names, cols)
+	require.Len(t, serverResp.Result.Coefficients, cols)
+	require.Len(t, serverResp.Result.StdErrors, cols)
+	require.Len(t, serverResp.Result.TStats, cols)
+	require.Len(t, serverResp.Result.PValues, cols)
+	require.Len(t, serverResp.Result.ConfLower, cols)
+	require.Len(t, serverResp.Result.ConfUpper, cols)
+	require.Equal(t, rows-cols, serverResp.Result.ResidualDF)
+
+	return serverResp
+}
@@ -0,0 +1,218 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	statsanal "github.com/yodeman/analyses-api/stats-analyses"
+)
+
+// Response format for arima request.
+type arimaResp struct {
+	Result *statsanal.ARIMAResult `json:"result"`
+	Name   string                 `json:"name,omitempty"`
+	// Rows holds the index in the file of each value of the series.
+	Rows  []int  `json:"rows,omitempty"`
+	Error string `json:"error"`
+}
+
+// Request format for arima queries.
+type arimaRequest struct {
+	Username   string     `json:"username" binding:"required,alphanum"`
+	FileID     int64      `json:"file_id" binding:"required,min=1"`
+	Version    int32      `json:"version" binding:"omitempty,min=1"`
+	Column     *columnRef `json:"column" binding:"required"`
+	P          int        `json:"p" binding:"omitempty,min=0"`
+	D          int        `json:"d" binding:"omitempty,min=0"`
+	Q          int        `json:"q" binding:"omitempty,min=0"`
+	Search     bool       `json:"search"`
+	MaxP       *int       `json:"max_p" binding:"omitempty,min=0,max=10"`
+	MaxD       *int       `json:"max_d" binding:"omitempty,min=0,max=10"`
+	MaxQ       *int       `json:"max_q" binding:"omitempty,min=0,max=10"`
+	Horizon    int        `json:"horizon" binding:"omitempty,min=1,max=1000"`
+	Confidence float64    `json:"confidence" binding:"omitempty,gt=0,lt=1"`
+	Missing    string     `json:"missing" binding:"omitempty,oneof=listwise mean median ffill"`
+}
+
+/*
+arima fits an ARIMA model to a column of one of the user's files, ordered
+like its rows, and forecasts its next values. The endpoint expects a GET
+request with a json body with the following key:
+
+	`username`   - alphanumeric user's username
+	`file_id`    - id of the user's file to analyse.
+	`version`    - optional, version of the file to analyse, defaults to the
+	               file's current version.
+	`column`     - index or name of the column holding the series.
+	`p`          - optional, order of the autoregressive part, defaults to 0.
+	`d`          - optional, number of differences, defaults to 0.
+	`q`          - optional, order of the moving average part, defaults to
+	               0.
+	`search`     - optional, choose the orders instead of `p`, `d` and `q`:
+	               `d` as the smallest number of differences after which the
+	               augmented Dickey-Fuller test rejects a unit root at the 5%
+	               level, then `p` and `q` with the smallest AIC, every
+	               candidate being conditioned on the first `max_p`
+	               differenced values.
+	`max_p`      - optional, largest `p` of the search, defaults to 3.
+	`max_d`      - optional, largest `d` of the search, defaults to 2.
+	`max_q`      - optional, largest `q` of the search, defaults to 3. A
+	               largest order of 0 limits the search to pure AR or MA
+	               models, or to no differences. The largest orders are at
+	               most 10.
+	`horizon`    - optional, number of values to forecast after the series,
+	               at most 1000.
+	`confidence` - optional, confidence level of the forecast intervals,
+	               defaults to 0.95.
+	`missing`    - optional, how missing values are handled: `listwise`
+	               (default), `mean`, `median` or `ffill`, see
+	               /analyses/regression.
+
+The coefficients minimize the conditional sum of squares, the sum of the
+squared one-step errors given the first `p` differenced values, and are kept
+stationary and invertible. The mean is only fitted without differences.
+`std_errors` holds the standard errors of `ar`, `ma` then `mean`. The
+residuals are ordered like `rows`, the indices in the file of the values of
+the series, which skip the rows dropped for missing values, and are null for
+the first `d` + `p` values. `ljung_box` tests whether they are white noise,
+and `candidates` lists the orders compared by the search.
+
+The request returns response with the following http status codes:
+
+200 - status OK:
+
+	with response body:
+	    {
+	        "result": {
+	            "p": *****,
+	            "d": *****,
+	            "q": *****,
+	            "ar": [*****],
+	            "ma": [*****],
+	            "mean": *****,
+	            "std_errors": [*****],
+	            "sigma2": *****,
+	            "log_likelihood": *****,
+	            "aic": *****,
+	            "bic": *****,
+	            "residuals": [*****],
+	            "ljung_box": {
+	                "test": "ljung_box",
+	                "statistic": *****,
+	                "df": [*****],
+	                "p_value": *****,
+	                "observations": [*****]
+	            },
+	            "candidates": [
+	                {
+	                    "p": *****,
+	                    "d": *****,
+	                    "q": *****,
+	                    "aic": *****
+	                },
+	                ...
+	            ],
+	            "confidence": *****,
+	            "forecast": [*****],
+	            "lower": [*****],
+	            "upper": [*****]
+	        },
+	        "name": "*****",
+	        "rows": [*****],
+	        "error":""
+	     }
+
+400 - status Bad Request:
+
+	Error parsing request body, or invalid column.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+401 - status Unauthorized:
+
+	If access token has expired or username in request body don't match username
+	in token payload.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+404 - status Not Found:
+
+	If the user has no file with id `file_id`, or the file has no such
+	version.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+422 - status Unprocessable Entity:
+
+	If the series is too short for the model, the model fits the series
+	exactly, or no data is left after handling missing values.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+501 - status Internal Server Error:
+
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+*/
+func (server *Server) arima(ctx *gin.Context) {
+	server.serveAnalysis(ctx, &arimaRequest{})
+}
+
+func (req *arimaRequest) owner() string {
+	return req.Username
+}
+
+// run fits the ARIMA model on the file of user `username` and returns the
+// response body.
+//
+// Returns a non-nil error along with the http status code to respond with if
+// the model can't be fitted.
+func (req *arimaRequest) run(
+	ctx context.Context, server *Server, username string,
+) (any, int, error) {
+	series, name, rows, status, err := server.seriesData(
+		ctx, username, req.FileID, req.Version, *req.Column, req.Missing)
+	if err != nil {
+		return nil, status, err
+	}
+
+	result, err := statsanal.ARIMA(
+		ctx,
+		series,
+		statsanal.ARIMAOptions{
+			P:          req.P,
+			D:          req.D,
+			Q:          req.Q,
+			Search:     req.Search,
+			MaxP:       req.MaxP,
+			MaxD:       req.MaxD,
+			MaxQ:       req.MaxQ,
+			Horizon:    req.Horizon,
+			Confidence: req.Confidence,
+		},
+	)
+	if err != nil {
+		return nil, http.StatusUnprocessableEntity,
+			fmt.Errorf("Error fitting ARIMA model\n%w", err)
+	}
+
+	return arimaResp{Result: &result, Name: name, Rows: rows}, http.StatusOK, nil
+}
@@ -0,0 +1,180 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	gomock "go.uber.org/mock/gomock"
+
+	mockdb "github.com/yodeman/analyses-api/dbase/mock"
+	db "github.com/yodeman/analyses-api/dbase/sqlc"
+)
+
+func TestARIMA(t *testing.T) {
+	user, _ := randomUser(t)
+	file := seriesFile(t, user.Username)
+	getFile := func(store *mockdb.MockStore) {
+		store.EXPECT().
+			GetFile(gomock.Any(), gomock.Eq(db.GetFileParams{
+				ID:       file.ID,
+				Username: user.Username,
+			})).
+			Times(1).
+			Return(file, nil)
+	}
+	noFile := func(store *mockdb.MockStore) {
+		store.EXPECT().
+			GetFile(gomock.Any(), gomock.Any()).
+			Times(0)
+	}
+	month, reading := columnName("month"), columnName("reading")
+	zero, one, eleven := 0, 1, 11
+
+	testCases := []hypothesisCase{
+		{
+			name: "OK",
+			params: arimaRequest{
+				Username: user.Username,
+				FileID:   file.ID,
+				Column:   &reading,
+				P:        1,
+				D:        1,
+				Horizon:  3,
+			},
+			username:   user.Username,
+			buildStubs: getFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var resp arimaResp
+				require.NoError(t, json.NewDecoder(recorder.Body).Decode(&resp))
+				require.Empty(t, resp.Error)
+				require.Equal(t, "reading", resp.Name)
+				require.Len(t, resp.Rows, 15)
+				require.Len(t, resp.Result.AR, 1)
+				require.Empty(t, resp.Result.MA)
+				require.Nil(t, resp.Result.Mean)
+				require.Len(t, resp.Result.Residuals, 15)
+				require.Nil(t, resp.Result.Residuals[1])
+				require.NotNil(t, resp.Result.Residuals[2])
+				require.Len(t, resp.Result.Forecast, 3)
+				require.Equal(t, 0.95, resp.Result.Confidence)
+				require.Empty(t, resp.Result.Candidates)
+			},
+		},
+		{
+			name: "RANDOM WALK",
+			params: arimaRequest{
+				Username: user.Username,
+				FileID:   file.ID,
+				Column:   &month,
+				D:        1,
+				Horizon:  2,
+			},
+			username:   user.Username,
+			buildStubs: getFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var resp arimaResp
+				require.NoError(t, json.NewDecoder(recorder.Body).Decode(&resp))
+				// without drift the last month is forecast.
+				require.InDeltaSlice(t, []float64{16, 16}, resp.Result.Forecast, 1e-9)
+			},
+		},
+		{
+			name: "SEARCH",
+			params: arimaRequest{
+				Username: user.Username,
+				FileID:   file.ID,
+				Column:   &reading,
+				Search:   true,
+				MaxP:     &one,
+				MaxD:     &one,
+				MaxQ:     &one,
+				Missing:  "ffill",
+			},
+			username:   user.Username,
+			buildStubs: getFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var resp arimaResp
+				require.NoError(t, json.NewDecoder(recorder.Body).Decode(&resp))
+				require.Len(t, resp.Rows, 16)
+				require.Len(t, resp.Result.Candidates, 4)
+				require.LessOrEqual(t, resp.Result.D, 1)
+				require.Nil(t, resp.Result.Forecast)
+			},
+		},
+		{
+			name: "SEARCH PURE AR",
+			params: arimaRequest{
+				Username: user.Username,
+				FileID:   file.ID,
+				Column:   &reading,
+				Search:   true,
+				MaxP:     &one,
+				MaxD:     &zero,
+				MaxQ:     &zero,
+				Missing:  "ffill",
+			},
+			username:   user.Username,
+			buildStubs: getFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var resp arimaResp
+				require.NoError(t, json.NewDecoder(recorder.Body).Decode(&resp))
+				require.Len(t, resp.Result.Candidates, 2)
+				require.Equal(t, 0, resp.Result.D)
+				require.Equal(t, 0, resp.Result.Q)
+			},
+		},
+		{
+			name: "TOO SHORT",
+			params: arimaRequest{
+				Username: user.Username,
+				FileID:   file.ID,
+				Column:   &reading,
+				P:        5,
+				Q:        5,
+			},
+			username:   user.Username,
+			buildStubs: getFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name: "NEGATIVE ORDER",
+			params: arimaRequest{
+				Username: user.Username,
+				FileID:   file.ID,
+				Column:   &reading,
+				P:        -1,
+			},
+			username:   user.Username,
+			buildStubs: noFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "LARGEST ORDER TOO HIGH",
+			params: arimaRequest{
+				Username: user.Username,
+				FileID:   file.ID,
+				Column:   &reading,
+				Search:   true,
+				MaxP:     &eleven,
+			},
+			username:   user.Username,
+			buildStubs: noFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	runHypothesisCases(t, "/analyses/timeseries/arima", testCases)
+}
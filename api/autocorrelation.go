@@ -0,0 +1,473 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	statsanal "github.com/yodeman/analyses-api/stats-analyses"
+)
+
+// Response format for difference request.
+type differenceResp struct {
+	Result []float64 `json:"result"`
+	Name   string    `json:"name,omitempty"`
+	// Rows holds the index in the file of the value each difference ends
+	// at.
+	Rows  []int  `json:"rows,omitempty"`
+	Error string `json:"error"`
+}
+
+// Request format for difference queries.
+type differenceRequest struct {
+	Username string     `json:"username" binding:"required,alphanum"`
+	FileID   int64      `json:"file_id" binding:"required,min=1"`
+	Version  int32      `json:"version" binding:"omitempty,min=1"`
+	Column   *columnRef `json:"column" binding:"required"`
+	Order    int        `json:"order" binding:"omitempty,min=1"`
+	Lag      int        `json:"lag" binding:"omitempty,min=1"`
+	Missing  string     `json:"missing" binding:"omitempty,oneof=listwise mean median ffill"`
+}
+
+/*
+difference differences a column of one of the user's files, ordered like its
+rows. The endpoint expects a GET request with a json body with the following
+key:
+
+	`username` - alphanumeric user's username
+	`file_id`  - id of the user's file to analyse.
+	`version`  - optional, version of the file to analyse, defaults to the
+	             file's current version.
+	`column`   - index or name of the column holding the series.
+	`order`    - optional, number of times the series is differenced,
+	             defaults to 1.
+	`lag`      - optional, distance between the values subtracted, the
+	             seasonal period for seasonal differences, defaults to 1.
+	`missing`  - optional, how missing values are handled: `listwise`
+	             (default), `mean`, `median` or `ffill`, see
+	             /analyses/regression.
+
+Each difference subtracts from a value the one `lag` values before it, so the
+differenced series is `order` * `lag` values shorter. `rows` holds the index
+in the file of the value each difference ends at, skipping the rows dropped
+for missing values.
+
+The request returns response with the following http status codes:
+
+200 - status OK:
+
+	with response body:
+	    {
+	        "result": [*****],
+	        "name": "*****",
+	        "rows": [*****],
+	        "error":""
+	     }
+
+400 - status Bad Request:
+
+	Error parsing request body, or invalid column.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+401 - status Unauthorized:
+
+	If access token has expired or username in request body don't match username
+	in token payload.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+404 - status Not Found:
+
+	If the user has no file with id `file_id`, or the file has no such
+	version.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+422 - status Unprocessable Entity:
+
+	If no value is left after differencing, or no data is left after
+	handling missing values.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+501 - status Internal Server Error:
+
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+*/
+func (server *Server) difference(ctx *gin.Context) {
+	server.serveAnalysis(ctx, &differenceRequest{})
+}
+
+func (req *differenceRequest) owner() string {
+	return req.Username
+}
+
+// run differences the series on the file of user `username` and returns the
+// response body.
+//
+// Returns a non-nil error along with the http status code to respond with if
+// the series can't be differenced.
+func (req *differenceRequest) run(
+	ctx context.Context, server *Server, username string,
+) (any, int, error) {
+	series, name, rows, status, err := server.seriesData(
+		ctx, username, req.FileID, req.Version, *req.Column, req.Missing)
+	if err != nil {
+		return nil, status, err
+	}
+
+	order, lag := max(req.Order, 1), max(req.Lag, 1)
+	result, err := statsanal.Difference(series, order, lag)
+	if err != nil {
+		return nil, http.StatusUnprocessableEntity,
+			fmt.Errorf("Error differencing series\n%w", err)
+	}
+
+	return differenceResp{Result: result, Name: name, Rows: rows[order*lag:]},
+		http.StatusOK, nil
+}
+
+// Response format for autocorrelation request.
+type autocorrelationResp struct {
+	Result   *statsanal.AutocorrelationResult `json:"result"`
+	LjungBox *statsanal.TestResult            `json:"ljung_box,omitempty"`
+	Name     string                           `json:"name,omitempty"`
+	Error    string                           `json:"error"`
+}
+
+// Request format for autocorrelation queries.
+type autocorrelationRequest struct {
+	Username    string     `json:"username" binding:"required,alphanum"`
+	FileID      int64      `json:"file_id" binding:"required,min=1"`
+	Version     int32      `json:"version" binding:"omitempty,min=1"`
+	Column      *columnRef `json:"column" binding:"required"`
+	Lags        int        `json:"lags" binding:"omitempty,min=1"`
+	Differences int        `json:"differences" binding:"omitempty,min=1"`
+	Confidence  float64    `json:"confidence" binding:"omitempty,gt=0,lt=1"`
+	Missing     string     `json:"missing" binding:"omitempty,oneof=listwise mean median ffill"`
+}
+
+/*
+autocorrelation finds the autocorrelations and partial autocorrelations of a
+column of one of the user's files, ordered like its rows, and tests whether
+it is white noise with the Ljung-Box test. The endpoint expects a GET request
+with a json body with the following key:
+
+	`username`    - alphanumeric user's username
+	`file_id`     - id of the user's file to analyse.
+	`version`     - optional, version of the file to analyse, defaults to the
+	                file's current version.
+	`column`      - index or name of the column holding the series.
+	`lags`        - optional, largest lag, defaults to 10 * log10(n) bounded
+	                by n - 1 for a series of n values.
+	`differences` - optional, number of times the series is differenced
+	                first.
+	`confidence`  - optional, confidence level of the band of white noise,
+	                defaults to 0.95.
+	`missing`     - optional, how missing values are handled: `listwise`
+	                (default), `mean`, `median` or `ffill`, see
+	                /analyses/regression.
+
+`acf` and `pacf` hold the autocorrelations and partial autocorrelations at
+lags 1 to `lags`. The autocorrelations of white noise lie within `bound` of 0
+at the `confidence` level. The Ljung-Box test uses the autocorrelations at
+every lag.
+
+The request returns response with the following http status codes:
+
+200 - status OK:
+
+	with response body:
+	    {
+	        "result": {
+	            "acf": [*****],
+	            "pacf": [*****],
+	            "bound": *****,
+	            "confidence": *****
+	        },
+	        "ljung_box": {
+	            "test": "ljung_box",
+	            "statistic": *****,
+	            "df": [*****],
+	            "p_value": *****,
+	            "observations": [*****]
+	        },
+	        "name": "*****",
+	        "error":""
+	     }
+
+400 - status Bad Request:
+
+	Error parsing request body, or invalid column.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+401 - status Unauthorized:
+
+	If access token has expired or username in request body don't match username
+	in token payload.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+404 - status Not Found:
+
+	If the user has no file with id `file_id`, or the file has no such
+	version.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+422 - status Unprocessable Entity:
+
+	If the series is constant, has no more values than `lags`, or no data
+	is left after differencing or handling missing values.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+501 - status Internal Server Error:
+
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+*/
+func (server *Server) autocorrelation(ctx *gin.Context) {
+	server.serveAnalysis(ctx, &autocorrelationRequest{})
+}
+
+func (req *autocorrelationRequest) owner() string {
+	return req.Username
+}
+
+// run computes the autocorrelations on the file of user `username` and
+// returns the response body.
+//
+// Returns a non-nil error along with the http status code to respond with if
+// the autocorrelations can't be computed.
+func (req *autocorrelationRequest) run(
+	ctx context.Context, server *Server, username string,
+) (any, int, error) {
+	series, name, status, err := server.differencedSeries(
+		ctx, username, req.FileID, req.Version, *req.Column, req.Missing,
+		req.Differences)
+	if err != nil {
+		return nil, status, err
+	}
+
+	result, err := statsanal.Autocorrelation(series, req.Lags, req.Confidence)
+	if err != nil {
+		return nil, http.StatusUnprocessableEntity,
+			fmt.Errorf("Error computing autocorrelations\n%w", err)
+	}
+	test, err := statsanal.LjungBox(series, len(result.ACF), 0)
+	if err != nil {
+		return nil, http.StatusUnprocessableEntity,
+			fmt.Errorf("Error during Ljung-Box test\n%w", err)
+	}
+
+	return autocorrelationResp{Result: &result, LjungBox: &test, Name: name},
+		http.StatusOK, nil
+}
+
+// Request format for adf queries.
+type adfRequest struct {
+	Username    string     `json:"username" binding:"required,alphanum"`
+	FileID      int64      `json:"file_id" binding:"required,min=1"`
+	Version     int32      `json:"version" binding:"omitempty,min=1"`
+	Column      *columnRef `json:"column" binding:"required"`
+	Regression  string     `json:"regression" binding:"omitempty,oneof=constant trend none"`
+	Lags        int        `json:"lags" binding:"omitempty,min=1"`
+	AutoLags    bool       `json:"autolags"`
+	Differences int        `json:"differences" binding:"omitempty,min=1"`
+	Missing     string     `json:"missing" binding:"omitempty,oneof=listwise mean median ffill"`
+}
+
+/*
+adf runs the augmented Dickey-Fuller test of a unit root in a column of one
+of the user's files, ordered like its rows, whose null hypothesis is that the
+series isn't stationary. The endpoint expects a GET request with a json body
+with the following key:
+
+	`username`    - alphanumeric user's username
+	`file_id`     - id of the user's file to analyse.
+	`version`     - optional, version of the file to analyse, defaults to the
+	                file's current version.
+	`column`      - index or name of the column holding the series.
+	`regression`  - optional, deterministic terms of the regression:
+	                `constant` (default), `trend` for a constant and a linear
+	                trend, or `none`.
+	`lags`        - optional, number of lagged differences in the regression,
+	                defaults to 0.
+	`autolags`    - optional, choose the number of lagged differences, up to
+	                `lags`, with the smallest AIC. `lags` then defaults to
+	                12 * (n / 100)^(1/4) for a series of n values.
+	`differences` - optional, number of times the series is differenced
+	                first.
+	`missing`     - optional, how missing values are handled: `listwise`
+	                (default), `mean`, `median` or `ffill`, see
+	                /analyses/regression.
+
+The statistic is the t statistic of the lagged value in the regression of
+the differences on it, the deterministic terms and the lagged differences.
+Its p-value follows MacKinnon's approximation of its asymptotic
+distribution. `lags` is the number of lagged differences used.
+
+The request returns response with the following http status codes:
+
+200 - status OK:
+
+	with response body:
+	    {
+	        "result": {
+	            "test": "adf",
+	            "statistic": *****,
+	            "p_value": *****,
+	            "method": "asymptotic",
+	            "lags": *****,
+	            "observations": [*****]
+	        },
+	        "names": ["*****"],
+	        "error":""
+	     }
+
+400 - status Bad Request:
+
+	Error parsing request body, or invalid column.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+401 - status Unauthorized:
+
+	If access token has expired or username in request body don't match username
+	in token payload.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+404 - status Not Found:
+
+	If the user has no file with id `file_id`, or the file has no such
+	version.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+422 - status Unprocessable Entity:
+
+	If the series is too short for the lags, the regression is rank
+	deficient, or no data is left after differencing or handling missing
+	values.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+501 - status Internal Server Error:
+
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+*/
+func (server *Server) adf(ctx *gin.Context) {
+	server.serveAnalysis(ctx, &adfRequest{})
+}
+
+func (req *adfRequest) owner() string {
+	return req.Username
+}
+
+// run performs the augmented Dickey-Fuller test on the file of user
+// `username` and returns the response body.
+//
+// Returns a non-nil error along with the http status code to respond with if
+// the test can't be performed.
+func (req *adfRequest) run(
+	ctx context.Context, server *Server, username string,
+) (any, int, error) {
+	series, name, status, err := server.differencedSeries(
+		ctx, username, req.FileID, req.Version, *req.Column, req.Missing,
+		req.Differences)
+	if err != nil {
+		return nil, status, err
+	}
+
+	result, err := statsanal.ADF(
+		series,
+		statsanal.ADFOptions{
+			Regression: statsanal.ADFRegression(req.Regression),
+			Lags:       req.Lags,
+			AutoLags:   req.AutoLags,
+		},
+	)
+	if err != nil {
+		return nil, http.StatusUnprocessableEntity,
+			fmt.Errorf("Error during augmented Dickey-Fuller test\n%w", err)
+	}
+
+	return testResp{Result: &result, Names: []string{name}}, http.StatusOK, nil
+}
+
+// differencedSeries loads `column` of version `version` of file `fileID` of
+// user `username` as a series like seriesData, and differences it
+// `differences` times. Returns the series along with the column's name.
+//
+// Returns a non-nil error along with the http status code to respond with if
+// the series can't be loaded.
+func (server *Server) differencedSeries(
+	ctx context.Context, username string, fileID int64, version int32,
+	column columnRef, missing string, differences int,
+) ([]float64, string, int, error) {
+	series, name, _, status, err := server.seriesData(
+		ctx, username, fileID, version, column, missing)
+	if err != nil {
+		return nil, "", status, err
+	}
+
+	series, err = statsanal.Difference(series, differences, 1)
+	if err != nil {
+		return nil, "", http.StatusUnprocessableEntity,
+			fmt.Errorf("Error differencing series\n%w", err)
+	}
+	return series, name, http.StatusOK, nil
+}
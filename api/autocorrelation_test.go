@@ -0,0 +1,288 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	gomock "go.uber.org/mock/gomock"
+
+	mockdb "github.com/yodeman/analyses-api/dbase/mock"
+	db "github.com/yodeman/analyses-api/dbase/sqlc"
+)
+
+func TestDifference(t *testing.T) {
+	user, _ := randomUser(t)
+	file := seriesFile(t, user.Username)
+	getFile := func(store *mockdb.MockStore) {
+		store.EXPECT().
+			GetFile(gomock.Any(), gomock.Eq(db.GetFileParams{
+				ID:       file.ID,
+				Username: user.Username,
+			})).
+			Times(1).
+			Return(file, nil)
+	}
+	noFile := func(store *mockdb.MockStore) {
+		store.EXPECT().
+			GetFile(gomock.Any(), gomock.Any()).
+			Times(0)
+	}
+	month, reading := columnName("month"), columnIndex(1)
+
+	testCases := []hypothesisCase{
+		{
+			name: "OK",
+			params: differenceRequest{
+				Username: user.Username,
+				FileID:   file.ID,
+				Column:   &month,
+			},
+			username:   user.Username,
+			buildStubs: getFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var resp differenceResp
+				require.NoError(t, json.NewDecoder(recorder.Body).Decode(&resp))
+				require.Empty(t, resp.Error)
+				require.Equal(t, "month", resp.Name)
+				require.Len(t, resp.Result, 15)
+				for _, v := range resp.Result {
+					require.Equal(t, 1.0, v)
+				}
+				require.Equal(t, 1, resp.Rows[0])
+			},
+		},
+		{
+			name: "SEASONAL",
+			params: differenceRequest{
+				Username: user.Username,
+				FileID:   file.ID,
+				Column:   &reading,
+				Lag:      4,
+				Missing:  "listwise",
+			},
+			username:   user.Username,
+			buildStubs: getFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var resp differenceResp
+				require.NoError(t, json.NewDecoder(recorder.Body).Decode(&resp))
+				// the second row is dropped, so the differences start at the
+				// sixth row.
+				require.Len(t, resp.Result, 11)
+				require.Len(t, resp.Rows, 11)
+				require.Equal(t, 5, resp.Rows[0])
+			},
+		},
+		{
+			name: "TOO MANY DIFFERENCES",
+			params: differenceRequest{
+				Username: user.Username,
+				FileID:   file.ID,
+				Column:   &month,
+				Order:    4,
+				Lag:      4,
+			},
+			username:   user.Username,
+			buildStubs: getFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name: "MISSING COLUMN",
+			params: differenceRequest{
+				Username: user.Username,
+				FileID:   file.ID,
+			},
+			username:   user.Username,
+			buildStubs: noFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	runHypothesisCases(t, "/analyses/timeseries/difference", testCases)
+}
+
+func TestAutocorrelation(t *testing.T) {
+	user, _ := randomUser(t)
+	file := seriesFile(t, user.Username)
+	getFile := func(store *mockdb.MockStore) {
+		store.EXPECT().
+			GetFile(gomock.Any(), gomock.Eq(db.GetFileParams{
+				ID:       file.ID,
+				Username: user.Username,
+			})).
+			Times(1).
+			Return(file, nil)
+	}
+	noFile := func(store *mockdb.MockStore) {
+		store.EXPECT().
+			GetFile(gomock.Any(), gomock.Any()).
+			Times(0)
+	}
+	month, reading := columnName("month"), columnName("reading")
+
+	testCases := []hypothesisCase{
+		{
+			name: "OK",
+			params: autocorrelationRequest{
+				Username: user.Username,
+				FileID:   file.ID,
+				Column:   &reading,
+				Lags:     4,
+				Missing:  "mean",
+			},
+			username:   user.Username,
+			buildStubs: getFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var resp autocorrelationResp
+				require.NoError(t, json.NewDecoder(recorder.Body).Decode(&resp))
+				require.Empty(t, resp.Error)
+				require.Equal(t, "reading", resp.Name)
+				require.Len(t, resp.Result.ACF, 4)
+				require.Len(t, resp.Result.PACF, 4)
+				require.InDelta(t, resp.Result.ACF[0], resp.Result.PACF[0], 1e-12)
+				require.Equal(t, "ljung_box", resp.LjungBox.Test)
+				require.Equal(t, []float64{4}, resp.LjungBox.DF)
+				require.Equal(t, []int{16}, resp.LjungBox.Observations)
+			},
+		},
+		{
+			name: "DIFFERENCED",
+			params: autocorrelationRequest{
+				Username:    user.Username,
+				FileID:      file.ID,
+				Column:      &reading,
+				Differences: 1,
+				Confidence:  0.9,
+			},
+			username:   user.Username,
+			buildStubs: getFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var resp autocorrelationResp
+				require.NoError(t, json.NewDecoder(recorder.Body).Decode(&resp))
+				// 10 * log10(14) lags of the 14 differences.
+				require.Len(t, resp.Result.ACF, 11)
+				require.Equal(t, 0.9, resp.Result.Confidence)
+				require.Equal(t, []int{14}, resp.LjungBox.Observations)
+			},
+		},
+		{
+			name: "CONSTANT",
+			params: autocorrelationRequest{
+				Username:    user.Username,
+				FileID:      file.ID,
+				Column:      &month,
+				Differences: 1,
+			},
+			username:   user.Username,
+			buildStubs: getFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name: "UNAUTHORIZED",
+			params: autocorrelationRequest{
+				Username: user.Username,
+				FileID:   file.ID,
+				Column:   &month,
+			},
+			username:   "deidara",
+			buildStubs: noFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	runHypothesisCases(t, "/analyses/timeseries/autocorrelation", testCases)
+}
+
+func TestADF(t *testing.T) {
+	user, _ := randomUser(t)
+	file := seriesFile(t, user.Username)
+	getFile := func(store *mockdb.MockStore) {
+		store.EXPECT().
+			GetFile(gomock.Any(), gomock.Eq(db.GetFileParams{
+				ID:       file.ID,
+				Username: user.Username,
+			})).
+			Times(1).
+			Return(file, nil)
+	}
+	noFile := func(store *mockdb.MockStore) {
+		store.EXPECT().
+			GetFile(gomock.Any(), gomock.Any()).
+			Times(0)
+	}
+	reading := columnName("reading")
+
+	testCases := []hypothesisCase{
+		{
+			name: "OK",
+			params: adfRequest{
+				Username:   user.Username,
+				FileID:     file.ID,
+				Column:     &reading,
+				Regression: "trend",
+				Lags:       2,
+				AutoLags:   true,
+			},
+			username:   user.Username,
+			buildStubs: getFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var resp testResp
+				require.NoError(t, json.NewDecoder(recorder.Body).Decode(&resp))
+				require.Empty(t, resp.Error)
+				require.Equal(t, "adf", resp.Result.Test)
+				require.Equal(t, []string{"reading"}, resp.Names)
+				require.NotNil(t, resp.Result.Statistic)
+				require.NotNil(t, resp.Result.PValue)
+				require.LessOrEqual(t, *resp.Result.Lags, 2)
+				// 15 values, less the first difference and the 2 lags
+				// compared.
+				require.Equal(t, []int{12}, resp.Result.Observations)
+			},
+		},
+		{
+			name: "TOO MANY LAGS",
+			params: adfRequest{
+				Username: user.Username,
+				FileID:   file.ID,
+				Column:   &reading,
+				Lags:     8,
+			},
+			username:   user.Username,
+			buildStubs: getFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name: "INVALID REGRESSION",
+			params: adfRequest{
+				Username:   user.Username,
+				FileID:     file.ID,
+				Column:     &reading,
+				Regression: "quadratic",
+			},
+			username:   user.Username,
+			buildStubs: noFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	runHypothesisCases(t, "/analyses/timeseries/adf", testCases)
+}
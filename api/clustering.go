@@ -0,0 +1,357 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gonum.org/v1/gonum/mat"
+
+	statsanal "github.com/yodeman/analyses-api/stats-analyses"
+)
+
+// Response format for hierarchical request.
+type hierarchicalResp struct {
+	Result *statsanal.AgglomerativeResult `json:"result"`
+	// Rows holds the index in the file of each clustered row.
+	Rows  []int  `json:"rows,omitempty"`
+	Error string `json:"error"`
+}
+
+// Request format for hierarchical queries.
+type hierarchicalRequest struct {
+	Username    string      `json:"username" binding:"required,alphanum"`
+	FileID      int64       `json:"file_id" binding:"required,min=1"`
+	Version     int32       `json:"version" binding:"omitempty,min=1"`
+	Columns     []columnRef `json:"columns"`
+	Linkage     string      `json:"linkage" binding:"omitempty,oneof=ward single complete average"`
+	Clusters    int         `json:"clusters" binding:"omitempty,min=1"`
+	Standardize bool        `json:"standardize"`
+	Missing     string      `json:"missing" binding:"omitempty,oneof=listwise mean median ffill"`
+}
+
+/*
+hierarchical clusters the rows of one of the user's files bottom-up with
+agglomerative clustering. The endpoint expects a GET request with a json body
+with the following key:
+
+	`username`    - alphanumeric user's username
+	`file_id`     - id of the user's file to analyse.
+	`version`     - optional, version of the file to analyse, defaults to the
+	                file's current version.
+	`columns`     - optional, list of indices or names of the columns to
+	                cluster on, defaults to every column.
+	`linkage`     - optional, distance between clusters: `ward` (default),
+	                `single`, `complete` or `average`.
+	`clusters`    - optional, number of clusters to label the rows with by
+	                cutting the dendrogram, no labels are returned otherwise.
+	`standardize` - optional, scale the columns to unit variance first.
+	`missing`     - optional, how missing values are handled: `listwise`
+	                (default), `mean`, `median` or `ffill`, see
+	                /analyses/regression.
+
+The dendrogram is the list of merges ordered by increasing distance. The
+clustered rows, ordered like `rows`, are the clusters 0 to n-1, and the i-th
+merge forms cluster n+i. `rows` holds the indices in the file of the
+clustered rows, which skip the rows dropped for missing values. At most 5000
+rows are clustered, see /analyses/kmeans for larger files.
+
+The request returns response with the following http status codes:
+
+200 - status OK:
+
+	with response body:
+	    {
+	        "result": {
+	            "names": ["*****", ...],
+	            "linkage": "*****",
+	            "merges": [
+	                {
+	                    "left": *****,
+	                    "right": *****,
+	                    "distance": *****,
+	                    "size": *****
+	                },
+	                ...
+	            ],
+	            "clusters": *****,
+	            "labels": [*****],
+	            "sizes": [*****]
+	        },
+	        "rows": [*****],
+	        "error":""
+	     }
+
+400 - status Bad Request:
+
+	Error parsing request body, or invalid columns.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+401 - status Unauthorized:
+
+	If access token has expired or username in request body don't match username
+	in token payload.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+404 - status Not Found:
+
+	If the user has no file with id `file_id`, or the file has no such
+	version.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+422 - status Unprocessable Entity:
+
+	If there are less than 2 rows or more than 5000, more clusters than rows,
+	a column is constant while standardizing, or no data is left after
+	handling missing values.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+501 - status Internal Server Error:
+
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+*/
+func (server *Server) hierarchical(ctx *gin.Context) {
+	server.serveAnalysis(ctx, &hierarchicalRequest{})
+}
+
+func (req *hierarchicalRequest) owner() string {
+	return req.Username
+}
+
+// run performs the agglomerative clustering on the file of user `username`
+// and returns the response body.
+//
+// Returns a non-nil error along with the http status code to respond with if
+// the clustering can't be performed.
+func (req *hierarchicalRequest) run(
+	ctx context.Context, server *Server, username string,
+) (any, int, error) {
+	data, names, rows, status, err := server.clusteringData(
+		ctx, username, req.FileID, req.Version, req.Columns, req.Missing)
+	if err != nil {
+		return nil, status, err
+	}
+
+	result, err := statsanal.Agglomerative(
+		ctx,
+		data,
+		statsanal.AgglomerativeOptions{
+			Linkage:     statsanal.Linkage(req.Linkage),
+			Clusters:    req.Clusters,
+			Standardize: req.Standardize,
+			Names:       names,
+		},
+	)
+	if err != nil {
+		return nil, http.StatusUnprocessableEntity,
+			fmt.Errorf("Error during agglomerative clustering\n%w", err)
+	}
+
+	return hierarchicalResp{Result: &result, Rows: rows}, http.StatusOK, nil
+}
+
+// Response format for dbscan request.
+type dbscanResp struct {
+	Result *statsanal.DBSCANResult `json:"result"`
+	// Rows holds the index in the file of each clustered row.
+	Rows  []int  `json:"rows,omitempty"`
+	Error string `json:"error"`
+}
+
+// Request format for dbscan queries.
+type dbscanRequest struct {
+	Username    string      `json:"username" binding:"required,alphanum"`
+	FileID      int64       `json:"file_id" binding:"required,min=1"`
+	Version     int32       `json:"version" binding:"omitempty,min=1"`
+	Columns     []columnRef `json:"columns"`
+	Eps         float64     `json:"eps" binding:"required,gt=0"`
+	MinPoints   int         `json:"min_points" binding:"omitempty,min=1"`
+	Standardize bool        `json:"standardize"`
+	Missing     string      `json:"missing" binding:"omitempty,oneof=listwise mean median ffill"`
+}
+
+/*
+dbscan clusters the rows of one of the user's files by density with DBSCAN.
+The endpoint expects a GET request with a json body with the following key:
+
+	`username`    - alphanumeric user's username
+	`file_id`     - id of the user's file to analyse.
+	`version`     - optional, version of the file to analyse, defaults to the
+	                file's current version.
+	`columns`     - optional, list of indices or names of the columns to
+	                cluster on, defaults to every column.
+	`eps`         - largest euclidean distance between two rows within reach
+	                of each other.
+	`min_points`  - optional, number of rows within reach of a core row,
+	                itself included, defaults to 5.
+	`standardize` - optional, scale the columns to unit variance first.
+	`missing`     - optional, how missing values are handled: `listwise`
+	                (default), `mean`, `median` or `ffill`, see
+	                /analyses/regression.
+
+A cluster gathers the core rows within reach of each other along with the
+rows within reach of them, the other rows are noise, labelled -1. The labels
+are ordered like `rows`, the indices in the file of the clustered rows,
+which skip the rows dropped for missing values, and `noise` holds the
+positions in `rows` of the noise rows.
+
+The request returns response with the following http status codes:
+
+200 - status OK:
+
+	with response body:
+	    {
+	        "result": {
+	            "names": ["*****", ...],
+	            "eps": *****,
+	            "min_points": *****,
+	            "clusters": *****,
+	            "labels": [*****],
+	            "sizes": [*****],
+	            "core": [*****],
+	            "noise": [*****]
+	        },
+	        "rows": [*****],
+	        "error":""
+	     }
+
+400 - status Bad Request:
+
+	Error parsing request body, or invalid columns.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+401 - status Unauthorized:
+
+	If access token has expired or username in request body don't match username
+	in token payload.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+404 - status Not Found:
+
+	If the user has no file with id `file_id`, or the file has no such
+	version.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+422 - status Unprocessable Entity:
+
+	If a column is constant while standardizing, or no data is left after
+	handling missing values.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+501 - status Internal Server Error:
+
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+*/
+func (server *Server) dbscan(ctx *gin.Context) {
+	server.serveAnalysis(ctx, &dbscanRequest{})
+}
+
+func (req *dbscanRequest) owner() string {
+	return req.Username
+}
+
+// run performs the DBSCAN clustering on the file of user `username` and
+// returns the response body.
+//
+// Returns a non-nil error along with the http status code to respond with if
+// the clustering can't be performed.
+func (req *dbscanRequest) run(
+	ctx context.Context, server *Server, username string,
+) (any, int, error) {
+	data, names, rows, status, err := server.clusteringData(
+		ctx, username, req.FileID, req.Version, req.Columns, req.Missing)
+	if err != nil {
+		return nil, status, err
+	}
+
+	result, err := statsanal.DBSCAN(
+		ctx,
+		data,
+		statsanal.DBSCANOptions{
+			Eps:         req.Eps,
+			MinPoints:   req.MinPoints,
+			Standardize: req.Standardize,
+			Names:       names,
+		},
+	)
+	if err != nil {
+		return nil, http.StatusUnprocessableEntity,
+			fmt.Errorf("Error during DBSCAN clustering\n%w", err)
+	}
+
+	return dbscanResp{Result: &result, Rows: rows}, http.StatusOK, nil
+}
+
+// clusteringData loads the `columns` of version `version` of file `fileID`
+// of user `username`, handling their missing values with strategy
+// `missing`. Returns the data along with the names of its columns and the
+// index in the file of its rows.
+//
+// Returns a non-nil error along with the http status code to respond with if
+// the data can't be loaded.
+func (server *Server) clusteringData(
+	ctx context.Context, username string, fileID int64, version int32,
+	columns []columnRef, missing string,
+) (*mat.Dense, []string, []int, int, error) {
+	ds, status, err := server.loadDataset(ctx, username, fileID, version)
+	if err != nil {
+		return nil, nil, nil, status, err
+	}
+
+	cols, err := resolveColumns(ds.names, columns)
+	if err != nil {
+		return nil, nil, nil, http.StatusBadRequest, err
+	}
+
+	data, rows, err := statsanal.HandleMissing(
+		statsanal.SelectColumns(ds.data, cols),
+		statsanal.MissingStrategy(missing),
+	)
+	if err != nil {
+		return nil, nil, nil, http.StatusUnprocessableEntity,
+			fmt.Errorf("Error handling missing values.\n%w", err)
+	}
+
+	return data, ds.selectNames(cols), rows, http.StatusOK, nil
+}
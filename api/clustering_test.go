@@ -0,0 +1,286 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	gomock "go.uber.org/mock/gomock"
+	"gonum.org/v1/gonum/mat"
+
+	mockdb "github.com/yodeman/analyses-api/dbase/mock"
+	db "github.com/yodeman/analyses-api/dbase/sqlc"
+	statsanal "github.com/yodeman/analyses-api/stats-analyses"
+	"github.com/yodeman/analyses-api/util"
+)
+
+// clusteringFile returns a file of two blobs of 3 rows, an outlier, and a
+// row with a missing value.
+func clusteringFile(t *testing.T, username string) db.File {
+	data, err := mat.NewDense(8, 2, []float64{
+		0, 0,
+		0, 0.5,
+		math.NaN(), 1,
+		0.5, 0,
+		10, 10,
+		10, 10.5,
+		10.5, 10,
+		50, 50,
+	}).MarshalBinary()
+	require.NoError(t, err)
+
+	return db.File{
+		ID:          util.RandomInt(1, 1000),
+		Username:    username,
+		Data:        data,
+		ColumnNames: []string{"x", "y"},
+	}
+}
+
+func TestHierarchical(t *testing.T) {
+	user, _ := randomUser(t)
+	file := clusteringFile(t, user.Username)
+	data, err := mat.NewDense(
+		statsanal.MaxAgglomerativeRows+1, 1, nil).MarshalBinary()
+	require.NoError(t, err)
+	large := db.File{
+		ID:          file.ID + 1,
+		Username:    user.Username,
+		Data:        data,
+		ColumnNames: []string{"x"},
+	}
+	getFile := func(store *mockdb.MockStore) {
+		store.EXPECT().
+			GetFile(gomock.Any(), gomock.Eq(db.GetFileParams{
+				ID:       file.ID,
+				Username: user.Username,
+			})).
+			Times(1).
+			Return(file, nil)
+	}
+	noFile := func(store *mockdb.MockStore) {
+		store.EXPECT().
+			GetFile(gomock.Any(), gomock.Any()).
+			Times(0)
+	}
+
+	testCases := []hypothesisCase{
+		{
+			name: "OK",
+			params: hierarchicalRequest{
+				Username: user.Username,
+				FileID:   file.ID,
+				Linkage:  "single",
+				Clusters: 3,
+			},
+			username:   user.Username,
+			buildStubs: getFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var resp hierarchicalResp
+				require.NoError(t, json.NewDecoder(recorder.Body).Decode(&resp))
+				require.Empty(t, resp.Error)
+				require.NotNil(t, resp.Result)
+				require.Equal(t, []int{0, 1, 3, 4, 5, 6, 7}, resp.Rows)
+				require.Len(t, resp.Result.Merges, 6)
+				require.InDelta(t, 0.5, resp.Result.Merges[0].Distance, 1e-12)
+				require.Equal(t, 7, resp.Result.Merges[5].Size)
+				require.Equal(t, []int{0, 0, 0, 1, 1, 1, 2}, resp.Result.Labels)
+			},
+		},
+		{
+			name: "NO LABELS",
+			params: hierarchicalRequest{
+				Username: user.Username,
+				FileID:   file.ID,
+				Columns:  []columnRef{columnName("y")},
+			},
+			username:   user.Username,
+			buildStubs: getFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var resp hierarchicalResp
+				require.NoError(t, json.NewDecoder(recorder.Body).Decode(&resp))
+				require.Equal(t, "ward", string(resp.Result.Linkage))
+				require.Equal(t, []string{"y"}, resp.Result.Names)
+				require.Len(t, resp.Result.Merges, 7)
+				require.Nil(t, resp.Result.Labels)
+			},
+		},
+		{
+			name: "TOO MANY CLUSTERS",
+			params: hierarchicalRequest{
+				Username: user.Username,
+				FileID:   file.ID,
+				Clusters: 8,
+			},
+			username:   user.Username,
+			buildStubs: getFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name: "TOO MANY ROWS",
+			params: hierarchicalRequest{
+				Username: user.Username,
+				FileID:   large.ID,
+			},
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetFile(gomock.Any(), gomock.Eq(db.GetFileParams{
+						ID:       large.ID,
+						Username: user.Username,
+					})).
+					Times(1).
+					Return(large, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name: "INVALID LINKAGE",
+			params: hierarchicalRequest{
+				Username: user.Username,
+				FileID:   file.ID,
+				Linkage:  "centroid",
+			},
+			username:   user.Username,
+			buildStubs: noFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:       "UNAUTHORIZED",
+			params:     hierarchicalRequest{Username: user.Username, FileID: file.ID},
+			username:   "deidara",
+			buildStubs: noFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	runHypothesisCases(t, "/analyses/hierarchical", testCases)
+}
+
+func TestDBSCAN(t *testing.T) {
+	user, _ := randomUser(t)
+	file := clusteringFile(t, user.Username)
+	getFile := func(store *mockdb.MockStore) {
+		store.EXPECT().
+			GetFile(gomock.Any(), gomock.Eq(db.GetFileParams{
+				ID:       file.ID,
+				Username: user.Username,
+			})).
+			Times(1).
+			Return(file, nil)
+	}
+	noFile := func(store *mockdb.MockStore) {
+		store.EXPECT().
+			GetFile(gomock.Any(), gomock.Any()).
+			Times(0)
+	}
+
+	testCases := []hypothesisCase{
+		{
+			name: "OK",
+			params: dbscanRequest{
+				Username:  user.Username,
+				FileID:    file.ID,
+				Eps:       1,
+				MinPoints: 3,
+			},
+			username:   user.Username,
+			buildStubs: getFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				resp := requireBodyMatchDBSCAN(t, recorder.Body)
+				require.Equal(t, []int{0, 1, 3, 4, 5, 6, 7}, resp.Rows)
+				require.Equal(t, 2, resp.Result.Clusters)
+				require.Equal(t, []int{0, 0, 0, 1, 1, 1, -1}, resp.Result.Labels)
+				require.Equal(t, []int{6}, resp.Result.Noise)
+			},
+		},
+		{
+			name: "IMPUTED",
+			params: dbscanRequest{
+				Username:  user.Username,
+				FileID:    file.ID,
+				Eps:       1,
+				MinPoints: 3,
+				Missing:   "ffill",
+			},
+			username:   user.Username,
+			buildStubs: getFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				resp := requireBodyMatchDBSCAN(t, recorder.Body)
+				require.Len(t, resp.Rows, 8)
+				require.Equal(t, []int{4, 3}, resp.Result.Sizes)
+			},
+		},
+		{
+			name: "MISSING EPS",
+			params: dbscanRequest{
+				Username: user.Username,
+				FileID:   file.ID,
+			},
+			username:   user.Username,
+			buildStubs: noFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "UNKNOWN COLUMN",
+			params: dbscanRequest{
+				Username: user.Username,
+				FileID:   file.ID,
+				Columns:  []columnRef{columnName("z")},
+				Eps:      1,
+			},
+			username:   user.Username,
+			buildStubs: getFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "UNAUTHORIZED",
+			params: dbscanRequest{
+				Username: user.Username,
+				FileID:   file.ID,
+				Eps:      1,
+			},
+			username:   "deidara",
+			buildStubs: noFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	runHypothesisCases(t, "/analyses/dbscan", testCases)
+}
+
+func requireBodyMatchDBSCAN(t *testing.T, responseBody *bytes.Buffer) dbscanResp {
+	var serverResp dbscanResp
+
+	err := json.NewDecoder(responseBody).Decode(&serverResp)
+	require.NoError(t, err)
+
+	require.Empty(t, serverResp.Error)
+	require.NotNil(t, serverResp.Result)
+	require.Len(t, serverResp.Result.Labels, len(serverResp.Rows))
+	require.Len(t, serverResp.Result.Core, len(serverResp.Rows))
+
+	return serverResp
+}
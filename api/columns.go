@@ -0,0 +1,154 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// columnRef references a dataset column either by its zero based index or
+// by its name. In json it is either a number or a string, e.g. `3` or
+// `"price"`.
+type columnRef struct {
+	index  int
+	name   string
+	byName bool
+}
+
+// columnIndex returns a reference to the column at index `i`.
+func columnIndex(i int) columnRef {
+	return columnRef{index: i}
+}
+
+// columnName returns a reference to the column named `name`.
+func columnName(name string) columnRef {
+	return columnRef{name: name, byName: true}
+}
+
+func (ref *columnRef) UnmarshalJSON(data []byte) error {
+	if bytes.HasPrefix(data, []byte(`"`)) {
+		ref.byName = true
+		return json.Unmarshal(data, &ref.name)
+	}
+
+	i, err := strconv.Atoi(string(data))
+	if err != nil {
+		return fmt.Errorf("column should be an index or a name, got %s", data)
+	}
+	ref.index = i
+	return nil
+}
+
+func (ref columnRef) MarshalJSON() ([]byte, error) {
+	if ref.byName {
+		return json.Marshal(ref.name)
+	}
+	return json.Marshal(ref.index)
+}
+
+func (ref columnRef) String() string {
+	if ref.byName {
+		return strconv.Quote(ref.name)
+	}
+	return strconv.Itoa(ref.index)
+}
+
+// resolve finds the index of the referenced column among the dataset's
+// column `names`.
+//
+// Returns an error if the index is out of range or the name is unknown.
+func (ref columnRef) resolve(names []string) (int, error) {
+	if !ref.byName {
+		if ref.index < 0 || ref.index >= len(names) {
+			return 0, fmt.Errorf(
+				"column index %d out of range [0, %d).", ref.index, len(names))
+		}
+		return ref.index, nil
+	}
+
+	for i, name := range names {
+		if name == ref.name {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown column %q.", ref.name)
+}
+
+// resolveModelColumns resolves the target and predictor columns of a model
+// against the dataset's column `names`. The last column is the default target
+// and every other column is a predictor when none is given. The returned
+// slice holds the predictors' indices followed by the target's index.
+//
+// Returns an error if a column can't be resolved, a column is repeated or the
+// target is also a predictor.
+func resolveModelColumns(
+	names []string, target *columnRef, predictors []columnRef,
+) ([]int, error) {
+	if len(names) < 2 {
+		return nil, fmt.Errorf("a model needs at least 2 columns, got %d.", len(names))
+	}
+
+	targetIdx := len(names) - 1
+	if target != nil {
+		i, err := target.resolve(names)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid target column.\n%w", err)
+		}
+		targetIdx = i
+	}
+
+	seen := map[int]bool{targetIdx: true}
+	var cols []int
+	if len(predictors) == 0 {
+		for i := range names {
+			if i != targetIdx {
+				cols = append(cols, i)
+			}
+		}
+	}
+	for _, ref := range predictors {
+		i, err := ref.resolve(names)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid predictor column.\n%w", err)
+		}
+		if seen[i] {
+			return nil, fmt.Errorf(
+				"column %s is repeated or used as both target and predictor.", ref)
+		}
+		seen[i] = true
+		cols = append(cols, i)
+	}
+
+	return append(cols, targetIdx), nil
+}
+
+// resolveColumns resolves the column references `refs` against the dataset's
+// column `names`, in the given order. Every column is selected when `refs` is
+// empty.
+//
+// Returns an error if a column can't be resolved or is repeated.
+func resolveColumns(names []string, refs []columnRef) ([]int, error) {
+	if len(refs) == 0 {
+		cols := make([]int, len(names))
+		for i := range cols {
+			cols[i] = i
+		}
+		return cols, nil
+	}
+
+	seen := make(map[int]bool, len(refs))
+	cols := make([]int, len(refs))
+	for j, ref := range refs {
+		i, err := ref.resolve(names)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid column.\n%w", err)
+		}
+		if seen[i] {
+			return nil, fmt.Errorf("column %s is repeated.", ref)
+		}
+		seen[i] = true
+		cols[j] = i
+	}
+	return cols, nil
+}
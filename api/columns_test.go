@@ -0,0 +1,104 @@
+package api
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestColumnRefJSON(t *testing.T) {
+	var refs []columnRef
+	err := json.Unmarshal([]byte(`[2, "price"]`), &refs)
+	require.NoError(t, err)
+	require.Equal(t, []columnRef{columnIndex(2), columnName("price")}, refs)
+
+	encoded, err := json.Marshal(refs)
+	require.NoError(t, err)
+	require.JSONEq(t, `[2, "price"]`, string(encoded))
+
+	err = json.Unmarshal([]byte(`[true]`), &refs)
+	require.Error(t, err)
+}
+
+func TestResolveModelColumns(t *testing.T) {
+	names := []string{"a", "b", "c", "d"}
+	target := columnName("b")
+
+	testCases := []struct {
+		name       string
+		target     *columnRef
+		predictors []columnRef
+		expected   []int
+		hasErr     bool
+	}{
+		{
+			name:     "DEFAULT",
+			expected: []int{0, 1, 2, 3},
+		},
+		{
+			name:     "TARGET ONLY",
+			target:   &target,
+			expected: []int{0, 2, 3, 1},
+		},
+		{
+			name:       "TARGET AND PREDICTORS",
+			target:     &target,
+			predictors: []columnRef{columnIndex(3), columnName("a")},
+			expected:   []int{3, 0, 1},
+		},
+		{
+			name:       "TARGET AS PREDICTOR",
+			target:     &target,
+			predictors: []columnRef{columnIndex(1)},
+			hasErr:     true,
+		},
+		{
+			name:       "REPEATED PREDICTOR",
+			predictors: []columnRef{columnIndex(0), columnName("a")},
+			hasErr:     true,
+		},
+		{
+			name:       "OUT OF RANGE",
+			predictors: []columnRef{columnIndex(4)},
+			hasErr:     true,
+		},
+		{
+			name:       "UNKNOWN NAME",
+			predictors: []columnRef{columnName("e")},
+			hasErr:     true,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			cols, err := resolveModelColumns(names, tc.target, tc.predictors)
+			if tc.hasErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, cols)
+		})
+	}
+}
+
+func TestResolveColumns(t *testing.T) {
+	names := []string{"a", "b", "c"}
+
+	cols, err := resolveColumns(names, nil)
+	require.NoError(t, err)
+	require.Equal(t, []int{0, 1, 2}, cols)
+
+	cols, err = resolveColumns(names, []columnRef{columnName("c"), columnIndex(0)})
+	require.NoError(t, err)
+	require.Equal(t, []int{2, 0}, cols)
+
+	_, err = resolveColumns(names, []columnRef{columnIndex(1), columnName("b")})
+	require.Error(t, err)
+
+	_, err = resolveColumns(names, []columnRef{columnName("d")})
+	require.Error(t, err)
+}
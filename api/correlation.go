@@ -0,0 +1,152 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	statsanal "github.com/yodeman/analyses-api/stats-analyses"
+)
+
+// Response format for correlation request.
+type correlationResp struct {
+	Result *statsanal.CorrelationResult `json:"result"`
+	Error  string                       `json:"error"`
+}
+
+// Request format for correlation queries.
+type correlationRequest struct {
+	Username string      `json:"username" binding:"required,alphanum"`
+	FileID   int64       `json:"file_id" binding:"required,min=1"`
+	Version  int32       `json:"version" binding:"omitempty,min=1"`
+	Columns  []columnRef `json:"columns"`
+	Method   string      `json:"method" binding:"omitempty,oneof=pearson spearman kendall"`
+	Pairwise bool        `json:"pairwise"`
+}
+
+/*
+correlation computes the correlation and covariance matrices of the columns
+of one of the user's files. The endpoint expects a GET request with a json
+body with the following key:
+
+	`username` - alphanumeric user's username
+	`file_id`  - id of the user's file to analyse.
+	`version`  - optional, version of the file to analyse, defaults to the
+	             file's current version.
+	`columns`  - optional, list of indices or names of the columns to
+	             correlate, defaults to every column.
+	`method`   - optional, correlation coefficient: `pearson` (default),
+	             `spearman` or `kendall`.
+	`pairwise` - optional, compute each pair of columns from the rows where
+	             both are observed, instead of dropping every row with a
+	             missing value.
+
+Statistics that can't be computed, e.g. the correlation with a constant
+column, are null.
+
+The request returns response with the following http status codes:
+
+200 - status OK:
+
+	with response body:
+	    {
+	        "result": {
+	            "names": ["*****", ...],
+	            "method": "*****",
+	            "correlation": [[*****], ...],
+	            "p_values": [[*****], ...],
+	            "covariance": [[*****], ...],
+	            "observations": [[*****], ...]
+	        },
+	        "error":""
+	     }
+
+400 - status Bad Request:
+
+	Error parsing request body, or invalid columns.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+401 - status Unauthorized:
+
+	If access token has expired or username in request body don't match username
+	in token payload.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+404 - status Not Found:
+
+	If the user has no file with id `file_id`, or the file has no such
+	version.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+422 - status Unprocessable Entity:
+
+	If no row is left after dropping missing values.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+501 - status Internal Server Error:
+
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+*/
+func (server *Server) correlation(ctx *gin.Context) {
+	server.serveAnalysis(ctx, &correlationRequest{})
+}
+
+func (req *correlationRequest) owner() string {
+	return req.Username
+}
+
+// run correlates the columns of the file of user `username` and returns the
+// response body.
+//
+// Returns a non-nil error along with the http status code to respond with if
+// the columns can't be correlated.
+func (req *correlationRequest) run(
+	ctx context.Context, server *Server, username string,
+) (any, int, error) {
+	ds, status, err := server.loadDataset(ctx, username, req.FileID, req.Version)
+	if err != nil {
+		return nil, status, err
+	}
+
+	columns, err := resolveColumns(ds.names, req.Columns)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	result, err := statsanal.Correlation(
+		statsanal.SelectColumns(ds.data, columns),
+		statsanal.CorrelationOptions{
+			Method:   statsanal.CorrelationMethod(req.Method),
+			Pairwise: req.Pairwise,
+			Names:    ds.selectNames(columns),
+		},
+	)
+	if err != nil {
+		return nil, http.StatusUnprocessableEntity,
+			fmt.Errorf("Error during correlation analysis\n%w", err)
+	}
+
+	return correlationResp{Result: &result}, http.StatusOK, nil
+}
@@ -0,0 +1,192 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	gomock "go.uber.org/mock/gomock"
+	"gonum.org/v1/gonum/mat"
+
+	mockdb "github.com/yodeman/analyses-api/dbase/mock"
+	db "github.com/yodeman/analyses-api/dbase/sqlc"
+	"github.com/yodeman/analyses-api/util"
+)
+
+func TestCorrelation(t *testing.T) {
+	user, _ := randomUser(t)
+	rows, cols, data, err := util.ParseCSVToFloatSlice(
+		strings.NewReader(util.RandomCSV(30, 4)))
+	require.NoError(t, err)
+	matrix := mat.NewDense(rows, cols, data)
+	matrix.Set(0, 0, math.NaN())
+	matrix.Set(1, 1, math.NaN())
+	matData, err := matrix.MarshalBinary()
+	require.NoError(t, err)
+
+	fileID := util.RandomInt(1, 1000)
+	getFileParams := db.GetFileParams{ID: fileID, Username: user.Username}
+	file := db.File{
+		ID:          fileID,
+		Username:    user.Username,
+		Data:        matData,
+		ColumnNames: []string{"a", "b", "c", "d"},
+	}
+
+	testCases := []struct {
+		name          string
+		params        correlationRequest
+		username      string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			params:   correlationRequest{Username: user.Username, FileID: fileID},
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetFile(gomock.Any(), gomock.Eq(getFileParams)).
+					Times(1).
+					Return(file, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				resp := requireBodyMatchCorrelation(t, recorder.Body, cols)
+				require.Equal(t, "pearson", resp.Result.Method)
+				// listwise deletion drops both rows.
+				require.Equal(t, rows-2, resp.Result.Observations[2][3])
+			},
+		},
+		{
+			name: "PAIRWISE SPEARMAN",
+			params: correlationRequest{
+				Username: user.Username,
+				FileID:   fileID,
+				Columns:  []columnRef{columnName("a"), columnName("c")},
+				Method:   "spearman",
+				Pairwise: true,
+			},
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetFile(gomock.Any(), gomock.Eq(getFileParams)).
+					Times(1).
+					Return(file, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				resp := requireBodyMatchCorrelation(t, recorder.Body, 2)
+				require.Equal(t, []string{"a", "c"}, resp.Result.Names)
+				require.Equal(t, "spearman", resp.Result.Method)
+				require.Equal(t, rows-1, resp.Result.Observations[0][1])
+				require.Equal(t, rows, resp.Result.Observations[1][1])
+			},
+		},
+		{
+			name: "INVALID METHOD",
+			params: correlationRequest{
+				Username: user.Username,
+				FileID:   fileID,
+				Method:   "cosine",
+			},
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetFile(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "UNAUTHORIZED",
+			params:   correlationRequest{Username: user.Username, FileID: fileID},
+			username: "deidara",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetFile(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:     "INTERNAL ERROR",
+			params:   correlationRequest{Username: user.Username, FileID: fileID},
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetFile(gomock.Any(), gomock.Eq(getFileParams)).
+					Times(1).
+					Return(db.File{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			encodedParams, err := json.Marshal(tc.params)
+			require.NoError(t, err)
+			request, err := http.NewRequest(
+				http.MethodGet, "/analyses/correlation", bytes.NewBuffer(encodedParams))
+			require.NoError(t, err)
+			addAuthorization(
+				t, request, server.tokenMaker, authorizationTypeToken,
+				tc.username, time.Minute,
+			)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func requireBodyMatchCorrelation(
+	t *testing.T, responseBody *bytes.Buffer, cols int,
+) correlationResp {
+	var serverResp correlationResp
+
+	err := json.NewDecoder(responseBody).Decode(&serverResp)
+	require.NoError(t, err)
+
+	require.Empty(t, serverResp.Error)
+	require.NotNil(t, serverResp.Result)
+	require.Len(t, serverResp.Result.Names, cols)
+	require.Len(t, serverResp.Result.Correlation, cols)
+	require.Len(t, serverResp.Result.PValues, cols)
+	require.Len(t, serverResp.Result.Covariance, cols)
+	for i := 0; i < cols; i++ {
+		require.InDelta(t, 1, *serverResp.Result.Correlation[i][i], 1e-9)
+		for j := 0; j < cols; j++ {
+			require.Equal(t,
+				*serverResp.Result.Correlation[i][j],
+				*serverResp.Result.Correlation[j][i])
+		}
+	}
+
+	return serverResp
+}
@@ -0,0 +1,246 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gonum.org/v1/gonum/mat"
+
+	statsanal "github.com/yodeman/analyses-api/stats-analyses"
+)
+
+// Response format for cross-validation request.
+type crossValidationResp struct {
+	Result *statsanal.CrossValidationResult `json:"result"`
+	Error  string                           `json:"error"`
+}
+
+// Request format for cross-validation queries.
+type crossValidationRequest struct {
+	Username    string      `json:"username" binding:"required,alphanum"`
+	FileID      int64       `json:"file_id" binding:"required,min=1"`
+	Version     int32       `json:"version" binding:"omitempty,min=1"`
+	Model       string      `json:"model" binding:"required,oneof=linear logistic regularized"`
+	Folds       int         `json:"folds" binding:"omitempty,min=2"`
+	Holdout     float64     `json:"holdout" binding:"omitempty,gt=0,lt=1"`
+	Seed        int64       `json:"seed"`
+	Threshold   float64     `json:"threshold" binding:"omitempty,gt=0,lt=1"`
+	NoIntercept bool        `json:"no_intercept"`
+	Penalty     string      `json:"penalty" binding:"omitempty,oneof=ridge lasso elastic_net"`
+	Alpha       float64     `json:"alpha" binding:"omitempty,gt=0,lt=1"`
+	Lambda      float64     `json:"lambda" binding:"omitempty,gt=0"`
+	Target      *columnRef  `json:"target"`
+	Predictors  []columnRef `json:"predictors"`
+	Missing     string      `json:"missing" binding:"omitempty,oneof=listwise mean median ffill"`
+}
+
+/*
+crossValidation estimates how well a regression model generalizes to new rows
+of one of the user's files, by scoring it on rows held out of its fit. The
+endpoint expects a GET request with a json body with the following key:
+
+	`username`   - alphanumeric user's username
+	`file_id`    - id of the user's file to analyse.
+	`version`    - optional, version of the file to analyse, defaults to the
+	               file's current version.
+	`model`      - `linear`, `logistic` or `regularized`, see the
+	               /analyses/regression, /analyses/logistic and
+	               /analyses/regularized endpoints.
+	`folds`      - optional, number of k-fold splits, defaults to 5.
+	`holdout`    - optional, share of rows in (0, 1) held out to test a single
+	               fit, replaces the k-fold splits.
+	`seed`       - optional, seed of the random shuffle of the rows before
+	               splitting them, defaults to 0.
+	`threshold`  - optional, probability above which a logistic model
+	               predicts the positive class, defaults to 0.5.
+	`no_intercept` - optional, fit linear and logistic models without an
+	               intercept.
+	`penalty`    - optional, penalty of regularized models, defaults to
+	               `ridge`.
+	`alpha`      - optional, L1 share of the elastic net penalty.
+	`lambda`     - optional, strength of the penalty of regularized models,
+	               chosen by cross-validation on each training split when not
+	               given.
+	`target`     - optional, index or name of the target column, defaults to
+	               the last column.
+	`predictors` - optional, list of indices or names of the predictor
+	               columns, defaults to every column but the target.
+	`missing`    - optional, how missing values in the selected columns are
+	               handled: `listwise` (default), `mean`, `median` or
+	               `ffill`, see /analyses/regression.
+
+Linear and regularized models are scored by `rmse`, `mae` and `r_squared`,
+logistic models by `accuracy` and `auc`. Scores undefined on a split, like
+the AUC of a split with a single class, are left out.
+
+The request returns response with the following http status codes:
+
+200 - status OK:
+
+	with response body:
+	    {
+	        "result": {
+	            "kind": "*****",
+	            "method": "*****",
+	            "seed": *****,
+	            "folds": [
+	                {
+	                    "fold": *****,
+	                    "train_rows": *****,
+	                    "test_rows": *****,
+	                    "rmse": *****,
+	                    "mae": *****,
+	                    "r_squared": *****
+	                },
+	                ...
+	            ],
+	            "mean": {"rmse": *****, "mae": *****, "r_squared": *****},
+	            "std_dev": {"rmse": *****, "mae": *****, "r_squared": *****}
+	        },
+	        "error":""
+	     }
+
+400 - status Bad Request:
+
+	Error parsing request body, or invalid target or predictor columns.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+401 - status Unauthorized:
+
+	If access token has expired or username in request body don't match username
+	in token payload.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+404 - status Not Found:
+
+	If the user has no file with id `file_id`, or the file has no such
+	version.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+422 - status Unprocessable Entity:
+
+	If a split has no training or test rows, a fit fails on a split, like
+	collinear predictors, a constant target or a target that isn't binary
+	for logistic models, or no data is left after handling missing values.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+501 - status Internal Server Error:
+
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+*/
+func (server *Server) crossValidation(ctx *gin.Context) {
+	server.serveAnalysis(ctx, &crossValidationRequest{})
+}
+
+func (req *crossValidationRequest) owner() string {
+	return req.Username
+}
+
+// run cross-validates the model on the file of user `username` and returns
+// the response body.
+//
+// Returns a non-nil error along with the http status code to respond with if
+// the cross-validation can't be performed.
+func (req *crossValidationRequest) run(
+	ctx context.Context, server *Server, username string,
+) (any, int, error) {
+	ds, status, err := server.loadDataset(ctx, username, req.FileID, req.Version)
+	if err != nil {
+		return nil, status, err
+	}
+
+	columns, err := resolveModelColumns(ds.names, req.Target, req.Predictors)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	modelData, _, err := statsanal.HandleMissing(
+		statsanal.SelectColumns(ds.data, columns),
+		statsanal.MissingStrategy(req.Missing),
+	)
+	if err != nil {
+		return nil, http.StatusUnprocessableEntity,
+			fmt.Errorf("Error handling missing values.\n%w", err)
+	}
+
+	result, err := statsanal.CrossValidate(
+		ctx,
+		modelData,
+		req.fitter(ds.selectNames(columns)),
+		statsanal.CrossValidationOptions{
+			Folds:     req.Folds,
+			Holdout:   req.Holdout,
+			Seed:      req.Seed,
+			Threshold: req.Threshold,
+		},
+	)
+	if err != nil {
+		if errors.Is(err, statsanal.ErrEmptySplit) ||
+			errors.Is(err, statsanal.ErrRankDeficient) ||
+			errors.Is(err, statsanal.ErrNoVariance) ||
+			errors.Is(err, statsanal.ErrNotBinary) ||
+			errors.Is(err, statsanal.ErrNotConverged) {
+			return nil, http.StatusUnprocessableEntity, err
+		}
+		return nil, http.StatusInternalServerError,
+			fmt.Errorf("Error during cross-validation\n%w", err)
+	}
+
+	return crossValidationResp{Result: &result}, http.StatusOK, nil
+}
+
+// fitter returns the fitter of the requested model, whose columns are named
+// `names` with the target last.
+func (req *crossValidationRequest) fitter(names []string) statsanal.Fitter {
+	predictors, target := names[:len(names)-1], names[len(names)-1]
+
+	return func(train *mat.Dense) (statsanal.Model, error) {
+		switch statsanal.ModelKind(req.Model) {
+		case statsanal.KindLogistic:
+			res, err := statsanal.LogisticRegression(
+				train,
+				statsanal.LogisticOptions{NoIntercept: req.NoIntercept},
+			)
+			return res.Model(predictors, target), err
+		case statsanal.KindRegularized:
+			res, err := statsanal.RegularizedRegression(
+				train,
+				statsanal.RegularizedOptions{
+					Penalty: statsanal.Penalty(req.Penalty),
+					Alpha:   req.Alpha,
+					Lambda:  req.Lambda,
+				},
+			)
+			return res.Model(predictors, target), err
+		default:
+			res, err := statsanal.LinearRegression(
+				train,
+				statsanal.RegressionOptions{NoIntercept: req.NoIntercept},
+			)
+			return res.Model(predictors, target), err
+		}
+	}
+}
@@ -0,0 +1,243 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	gomock "go.uber.org/mock/gomock"
+	"gonum.org/v1/gonum/mat"
+
+	mockdb "github.com/yodeman/analyses-api/dbase/mock"
+	db "github.com/yodeman/analyses-api/dbase/sqlc"
+	"github.com/yodeman/analyses-api/util"
+)
+
+func TestCrossValidation(t *testing.T) {
+	user, _ := randomUser(t)
+	rows, cols, data, err := util.ParseCSVToFloatSlice(
+		strings.NewReader(util.RandomCSV(40, 3)))
+	require.NoError(t, err)
+	matrix := mat.NewDense(rows, cols, data)
+	// binary last column for logistic models.
+	binary := mat.DenseCopyOf(matrix)
+	for i := 0; i < rows; i++ {
+		binary.Set(i, cols-1, float64(i%2))
+	}
+	matData, err := matrix.MarshalBinary()
+	require.NoError(t, err)
+	binaryData, err := binary.MarshalBinary()
+	require.NoError(t, err)
+
+	fileID := util.RandomInt(1, 1000)
+	getFileParams := db.GetFileParams{ID: fileID, Username: user.Username}
+	file := db.File{ID: fileID, Username: user.Username, Data: matData}
+	binaryFile := db.File{ID: fileID, Username: user.Username, Data: binaryData}
+
+	testCases := []struct {
+		name          string
+		params        crossValidationRequest
+		username      string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "LINEAR KFOLD",
+			params: crossValidationRequest{
+				Username: user.Username,
+				FileID:   fileID,
+				Model:    "linear",
+				Folds:    4,
+				Seed:     3,
+			},
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetFile(gomock.Any(), gomock.Eq(getFileParams)).
+					Times(1).
+					Return(file, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				resp := requireBodyMatchCrossValidation(t, recorder.Body, rows, 4)
+				require.Equal(t, "kfold", resp.Result.Method)
+				require.Equal(t, int64(3), resp.Result.Seed)
+				require.NotNil(t, resp.Result.Mean.RMSE)
+				require.NotNil(t, resp.Result.Mean.MAE)
+				require.Nil(t, resp.Result.Mean.Accuracy)
+			},
+		},
+		{
+			name: "LOGISTIC HOLDOUT",
+			params: crossValidationRequest{
+				Username: user.Username,
+				FileID:   fileID,
+				Model:    "logistic",
+				Holdout:  0.25,
+			},
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetFile(gomock.Any(), gomock.Eq(getFileParams)).
+					Times(1).
+					Return(binaryFile, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				resp := requireBodyMatchCrossValidation(t, recorder.Body, rows/4, 1)
+				require.Equal(t, "holdout", resp.Result.Method)
+				require.Equal(t, "logistic", string(resp.Result.Kind))
+				require.NotNil(t, resp.Result.Mean.Accuracy)
+				require.Nil(t, resp.Result.Mean.RMSE)
+			},
+		},
+		{
+			name: "REGULARIZED",
+			params: crossValidationRequest{
+				Username: user.Username,
+				FileID:   fileID,
+				Model:    "regularized",
+				Penalty:  "lasso",
+				Lambda:   0.1,
+			},
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetFile(gomock.Any(), gomock.Eq(getFileParams)).
+					Times(1).
+					Return(file, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				resp := requireBodyMatchCrossValidation(t, recorder.Body, rows, 5)
+				require.Equal(t, "regularized", string(resp.Result.Kind))
+			},
+		},
+		{
+			name: "NOT BINARY",
+			params: crossValidationRequest{
+				Username: user.Username,
+				FileID:   fileID,
+				Model:    "logistic",
+			},
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetFile(gomock.Any(), gomock.Eq(getFileParams)).
+					Times(1).
+					Return(file, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name: "TOO MANY FOLDS",
+			params: crossValidationRequest{
+				Username: user.Username,
+				FileID:   fileID,
+				Model:    "linear",
+				Folds:    rows + 1,
+			},
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetFile(gomock.Any(), gomock.Eq(getFileParams)).
+					Times(1).
+					Return(file, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name: "INVALID MODEL",
+			params: crossValidationRequest{
+				Username: user.Username,
+				FileID:   fileID,
+				Model:    "forest",
+			},
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetFile(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "UNAUTHORIZED",
+			params: crossValidationRequest{
+				Username: user.Username,
+				FileID:   fileID,
+				Model:    "linear",
+			},
+			username: "deidara",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetFile(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			encodedParams, err := json.Marshal(tc.params)
+			require.NoError(t, err)
+			request, err := http.NewRequest(
+				http.MethodGet, "/analyses/crossvalidation", bytes.NewBuffer(encodedParams))
+			require.NoError(t, err)
+			addAuthorization(
+				t, request, server.tokenMaker, authorizationTypeToken,
+				tc.username, time.Minute,
+			)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+// requireBodyMatchCrossValidation checks the response has `folds` folds,
+// testing `tested` rows in total.
+func requireBodyMatchCrossValidation(
+	t *testing.T, responseBody *bytes.Buffer, tested, folds int,
+) crossValidationResp {
+	var serverResp crossValidationResp
+
+	err := json.NewDecoder(responseBody).Decode(&serverResp)
+	require.NoError(t, err)
+
+	require.Empty(t, serverResp.Error)
+	require.NotNil(t, serverResp.Result)
+	require.Len(t, serverResp.Result.Folds, folds)
+	var total int
+	for _, fold := range serverResp.Result.Folds {
+		total += fold.TestRows
+	}
+	require.Equal(t, tested, total)
+
+	return serverResp
+}
@@ -0,0 +1,84 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"gonum.org/v1/gonum/mat"
+
+	db "github.com/yodeman/analyses-api/dbase/sqlc"
+	statsanal "github.com/yodeman/analyses-api/stats-analyses"
+)
+
+// dataset is a user's file decoded for analyses.
+type dataset struct {
+	data    *mat.Dense
+	names   []string // column names, positional names if the file has none.
+	version int32
+}
+
+// selectNames returns the names of the dataset's columns at indices `cols`.
+func (ds dataset) selectNames(cols []int) []string {
+	names := make([]string, len(cols))
+	for i, col := range cols {
+		names[i] = ds.names[col]
+	}
+	return names
+}
+
+// loadDataset fetches the user's file with id `fileID` and decodes its data.
+// The file's current version is used when `version` is zero.
+//
+// Returns a non-nil error along with the http status code to respond with if
+// the file or version doesn't exist or can't be decoded.
+func (server *Server) loadDataset(
+	ctx context.Context, username string, fileID int64, version int32,
+) (dataset, int, error) {
+	var raw []byte
+	var names []string
+	var err error
+	var current int32
+
+	if version == 0 {
+		var userFile db.File
+		userFile, err = server.store.GetFile(
+			ctx, db.GetFileParams{ID: fileID, Username: username})
+		raw, names, current = userFile.Data, userFile.ColumnNames, userFile.Version
+	} else {
+		var fileVersion db.FileVersion
+		fileVersion, err = server.store.GetFileVersion(
+			ctx,
+			db.GetFileVersionParams{
+				FileID:   fileID,
+				Version:  version,
+				Username: username,
+			},
+		)
+		raw, names, current = fileVersion.Data, fileVersion.ColumnNames, version
+	}
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dataset{}, http.StatusNotFound,
+				fmt.Errorf("File or version does not exist.\n%w", err)
+		}
+		return dataset{}, http.StatusInternalServerError,
+			fmt.Errorf("Error fetching user's file\n%w", err)
+	}
+
+	var data mat.Dense
+	err = data.UnmarshalBinary(raw)
+	if err != nil {
+		return dataset{}, http.StatusInternalServerError,
+			fmt.Errorf("Error decoding user's file\n%w", err)
+	}
+
+	_, cols := data.Dims()
+	if len(names) != cols {
+		names = statsanal.ColumnNames(cols)
+	}
+
+	return dataset{data: &data, names: names, version: current}, http.StatusOK, nil
+}
@@ -0,0 +1,357 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	statsanal "github.com/yodeman/analyses-api/stats-analyses"
+)
+
+// Response format for decomposition request.
+type decompositionResp struct {
+	Result *statsanal.DecompositionResult `json:"result"`
+	Name   string                         `json:"name,omitempty"`
+	// Rows holds the index in the file of each value of the series.
+	Rows  []int  `json:"rows,omitempty"`
+	Error string `json:"error"`
+}
+
+// Request format for decomposition queries.
+type decompositionRequest struct {
+	Username       string     `json:"username" binding:"required,alphanum"`
+	FileID         int64      `json:"file_id" binding:"required,min=1"`
+	Version        int32      `json:"version" binding:"omitempty,min=1"`
+	Column         *columnRef `json:"column" binding:"required"`
+	Period         int        `json:"period" binding:"required,min=2"`
+	Method         string     `json:"method" binding:"omitempty,oneof=classical stl"`
+	Seasonality    string     `json:"seasonality" binding:"omitempty,oneof=additive multiplicative"`
+	SeasonalWindow int        `json:"seasonal_window" binding:"omitempty,min=3"`
+	TrendWindow    int        `json:"trend_window" binding:"omitempty,min=3"`
+	Robust         bool       `json:"robust"`
+	Missing        string     `json:"missing" binding:"omitempty,oneof=listwise mean median ffill"`
+}
+
+/*
+decomposition splits a column of one of the user's files, ordered like its
+rows, into a trend, a seasonal component and residuals. The endpoint expects a
+GET request with a json body with the following key:
+
+	`username`        - alphanumeric user's username
+	`file_id`         - id of the user's file to analyse.
+	`version`         - optional, version of the file to analyse, defaults to
+	                    the file's current version.
+	`column`          - index or name of the column holding the series.
+	`period`          - number of values of a season.
+	`method`          - optional, `classical` (default) for moving averages,
+	                    or `stl` for loess smoothing.
+	`seasonality`     - optional, `additive` (default) or `multiplicative`
+	                    components, which the classical method only supports.
+	`seasonal_window` - optional, odd number of seasons each seasonal value is
+	                    smoothed over by `stl`, defaults to 7.
+	`trend_window`    - optional, odd number of values the trend is smoothed
+	                    over by `stl`, defaults to the smallest odd integer not
+	                    below 1.5 * `period` / (1 - 1.5 / `seasonal_window`).
+	`robust`          - optional, make `stl` downweight outlying values.
+	`missing`         - optional, how missing values are handled: `listwise`
+	                    (default), `mean`, `median` or `ffill`, see
+	                    /analyses/regression.
+
+The series needs at least two periods. The classical trend is the centered
+moving average over a period, null for the first and last half period, and
+`figure` holds the seasonal component of each position of the period. The
+components are ordered like `rows`, the indices in the file of the values of
+the series, which skip the rows dropped for missing values. The strengths of
+the trend and seasonal components, in [0, 1], compare the variance of the
+residuals to the one of the component plus residuals.
+
+The request returns response with the following http status codes:
+
+200 - status OK:
+
+	with response body:
+	    {
+	        "result": {
+	            "method": "*****",
+	            "seasonality": "*****",
+	            "period": *****,
+	            "seasonal_window": *****,
+	            "trend_window": *****,
+	            "trend": [*****],
+	            "seasonal": [*****],
+	            "residual": [*****],
+	            "figure": [*****],
+	            "trend_strength": *****,
+	            "seasonal_strength": *****
+	        },
+	        "name": "*****",
+	        "rows": [*****],
+	        "error":""
+	     }
+
+400 - status Bad Request:
+
+	Error parsing request body, or invalid column.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+401 - status Unauthorized:
+
+	If access token has expired or username in request body don't match username
+	in token payload.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+404 - status Not Found:
+
+	If the user has no file with id `file_id`, or the file has no such
+	version.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+422 - status Unprocessable Entity:
+
+	If the series has less than two periods, a window is even, `stl` is
+	asked for multiplicative components, a multiplicative series has
+	non-positive values, or no data is left after handling missing values.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+501 - status Internal Server Error:
+
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+*/
+func (server *Server) decomposition(ctx *gin.Context) {
+	server.serveAnalysis(ctx, &decompositionRequest{})
+}
+
+func (req *decompositionRequest) owner() string {
+	return req.Username
+}
+
+// run performs the seasonal decomposition on the file of user `username` and
+// returns the response body.
+//
+// Returns a non-nil error along with the http status code to respond with if
+// the decomposition can't be performed.
+func (req *decompositionRequest) run(
+	ctx context.Context, server *Server, username string,
+) (any, int, error) {
+	series, name, rows, status, err := server.seriesData(
+		ctx, username, req.FileID, req.Version, *req.Column, req.Missing)
+	if err != nil {
+		return nil, status, err
+	}
+
+	result, err := statsanal.Decompose(
+		series,
+		statsanal.DecompositionOptions{
+			Method:         statsanal.DecompositionMethod(req.Method),
+			Period:         req.Period,
+			Seasonality:    statsanal.Seasonality(req.Seasonality),
+			SeasonalWindow: req.SeasonalWindow,
+			TrendWindow:    req.TrendWindow,
+			Robust:         req.Robust,
+		},
+	)
+	if err != nil {
+		return nil, http.StatusUnprocessableEntity,
+			fmt.Errorf("Error during seasonal decomposition\n%w", err)
+	}
+
+	return decompositionResp{Result: &result, Name: name, Rows: rows}, http.StatusOK, nil
+}
+
+// Response format for change points request.
+type changePointsResp struct {
+	Result *statsanal.ChangePointResult `json:"result"`
+	Name   string                       `json:"name,omitempty"`
+	// Rows holds the index in the file of each value of the series, and
+	// ChangeRows the one of the first value of each segment but the first.
+	Rows       []int  `json:"rows,omitempty"`
+	ChangeRows []int  `json:"change_rows,omitempty"`
+	Error      string `json:"error"`
+}
+
+// Request format for change points queries.
+type changePointsRequest struct {
+	Username        string     `json:"username" binding:"required,alphanum"`
+	FileID          int64      `json:"file_id" binding:"required,min=1"`
+	Version         int32      `json:"version" binding:"omitempty,min=1"`
+	Column          *columnRef `json:"column" binding:"required"`
+	Method          string     `json:"method" binding:"omitempty,oneof=pelt binary"`
+	Cost            string     `json:"cost" binding:"omitempty,oneof=mean variance meanvar"`
+	Penalty         float64    `json:"penalty" binding:"omitempty,gt=0"`
+	MinSegment      int        `json:"min_segment" binding:"omitempty,min=1"`
+	MaxChangePoints int        `json:"max_change_points" binding:"omitempty,min=1"`
+	Missing         string     `json:"missing" binding:"omitempty,oneof=listwise mean median ffill"`
+}
+
+/*
+changePoints detects the shifts in the distribution of a column of one of the
+user's files, ordered like its rows. The endpoint expects a GET request with a
+json body with the following key:
+
+	`username`          - alphanumeric user's username
+	`file_id`           - id of the user's file to analyse.
+	`version`           - optional, version of the file to analyse, defaults
+	                      to the file's current version.
+	`column`            - index or name of the column holding the series.
+	`method`            - optional, `pelt` (default) for the optimal
+	                      segmentation, or `binary` for binary segmentation.
+	`cost`              - optional, shifts looked for: `mean` (default),
+	                      `variance` or `meanvar` for both.
+	`penalty`           - optional, cost of each change point, defaults to
+	                      log(n) times the number of parameters of a segment
+	                      plus one, for a series of n values.
+	`min_segment`       - optional, smallest number of values of a segment,
+	                      defaults to 2.
+	`max_change_points` - optional, largest number of change points of a
+	                      binary segmentation.
+	`missing`           - optional, how missing values are handled:
+	                      `listwise` (default), `mean`, `median` or `ffill`,
+	                      see /analyses/regression.
+
+The segments minimize twice their negative normal log-likelihood plus the
+penalty of the change points. With the `mean` cost, the variance is estimated
+from the median absolute deviation of the differences of the series. Change
+points and segment bounds are positions in `rows`, the indices in the file of
+the values of the series, which skip the rows dropped for missing values, and
+`change_rows` holds the index in the file of each change point.
+
+The request returns response with the following http status codes:
+
+200 - status OK:
+
+	with response body:
+	    {
+	        "result": {
+	            "method": "*****",
+	            "cost": "*****",
+	            "penalty": *****,
+	            "change_points": [*****],
+	            "segments": [
+	                {
+	                    "start": *****,
+	                    "end": *****,
+	                    "mean": *****,
+	                    "variance": *****
+	                },
+	                ...
+	            ],
+	            "total_cost": *****
+	        },
+	        "name": "*****",
+	        "rows": [*****],
+	        "change_rows": [*****],
+	        "error":""
+	     }
+
+400 - status Bad Request:
+
+	Error parsing request body, or invalid column.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+401 - status Unauthorized:
+
+	If access token has expired or username in request body don't match username
+	in token payload.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+404 - status Not Found:
+
+	If the user has no file with id `file_id`, or the file has no such
+	version.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+422 - status Unprocessable Entity:
+
+	If the series is constant, has less than two segments' worth of values,
+	or no data is left after handling missing values.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+501 - status Internal Server Error:
+
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+*/
+func (server *Server) changePoints(ctx *gin.Context) {
+	server.serveAnalysis(ctx, &changePointsRequest{})
+}
+
+func (req *changePointsRequest) owner() string {
+	return req.Username
+}
+
+// run detects the change points on the file of user `username` and returns
+// the response body.
+//
+// Returns a non-nil error along with the http status code to respond with if
+// the change points can't be detected.
+func (req *changePointsRequest) run(
+	ctx context.Context, server *Server, username string,
+) (any, int, error) {
+	series, name, rows, status, err := server.seriesData(
+		ctx, username, req.FileID, req.Version, *req.Column, req.Missing)
+	if err != nil {
+		return nil, status, err
+	}
+
+	result, err := statsanal.ChangePoints(
+		series,
+		statsanal.ChangePointOptions{
+			Method:          statsanal.ChangePointMethod(req.Method),
+			Cost:            statsanal.ChangePointCost(req.Cost),
+			Penalty:         req.Penalty,
+			MinSegment:      req.MinSegment,
+			MaxChangePoints: req.MaxChangePoints,
+		},
+	)
+	if err != nil {
+		return nil, http.StatusUnprocessableEntity,
+			fmt.Errorf("Error detecting change points\n%w", err)
+	}
+
+	resp := changePointsResp{Result: &result, Name: name, Rows: rows}
+	for _, p := range result.ChangePoints {
+		resp.ChangeRows = append(resp.ChangeRows, rows[p])
+	}
+	return resp, http.StatusOK, nil
+}
@@ -0,0 +1,256 @@
+package api
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	gomock "go.uber.org/mock/gomock"
+	"gonum.org/v1/gonum/mat"
+
+	mockdb "github.com/yodeman/analyses-api/dbase/mock"
+	db "github.com/yodeman/analyses-api/dbase/sqlc"
+	"github.com/yodeman/analyses-api/util"
+)
+
+func TestDecomposition(t *testing.T) {
+	user, _ := randomUser(t)
+	file := seriesFile(t, user.Username)
+	getFile := func(store *mockdb.MockStore) {
+		store.EXPECT().
+			GetFile(gomock.Any(), gomock.Eq(db.GetFileParams{
+				ID:       file.ID,
+				Username: user.Username,
+			})).
+			Times(1).
+			Return(file, nil)
+	}
+	noFile := func(store *mockdb.MockStore) {
+		store.EXPECT().
+			GetFile(gomock.Any(), gomock.Any()).
+			Times(0)
+	}
+	month, reading := columnName("month"), columnIndex(1)
+
+	testCases := []hypothesisCase{
+		{
+			name: "CLASSICAL",
+			params: decompositionRequest{
+				Username: user.Username,
+				FileID:   file.ID,
+				Column:   &month,
+				Period:   4,
+			},
+			username:   user.Username,
+			buildStubs: getFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var resp decompositionResp
+				require.NoError(t, json.NewDecoder(recorder.Body).Decode(&resp))
+				require.Empty(t, resp.Error)
+				require.Equal(t, "month", resp.Name)
+				require.Equal(t, "classical", string(resp.Result.Method))
+				require.Len(t, resp.Rows, 16)
+				require.Nil(t, resp.Result.Trend[1])
+				require.InDelta(t, 3, *resp.Result.Trend[2], 1e-12)
+				require.InDeltaSlice(t, []float64{0, 0, 0, 0}, resp.Result.Figure, 1e-12)
+				require.InDelta(t, 0, *resp.Result.Residual[7], 1e-12)
+			},
+		},
+		{
+			name: "STL",
+			params: decompositionRequest{
+				Username: user.Username,
+				FileID:   file.ID,
+				Column:   &reading,
+				Period:   4,
+				Method:   "stl",
+				Robust:   true,
+				Missing:  "ffill",
+			},
+			username:   user.Username,
+			buildStubs: getFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var resp decompositionResp
+				require.NoError(t, json.NewDecoder(recorder.Body).Decode(&resp))
+				require.Equal(t, "stl", string(resp.Result.Method))
+				require.Equal(t, 7, resp.Result.SeasonalWindow)
+				require.Len(t, resp.Result.Trend, 16)
+				require.Len(t, resp.Result.Seasonal, 16)
+				require.NotNil(t, resp.Result.Trend[0])
+				require.Nil(t, resp.Result.Figure)
+			},
+		},
+		{
+			name: "TOO SHORT",
+			params: decompositionRequest{
+				Username: user.Username,
+				FileID:   file.ID,
+				Column:   &month,
+				Period:   9,
+			},
+			username:   user.Username,
+			buildStubs: getFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name: "MULTIPLICATIVE STL",
+			params: decompositionRequest{
+				Username:    user.Username,
+				FileID:      file.ID,
+				Column:      &month,
+				Period:      4,
+				Method:      "stl",
+				Seasonality: "multiplicative",
+			},
+			username:   user.Username,
+			buildStubs: getFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name: "MISSING PERIOD",
+			params: decompositionRequest{
+				Username: user.Username,
+				FileID:   file.ID,
+				Column:   &month,
+			},
+			username:   user.Username,
+			buildStubs: noFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	runHypothesisCases(t, "/analyses/timeseries/decomposition", testCases)
+}
+
+// shiftFile returns a file of 40 readings whose level shifts from about 1 to
+// about 6 at the 21st row, with a missing value in the fourth row.
+func shiftFile(t *testing.T, username string) db.File {
+	m := mat.NewDense(40, 1, nil)
+	for i := 0; i < 40; i++ {
+		v := 1 + 0.2*math.Sin(float64(i*i))
+		if i >= 20 {
+			v += 5
+		}
+		m.Set(i, 0, v)
+	}
+	m.Set(3, 0, math.NaN())
+	data, err := m.MarshalBinary()
+	require.NoError(t, err)
+
+	return db.File{
+		ID:          util.RandomInt(1, 1000),
+		Username:    username,
+		Data:        data,
+		ColumnNames: []string{"latency"},
+	}
+}
+
+func TestChangePoints(t *testing.T) {
+	user, _ := randomUser(t)
+	file := shiftFile(t, user.Username)
+	getFile := func(store *mockdb.MockStore) {
+		store.EXPECT().
+			GetFile(gomock.Any(), gomock.Eq(db.GetFileParams{
+				ID:       file.ID,
+				Username: user.Username,
+			})).
+			Times(1).
+			Return(file, nil)
+	}
+	noFile := func(store *mockdb.MockStore) {
+		store.EXPECT().
+			GetFile(gomock.Any(), gomock.Any()).
+			Times(0)
+	}
+	latency := columnName("latency")
+
+	testCases := []hypothesisCase{
+		{
+			name: "PELT",
+			params: changePointsRequest{
+				Username: user.Username,
+				FileID:   file.ID,
+				Column:   &latency,
+			},
+			username:   user.Username,
+			buildStubs: getFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var resp changePointsResp
+				require.NoError(t, json.NewDecoder(recorder.Body).Decode(&resp))
+				require.Empty(t, resp.Error)
+				require.Equal(t, "latency", resp.Name)
+				require.Len(t, resp.Rows, 39)
+				// the dropped fourth row shifts the positions by one.
+				require.Equal(t, []int{19}, resp.Result.ChangePoints)
+				require.Equal(t, []int{20}, resp.ChangeRows)
+				require.Len(t, resp.Result.Segments, 2)
+				require.InDelta(t, 6, resp.Result.Segments[1].Mean, 0.1)
+			},
+		},
+		{
+			name: "BINARY",
+			params: changePointsRequest{
+				Username:        user.Username,
+				FileID:          file.ID,
+				Column:          &latency,
+				Method:          "binary",
+				Cost:            "meanvar",
+				MinSegment:      5,
+				MaxChangePoints: 1,
+				Missing:         "median",
+			},
+			username:   user.Username,
+			buildStubs: getFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var resp changePointsResp
+				require.NoError(t, json.NewDecoder(recorder.Body).Decode(&resp))
+				require.Equal(t, "binary", string(resp.Result.Method))
+				require.Equal(t, []int{20}, resp.Result.ChangePoints)
+				require.Equal(t, []int{20}, resp.ChangeRows)
+			},
+		},
+		{
+			name: "TOO SHORT",
+			params: changePointsRequest{
+				Username:   user.Username,
+				FileID:     file.ID,
+				Column:     &latency,
+				MinSegment: 20,
+			},
+			username:   user.Username,
+			buildStubs: getFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name: "INVALID COST",
+			params: changePointsRequest{
+				Username: user.Username,
+				FileID:   file.ID,
+				Column:   &latency,
+				Cost:     "poisson",
+			},
+			username:   user.Username,
+			buildStubs: noFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	runHypothesisCases(t, "/analyses/timeseries/changepoints", testCases)
+}
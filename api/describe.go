@@ -0,0 +1,142 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	statsanal "github.com/yodeman/analyses-api/stats-analyses"
+)
+
+// Response format for describe request.
+type describeResp struct {
+	Result []statsanal.ColumnSummary `json:"result"`
+	Error  string                    `json:"error"`
+}
+
+// Request format for describe queries.
+type describeRequest struct {
+	Username string      `json:"username" binding:"required,alphanum"`
+	FileID   int64       `json:"file_id" binding:"required,min=1"`
+	Version  int32       `json:"version" binding:"omitempty,min=1"`
+	Columns  []columnRef `json:"columns"`
+}
+
+/*
+describe computes the descriptive statistics of every column of one of the
+user's files, ignoring missing values. The endpoint expects a GET request
+with a json body with the following key:
+
+	`username` - alphanumeric user's username
+	`file_id`  - id of the user's file to describe.
+	`version`  - optional, version of the file to describe, defaults to the
+	             file's current version.
+	`columns`  - optional, list of indices or names of the columns to
+	             describe, defaults to every column.
+
+Statistics that can't be computed, e.g. the variance of a column with a
+single observed value, are null.
+
+The request returns response with the following http status codes:
+
+200 - status OK:
+
+	with response body:
+	    {
+	        "result": [
+	            {
+	                "name": "*****",
+	                "count": *****,
+	                "missing": *****,
+	                "mean": *****,
+	                "variance": *****,
+	                "std_dev": *****,
+	                "min": *****,
+	                "q1": *****,
+	                "median": *****,
+	                "q3": *****,
+	                "max": *****,
+	                "skewness": *****,
+	                "kurtosis": *****,
+	                "mode": *****,
+	                "mode_count": *****
+	            },
+	            ...
+	        ],
+	        "error":""
+	     }
+
+400 - status Bad Request:
+
+	Error parsing request body, or invalid columns.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+401 - status Unauthorized:
+
+	If access token has expired or username in request body don't match username
+	in token payload.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+404 - status Not Found:
+
+	If the user has no file with id `file_id`, or the file has no such
+	version.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+501 - status Internal Server Error:
+
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+*/
+func (server *Server) describe(ctx *gin.Context) {
+	server.serveAnalysis(ctx, &describeRequest{})
+}
+
+func (req *describeRequest) owner() string {
+	return req.Username
+}
+
+// run describes the columns of the file of user `username` and returns the
+// response body.
+//
+// Returns a non-nil error along with the http status code to respond with if
+// the file can't be described.
+func (req *describeRequest) run(
+	ctx context.Context, server *Server, username string,
+) (any, int, error) {
+	ds, status, err := server.loadDataset(ctx, username, req.FileID, req.Version)
+	if err != nil {
+		return nil, status, err
+	}
+
+	columns, err := resolveColumns(ds.names, req.Columns)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	result, err := statsanal.Describe(
+		statsanal.SelectColumns(ds.data, columns), ds.selectNames(columns))
+	if err != nil {
+		return nil, http.StatusInternalServerError,
+			fmt.Errorf("Error describing columns\n%w", err)
+	}
+
+	return describeResp{Result: result}, http.StatusOK, nil
+}
@@ -0,0 +1,197 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	gomock "go.uber.org/mock/gomock"
+	"gonum.org/v1/gonum/mat"
+
+	mockdb "github.com/yodeman/analyses-api/dbase/mock"
+	db "github.com/yodeman/analyses-api/dbase/sqlc"
+	"github.com/yodeman/analyses-api/util"
+)
+
+func TestDescribe(t *testing.T) {
+	user, _ := randomUser(t)
+	rows, cols, data, err := util.ParseCSVToFloatSlice(
+		strings.NewReader(util.RandomCSV(30, 4)))
+	require.NoError(t, err)
+	matrix := mat.NewDense(rows, cols, data)
+	matrix.Set(3, 1, math.NaN())
+	matData, err := matrix.MarshalBinary()
+	require.NoError(t, err)
+
+	fileID := util.RandomInt(1, 1000)
+	getFileParams := db.GetFileParams{ID: fileID, Username: user.Username}
+	file := db.File{
+		ID:          fileID,
+		Username:    user.Username,
+		Data:        matData,
+		ColumnNames: []string{"a", "b", "c", "d"},
+	}
+
+	testCases := []struct {
+		name          string
+		params        describeRequest
+		username      string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			params:   describeRequest{Username: user.Username, FileID: fileID},
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetFile(gomock.Any(), gomock.Eq(getFileParams)).
+					Times(1).
+					Return(file, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				resp := requireBodyMatchDescribe(t, recorder.Body, cols)
+				require.Equal(t, "b", resp.Result[1].Name)
+				require.Equal(t, rows-1, resp.Result[1].Count)
+				require.Equal(t, 1, resp.Result[1].Missing)
+				require.InDelta(t, mat.Sum(matrix.ColView(0))/float64(rows),
+					*resp.Result[0].Mean, 1e-9)
+			},
+		},
+		{
+			name: "COLUMNS",
+			params: describeRequest{
+				Username: user.Username,
+				FileID:   fileID,
+				Columns:  []columnRef{columnName("d"), columnIndex(0)},
+			},
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetFile(gomock.Any(), gomock.Eq(getFileParams)).
+					Times(1).
+					Return(file, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				resp := requireBodyMatchDescribe(t, recorder.Body, 2)
+				require.Equal(t, "d", resp.Result[0].Name)
+				require.Equal(t, "a", resp.Result[1].Name)
+			},
+		},
+		{
+			name: "INVALID COLUMN",
+			params: describeRequest{
+				Username: user.Username,
+				FileID:   fileID,
+				Columns:  []columnRef{columnName("e")},
+			},
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetFile(gomock.Any(), gomock.Eq(getFileParams)).
+					Times(1).
+					Return(file, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "MISSING FILE ID",
+			params:   describeRequest{Username: user.Username},
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetFile(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "UNAUTHORIZED",
+			params:   describeRequest{Username: user.Username, FileID: fileID},
+			username: "deidara",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetFile(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:     "NOT FOUND",
+			params:   describeRequest{Username: user.Username, FileID: fileID},
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetFile(gomock.Any(), gomock.Eq(getFileParams)).
+					Times(1).
+					Return(db.File{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			encodedParams, err := json.Marshal(tc.params)
+			require.NoError(t, err)
+			request, err := http.NewRequest(
+				http.MethodGet, "/analyses/describe", bytes.NewBuffer(encodedParams))
+			require.NoError(t, err)
+			addAuthorization(
+				t, request, server.tokenMaker, authorizationTypeToken,
+				tc.username, time.Minute,
+			)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func requireBodyMatchDescribe(
+	t *testing.T, responseBody *bytes.Buffer, cols int,
+) describeResp {
+	var serverResp describeResp
+
+	err := json.NewDecoder(responseBody).Decode(&serverResp)
+	require.NoError(t, err)
+
+	require.Empty(t, serverResp.Error)
+	require.Len(t, serverResp.Result, cols)
+	for _, summary := range serverResp.Result {
+		require.NotNil(t, summary.Mean)
+		require.NotNil(t, summary.Median)
+		require.LessOrEqual(t, *summary.Min, *summary.Q1)
+		require.LessOrEqual(t, *summary.Q3, *summary.Max)
+	}
+
+	return serverResp
+}
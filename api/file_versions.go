@@ -0,0 +1,240 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	db "github.com/yodeman/analyses-api/dbase/sqlc"
+)
+
+// Response format for file version
+// fileVersionResp is used to hide information
+type fileVersionResp struct {
+	Version       int32     `json:"version"`
+	Rows          int32     `json:"rows"`
+	Cols          int32     `json:"cols"`
+	Hash          string    `json:"hash"`
+	ColumnNames   []string  `json:"column_names"`
+	MissingCounts []int32   `json:"missing_counts"`
+	ChangedAt     time.Time `json:"changed_at"`
+}
+
+// Response format for listing file versions.
+type listFileVersionsResponse struct {
+	Versions []fileVersionResp `json:"versions"`
+	Error    string            `json:"error"`
+}
+
+/*
+listFileVersions lists the versions of one of the authenticated user's files,
+latest first. Every upload to a file creates an immutable version. The
+endpoint expects a GET request at `/files/:id/versions` with the following
+optional query parameters:
+
+	`page_id`    - page to list, starting from 1 (default).
+	`page_size`  - number of versions in a page between 1 and 100, defaults
+	               to 10.
+
+The request returns response with the following http status codes:
+
+200 - status OK:
+
+	with response body:
+	    {
+	        "versions": [
+	            {
+	                "version": *****,
+	                "rows": *****,
+	                "cols": *****,
+	                "hash": "*****",
+	                "column_names": ["*****", ...],
+	                "missing_counts": [*****, ...],
+	                "changed_at": "*****",
+	            },
+	            ...
+	        ],
+	        "error":""
+	     }
+
+	`hash` is the hex encoded sha256 hash of the version's data.
+
+400 - status Bad Request:
+
+	Invalid file id or error parsing query parameters.
+
+401 - status Unauthorized:
+
+	If access token is missing or has expired.
+
+404 - status Not Found:
+
+	If the user has no file with the given id.
+
+501 - status Internal Server Error:
+
+	with response body:
+	    {
+	        "versions": null,
+	        "error": "*****"
+	    }
+*/
+func (server *Server) listFileVersions(ctx *gin.Context) {
+	var uri fileURIRequest
+	var req listFilesRequest
+	var resp listFileVersionsResponse
+
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		resp.Error = errResponse(fmt.Errorf("Error parsing file id.\n%w", err))
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		resp.Error = errResponse(fmt.Errorf("Error parsing query parameters.\n%w", err))
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+	if req.PageID == 0 {
+		req.PageID = 1
+	}
+	if req.PageSize == 0 {
+		req.PageSize = defaultPageSize
+	}
+
+	authPayload, err := getPayload(ctx)
+	if err != nil {
+		resp.Error = errResponse(
+			fmt.Errorf("Error getting authentication payload.\n%w", err))
+		ctx.JSON(http.StatusInternalServerError, resp)
+		return
+	}
+
+	versions, err := server.store.ListFileVersions(
+		ctx,
+		db.ListFileVersionsParams{
+			FileID:   uri.ID,
+			Username: authPayload.Username,
+			Limit:    req.PageSize,
+			Offset:   (req.PageID - 1) * req.PageSize,
+		},
+	)
+	if err != nil {
+		resp.Error = errResponse(fmt.Errorf("Error fetching file's versions.\n%w", err))
+		ctx.JSON(http.StatusInternalServerError, resp)
+		return
+	}
+	// every file has at least one version.
+	if len(versions) == 0 && req.PageID == 1 {
+		resp.Error = errResponse(fmt.Errorf("File does not exist."))
+		ctx.JSON(http.StatusNotFound, resp)
+		return
+	}
+
+	resp.Versions = make([]fileVersionResp, len(versions))
+	for i, version := range versions {
+		resp.Versions[i] = fileVersionResp{
+			Version:       version.Version,
+			Rows:          version.Rows,
+			Cols:          version.Cols,
+			Hash:          version.Hash,
+			ColumnNames:   version.ColumnNames,
+			MissingCounts: version.MissingCounts,
+			ChangedAt:     version.CreatedAt,
+		}
+	}
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// Request format for rolling back a file.
+type rollbackFileRequest struct {
+	Version int32 `json:"version" binding:"required,min=1"`
+}
+
+/*
+rollbackFile makes an earlier version the current version of one of the
+authenticated user's files, the version used by analyses when none is given.
+Versions are never deleted by a rollback, so the file can be rolled forward
+again. The endpoint expects a POST request at `/files/:id/rollback` with a
+json body with the following key:
+
+	`version`    - version of the file to roll back to.
+
+The request returns response with the following http status codes:
+
+200 - status OK:
+
+	with the rolled back file in the response body, see getFile.
+
+400 - status Bad Request:
+
+	Invalid file id or error parsing request body.
+
+401 - status Unauthorized:
+
+	If access token is missing or has expired.
+
+404 - status Not Found:
+
+	If the user has no file with the given id, or the file has no such
+	version.
+
+501 - status Internal Server Error:
+
+	with response body:
+	    {
+	        "file": {},
+	        "error": "*****"
+	    }
+*/
+func (server *Server) rollbackFile(ctx *gin.Context) {
+	var uri fileURIRequest
+	var req rollbackFileRequest
+	var resp fileResponse
+
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		resp.Error = errResponse(fmt.Errorf("Error parsing file id.\n%w", err))
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		resp.Error = errResponse(fmt.Errorf("Error parsing request body.\n%w", err))
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	authPayload, err := getPayload(ctx)
+	if err != nil {
+		resp.Error = errResponse(
+			fmt.Errorf("Error getting authentication payload.\n%w", err))
+		ctx.JSON(http.StatusInternalServerError, resp)
+		return
+	}
+
+	result, err := server.store.RollbackFileTx(
+		ctx,
+		db.RollbackFileTxParams{
+			ID:       uri.ID,
+			Username: authPayload.Username,
+			Version:  req.Version,
+		},
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			resp.Error = errResponse(
+				fmt.Errorf("File or version does not exist.\n%w", err))
+			ctx.JSON(http.StatusNotFound, resp)
+			return
+		}
+
+		resp.Error = errResponse(fmt.Errorf("Error rolling back user's file.\n%w", err))
+		ctx.JSON(http.StatusInternalServerError, resp)
+		return
+	}
+
+	resp.File = newFileResp(result.File)
+	ctx.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,249 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	gomock "go.uber.org/mock/gomock"
+
+	mockdb "github.com/yodeman/analyses-api/dbase/mock"
+	db "github.com/yodeman/analyses-api/dbase/sqlc"
+	"github.com/yodeman/analyses-api/util"
+)
+
+func TestListFileVersions(t *testing.T) {
+	user, _ := randomUser(t)
+	fileID := util.RandomInt(1, 1000)
+	versions := []db.ListFileVersionsRow{
+		{FileID: fileID, Version: 2, Rows: 30, Cols: 10, Hash: util.RandomString(64)},
+		{FileID: fileID, Version: 1, Rows: 20, Cols: 10, Hash: util.RandomString(64)},
+	}
+
+	testCases := []struct {
+		name          string
+		fileID        int64
+		query         string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "OK",
+			fileID: fileID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListFileVersions(gomock.Any(), gomock.Eq(db.ListFileVersionsParams{
+						FileID:   fileID,
+						Username: user.Username,
+						Limit:    defaultPageSize,
+						Offset:   0,
+					})).
+					Times(1).
+					Return(versions, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var serverResp listFileVersionsResponse
+				err := json.NewDecoder(recorder.Body).Decode(&serverResp)
+				require.NoError(t, err)
+				require.Len(t, serverResp.Versions, len(versions))
+				for i, version := range versions {
+					require.Equal(t, version.Version, serverResp.Versions[i].Version)
+					require.Equal(t, version.Rows, serverResp.Versions[i].Rows)
+					require.Equal(t, version.Hash, serverResp.Versions[i].Hash)
+				}
+			},
+		},
+		{
+			name:   "NOT FOUND",
+			fileID: fileID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListFileVersions(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]db.ListFileVersionsRow{}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:   "EMPTY PAGE",
+			fileID: fileID,
+			query:  "?page_id=3",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListFileVersions(gomock.Any(), gomock.Eq(db.ListFileVersionsParams{
+						FileID:   fileID,
+						Username: user.Username,
+						Limit:    defaultPageSize,
+						Offset:   2 * defaultPageSize,
+					})).
+					Times(1).
+					Return([]db.ListFileVersionsRow{}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:   "INVALID ID",
+			fileID: 0,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListFileVersions(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:   "INTERNAL ERROR",
+			fileID: fileID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListFileVersions(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/files/%d/versions%s", tc.fileID, tc.query)
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+			addAuthorization(
+				t, request, server.tokenMaker, authorizationTypeToken,
+				user.Username, time.Minute,
+			)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestRollbackFile(t *testing.T) {
+	user, _ := randomUser(t)
+	file := db.File{
+		ID:       util.RandomInt(1, 1000),
+		Username: user.Username,
+		Name:     "data",
+		Version:  1,
+	}
+
+	testCases := []struct {
+		name          string
+		body          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: `{"version": 1}`,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					RollbackFileTx(gomock.Any(), gomock.Eq(db.RollbackFileTxParams{
+						ID:       file.ID,
+						Username: user.Username,
+						Version:  1,
+					})).
+					Times(1).
+					Return(db.FileTxResult{File: file}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchFile(t, recorder.Body, file)
+			},
+		},
+		{
+			name: "NOT FOUND",
+			body: `{"version": 5}`,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					RollbackFileTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.FileTxResult{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "MISSING VERSION",
+			body: `{}`,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					RollbackFileTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "INTERNAL ERROR",
+			body: `{"version": 1}`,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					RollbackFileTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.FileTxResult{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/files/%d/rollback", file.ID)
+			request, err := http.NewRequest(
+				http.MethodPost, url, strings.NewReader(tc.body))
+			require.NoError(t, err)
+			addAuthorization(
+				t, request, server.tokenMaker, authorizationTypeToken,
+				user.Username, time.Minute,
+			)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
@@ -1,39 +1,87 @@
 package api
 
 import (
+	"crypto/sha256"
 	"database/sql"
-	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
 	"gonum.org/v1/gonum/mat"
 
 	db "github.com/yodeman/analyses-api/dbase/sqlc"
 	"github.com/yodeman/analyses-api/util"
 )
 
+const (
+	maxFileNameLen  = 128 // maximum length of a file name.
+	defaultPageSize = 10  // default number of files listed per page.
+)
+
 // Response format for file
 // fileResp is used to hide information
 type fileResp struct {
-	ID        int64     `json:"id"`
-	ChangedAt time.Time `json:"changed_at"`
+	ID            int64     `json:"id"`
+	Name          string    `json:"name"`
+	ColumnNames   []string  `json:"column_names"`
+	MissingCounts []int32   `json:"missing_counts"`
+	Version       int32     `json:"version"`
+	ChangedAt     time.Time `json:"changed_at"`
+	CreatedAt     time.Time `json:"created_at"`
 }
 type fileResponse struct {
 	File  fileResp `json:"file"`
 	Error string   `json:"error"`
 }
 
+// newFileResp hides the data of the user's file.
+func newFileResp(file db.File) fileResp {
+	return fileResp{
+		ID:            file.ID,
+		Name:          file.Name,
+		ColumnNames:   file.ColumnNames,
+		MissingCounts: file.MissingCounts,
+		Version:       file.Version,
+		ChangedAt:     file.ChangedAt,
+		CreatedAt:     file.CreatedAt,
+	}
+}
+
+// validateFileName trims the file `name` and checks its length.
+func validateFileName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > maxFileNameLen {
+		return "", fmt.Errorf(
+			"File name should have between 1 and %d characters.", maxFileNameLen)
+	}
+	return name, nil
+}
+
 /*
 uploadFile uploads encoded user data to the database using the data in the body
-of the request. If a user has a file in the database, the new file replaces the
-old file in the database. The endpoint expects a POST request with a form-data
-body with the following key:
+of the request. Every upload creates a new file unless `file_id` is given, in
+which case a new version of that file is created and becomes its current
+version, see listFileVersions. The endpoint expects a POST request with a
+form-data body with the following key:
 
 	`username`   - alphanumeric user's username
 	`file`       - a csv file.
+	`name`       - optional, name of the new file, unique among the user's
+	               files. Defaults to the uploaded file's name.
+	`file_id`    - optional, id of the user's file to add a version to.
+	`header`     - optional, whether the first row of the file holds the
+	               column names: `true`, `false` or `auto` (default), which
+	               treats the first row as a header if any of its fields is
+	               not a number.
+	`missing_tokens` - optional, comma separated list of cell values read as
+	               missing values, defaults to `NA,NaN,null`. Empty cells are
+	               always missing values.
 
 The request returns response with the following http status codes:
 
@@ -43,14 +91,21 @@ The request returns response with the following http status codes:
 	    {
 	        "file": {
 	            "id":"****",
+	            "name": "*****",
+	            "column_names": ["*****", ...],
+	            "missing_counts": [*****, ...],
+	            "version": *****,
 	            "changed_at": "*****",
+	            "created_at": "*****",
 	        },
 	        "error":""
 	     }
 
 400 - status Bad Request:
 
-	Error parsing request body, and missing `username` or `file` key.
+	Error parsing request body or the uploaded csv file, missing `username` or
+	`file` key, invalid `name`, `file_id` or `header` value, or a file without
+	data rows.
 	with response body:
 	    {
 	        "file": {},
@@ -67,6 +122,24 @@ The request returns response with the following http status codes:
 	        "error": "*****"
 	    }
 
+403 - status Forbidden:
+
+	If the user already has a file with the same name.
+	with response body:
+	    {
+	        "file": {},
+	        "error": "*****"
+	    }
+
+404 - status Not Found:
+
+	If the user has no file with id `file_id`.
+	with response body:
+	    {
+	        "file": {},
+	        "error": "*****"
+	    }
+
 413 - status Request Entity Too Large:
 
 	If file size exceed maximum limit.
@@ -108,6 +181,33 @@ func (server *Server) uploadFile(ctx *gin.Context) {
 		return
 	}
 
+	header, err := util.ParseHeaderMode(ctx.PostForm("header"))
+	if err != nil {
+		resp.Error = errResponse(err)
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	var fileID int64
+	if value, ok := ctx.GetPostForm("file_id"); ok {
+		fileID, err = strconv.ParseInt(value, 10, 64)
+		if err != nil || fileID < 1 {
+			resp.Error = errResponse(fmt.Errorf("Invalid `file_id` %q.", value))
+			ctx.JSON(http.StatusBadRequest, resp)
+			return
+		}
+	}
+
+	var name string
+	if fileID == 0 {
+		name, err = validateFileName(ctx.DefaultPostForm("name", file.Filename))
+		if err != nil {
+			resp.Error = errResponse(err)
+			ctx.JSON(http.StatusBadRequest, resp)
+			return
+		}
+	}
+
 	authPayload, err := getPayload(ctx)
 	if err != nil {
 		resp.Error = errResponse(
@@ -130,65 +230,471 @@ func (server *Server) uploadFile(ctx *gin.Context) {
 		return
 	}
 
-	rows, cols, data, err := util.ParseCSVToFloatSlice(reader)
+	csvData, err := util.ParseCSV(
+		reader,
+		util.CSVOptions{
+			Header:        header,
+			MissingTokens: util.ParseMissingTokens(ctx.PostForm("missing_tokens")),
+		},
+	)
 	if err != nil {
 		resp.Error = errResponse(fmt.Errorf("Error parsing uploaded file.\n%w", err))
-		ctx.JSON(http.StatusInternalServerError, resp)
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+	if csvData.Rows == 0 || csvData.Cols == 0 {
+		resp.Error = errResponse(fmt.Errorf("Uploaded file has no data row."))
+		ctx.JSON(http.StatusBadRequest, resp)
 		return
 	}
 
-	bytes, err := mat.NewDense(rows, cols, data).MarshalBinary()
+	bytes, err := mat.NewDense(csvData.Rows, csvData.Cols, csvData.Data).MarshalBinary()
 	if err != nil {
 		resp.Error = errResponse(fmt.Errorf("Error encoding uploaded file.\n%w", err))
 		ctx.JSON(http.StatusInternalServerError, resp)
 		return
 	}
-	encoded := base64.StdEncoding.EncodeToString(bytes)
+	hash := sha256.Sum256(bytes)
 
-	userFile, err := server.querier.GetFile(ctx, username)
-	if err != nil {
+	if fileID == 0 {
 		// upload new file
-		if errors.Is(err, sql.ErrNoRows) {
-			userFile, err := server.querier.CreateFile(
-				ctx,
-				db.CreateFileParams{
-					Username: username,
-					Data:     encoded,
-				},
-			)
-			if err != nil {
-				resp.Error = errResponse(fmt.Errorf("Error uploading data.\n%w", err))
-				ctx.JSON(http.StatusInternalServerError, resp)
-				return
+		result, err := server.store.CreateFileTx(
+			ctx,
+			db.CreateFileTxParams{
+				Username:      username,
+				Name:          name,
+				Data:          bytes,
+				ColumnNames:   csvData.Names,
+				MissingCounts: csvData.Missing,
+				Rows:          int32(csvData.Rows),
+				Cols:          int32(csvData.Cols),
+				Hash:          hex.EncodeToString(hash[:]),
+			},
+		)
+		if err != nil {
+			if pqErr, ok := err.(*pq.Error); ok {
+				if pqErr.Code.Name() == "unique_violation" {
+					resp.Error = errResponse(
+						fmt.Errorf("File with name %q already exists.\n%w", name, err))
+					ctx.JSON(http.StatusForbidden, resp)
+					return
+				}
 			}
 
-			resp.File = fileResp{
-				ID:        userFile.ID,
-				ChangedAt: userFile.ChangedAt,
-			}
-			ctx.JSON(http.StatusOK, resp)
+			resp.Error = errResponse(fmt.Errorf("Error uploading data.\n%w", err))
+			ctx.JSON(http.StatusInternalServerError, resp)
 			return
 		}
+
+		resp.File = newFileResp(result.File)
+		ctx.JSON(http.StatusOK, resp)
+		return
 	}
 
-	// file with user already exists, update the entry
-	userFile, err = server.querier.UpdateFile(
+	// add a new version to the given file
+	result, err := server.store.UpdateFileTx(
 		ctx,
-		db.UpdateFileParams{
-			Username: username,
-			Data:     encoded,
+		db.UpdateFileTxParams{
+			ID:            fileID,
+			Username:      username,
+			Data:          bytes,
+			ColumnNames:   csvData.Names,
+			MissingCounts: csvData.Missing,
+			Rows:          int32(csvData.Rows),
+			Cols:          int32(csvData.Cols),
+			Hash:          hex.EncodeToString(hash[:]),
 		},
 	)
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			resp.Error = errResponse(fmt.Errorf("File does not exist.\n%w", err))
+			ctx.JSON(http.StatusNotFound, resp)
+			return
+		}
+
 		resp.Error = errResponse(fmt.Errorf("Error uploading data.\n%w", err))
 		ctx.JSON(http.StatusInternalServerError, resp)
 		return
 	}
 
-	resp.File = fileResp{
-		ID:        userFile.ID,
-		ChangedAt: userFile.ChangedAt,
-	}
+	resp.File = newFileResp(result.File)
 	ctx.JSON(http.StatusOK, resp)
 	return
 }
+
+// Response format for listing files.
+type listFilesResponse struct {
+	Files []fileResp `json:"files"`
+	Error string     `json:"error"`
+}
+
+// Request format for listing files.
+type listFilesRequest struct {
+	PageID   int32 `form:"page_id" binding:"omitempty,min=1"`
+	PageSize int32 `form:"page_size" binding:"omitempty,min=1,max=100"`
+}
+
+/*
+listFiles lists the authenticated user's files, ordered by id. The endpoint
+expects a GET request with the following optional query parameters:
+
+	`page_id`    - page to list, starting from 1 (default).
+	`page_size`  - number of files in a page between 1 and 100, defaults
+	               to 10.
+
+The request returns response with the following http status codes:
+
+200 - status OK:
+
+	with response body:
+	    {
+	        "files": [
+	            {
+	                "id":"****",
+	                "name": "*****",
+	                "column_names": ["*****", ...],
+	                "missing_counts": [*****, ...],
+	                "version": *****,
+	                "changed_at": "*****",
+	                "created_at": "*****",
+	            },
+	            ...
+	        ],
+	        "error":""
+	     }
+
+400 - status Bad Request:
+
+	Error parsing query parameters.
+	with response body:
+	    {
+	        "files": null,
+	        "error": "*****"
+	    }
+
+401 - status Unauthorized:
+
+	If access token is missing or has expired.
+
+501 - status Internal Server Error:
+
+	with response body:
+	    {
+	        "files": null,
+	        "error": "*****"
+	    }
+*/
+func (server *Server) listFiles(ctx *gin.Context) {
+	var req listFilesRequest
+	var resp listFilesResponse
+
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		resp.Error = errResponse(fmt.Errorf("Error parsing query parameters.\n%w", err))
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+	if req.PageID == 0 {
+		req.PageID = 1
+	}
+	if req.PageSize == 0 {
+		req.PageSize = defaultPageSize
+	}
+
+	authPayload, err := getPayload(ctx)
+	if err != nil {
+		resp.Error = errResponse(
+			fmt.Errorf("Error getting authentication payload.\n%w", err))
+		ctx.JSON(http.StatusInternalServerError, resp)
+		return
+	}
+
+	files, err := server.store.ListFiles(
+		ctx,
+		db.ListFilesParams{
+			Username: authPayload.Username,
+			Limit:    req.PageSize,
+			Offset:   (req.PageID - 1) * req.PageSize,
+		},
+	)
+	if err != nil {
+		resp.Error = errResponse(fmt.Errorf("Error fetching user's files.\n%w", err))
+		ctx.JSON(http.StatusInternalServerError, resp)
+		return
+	}
+
+	resp.Files = make([]fileResp, len(files))
+	for i, file := range files {
+		resp.Files[i] = fileResp{
+			ID:            file.ID,
+			Name:          file.Name,
+			ColumnNames:   file.ColumnNames,
+			MissingCounts: file.MissingCounts,
+			Version:       file.Version,
+			ChangedAt:     file.ChangedAt,
+			CreatedAt:     file.CreatedAt,
+		}
+	}
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// Request format for the file in the url path.
+type fileURIRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+/*
+getFile fetches the metadata of one of the authenticated user's files. The
+endpoint expects a GET request at `/files/:id`.
+
+The request returns response with the following http status codes:
+
+200 - status OK:
+
+	with response body:
+	    {
+	        "file": {
+	            "id":"****",
+	            "name": "*****",
+	            "column_names": ["*****", ...],
+	            "missing_counts": [*****, ...],
+	            "version": *****,
+	            "changed_at": "*****",
+	            "created_at": "*****",
+	        },
+	        "error":""
+	     }
+
+400 - status Bad Request:
+
+	Invalid file id.
+
+401 - status Unauthorized:
+
+	If access token is missing or has expired.
+
+404 - status Not Found:
+
+	If the user has no file with the given id.
+
+501 - status Internal Server Error:
+
+	with response body:
+	    {
+	        "file": {},
+	        "error": "*****"
+	    }
+*/
+func (server *Server) getFile(ctx *gin.Context) {
+	var uri fileURIRequest
+	var resp fileResponse
+
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		resp.Error = errResponse(fmt.Errorf("Error parsing file id.\n%w", err))
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	authPayload, err := getPayload(ctx)
+	if err != nil {
+		resp.Error = errResponse(
+			fmt.Errorf("Error getting authentication payload.\n%w", err))
+		ctx.JSON(http.StatusInternalServerError, resp)
+		return
+	}
+
+	userFile, err := server.store.GetFile(
+		ctx,
+		db.GetFileParams{ID: uri.ID, Username: authPayload.Username},
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			resp.Error = errResponse(fmt.Errorf("File does not exist.\n%w", err))
+			ctx.JSON(http.StatusNotFound, resp)
+			return
+		}
+
+		resp.Error = errResponse(fmt.Errorf("Error fetching user's file.\n%w", err))
+		ctx.JSON(http.StatusInternalServerError, resp)
+		return
+	}
+
+	resp.File = newFileResp(userFile)
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// Request format for renaming a file.
+type renameFileRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+/*
+renameFile renames one of the authenticated user's files. The endpoint
+expects a PATCH request at `/files/:id` with a json body with the following
+key:
+
+	`name`       - new name of the file, unique among the user's files.
+
+The request returns response with the following http status codes:
+
+200 - status OK:
+
+	with the renamed file in the response body, see getFile.
+
+400 - status Bad Request:
+
+	Invalid file id or error parsing request body.
+
+401 - status Unauthorized:
+
+	If access token is missing or has expired.
+
+403 - status Forbidden:
+
+	If the user already has a file with the same name.
+
+404 - status Not Found:
+
+	If the user has no file with the given id.
+
+501 - status Internal Server Error:
+
+	with response body:
+	    {
+	        "file": {},
+	        "error": "*****"
+	    }
+*/
+func (server *Server) renameFile(ctx *gin.Context) {
+	var uri fileURIRequest
+	var req renameFileRequest
+	var resp fileResponse
+
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		resp.Error = errResponse(fmt.Errorf("Error parsing file id.\n%w", err))
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		resp.Error = errResponse(fmt.Errorf("Error parsing request body.\n%w", err))
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+	name, err := validateFileName(req.Name)
+	if err != nil {
+		resp.Error = errResponse(err)
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	authPayload, err := getPayload(ctx)
+	if err != nil {
+		resp.Error = errResponse(
+			fmt.Errorf("Error getting authentication payload.\n%w", err))
+		ctx.JSON(http.StatusInternalServerError, resp)
+		return
+	}
+
+	userFile, err := server.store.UpdateFileName(
+		ctx,
+		db.UpdateFileNameParams{
+			ID:       uri.ID,
+			Username: authPayload.Username,
+			Name:     name,
+		},
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			resp.Error = errResponse(fmt.Errorf("File does not exist.\n%w", err))
+			ctx.JSON(http.StatusNotFound, resp)
+			return
+		}
+		if pqErr, ok := err.(*pq.Error); ok {
+			if pqErr.Code.Name() == "unique_violation" {
+				resp.Error = errResponse(
+					fmt.Errorf("File with name %q already exists.\n%w", name, err))
+				ctx.JSON(http.StatusForbidden, resp)
+				return
+			}
+		}
+
+		resp.Error = errResponse(fmt.Errorf("Error renaming user's file.\n%w", err))
+		ctx.JSON(http.StatusInternalServerError, resp)
+		return
+	}
+
+	resp.File = newFileResp(userFile)
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// Response format for deleting a file.
+type deleteFileResponse struct {
+	Error string `json:"error"`
+}
+
+/*
+deleteFile deletes one of the authenticated user's files. The endpoint
+expects a DELETE request at `/files/:id`.
+
+The request returns response with the following http status codes:
+
+200 - status OK:
+
+	with response body:
+	    {
+	        "error":""
+	    }
+
+400 - status Bad Request:
+
+	Invalid file id.
+
+401 - status Unauthorized:
+
+	If access token is missing or has expired.
+
+404 - status Not Found:
+
+	If the user has no file with the given id.
+
+501 - status Internal Server Error:
+
+	with response body:
+	    {
+	        "error": "*****"
+	    }
+*/
+func (server *Server) deleteFile(ctx *gin.Context) {
+	var uri fileURIRequest
+	var resp deleteFileResponse
+
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		resp.Error = errResponse(fmt.Errorf("Error parsing file id.\n%w", err))
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	authPayload, err := getPayload(ctx)
+	if err != nil {
+		resp.Error = errResponse(
+			fmt.Errorf("Error getting authentication payload.\n%w", err))
+		ctx.JSON(http.StatusInternalServerError, resp)
+		return
+	}
+
+	deleted, err := server.store.DeleteFile(
+		ctx,
+		db.DeleteFileParams{ID: uri.ID, Username: authPayload.Username},
+	)
+	if err != nil {
+		resp.Error = errResponse(fmt.Errorf("Error deleting user's file.\n%w", err))
+		ctx.JSON(http.StatusInternalServerError, resp)
+		return
+	}
+	if deleted == 0 {
+		resp.Error = errResponse(fmt.Errorf("File does not exist."))
+		ctx.JSON(http.StatusNotFound, resp)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, resp)
+}
@@ -2,10 +2,12 @@ package api
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"database/sql"
-	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"math"
 	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
@@ -13,6 +15,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/require"
 	gomock "go.uber.org/mock/gomock"
 	"gonum.org/v1/gonum/mat"
@@ -31,23 +34,79 @@ func TestUploadFile(t *testing.T) {
 	matrix := mat.NewDense(rows, cols, data)
 	byteData, err := matrix.MarshalBinary()
 	require.NoError(t, err)
-	encoded := base64.StdEncoding.EncodeToString(byteData)
+	matData := byteData
+	hash := sha256.Sum256(byteData)
 
-	createFileParams := db.CreateFileParams{
-		Username: user.Username,
-		Data:     encoded,
+	createFileParams := db.CreateFileTxParams{
+		Username:      user.Username,
+		Name:          "test.csv",
+		Data:          matData,
+		ColumnNames:   []string{},
+		MissingCounts: make([]int32, cols),
+		Rows:          int32(rows),
+		Cols:          int32(cols),
+		Hash:          hex.EncodeToString(hash[:]),
+	}
+	updateFileParams := db.UpdateFileTxParams{
+		Username:      user.Username,
+		Data:          matData,
+		ColumnNames:   []string{},
+		MissingCounts: make([]int32, cols),
+		Rows:          int32(rows),
+		Cols:          int32(cols),
+		Hash:          hex.EncodeToString(hash[:]),
+	}
+
+	header := "a,b,c,d,e,f,g,h,i,j\n"
+	headerNames := strings.Split(strings.TrimSpace(header), ",")
+	headerUploadResp := db.File{
+		ID:          util.RandomInt(1, 1000),
+		Username:    user.Username,
+		Data:        matData,
+		ColumnNames: headerNames,
 	}
 
 	uploadResp := db.File{
 		ID:       util.RandomInt(1, 1000),
 		Username: user.Username,
-		Data:     encoded,
+		Data:     matData,
+		Version:  1,
+	}
+	updateFileParams.ID = uploadResp.ID
+	updateResp := uploadResp
+	updateResp.Version = 2
+
+	missingCSV := "1,?,3\n4,,6\nNA,8,9\n"
+	missingMatrix := mat.NewDense(3, 3, []float64{
+		1, math.NaN(), 3,
+		4, math.NaN(), 6,
+		math.NaN(), 8, 9,
+	})
+	byteData, err = missingMatrix.MarshalBinary()
+	require.NoError(t, err)
+	missingHash := sha256.Sum256(byteData)
+	missingParams := db.CreateFileTxParams{
+		Username:      user.Username,
+		Name:          "test.csv",
+		Data:          byteData,
+		ColumnNames:   []string{},
+		MissingCounts: []int32{1, 2, 0},
+		Rows:          3,
+		Cols:          3,
+		Hash:          hex.EncodeToString(missingHash[:]),
+	}
+	missingUploadResp := db.File{
+		ID:            util.RandomInt(1, 1000),
+		Username:      user.Username,
+		Data:          missingParams.Data,
+		ColumnNames:   []string{},
+		MissingCounts: missingParams.MissingCounts,
 	}
 
 	testCases := []struct {
 		name       string
 		params     map[string]string
-		buildStubs func(querier *mockdb.MockQuerier)
+		buildStubs func(store *mockdb.MockStore)
 		setupAuth  func(
 			t *testing.T, request *http.Request, tokenMaker *token.PasetoMaker,
 		)
@@ -60,16 +119,11 @@ func TestUploadFile(t *testing.T) {
 				"usernameKey": "username",
 				"fileKey":     "file",
 			},
-			buildStubs: func(querier *mockdb.MockQuerier) {
-				querier.EXPECT().
-					GetFile(gomock.Any(), gomock.Eq(user.Username)).
-					Times(1).
-					Return(db.File{}, sql.ErrNoRows)
-
-				querier.EXPECT().
-					CreateFile(gomock.Any(), gomock.Eq(createFileParams)).
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateFileTx(gomock.Any(), gomock.Eq(createFileParams)).
 					Times(1).
-					Return(uploadResp, nil)
+					Return(db.FileTxResult{File: uploadResp}, nil)
 			},
 			setupAuth: func(
 				t *testing.T, request *http.Request, tokenMaker *token.PasetoMaker,
@@ -90,28 +144,59 @@ func TestUploadFile(t *testing.T) {
 				"username":    user.Username,
 				"usernameKey": "username",
 				"fileKey":     "file",
+				"file_id":     fmt.Sprint(uploadResp.ID),
 			},
-			buildStubs: func(querier *mockdb.MockQuerier) {
-				querier.EXPECT().
-					GetFile(gomock.Any(), gomock.Eq(user.Username)).
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpdateFileTx(gomock.Any(), gomock.Eq(updateFileParams)).
 					Times(1).
-					Return(uploadResp, nil)
+					Return(db.FileTxResult{File: updateResp}, nil)
+			},
+			setupAuth: func(
+				t *testing.T, request *http.Request, tokenMaker *token.PasetoMaker,
+			) {
 
-				querier.EXPECT().
-					UpdateFile(
+				addAuthorization(
+					t, request, tokenMaker, authorizationTypeToken, user.Username,
+					time.Minute,
+				)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchFile(t, recorder.Body, updateResp)
+			},
+		},
+		{
+			name: "WITH HEADER",
+			params: map[string]string{
+				"username":    user.Username,
+				"usernameKey": "username",
+				"fileKey":     "file",
+				"header":      "auto",
+				"name":        " data ",
+				"csv":         header + sampleCSV,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateFileTx(
 						gomock.Any(),
-						gomock.Eq(db.UpdateFileParams{
-							Username: user.Username,
-							Data:     encoded,
+						gomock.Eq(db.CreateFileTxParams{
+							Username:      user.Username,
+							Name:          "data",
+							Data:          matData,
+							ColumnNames:   headerNames,
+							MissingCounts: make([]int32, cols),
+							Rows:          int32(rows),
+							Cols:          int32(cols),
+							Hash:          createFileParams.Hash,
 						}),
 					).
 					Times(1).
-					Return(uploadResp, nil)
+					Return(db.FileTxResult{File: headerUploadResp}, nil)
 			},
 			setupAuth: func(
 				t *testing.T, request *http.Request, tokenMaker *token.PasetoMaker,
 			) {
-
 				addAuthorization(
 					t, request, tokenMaker, authorizationTypeToken, user.Username,
 					time.Minute,
@@ -119,7 +204,213 @@ func TestUploadFile(t *testing.T) {
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recorder.Code)
-				requireBodyMatchFile(t, recorder.Body, uploadResp)
+				requireBodyMatchFile(t, recorder.Body, headerUploadResp)
+			},
+		},
+		{
+			name: "MISSING VALUES",
+			params: map[string]string{
+				"username":       user.Username,
+				"usernameKey":    "username",
+				"fileKey":        "file",
+				"missing_tokens": "?, na",
+				"csv":            missingCSV,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateFileTx(gomock.Any(), gomock.Eq(missingParams)).
+					Times(1).
+					Return(db.FileTxResult{File: missingUploadResp}, nil)
+			},
+			setupAuth: func(
+				t *testing.T, request *http.Request, tokenMaker *token.PasetoMaker,
+			) {
+				addAuthorization(
+					t, request, tokenMaker, authorizationTypeToken, user.Username,
+					time.Minute,
+				)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchFile(t, recorder.Body, missingUploadResp)
+			},
+		},
+		{
+			name: "INVALID HEADER MODE",
+			params: map[string]string{
+				"username":    user.Username,
+				"usernameKey": "username",
+				"fileKey":     "file",
+				"header":      "sometimes",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateFileTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			setupAuth: func(
+				t *testing.T, request *http.Request, tokenMaker *token.PasetoMaker,
+			) {
+				addAuthorization(
+					t, request, tokenMaker, authorizationTypeToken, user.Username,
+					time.Minute,
+				)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "HEADER ONLY",
+			params: map[string]string{
+				"username":    user.Username,
+				"usernameKey": "username",
+				"fileKey":     "file",
+				"header":      "true",
+				"csv":         "a,b,c\n",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateFileTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			setupAuth: func(
+				t *testing.T, request *http.Request, tokenMaker *token.PasetoMaker,
+			) {
+				addAuthorization(
+					t, request, tokenMaker, authorizationTypeToken, user.Username,
+					time.Minute,
+				)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "EMPTY FILE",
+			params: map[string]string{
+				"username":    user.Username,
+				"usernameKey": "username",
+				"fileKey":     "file",
+				"header":      "true",
+				"csv":         "",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateFileTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			setupAuth: func(
+				t *testing.T, request *http.Request, tokenMaker *token.PasetoMaker,
+			) {
+				addAuthorization(
+					t, request, tokenMaker, authorizationTypeToken, user.Username,
+					time.Minute,
+				)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "INVALID CSV",
+			params: map[string]string{
+				"username":    user.Username,
+				"usernameKey": "username",
+				"fileKey":     "file",
+				"csv":         "1,2,3\n4,x,6\n",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateFileTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			setupAuth: func(
+				t *testing.T, request *http.Request, tokenMaker *token.PasetoMaker,
+			) {
+				addAuthorization(
+					t, request, tokenMaker, authorizationTypeToken, user.Username,
+					time.Minute,
+				)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "DUPLICATE NAME",
+			params: map[string]string{
+				"username":    user.Username,
+				"usernameKey": "username",
+				"fileKey":     "file",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateFileTx(gomock.Any(), gomock.Eq(createFileParams)).
+					Times(1).
+					Return(db.FileTxResult{}, &pq.Error{Code: "23505"})
+			},
+			setupAuth: func(
+				t *testing.T, request *http.Request, tokenMaker *token.PasetoMaker,
+			) {
+				addAuthorization(
+					t, request, tokenMaker, authorizationTypeToken, user.Username,
+					time.Minute,
+				)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "FILE NOT FOUND",
+			params: map[string]string{
+				"username":    user.Username,
+				"usernameKey": "username",
+				"fileKey":     "file",
+				"file_id":     fmt.Sprint(uploadResp.ID),
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpdateFileTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.FileTxResult{}, sql.ErrNoRows)
+			},
+			setupAuth: func(
+				t *testing.T, request *http.Request, tokenMaker *token.PasetoMaker,
+			) {
+				addAuthorization(
+					t, request, tokenMaker, authorizationTypeToken, user.Username,
+					time.Minute,
+				)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "INVALID FILE ID",
+			params: map[string]string{
+				"username":    user.Username,
+				"usernameKey": "username",
+				"fileKey":     "file",
+				"file_id":     "abc",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpdateFileTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			setupAuth: func(
+				t *testing.T, request *http.Request, tokenMaker *token.PasetoMaker,
+			) {
+				addAuthorization(
+					t, request, tokenMaker, authorizationTypeToken, user.Username,
+					time.Minute,
+				)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
 			},
 		},
 		{
@@ -129,17 +420,13 @@ func TestUploadFile(t *testing.T) {
 				"usernameKey": "username",
 				"fileKey":     "file",
 			},
-			buildStubs: func(querier *mockdb.MockQuerier) {
-				querier.EXPECT().
-					GetFile(gomock.Any(), gomock.Any()).
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateFileTx(gomock.Any(), gomock.Any()).
 					Times(0)
 
-				querier.EXPECT().
-					CreateFile(gomock.Any(), gomock.Any()).
-					Times(0)
-
-				querier.EXPECT().
-					UpdateFile(gomock.Any(), gomock.Any()).
+				store.EXPECT().
+					UpdateFileTx(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			setupAuth: func(
@@ -161,17 +448,13 @@ func TestUploadFile(t *testing.T) {
 				"usernameKey": "user",
 				"fileKey":     "file",
 			},
-			buildStubs: func(querier *mockdb.MockQuerier) {
-				querier.EXPECT().
-					GetFile(gomock.Any(), gomock.Any()).
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateFileTx(gomock.Any(), gomock.Any()).
 					Times(0)
 
-				querier.EXPECT().
-					CreateFile(gomock.Any(), gomock.Any()).
-					Times(0)
-
-				querier.EXPECT().
-					UpdateFile(gomock.Any(), gomock.Any()).
+				store.EXPECT().
+					UpdateFileTx(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			setupAuth: func(
@@ -193,17 +476,13 @@ func TestUploadFile(t *testing.T) {
 				"usernameKey": "username",
 				"fileKey":     "data",
 			},
-			buildStubs: func(querier *mockdb.MockQuerier) {
-				querier.EXPECT().
-					GetFile(gomock.Any(), gomock.Any()).
-					Times(0)
-
-				querier.EXPECT().
-					CreateFile(gomock.Any(), gomock.Any()).
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateFileTx(gomock.Any(), gomock.Any()).
 					Times(0)
 
-				querier.EXPECT().
-					UpdateFile(gomock.Any(), gomock.Any()).
+				store.EXPECT().
+					UpdateFileTx(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			setupAuth: func(
@@ -224,23 +503,13 @@ func TestUploadFile(t *testing.T) {
 				"username":    user.Username,
 				"usernameKey": "username",
 				"fileKey":     "file",
+				"file_id":     fmt.Sprint(uploadResp.ID),
 			},
-			buildStubs: func(querier *mockdb.MockQuerier) {
-				querier.EXPECT().
-					GetFile(gomock.Any(), gomock.Eq(user.Username)).
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpdateFileTx(gomock.Any(), gomock.Eq(updateFileParams)).
 					Times(1).
-					Return(uploadResp, nil)
-
-				querier.EXPECT().
-					UpdateFile(
-						gomock.Any(),
-						gomock.Eq(db.UpdateFileParams{
-							Username: user.Username,
-							Data:     encoded,
-						}),
-					).
-					Times(1).
-					Return(db.File{}, sql.ErrConnDone)
+					Return(db.FileTxResult{}, sql.ErrConnDone)
 			},
 			setupAuth: func(
 				t *testing.T, request *http.Request, tokenMaker *token.PasetoMaker,
@@ -264,12 +533,12 @@ func TestUploadFile(t *testing.T) {
 			ctrl := gomock.NewController(t)
 			defer ctrl.Finish()
 
-			querier := mockdb.NewMockQuerier(ctrl)
+			store := mockdb.NewMockStore(ctrl)
 			// build stubs
-			tc.buildStubs(querier)
+			tc.buildStubs(store)
 
 			// start test server and send request
-			server := newTestServer(t, querier)
+			server := newTestServer(t, store)
 			recorder := httptest.NewRecorder()
 
 			url := fmt.Sprint("/files/upload")
@@ -278,10 +547,20 @@ func TestUploadFile(t *testing.T) {
 			err := mimeWriter.WriteField(
 				tc.params["usernameKey"], tc.params["username"])
 			require.NoError(t, err)
+			for _, key := range []string{"name", "file_id", "header", "missing_tokens"} {
+				if value, ok := tc.params[key]; ok {
+					err = mimeWriter.WriteField(key, value)
+					require.NoError(t, err)
+				}
+			}
 			formWriter, err := mimeWriter.CreateFormFile(
 				tc.params["fileKey"], "test.csv")
 			require.NoError(t, err)
-			_, err = formWriter.Write([]byte(sampleCSV))
+			content, ok := tc.params["csv"]
+			if !ok {
+				content = sampleCSV
+			}
+			_, err = formWriter.Write([]byte(content))
 			require.NoError(t, err)
 			mimeWriter.Close()
 
@@ -297,6 +576,302 @@ func TestUploadFile(t *testing.T) {
 	}
 }
 
+func TestListFiles(t *testing.T) {
+	user, _ := randomUser(t)
+	files := []db.ListFilesRow{
+		{ID: 1, Username: user.Username, Name: "a"},
+		{ID: 2, Username: user.Username, Name: "b"},
+	}
+
+	testCases := []struct {
+		name          string
+		query         string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "OK",
+			query: "?page_id=2&page_size=5",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListFiles(gomock.Any(), gomock.Eq(db.ListFilesParams{
+						Username: user.Username,
+						Limit:    5,
+						Offset:   5,
+					})).
+					Times(1).
+					Return(files, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var serverResp listFilesResponse
+				err := json.NewDecoder(recorder.Body).Decode(&serverResp)
+				require.NoError(t, err)
+				require.Len(t, serverResp.Files, len(files))
+				for i, file := range files {
+					require.Equal(t, file.ID, serverResp.Files[i].ID)
+					require.Equal(t, file.Name, serverResp.Files[i].Name)
+				}
+			},
+		},
+		{
+			name:  "DEFAULT PAGE",
+			query: "",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListFiles(gomock.Any(), gomock.Eq(db.ListFilesParams{
+						Username: user.Username,
+						Limit:    defaultPageSize,
+						Offset:   0,
+					})).
+					Times(1).
+					Return([]db.ListFilesRow{}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:  "INVALID PAGE SIZE",
+			query: "?page_size=1000",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListFiles(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "INTERNAL ERROR",
+			query: "",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListFiles(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, "/files"+tc.query, nil)
+			require.NoError(t, err)
+			addAuthorization(
+				t, request, server.tokenMaker, authorizationTypeToken,
+				user.Username, time.Minute,
+			)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestFileByID(t *testing.T) {
+	user, _ := randomUser(t)
+	file := db.File{
+		ID:       util.RandomInt(1, 1000),
+		Username: user.Username,
+		Name:     "data",
+	}
+	renamed := file
+	renamed.Name = "renamed"
+
+	testCases := []struct {
+		name          string
+		method        string
+		fileID        string
+		body          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "GET OK",
+			method: http.MethodGet,
+			fileID: fmt.Sprint(file.ID),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetFile(gomock.Any(), gomock.Eq(db.GetFileParams{
+						ID:       file.ID,
+						Username: user.Username,
+					})).
+					Times(1).
+					Return(file, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchFile(t, recorder.Body, file)
+			},
+		},
+		{
+			name:   "GET NOT FOUND",
+			method: http.MethodGet,
+			fileID: fmt.Sprint(file.ID),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetFile(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.File{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:   "GET INVALID ID",
+			method: http.MethodGet,
+			fileID: "0",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetFile(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:   "RENAME OK",
+			method: http.MethodPatch,
+			fileID: fmt.Sprint(file.ID),
+			body:   `{"name": "renamed"}`,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpdateFileName(gomock.Any(), gomock.Eq(db.UpdateFileNameParams{
+						Name:     renamed.Name,
+						ID:       file.ID,
+						Username: user.Username,
+					})).
+					Times(1).
+					Return(renamed, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchFile(t, recorder.Body, renamed)
+			},
+		},
+		{
+			name:   "RENAME DUPLICATE",
+			method: http.MethodPatch,
+			fileID: fmt.Sprint(file.ID),
+			body:   `{"name": "renamed"}`,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpdateFileName(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.File{}, &pq.Error{Code: "23505"})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:   "RENAME EMPTY NAME",
+			method: http.MethodPatch,
+			fileID: fmt.Sprint(file.ID),
+			body:   `{"name": "  "}`,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpdateFileName(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:   "DELETE OK",
+			method: http.MethodDelete,
+			fileID: fmt.Sprint(file.ID),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					DeleteFile(gomock.Any(), gomock.Eq(db.DeleteFileParams{
+						ID:       file.ID,
+						Username: user.Username,
+					})).
+					Times(1).
+					Return(int64(1), nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:   "DELETE NOT FOUND",
+			method: http.MethodDelete,
+			fileID: fmt.Sprint(file.ID),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					DeleteFile(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(int64(0), nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:   "DELETE INTERNAL ERROR",
+			method: http.MethodDelete,
+			fileID: fmt.Sprint(file.ID),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					DeleteFile(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(int64(0), sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/files/%s", tc.fileID)
+			request, err := http.NewRequest(
+				tc.method, url, strings.NewReader(tc.body))
+			require.NoError(t, err)
+			addAuthorization(
+				t, request, server.tokenMaker, authorizationTypeToken,
+				user.Username, time.Minute,
+			)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
 func requireBodyMatchFile(t *testing.T, responseBody *bytes.Buffer, file db.File) {
 	var serverResp fileResponse
 
@@ -305,5 +880,8 @@ func requireBodyMatchFile(t *testing.T, responseBody *bytes.Buffer, file db.File
 
 	fmt.Println(serverResp.Error)
 	require.Equal(t, file.ID, serverResp.File.ID)
+	require.Equal(t, file.ColumnNames, serverResp.File.ColumnNames)
+	require.Equal(t, file.MissingCounts, serverResp.File.MissingCounts)
+	require.Equal(t, file.Version, serverResp.File.Version)
 	require.Equal(t, file.ChangedAt, serverResp.File.ChangedAt)
 }
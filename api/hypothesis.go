@@ -0,0 +1,519 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gonum.org/v1/gonum/mat"
+
+	statsanal "github.com/yodeman/analyses-api/stats-analyses"
+)
+
+// Response format for hypothesis test requests.
+type testResp struct {
+	Result *statsanal.TestResult `json:"result"`
+	// Names of the tested samples or groups.
+	Names []string `json:"names,omitempty"`
+	// Rows and Columns label the contingency table of a chi-square test.
+	Rows    []string `json:"rows,omitempty"`
+	Columns []string `json:"columns,omitempty"`
+	Error   string   `json:"error"`
+}
+
+// Request format for t-test queries.
+type tTestRequest struct {
+	Username    string      `json:"username" binding:"required,alphanum"`
+	FileID      int64       `json:"file_id" binding:"required,min=1"`
+	Version     int32       `json:"version" binding:"omitempty,min=1"`
+	Test        string      `json:"test" binding:"required,oneof=one_sample welch paired"`
+	Columns     []columnRef `json:"columns" binding:"required,min=1,max=2"`
+	Mu          float64     `json:"mu"`
+	Alternative string      `json:"alternative" binding:"omitempty,oneof=two_sided less greater"`
+	Confidence  float64     `json:"confidence" binding:"omitempty,gt=0,lt=1"`
+}
+
+/*
+tTest runs a one-sample, two-sample Welch or paired t-test on columns of one
+of the user's files. The endpoint expects a GET request with a json body with
+the following key:
+
+	`username`    - alphanumeric user's username
+	`file_id`     - id of the user's file to analyse.
+	`version`     - optional, version of the file to analyse, defaults to
+	                the file's current version.
+	`test`        - `one_sample` tests the mean of one column, `welch` the
+	                difference of the means of two columns without assuming
+	                equal variances, and `paired` the mean of the row-wise
+	                differences of two columns.
+	`columns`     - list of indices or names of the tested column, or of the
+	                two compared columns.
+	`mu`          - optional, mean or mean difference under the null
+	                hypothesis, defaults to 0.
+	`alternative` - optional, alternative hypothesis: `two_sided`
+	                (default), `less` or `greater`.
+	`confidence`  - optional, confidence level of the interval of the
+	                estimate in (0, 1), defaults to 0.95.
+
+Missing values are ignored, along with the rows missing either value for
+paired tests. The effect size is Cohen's d, and the bound of one-sided
+intervals which is infinite is null.
+
+The request returns response with the following http status codes:
+
+200 - status OK:
+
+	with response body:
+	    {
+	        "result": {
+	            "test": "*****",
+	            "alternative": "*****",
+	            "statistic": *****,
+	            "df": [*****],
+	            "p_value": *****,
+	            "effect_size": *****,
+	            "effect_size_name": "cohens_d",
+	            "estimate": *****,
+	            "confidence": *****,
+	            "conf_lower": *****,
+	            "conf_upper": *****,
+	            "observations": [*****]
+	        },
+	        "names": ["*****", ...],
+	        "error":""
+	     }
+
+400 - status Bad Request:
+
+	Error parsing request body, invalid columns, or not one column for
+	one-sample tests and two columns for the others.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+401 - status Unauthorized:
+
+	If access token has expired or username in request body don't match username
+	in token payload.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+404 - status Not Found:
+
+	If the user has no file with id `file_id`, or the file has no such
+	version.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+422 - status Unprocessable Entity:
+
+	If a column has less than 2 observed values, or both columns are constant
+	for Welch tests.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+501 - status Internal Server Error:
+
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+*/
+func (server *Server) tTest(ctx *gin.Context) {
+	server.serveAnalysis(ctx, &tTestRequest{})
+}
+
+func (req *tTestRequest) owner() string {
+	return req.Username
+}
+
+// run performs the t-test on the file of user `username` and returns the
+// response body.
+//
+// Returns a non-nil error along with the http status code to respond with if
+// the test can't be performed.
+func (req *tTestRequest) run(
+	ctx context.Context, server *Server, username string,
+) (any, int, error) {
+	want := 2
+	if req.Test == "one_sample" {
+		want = 1
+	}
+	if len(req.Columns) != want {
+		return nil, http.StatusBadRequest, fmt.Errorf(
+			"%s t-test needs %d columns, got %d.", req.Test, want, len(req.Columns))
+	}
+
+	ds, status, err := server.loadDataset(ctx, username, req.FileID, req.Version)
+	if err != nil {
+		return nil, status, err
+	}
+
+	columns, err := resolveColumns(ds.names, req.Columns)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	opts := statsanal.TTestOptions{
+		Mu:          req.Mu,
+		Alternative: statsanal.Alternative(req.Alternative),
+		Confidence:  req.Confidence,
+	}
+	x := mat.Col(nil, columns[0], ds.data)
+
+	var result statsanal.TestResult
+	switch req.Test {
+	case "one_sample":
+		result, err = statsanal.OneSampleTTest(x, opts)
+	case "welch":
+		result, err = statsanal.WelchTTest(x, mat.Col(nil, columns[1], ds.data), opts)
+	default:
+		result, err = statsanal.PairedTTest(x, mat.Col(nil, columns[1], ds.data), opts)
+	}
+	if err != nil {
+		return nil, http.StatusUnprocessableEntity,
+			fmt.Errorf("Error during t-test\n%w", err)
+	}
+
+	return testResp{Result: &result, Names: ds.selectNames(columns)}, http.StatusOK, nil
+}
+
+// Request format for chi-square test queries.
+type chiSquareRequest struct {
+	Username string      `json:"username" binding:"required,alphanum"`
+	FileID   int64       `json:"file_id" binding:"required,min=1"`
+	Version  int32       `json:"version" binding:"omitempty,min=1"`
+	Columns  []columnRef `json:"columns" binding:"required,min=2"`
+	Table    bool        `json:"table"`
+}
+
+/*
+chiSquare runs Pearson's chi-square test of independence on columns of one of
+the user's files. The endpoint expects a GET request with a json body with
+the following key:
+
+	`username` - alphanumeric user's username
+	`file_id`  - id of the user's file to analyse.
+	`version`  - optional, version of the file to analyse, defaults to the
+	             file's current version.
+	`columns`  - list of indices or names of the columns. Without `table`,
+	             the two columns hold categories coded as numbers, whose
+	             pairs are counted into a contingency table.
+	`table`    - optional, the selected columns already hold the counts of
+	             a contingency table, one row per row of the file.
+
+The effect size is Cramér's V. The rows and columns of a contingency table
+counted from two columns are labelled with the categories.
+
+The request returns response with the following http status codes:
+
+200 - status OK:
+
+	with response body:
+	    {
+	        "result": {
+	            "test": "chi_square",
+	            "statistic": *****,
+	            "df": [*****],
+	            "p_value": *****,
+	            "effect_size": *****,
+	            "effect_size_name": "cramers_v",
+	            "observations": [*****],
+	            "expected": [[*****], ...]
+	        },
+	        "names": ["*****", ...],
+	        "rows": ["*****", ...],
+	        "columns": ["*****", ...],
+	        "error":""
+	     }
+
+400 - status Bad Request:
+
+	Error parsing request body, invalid columns, or not two columns without
+	`table`.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+401 - status Unauthorized:
+
+	If access token has expired or username in request body don't match username
+	in token payload.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+404 - status Not Found:
+
+	If the user has no file with id `file_id`, or the file has no such
+	version.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+422 - status Unprocessable Entity:
+
+	If the contingency table is smaller than 2x2, has a missing or negative
+	count, or a row or column without counts.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+501 - status Internal Server Error:
+
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+*/
+func (server *Server) chiSquare(ctx *gin.Context) {
+	server.serveAnalysis(ctx, &chiSquareRequest{})
+}
+
+func (req *chiSquareRequest) owner() string {
+	return req.Username
+}
+
+// run performs the chi-square test on the file of user `username` and
+// returns the response body.
+//
+// Returns a non-nil error along with the http status code to respond with if
+// the test can't be performed.
+func (req *chiSquareRequest) run(
+	ctx context.Context, server *Server, username string,
+) (any, int, error) {
+	if !req.Table && len(req.Columns) != 2 {
+		return nil, http.StatusBadRequest, fmt.Errorf(
+			"Need 2 columns of categories, got %d.", len(req.Columns))
+	}
+
+	ds, status, err := server.loadDataset(ctx, username, req.FileID, req.Version)
+	if err != nil {
+		return nil, status, err
+	}
+
+	columns, err := resolveColumns(ds.names, req.Columns)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	resp := testResp{Names: ds.selectNames(columns)}
+	var table mat.Matrix
+	if req.Table {
+		table = statsanal.SelectColumns(ds.data, columns)
+		resp.Columns = resp.Names
+	} else {
+		var rows, cols []float64
+		table, rows, cols, err = statsanal.Crosstab(
+			mat.Col(nil, columns[0], ds.data), mat.Col(nil, columns[1], ds.data))
+		if err != nil {
+			return nil, http.StatusUnprocessableEntity, err
+		}
+		resp.Rows, resp.Columns = formatLevels(rows), formatLevels(cols)
+	}
+
+	result, err := statsanal.ChiSquareTest(table)
+	if err != nil {
+		return nil, http.StatusUnprocessableEntity,
+			fmt.Errorf("Error during chi-square test\n%w", err)
+	}
+
+	resp.Result = &result
+	return resp, http.StatusOK, nil
+}
+
+// Request format for one-way ANOVA queries.
+type anovaRequest struct {
+	Username string      `json:"username" binding:"required,alphanum"`
+	FileID   int64       `json:"file_id" binding:"required,min=1"`
+	Version  int32       `json:"version" binding:"omitempty,min=1"`
+	Columns  []columnRef `json:"columns"`
+	Value    *columnRef  `json:"value"`
+	Group    *columnRef  `json:"group"`
+}
+
+/*
+anova runs a one-way analysis of variance on one of the user's files. The
+endpoint expects a GET request with a json body with the following key:
+
+	`username` - alphanumeric user's username
+	`file_id`  - id of the user's file to analyse.
+	`version`  - optional, version of the file to analyse, defaults to the
+	             file's current version.
+	`columns`  - optional, list of indices or names of the columns holding
+	             the values of each group, defaults to every column.
+	`value`    - optional, index or name of the column holding the values,
+	             along with `group` instead of `columns`.
+	`group`    - optional, index or name of the column holding the group of
+	             each value, coded as numbers.
+
+Missing values are ignored. The effect size is eta squared, the share of the
+variance explained by the groups.
+
+The request returns response with the following http status codes:
+
+200 - status OK:
+
+	with response body:
+	    {
+	        "result": {
+	            "test": "anova",
+	            "statistic": *****,
+	            "df": [*****, *****],
+	            "p_value": *****,
+	            "effect_size": *****,
+	            "effect_size_name": "eta_squared",
+	            "observations": [*****]
+	        },
+	        "names": ["*****", ...],
+	        "error":""
+	     }
+
+400 - status Bad Request:
+
+	Error parsing request body, invalid columns, or only one of `value` and
+	`group`.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+401 - status Unauthorized:
+
+	If access token has expired or username in request body don't match username
+	in token payload.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+404 - status Not Found:
+
+	If the user has no file with id `file_id`, or the file has no such
+	version.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+422 - status Unprocessable Entity:
+
+	If there are less than 2 groups, a group without observed values, or no
+	more observations than groups.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+501 - status Internal Server Error:
+
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+*/
+func (server *Server) anova(ctx *gin.Context) {
+	server.serveAnalysis(ctx, &anovaRequest{})
+}
+
+func (req *anovaRequest) owner() string {
+	return req.Username
+}
+
+// run performs the ANOVA on the file of user `username` and returns the
+// response body.
+//
+// Returns a non-nil error along with the http status code to respond with if
+// the test can't be performed.
+func (req *anovaRequest) run(
+	ctx context.Context, server *Server, username string,
+) (any, int, error) {
+	if (req.Value == nil) != (req.Group == nil) {
+		return nil, http.StatusBadRequest,
+			fmt.Errorf("`value` and `group` should be given together.")
+	}
+
+	ds, status, err := server.loadDataset(ctx, username, req.FileID, req.Version)
+	if err != nil {
+		return nil, status, err
+	}
+
+	groups, names, err := ds.selectGroups(req.Columns, req.Value, req.Group)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	result, err := statsanal.OneWayANOVA(groups)
+	if err != nil {
+		return nil, http.StatusUnprocessableEntity,
+			fmt.Errorf("Error during ANOVA\n%w", err)
+	}
+
+	return testResp{Result: &result, Names: names}, http.StatusOK, nil
+}
+
+// selectGroups returns the values of each group along with its name. The
+// groups are either the `columns`, or the values of column `value` split by
+// the labels of column `group` when both are given.
+//
+// Returns an error if a column can't be resolved.
+func (ds dataset) selectGroups(
+	columns []columnRef, value, group *columnRef,
+) (groups [][]float64, names []string, err error) {
+	if value != nil && group != nil {
+		cols, err := resolveColumns(ds.names, []columnRef{*value, *group})
+		if err != nil {
+			return nil, nil, err
+		}
+		var labels []float64
+		groups, labels = statsanal.GroupBy(
+			mat.Col(nil, cols[0], ds.data), mat.Col(nil, cols[1], ds.data))
+		return groups, formatLevels(labels), nil
+	}
+
+	cols, err := resolveColumns(ds.names, columns)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, col := range cols {
+		groups = append(groups, mat.Col(nil, col, ds.data))
+	}
+	return groups, ds.selectNames(cols), nil
+}
+
+// formatLevels formats the category `levels` as strings.
+func formatLevels(levels []float64) []string {
+	names := make([]string, len(levels))
+	for i, v := range levels {
+		names[i] = strconv.FormatFloat(v, 'g', -1, 64)
+	}
+	return names
+}
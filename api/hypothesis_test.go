@@ -0,0 +1,400 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	gomock "go.uber.org/mock/gomock"
+	"gonum.org/v1/gonum/mat"
+
+	mockdb "github.com/yodeman/analyses-api/dbase/mock"
+	db "github.com/yodeman/analyses-api/dbase/sqlc"
+	"github.com/yodeman/analyses-api/util"
+)
+
+// hypothesisFile returns a file of 10 rows holding the extra hours of sleep
+// with 2 drugs, a group and a category column.
+func hypothesisFile(t *testing.T, username string) db.File {
+	m := mat.NewDense(10, 4, nil)
+	m.SetCol(0, []float64{0.7, -1.6, -0.2, -1.2, -0.1, 3.4, 3.7, 0.8, 0.0, 2.0})
+	m.SetCol(1, []float64{1.9, 0.8, 1.1, 0.1, -0.1, 4.4, 5.5, 1.6, 4.6, 3.4})
+	m.SetCol(2, []float64{1, 1, 1, 2, 2, 2, 3, 3, 3, 3})
+	m.SetCol(3, []float64{0, 1, 0, 1, 0, 1, 0, 1, 1, 1})
+	data, err := m.MarshalBinary()
+	require.NoError(t, err)
+
+	return db.File{
+		ID:          util.RandomInt(1, 1000),
+		Username:    username,
+		Data:        data,
+		ColumnNames: []string{"drug1", "drug2", "group", "category"},
+	}
+}
+
+// hypothesisCase is a test case of the hypothesis test endpoints.
+type hypothesisCase struct {
+	name          string
+	params        any
+	username      string
+	buildStubs    func(store *mockdb.MockStore)
+	checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+}
+
+func TestTTest(t *testing.T) {
+	user, _ := randomUser(t)
+	file := hypothesisFile(t, user.Username)
+	getFile := func(store *mockdb.MockStore) {
+		store.EXPECT().
+			GetFile(gomock.Any(), gomock.Eq(db.GetFileParams{
+				ID:       file.ID,
+				Username: user.Username,
+			})).
+			Times(1).
+			Return(file, nil)
+	}
+	noFile := func(store *mockdb.MockStore) {
+		store.EXPECT().
+			GetFile(gomock.Any(), gomock.Any()).
+			Times(0)
+	}
+
+	testCases := []hypothesisCase{
+		{
+			name: "ONE SAMPLE",
+			params: tTestRequest{
+				Username: user.Username,
+				FileID:   file.ID,
+				Test:     "one_sample",
+				Columns:  []columnRef{columnName("drug1")},
+			},
+			username:   user.Username,
+			buildStubs: getFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				resp := requireBodyMatchTest(t, recorder.Body, "one_sample")
+				require.InDelta(t, 1.3257, *resp.Result.Statistic, 1e-4)
+				require.Equal(t, []string{"drug1"}, resp.Names)
+			},
+		},
+		{
+			name: "PAIRED GREATER",
+			params: tTestRequest{
+				Username:    user.Username,
+				FileID:      file.ID,
+				Test:        "paired",
+				Columns:     []columnRef{columnIndex(1), columnIndex(0)},
+				Alternative: "greater",
+			},
+			username:   user.Username,
+			buildStubs: getFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				resp := requireBodyMatchTest(t, recorder.Body, "paired")
+				require.InDelta(t, 4.0621, *resp.Result.Statistic, 1e-4)
+				require.InDelta(t, 0.002833/2, *resp.Result.PValue, 1e-6)
+				require.Nil(t, resp.Result.ConfUpper)
+				require.Equal(t, []string{"drug2", "drug1"}, resp.Names)
+			},
+		},
+		{
+			name: "WELCH",
+			params: tTestRequest{
+				Username: user.Username,
+				FileID:   file.ID,
+				Test:     "welch",
+				Columns:  []columnRef{columnName("drug1"), columnName("drug2")},
+			},
+			username:   user.Username,
+			buildStubs: getFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				resp := requireBodyMatchTest(t, recorder.Body, "welch")
+				require.InDelta(t, 17.776, resp.Result.DF[0], 1e-3)
+			},
+		},
+		{
+			name: "WRONG COLUMN COUNT",
+			params: tTestRequest{
+				Username: user.Username,
+				FileID:   file.ID,
+				Test:     "welch",
+				Columns:  []columnRef{columnName("drug1")},
+			},
+			username:   user.Username,
+			buildStubs: noFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "UNKNOWN COLUMN",
+			params: tTestRequest{
+				Username: user.Username,
+				FileID:   file.ID,
+				Test:     "one_sample",
+				Columns:  []columnRef{columnName("drug3")},
+			},
+			username:   user.Username,
+			buildStubs: getFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "INVALID ALTERNATIVE",
+			params: tTestRequest{
+				Username:    user.Username,
+				FileID:      file.ID,
+				Test:        "one_sample",
+				Columns:     []columnRef{columnName("drug1")},
+				Alternative: "both",
+			},
+			username:   user.Username,
+			buildStubs: noFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "UNAUTHORIZED",
+			params: tTestRequest{
+				Username: user.Username,
+				FileID:   file.ID,
+				Test:     "one_sample",
+				Columns:  []columnRef{columnName("drug1")},
+			},
+			username:   "deidara",
+			buildStubs: noFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	runHypothesisCases(t, "/analyses/tests/ttest", testCases)
+}
+
+func TestChiSquare(t *testing.T) {
+	user, _ := randomUser(t)
+	file := hypothesisFile(t, user.Username)
+	getFile := func(store *mockdb.MockStore) {
+		store.EXPECT().
+			GetFile(gomock.Any(), gomock.Eq(db.GetFileParams{
+				ID:       file.ID,
+				Username: user.Username,
+			})).
+			Times(1).
+			Return(file, nil)
+	}
+
+	testCases := []hypothesisCase{
+		{
+			name: "CROSSTAB",
+			params: chiSquareRequest{
+				Username: user.Username,
+				FileID:   file.ID,
+				Columns:  []columnRef{columnName("group"), columnName("category")},
+			},
+			username:   user.Username,
+			buildStubs: getFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				resp := requireBodyMatchTest(t, recorder.Body, "chi_square")
+				require.Equal(t, []string{"1", "2", "3"}, resp.Rows)
+				require.Equal(t, []string{"0", "1"}, resp.Columns)
+				require.Equal(t, []float64{2}, resp.Result.DF)
+				require.Equal(t, []int{10}, resp.Result.Observations)
+			},
+		},
+		{
+			name: "TABLE",
+			params: chiSquareRequest{
+				Username: user.Username,
+				FileID:   file.ID,
+				Columns:  []columnRef{columnName("group"), columnName("category")},
+				Table:    true,
+			},
+			username:   user.Username,
+			buildStubs: getFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				resp := requireBodyMatchTest(t, recorder.Body, "chi_square")
+				require.Equal(t, []string{"group", "category"}, resp.Columns)
+				require.Equal(t, []float64{9}, resp.Result.DF)
+			},
+		},
+		{
+			name: "NEGATIVE COUNTS",
+			params: chiSquareRequest{
+				Username: user.Username,
+				FileID:   file.ID,
+				Columns:  []columnRef{columnName("drug1"), columnName("drug2")},
+				Table:    true,
+			},
+			username:   user.Username,
+			buildStubs: getFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name: "TOO MANY COLUMNS",
+			params: chiSquareRequest{
+				Username: user.Username,
+				FileID:   file.ID,
+				Columns: []columnRef{
+					columnName("group"), columnName("category"), columnName("drug1"),
+				},
+			},
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetFile(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	runHypothesisCases(t, "/analyses/tests/chisquare", testCases)
+}
+
+func TestANOVA(t *testing.T) {
+	user, _ := randomUser(t)
+	file := hypothesisFile(t, user.Username)
+	getFile := func(store *mockdb.MockStore) {
+		store.EXPECT().
+			GetFile(gomock.Any(), gomock.Eq(db.GetFileParams{
+				ID:       file.ID,
+				Username: user.Username,
+			})).
+			Times(1).
+			Return(file, nil)
+	}
+	value, group := columnName("drug1"), columnName("group")
+
+	testCases := []hypothesisCase{
+		{
+			name: "COLUMNS",
+			params: anovaRequest{
+				Username: user.Username,
+				FileID:   file.ID,
+				Columns:  []columnRef{columnName("drug1"), columnName("drug2")},
+			},
+			username:   user.Username,
+			buildStubs: getFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				resp := requireBodyMatchTest(t, recorder.Body, "anova")
+				require.Equal(t, []string{"drug1", "drug2"}, resp.Names)
+				require.Equal(t, []float64{1, 18}, resp.Result.DF)
+				// F is the square of the pooled two-sample t statistic.
+				require.InDelta(t, 1.8608*1.8608, *resp.Result.Statistic, 1e-3)
+			},
+		},
+		{
+			name: "VALUE AND GROUP",
+			params: anovaRequest{
+				Username: user.Username,
+				FileID:   file.ID,
+				Value:    &value,
+				Group:    &group,
+			},
+			username:   user.Username,
+			buildStubs: getFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				resp := requireBodyMatchTest(t, recorder.Body, "anova")
+				require.Equal(t, []string{"1", "2", "3"}, resp.Names)
+				require.Equal(t, []int{3, 3, 4}, resp.Result.Observations)
+			},
+		},
+		{
+			name: "VALUE WITHOUT GROUP",
+			params: anovaRequest{
+				Username: user.Username,
+				FileID:   file.ID,
+				Value:    &value,
+			},
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetFile(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "SINGLE GROUP",
+			params: anovaRequest{
+				Username: user.Username,
+				FileID:   file.ID,
+				Columns:  []columnRef{columnName("drug1")},
+			},
+			username:   user.Username,
+			buildStubs: getFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+	}
+
+	runHypothesisCases(t, "/analyses/tests/anova", testCases)
+}
+
+// runHypothesisCases runs the `testCases` against the endpoint at `url`.
+func runHypothesisCases(t *testing.T, url string, testCases []hypothesisCase) {
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			encodedParams, err := json.Marshal(tc.params)
+			require.NoError(t, err)
+			request, err := http.NewRequest(
+				http.MethodGet, url, bytes.NewBuffer(encodedParams))
+			require.NoError(t, err)
+			addAuthorization(
+				t, request, server.tokenMaker, authorizationTypeToken,
+				tc.username, time.Minute,
+			)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func requireBodyMatchTest(
+	t *testing.T, responseBody *bytes.Buffer, test string,
+) testResp {
+	var serverResp testResp
+
+	err := json.NewDecoder(responseBody).Decode(&serverResp)
+	require.NoError(t, err)
+
+	require.Empty(t, serverResp.Error)
+	require.NotNil(t, serverResp.Result)
+	require.Equal(t, test, serverResp.Result.Test)
+	require.NotNil(t, serverResp.Result.Statistic)
+	require.NotNil(t, serverResp.Result.PValue)
+
+	return serverResp
+}
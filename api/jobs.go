@@ -0,0 +1,407 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	db "github.com/yodeman/analyses-api/dbase/sqlc"
+)
+
+// analysisRequest is the request of an analysis, which can either be answered
+// right away or submitted as a job.
+type analysisRequest interface {
+	// owner returns the username in the request.
+	owner() string
+	// run performs the analysis for user `username` and returns the response
+	// body, or an error along with the http status code to respond with.
+	run(ctx context.Context, server *Server, username string) (any, int, error)
+}
+
+// jobKinds maps the kinds of job to a constructor of their request.
+var jobKinds = map[string]func() analysisRequest{
+	"regression":      func() analysisRequest { return &regressionRequest{} },
+	"logistic":        func() analysisRequest { return &logisticRequest{} },
+	"regularized":     func() analysisRequest { return &regularizedRequest{} },
+	"crossvalidation": func() analysisRequest { return &crossValidationRequest{} },
+	"describe":        func() analysisRequest { return &describeRequest{} },
+	"correlation":     func() analysisRequest { return &correlationRequest{} },
+	"pca":             func() analysisRequest { return &pcaRequest{} },
+	"kmeans":          func() analysisRequest { return &kmeansRequest{} },
+	"hierarchical":    func() analysisRequest { return &hierarchicalRequest{} },
+	"dbscan":          func() analysisRequest { return &dbscanRequest{} },
+	"outliers":        func() analysisRequest { return &outliersRequest{} },
+	"movingaverage":   func() analysisRequest { return &movingAverageRequest{} },
+	"smoothing":       func() analysisRequest { return &smoothingRequest{} },
+	"difference":      func() analysisRequest { return &differenceRequest{} },
+	"autocorrelation": func() analysisRequest { return &autocorrelationRequest{} },
+	"adf":             func() analysisRequest { return &adfRequest{} },
+	"arima":           func() analysisRequest { return &arimaRequest{} },
+	"decomposition":   func() analysisRequest { return &decompositionRequest{} },
+	"changepoints":    func() analysisRequest { return &changePointsRequest{} },
+	"ttest":           func() analysisRequest { return &tTestRequest{} },
+	"chisquare":       func() analysisRequest { return &chiSquareRequest{} },
+	"anova":           func() analysisRequest { return &anovaRequest{} },
+	"mannwhitney":     func() analysisRequest { return &mannWhitneyRequest{} },
+	"wilcoxon":        func() analysisRequest { return &wilcoxonRequest{} },
+	"kruskal":         func() analysisRequest { return &kruskalRequest{} },
+	"ks":              func() analysisRequest { return &ksRequest{} },
+}
+
+// Response format for job
+type jobResp struct {
+	ID     int64           `json:"id"`
+	Kind   string          `json:"kind"`
+	Status string          `json:"status"`
+	Result json.RawMessage `json:"result"`
+	// Error is the error of a failed job.
+	Error      string     `json:"error"`
+	CreatedAt  time.Time  `json:"created_at"`
+	StartedAt  *time.Time `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+}
+type jobResponse struct {
+	Job   *jobResp `json:"job"`
+	Error string   `json:"error"`
+}
+
+// newJobResp hides the parameters of the user's job.
+func newJobResp(job db.Job) *jobResp {
+	resp := &jobResp{
+		ID:        job.ID,
+		Kind:      job.Kind,
+		Status:    job.Status,
+		Result:    job.Result,
+		Error:     job.Error,
+		CreatedAt: job.CreatedAt,
+	}
+	if job.StartedAt.Valid {
+		resp.StartedAt = &job.StartedAt.Time
+	}
+	if job.FinishedAt.Valid {
+		resp.FinishedAt = &job.FinishedAt.Time
+	}
+	return resp
+}
+
+// runJob runs the analysis of a job for the worker pool.
+func (server *Server) runJob(ctx context.Context, job db.Job) (json.RawMessage, error) {
+	newRequest, ok := jobKinds[job.Kind]
+	if !ok {
+		return nil, fmt.Errorf("Unknown job kind %q.", job.Kind)
+	}
+
+	req := newRequest()
+	if err := json.Unmarshal(job.Params, req); err != nil {
+		return nil, fmt.Errorf("Error decoding job parameters.\n%w", err)
+	}
+
+	body, _, err := req.run(ctx, server, job.Username)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(body)
+}
+
+// Request format for the job in the url path.
+type jobURIRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+/*
+submitJob queues an analysis to run in the background and returns right away.
+The endpoint expects a POST request at `/jobs?kind=*****`, where `kind` is one
+of:
+
+	`regression`      - see linearRegression.
+	`logistic`        - see logisticRegression.
+	`regularized`     - see regularizedRegression.
+	`crossvalidation` - see crossValidation.
+	`describe`        - see describe.
+	`correlation`     - see correlation.
+	`pca`             - see pca.
+	`kmeans`          - see kmeans.
+	`hierarchical`    - see hierarchical.
+	`dbscan`          - see dbscan.
+	`outliers`        - see outliers.
+	`movingaverage`   - see movingAverage.
+	`smoothing`       - see smoothing.
+	`difference`      - see difference.
+	`autocorrelation` - see autocorrelation.
+	`adf`             - see adf.
+	`arima`           - see arima.
+	`decomposition`   - see decomposition.
+	`changepoints`    - see changePoints.
+	`ttest`           - see tTest.
+	`chisquare`       - see chiSquare.
+	`anova`           - see anova.
+	`mannwhitney`     - see mannWhitney.
+	`wilcoxon`        - see wilcoxon.
+	`kruskal`         - see kruskal.
+	`ks`              - see ks.
+
+with the json body of the analysis endpoint.
+
+The request returns response with the following http status codes:
+
+202 - status Accepted:
+
+	with response body:
+	    {
+	        "job": {
+	            "id": *****,
+	            "kind": "*****",
+	            "status": "queued",
+	            "result": null,
+	            "error": "",
+	            "created_at": "*****",
+	            "started_at": null,
+	            "finished_at": null
+	        },
+	        "error":""
+	     }
+
+400 - status Bad Request:
+
+	Error parsing request body.
+
+401 - status Unauthorized:
+
+	If access token has expired or username in request body don't match username
+	in token payload.
+
+404 - status Not Found:
+
+	If the kind of job is missing or unknown.
+
+501 - status Internal Server Error:
+
+	with response body:
+	    {
+	        "job": null,
+	        "error": "*****"
+	    }
+*/
+func (server *Server) submitJob(ctx *gin.Context) {
+	var resp jobResponse
+
+	kind := ctx.Query("kind")
+	newRequest, ok := jobKinds[kind]
+	if !ok {
+		resp.Error = errResponse(fmt.Errorf("Unknown job kind %q.", kind))
+		ctx.JSON(http.StatusNotFound, resp)
+		return
+	}
+
+	req := newRequest()
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		resp.Error = errResponse(fmt.Errorf("Error parsing request body.\n%w", err))
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	authPayload, err := getPayload(ctx)
+	if err != nil {
+		resp.Error = errResponse(
+			fmt.Errorf("Error getting authentication payload.\n%w", err))
+		ctx.JSON(http.StatusInternalServerError, resp)
+		return
+	}
+
+	if req.owner() != authPayload.Username {
+		resp.Error = errResponse(
+			fmt.Errorf("request `username` and auth `username` don't match."))
+		ctx.JSON(http.StatusUnauthorized, resp)
+		return
+	}
+
+	params, err := json.Marshal(req)
+	if err != nil {
+		resp.Error = errResponse(fmt.Errorf("Error encoding job parameters.\n%w", err))
+		ctx.JSON(http.StatusInternalServerError, resp)
+		return
+	}
+
+	job, err := server.store.CreateJob(
+		ctx,
+		db.CreateJobParams{
+			Username: authPayload.Username,
+			Kind:     kind,
+			Params:   params,
+		},
+	)
+	if err != nil {
+		resp.Error = errResponse(fmt.Errorf("Error queuing job.\n%w", err))
+		ctx.JSON(http.StatusInternalServerError, resp)
+		return
+	}
+	server.jobs.Notify()
+
+	resp.Job = newJobResp(job)
+	ctx.JSON(http.StatusAccepted, resp)
+}
+
+/*
+getJob reports the status of one of the authenticated user's jobs. The
+endpoint expects a GET request at `/jobs/:id`.
+
+The status of a job is one of `queued`, `running`, `succeeded`, `failed` or
+`cancelled`. The result of a succeeded job is the response body of the
+analysis endpoint, and the error of a failed job is in the job's `error`.
+
+The request returns response with the following http status codes:
+
+200 - status OK:
+
+	with the job in the response body, see submitJob.
+
+400 - status Bad Request:
+
+	Invalid job id.
+
+401 - status Unauthorized:
+
+	If access token is missing or has expired.
+
+404 - status Not Found:
+
+	If the user has no job with the given id.
+
+501 - status Internal Server Error:
+
+	with response body:
+	    {
+	        "job": null,
+	        "error": "*****"
+	    }
+*/
+func (server *Server) getJob(ctx *gin.Context) {
+	var uri jobURIRequest
+	var resp jobResponse
+
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		resp.Error = errResponse(fmt.Errorf("Error parsing job id.\n%w", err))
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	authPayload, err := getPayload(ctx)
+	if err != nil {
+		resp.Error = errResponse(
+			fmt.Errorf("Error getting authentication payload.\n%w", err))
+		ctx.JSON(http.StatusInternalServerError, resp)
+		return
+	}
+
+	job, err := server.store.GetJob(
+		ctx, db.GetJobParams{ID: uri.ID, Username: authPayload.Username})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			resp.Error = errResponse(fmt.Errorf("Job does not exist.\n%w", err))
+			ctx.JSON(http.StatusNotFound, resp)
+			return
+		}
+
+		resp.Error = errResponse(fmt.Errorf("Error fetching user's job.\n%w", err))
+		ctx.JSON(http.StatusInternalServerError, resp)
+		return
+	}
+
+	resp.Job = newJobResp(job)
+	ctx.JSON(http.StatusOK, resp)
+}
+
+/*
+cancelJob cancels one of the authenticated user's queued or running jobs. The
+endpoint expects a POST request at `/jobs/:id/cancel`. A running job stops at
+the next step of its analysis, like a restart, fold, merge, tree or
+candidate model, and its result is discarded.
+
+The request returns response with the following http status codes:
+
+200 - status OK:
+
+	with the cancelled job in the response body, see submitJob.
+
+400 - status Bad Request:
+
+	Invalid job id.
+
+401 - status Unauthorized:
+
+	If access token is missing or has expired.
+
+404 - status Not Found:
+
+	If the user has no job with the given id.
+
+409 - status Conflict:
+
+	If the job has already finished.
+
+501 - status Internal Server Error:
+
+	with response body:
+	    {
+	        "job": null,
+	        "error": "*****"
+	    }
+*/
+func (server *Server) cancelJob(ctx *gin.Context) {
+	var uri jobURIRequest
+	var resp jobResponse
+
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		resp.Error = errResponse(fmt.Errorf("Error parsing job id.\n%w", err))
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	authPayload, err := getPayload(ctx)
+	if err != nil {
+		resp.Error = errResponse(
+			fmt.Errorf("Error getting authentication payload.\n%w", err))
+		ctx.JSON(http.StatusInternalServerError, resp)
+		return
+	}
+
+	job, err := server.store.CancelJob(
+		ctx, db.CancelJobParams{ID: uri.ID, Username: authPayload.Username})
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			resp.Error = errResponse(fmt.Errorf("Error cancelling user's job.\n%w", err))
+			ctx.JSON(http.StatusInternalServerError, resp)
+			return
+		}
+
+		// the job either doesn't exist or has finished.
+		job, err = server.store.GetJob(
+			ctx, db.GetJobParams{ID: uri.ID, Username: authPayload.Username})
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				resp.Error = errResponse(fmt.Errorf("Job does not exist.\n%w", err))
+				ctx.JSON(http.StatusNotFound, resp)
+				return
+			}
+
+			resp.Error = errResponse(fmt.Errorf("Error fetching user's job.\n%w", err))
+			ctx.JSON(http.StatusInternalServerError, resp)
+			return
+		}
+
+		resp.Job = newJobResp(job)
+		resp.Error = errResponse(fmt.Errorf("Job has already %s.", job.Status))
+		ctx.JSON(http.StatusConflict, resp)
+		return
+	}
+	server.jobs.Cancel(job.ID)
+
+	resp.Job = newJobResp(job)
+	ctx.JSON(http.StatusOK, resp)
+}
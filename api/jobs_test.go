@@ -0,0 +1,397 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	gomock "go.uber.org/mock/gomock"
+	"gonum.org/v1/gonum/mat"
+
+	mockdb "github.com/yodeman/analyses-api/dbase/mock"
+	db "github.com/yodeman/analyses-api/dbase/sqlc"
+	"github.com/yodeman/analyses-api/util"
+	"github.com/yodeman/analyses-api/worker"
+)
+
+func TestSubmitJob(t *testing.T) {
+	user, _ := randomUser(t)
+	regReq := regressionRequest{Username: user.Username, FileID: 1}
+	params, err := json.Marshal(&regReq)
+	require.NoError(t, err)
+
+	job := db.Job{
+		ID:       util.RandomInt(1, 1000),
+		Username: user.Username,
+		Kind:     "regression",
+		Params:   params,
+		Status:   worker.StatusQueued,
+		Result:   json.RawMessage("null"),
+	}
+
+	testCases := []struct {
+		name          string
+		kind          string
+		params        any
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "OK",
+			kind:   "regression",
+			params: regReq,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateJob(gomock.Any(), gomock.Eq(db.CreateJobParams{
+						Username: user.Username,
+						Kind:     "regression",
+						Params:   params,
+					})).
+					Times(1).
+					Return(job, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusAccepted, recorder.Code)
+
+				var serverResp jobResponse
+				err := json.NewDecoder(recorder.Body).Decode(&serverResp)
+				require.NoError(t, err)
+				require.Equal(t, job.ID, serverResp.Job.ID)
+				require.Equal(t, worker.StatusQueued, serverResp.Job.Status)
+				require.Nil(t, serverResp.Job.StartedAt)
+			},
+		},
+		{
+			name:   "UNKNOWN KIND",
+			kind:   "astrology",
+			params: regReq,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateJob(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:   "INVALID PARAMS",
+			kind:   "regression",
+			params: regressionRequest{Username: user.Username},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateJob(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:   "UNAUTHORIZED",
+			kind:   "regression",
+			params: regressionRequest{Username: "deidara", FileID: 1},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateJob(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:   "INTERNAL ERROR",
+			kind:   "regression",
+			params: regReq,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateJob(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Job{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			body, err := json.Marshal(tc.params)
+			require.NoError(t, err)
+			url := fmt.Sprintf("/jobs?kind=%s", tc.kind)
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+			require.NoError(t, err)
+			addAuthorization(
+				t, request, server.tokenMaker, authorizationTypeToken,
+				user.Username, time.Minute,
+			)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestGetAndCancelJob(t *testing.T) {
+	user, _ := randomUser(t)
+	jobID := util.RandomInt(1, 1000)
+	jobParams := db.GetJobParams{ID: jobID, Username: user.Username}
+	cancelParams := db.CancelJobParams{ID: jobID, Username: user.Username}
+	finished := db.Job{
+		ID:         jobID,
+		Username:   user.Username,
+		Kind:       "regression",
+		Status:     worker.StatusSucceeded,
+		Result:     json.RawMessage(`{"result": {}, "error": ""}`),
+		StartedAt:  sql.NullTime{Time: time.Now(), Valid: true},
+		FinishedAt: sql.NullTime{Time: time.Now(), Valid: true},
+	}
+	cancelled := db.Job{
+		ID:       jobID,
+		Username: user.Username,
+		Kind:     "regression",
+		Status:   worker.StatusCancelled,
+		Result:   json.RawMessage("null"),
+	}
+
+	testCases := []struct {
+		name          string
+		method        string
+		path          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "GET OK",
+			method: http.MethodGet,
+			path:   fmt.Sprintf("/jobs/%d", jobID),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetJob(gomock.Any(), gomock.Eq(jobParams)).
+					Times(1).
+					Return(finished, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var serverResp jobResponse
+				err := json.NewDecoder(recorder.Body).Decode(&serverResp)
+				require.NoError(t, err)
+				require.Equal(t, worker.StatusSucceeded, serverResp.Job.Status)
+				require.JSONEq(t, string(finished.Result), string(serverResp.Job.Result))
+				require.NotNil(t, serverResp.Job.FinishedAt)
+			},
+		},
+		{
+			name:   "GET NOT FOUND",
+			method: http.MethodGet,
+			path:   fmt.Sprintf("/jobs/%d", jobID),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetJob(gomock.Any(), gomock.Eq(jobParams)).
+					Times(1).
+					Return(db.Job{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:   "CANCEL OK",
+			method: http.MethodPost,
+			path:   fmt.Sprintf("/jobs/%d/cancel", jobID),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CancelJob(gomock.Any(), gomock.Eq(cancelParams)).
+					Times(1).
+					Return(cancelled, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var serverResp jobResponse
+				err := json.NewDecoder(recorder.Body).Decode(&serverResp)
+				require.NoError(t, err)
+				require.Equal(t, worker.StatusCancelled, serverResp.Job.Status)
+			},
+		},
+		{
+			name:   "CANCEL FINISHED",
+			method: http.MethodPost,
+			path:   fmt.Sprintf("/jobs/%d/cancel", jobID),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CancelJob(gomock.Any(), gomock.Eq(cancelParams)).
+					Times(1).
+					Return(db.Job{}, sql.ErrNoRows)
+
+				store.EXPECT().
+					GetJob(gomock.Any(), gomock.Eq(jobParams)).
+					Times(1).
+					Return(finished, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name:   "CANCEL NOT FOUND",
+			method: http.MethodPost,
+			path:   fmt.Sprintf("/jobs/%d/cancel", jobID),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CancelJob(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Job{}, sql.ErrNoRows)
+
+				store.EXPECT().
+					GetJob(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Job{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:   "CANCEL INTERNAL ERROR",
+			method: http.MethodPost,
+			path:   fmt.Sprintf("/jobs/%d/cancel", jobID),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CancelJob(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Job{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(tc.method, tc.path, nil)
+			require.NoError(t, err)
+			addAuthorization(
+				t, request, server.tokenMaker, authorizationTypeToken,
+				user.Username, time.Minute,
+			)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestRunJob(t *testing.T) {
+	user, _ := randomUser(t)
+	rows, cols, data, err := util.ParseCSVToFloatSlice(
+		bytes.NewBufferString(util.RandomCSV(30, 4)))
+	require.NoError(t, err)
+	matData, err := mat.NewDense(rows, cols, data).MarshalBinary()
+	require.NoError(t, err)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		GetFile(gomock.Any(), gomock.Eq(db.GetFileParams{ID: 1, Username: user.Username})).
+		Times(1).
+		Return(db.File{ID: 1, Username: user.Username, Data: matData}, nil)
+
+	server := newTestServer(t, store)
+
+	result, err := server.runJob(context.Background(), db.Job{
+		Username: user.Username,
+		Kind:     "regression",
+		Params:   json.RawMessage(`{"username": "ignored", "file_id": 1}`),
+	})
+	require.NoError(t, err)
+
+	var resp regressionResp
+	require.NoError(t, json.Unmarshal(result, &resp))
+	require.NotNil(t, resp.Result)
+	require.Len(t, resp.Result.Coefficients, cols)
+
+	_, err = server.runJob(context.Background(), db.Job{Kind: "astrology"})
+	require.Error(t, err)
+}
+
+func TestRunJobCancelled(t *testing.T) {
+	user, _ := randomUser(t)
+	rows, cols, data, err := util.ParseCSVToFloatSlice(
+		bytes.NewBufferString(util.RandomCSV(30, 4)))
+	require.NoError(t, err)
+	matData, err := mat.NewDense(rows, cols, data).MarshalBinary()
+	require.NoError(t, err)
+
+	testCases := map[string]string{
+		"hierarchical":    `{"file_id": 1}`,
+		"dbscan":          `{"file_id": 1, "eps": 1}`,
+		"kmeans":          `{"file_id": 1, "k": 2}`,
+		"crossvalidation": `{"file_id": 1, "model": "linear"}`,
+		"outliers":        `{"file_id": 1, "method": "isolation_forest"}`,
+		"arima":           `{"file_id": 1, "column": 0, "search": true}`,
+	}
+
+	for kind, params := range testCases {
+		t.Run(kind, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			// the job is cancelled while it loads its data.
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().
+				GetFile(gomock.Any(), gomock.Eq(db.GetFileParams{ID: 1, Username: user.Username})).
+				Times(1).
+				DoAndReturn(func(context.Context, db.GetFileParams) (db.File, error) {
+					cancel()
+					return db.File{ID: 1, Username: user.Username, Data: matData}, nil
+				})
+
+			server := newTestServer(t, store)
+			_, err := server.runJob(ctx, db.Job{
+				Username: user.Username,
+				Kind:     kind,
+				Params:   json.RawMessage(params),
+			})
+			require.ErrorIs(t, err, context.Canceled)
+		})
+	}
+}
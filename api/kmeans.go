@@ -0,0 +1,205 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	statsanal "github.com/yodeman/analyses-api/stats-analyses"
+)
+
+// Response format for kmeans request.
+type kmeansResp struct {
+	Result *statsanal.KMeansResult `json:"result"`
+	Elbow  []statsanal.ElbowPoint  `json:"elbow,omitempty"`
+	// Rows holds the index in the file of each clustered row.
+	Rows  []int  `json:"rows,omitempty"`
+	Error string `json:"error"`
+}
+
+// Request format for kmeans queries.
+type kmeansRequest struct {
+	Username      string      `json:"username" binding:"required,alphanum"`
+	FileID        int64       `json:"file_id" binding:"required,min=1"`
+	Version       int32       `json:"version" binding:"omitempty,min=1"`
+	Columns       []columnRef `json:"columns"`
+	K             int         `json:"k" binding:"omitempty,min=1"`
+	MinK          int         `json:"min_k" binding:"omitempty,min=1"`
+	MaxK          int         `json:"max_k" binding:"omitempty,min=1"`
+	Restarts      int         `json:"restarts" binding:"omitempty,min=1"`
+	MaxIterations int         `json:"max_iterations" binding:"omitempty,min=1"`
+	Seed          int64       `json:"seed"`
+	Standardize   bool        `json:"standardize"`
+	Missing       string      `json:"missing" binding:"omitempty,oneof=listwise mean median ffill"`
+}
+
+/*
+kmeans clusters the rows of one of the user's files with k-means. The
+endpoint expects a GET request with a json body with the following key:
+
+	`username`       - alphanumeric user's username
+	`file_id`        - id of the user's file to analyse.
+	`version`        - optional, version of the file to analyse, defaults to
+	                   the file's current version.
+	`columns`        - optional, list of indices or names of the columns to
+	                   cluster on, defaults to every column.
+	`k`              - number of clusters, required unless `max_k` is given.
+	`min_k`          - optional, smallest number of clusters of the elbow
+	                   curve, defaults to 1.
+	`max_k`          - optional, largest number of clusters of the elbow
+	                   curve, which is only returned when given.
+	`restarts`       - optional, number of runs from different k-means++
+	                   initial centroids, defaults to 10.
+	`max_iterations` - optional, maximum number of iterations of each run,
+	                   defaults to 300.
+	`seed`           - optional, seed of the random initial centroids,
+	                   defaults to 0.
+	`standardize`    - optional, scale the columns to unit variance first.
+	`missing`        - optional, how missing values are handled: `listwise`
+	                   (default), `mean`, `median` or `ffill`, see
+	                   /analyses/regression.
+
+The labels are ordered like `rows`, the indices in the file of the clustered
+rows, which skip the rows dropped for missing values. Clusters are numbered
+from 0 in the order of their first row.
+
+The request returns response with the following http status codes:
+
+200 - status OK:
+
+	with response body:
+	    {
+	        "result": {
+	            "names": ["*****", ...],
+	            "k": *****,
+	            "seed": *****,
+	            "centroids": [[*****], ...],
+	            "labels": [*****],
+	            "sizes": [*****],
+	            "inertia": *****,
+	            "silhouette": *****,
+	            "iterations": *****,
+	            "converged": *****
+	        },
+	        "elbow": [
+	            {
+	                "k": *****,
+	                "inertia": *****,
+	                "silhouette": *****
+	            },
+	            ...
+	        ],
+	        "rows": [*****],
+	        "error":""
+	     }
+
+400 - status Bad Request:
+
+	Error parsing request body, invalid columns, or neither `k` nor `max_k`.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+401 - status Unauthorized:
+
+	If access token has expired or username in request body don't match username
+	in token payload.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+404 - status Not Found:
+
+	If the user has no file with id `file_id`, or the file has no such
+	version.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+422 - status Unprocessable Entity:
+
+	If there are more clusters than rows or distinct rows, a column is
+	constant while standardizing, or no data is left after handling missing
+	values.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+501 - status Internal Server Error:
+
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+*/
+func (server *Server) kmeans(ctx *gin.Context) {
+	server.serveAnalysis(ctx, &kmeansRequest{})
+}
+
+func (req *kmeansRequest) owner() string {
+	return req.Username
+}
+
+// run performs the k-means clustering on the file of user `username` and
+// returns the response body.
+//
+// Returns a non-nil error along with the http status code to respond with if
+// the clustering can't be performed.
+func (req *kmeansRequest) run(
+	ctx context.Context, server *Server, username string,
+) (any, int, error) {
+	if req.K == 0 && req.MaxK == 0 {
+		return nil, http.StatusBadRequest,
+			fmt.Errorf("Either `k` or `max_k` should be given.")
+	}
+
+	data, names, rows, status, err := server.clusteringData(
+		ctx, username, req.FileID, req.Version, req.Columns, req.Missing)
+	if err != nil {
+		return nil, status, err
+	}
+
+	opts := statsanal.KMeansOptions{
+		K:             req.K,
+		Restarts:      req.Restarts,
+		MaxIterations: req.MaxIterations,
+		Seed:          req.Seed,
+		Standardize:   req.Standardize,
+		Names:         names,
+	}
+
+	var resp kmeansResp
+	if req.MaxK != 0 {
+		minK := req.MinK
+		if minK == 0 {
+			minK = 1
+		}
+		resp.Elbow, err = statsanal.KMeansElbow(ctx, data, minK, req.MaxK, opts)
+		if err != nil {
+			return nil, http.StatusUnprocessableEntity,
+				fmt.Errorf("Error during k-means elbow scan\n%w", err)
+		}
+	}
+	if req.K != 0 {
+		result, err := statsanal.KMeans(ctx, data, opts)
+		if err != nil {
+			return nil, http.StatusUnprocessableEntity,
+				fmt.Errorf("Error during k-means clustering\n%w", err)
+		}
+		resp.Result = &result
+		resp.Rows = rows
+	}
+
+	return resp, http.StatusOK, nil
+}
@@ -0,0 +1,191 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	gomock "go.uber.org/mock/gomock"
+	"gonum.org/v1/gonum/mat"
+
+	mockdb "github.com/yodeman/analyses-api/dbase/mock"
+	db "github.com/yodeman/analyses-api/dbase/sqlc"
+	"github.com/yodeman/analyses-api/util"
+)
+
+func TestKMeans(t *testing.T) {
+	user, _ := randomUser(t)
+	// two blobs of 4 rows, with a missing value in the third row.
+	matData, err := mat.NewDense(9, 2, []float64{
+		0, 0,
+		0, 1,
+		math.NaN(), 5,
+		1, 0,
+		1, 1,
+		10, 10,
+		10, 11,
+		11, 10,
+		11, 11,
+	}).MarshalBinary()
+	require.NoError(t, err)
+
+	fileID := util.RandomInt(1, 1000)
+	getFileParams := db.GetFileParams{ID: fileID, Username: user.Username}
+	file := db.File{
+		ID:          fileID,
+		Username:    user.Username,
+		Data:        matData,
+		ColumnNames: []string{"x", "y"},
+	}
+	getFile := func(store *mockdb.MockStore) {
+		store.EXPECT().
+			GetFile(gomock.Any(), gomock.Eq(getFileParams)).
+			Times(1).
+			Return(file, nil)
+	}
+	noFile := func(store *mockdb.MockStore) {
+		store.EXPECT().
+			GetFile(gomock.Any(), gomock.Any()).
+			Times(0)
+	}
+
+	testCases := []struct {
+		name          string
+		params        kmeansRequest
+		username      string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:       "OK",
+			params:     kmeansRequest{Username: user.Username, FileID: fileID, K: 2},
+			username:   user.Username,
+			buildStubs: getFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				resp := requireBodyMatchKMeans(t, recorder.Body)
+				require.Equal(t, []string{"x", "y"}, resp.Result.Names)
+				require.Equal(t, []int{0, 1, 3, 4, 5, 6, 7, 8}, resp.Rows)
+				require.Equal(t, []int{0, 0, 0, 0, 1, 1, 1, 1}, resp.Result.Labels)
+				require.InDelta(t, 4, resp.Result.Inertia, 1e-9)
+				require.Nil(t, resp.Elbow)
+			},
+		},
+		{
+			name: "ELBOW",
+			params: kmeansRequest{
+				Username: user.Username,
+				FileID:   fileID,
+				Columns:  []columnRef{columnName("y")},
+				MinK:     2,
+				MaxK:     4,
+				Missing:  "mean",
+			},
+			username:   user.Username,
+			buildStubs: getFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var resp kmeansResp
+				require.NoError(t, json.NewDecoder(recorder.Body).Decode(&resp))
+				require.Nil(t, resp.Result)
+				require.Nil(t, resp.Rows)
+				require.Len(t, resp.Elbow, 3)
+				require.Equal(t, 2, resp.Elbow[0].K)
+				require.Equal(t, 4, resp.Elbow[2].K)
+			},
+		},
+		{
+			name: "TOO MANY CLUSTERS",
+			params: kmeansRequest{
+				Username: user.Username,
+				FileID:   fileID,
+				K:        9,
+			},
+			username:   user.Username,
+			buildStubs: getFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name:       "NO K",
+			params:     kmeansRequest{Username: user.Username, FileID: fileID},
+			username:   user.Username,
+			buildStubs: noFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "UNKNOWN COLUMN",
+			params: kmeansRequest{
+				Username: user.Username,
+				FileID:   fileID,
+				Columns:  []columnRef{columnName("z")},
+				K:        2,
+			},
+			username:   user.Username,
+			buildStubs: getFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:       "UNAUTHORIZED",
+			params:     kmeansRequest{Username: user.Username, FileID: fileID, K: 2},
+			username:   "deidara",
+			buildStubs: noFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			encodedParams, err := json.Marshal(tc.params)
+			require.NoError(t, err)
+			request, err := http.NewRequest(
+				http.MethodGet, "/analyses/kmeans", bytes.NewBuffer(encodedParams))
+			require.NoError(t, err)
+			addAuthorization(
+				t, request, server.tokenMaker, authorizationTypeToken,
+				tc.username, time.Minute,
+			)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func requireBodyMatchKMeans(t *testing.T, responseBody *bytes.Buffer) kmeansResp {
+	var serverResp kmeansResp
+
+	err := json.NewDecoder(responseBody).Decode(&serverResp)
+	require.NoError(t, err)
+
+	require.Empty(t, serverResp.Error)
+	require.NotNil(t, serverResp.Result)
+	require.Len(t, serverResp.Result.Centroids, serverResp.Result.K)
+	require.Len(t, serverResp.Result.Labels, len(serverResp.Rows))
+	require.NotNil(t, serverResp.Result.Silhouette)
+
+	return serverResp
+}
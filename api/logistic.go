@@ -0,0 +1,206 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	statsanal "github.com/yodeman/analyses-api/stats-analyses"
+)
+
+// Response format for logistic regression request.
+type logisticResp struct {
+	Result *statsanal.LogisticResult `json:"result"`
+	// ModelID is the id of the saved model, only set when `save` is requested.
+	ModelID int64  `json:"model_id,omitempty"`
+	Error   string `json:"error"`
+}
+
+// Request format for logistic regression queries.
+type logisticRequest struct {
+	Username      string      `json:"username" binding:"required,alphanum"`
+	FileID        int64       `json:"file_id" binding:"required,min=1"`
+	Version       int32       `json:"version" binding:"omitempty,min=1"`
+	Threshold     float64     `json:"threshold" binding:"omitempty,gt=0,lt=1"`
+	MaxIterations int         `json:"max_iterations" binding:"omitempty,min=1"`
+	Target        *columnRef  `json:"target"`
+	Predictors    []columnRef `json:"predictors"`
+	NoIntercept   bool        `json:"no_intercept"`
+	Missing       string      `json:"missing" binding:"omitempty,oneof=listwise mean median ffill"`
+	Save          bool        `json:"save"`
+}
+
+/*
+logisticRegression fits a logistic regression of a binary target on one of
+the user's files. The endpoint expects a GET request with a json body with
+the following key:
+
+	`username`   - alphanumeric user's username
+	`file_id`    - id of the user's file to analyse.
+	`version`    - optional, version of the file to analyse, defaults to the
+	               file's current version.
+	`threshold`  - optional, probability in (0, 1) above which observations
+	               are classified as positive in the confusion matrix,
+	               defaults to 0.5.
+	`max_iterations` - optional, maximum number of iterations of the fit,
+	               defaults to 25.
+	`target`     - optional, index or name of the target column, whose values
+	               should be 0 or 1, defaults to the last column.
+	`predictors` - optional, list of indices or names of the predictor
+	               columns, defaults to every column but the target.
+	`no_intercept` - optional, fit the model without an intercept.
+	`missing`    - optional, how missing values in the selected columns are
+	               handled: `listwise` (default), `mean`, `median` or
+	               `ffill`, see /analyses/regression.
+	`save`       - optional, save the fitted model for predictions at
+	               /models/:id/predict.
+
+The odds ratios are null when they overflow, for badly scaled predictors.
+
+The request returns response with the following http status codes:
+
+200 - status OK:
+
+	with response body:
+	    {
+	        "result": {
+	            "names": ["intercept", "*****", ...],
+	            "coefficients": [*****],
+	            "standard_errors": [*****],
+	            "z_statistics": [*****],
+	            "p_values": [*****],
+	            "odds_ratios": [*****],
+	            "observations": *****,
+	            "log_likelihood": *****,
+	            "null_log_likelihood": *****,
+	            "pseudo_r_squared": *****,
+	            "aic": *****,
+	            "iterations": *****,
+	            "threshold": *****,
+	            "confusion_matrix": {
+	                "true_positive": *****,
+	                "false_positive": *****,
+	                "true_negative": *****,
+	                "false_negative": *****
+	            },
+	            "accuracy": *****
+	        },
+	        "model_id": *****,
+	        "error":""
+	     }
+
+400 - status Bad Request:
+
+	Error parsing request body, or invalid target or predictor columns.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+401 - status Unauthorized:
+
+	If access token has expired or username in request body don't match username
+	in token payload.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+404 - status Not Found:
+
+	If the user has no file with id `file_id`, or the file has no such
+	version.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+422 - status Unprocessable Entity:
+
+	If the target isn't binary, the predictors are collinear, the fit doesn't
+	converge, e.g. when the classes are perfectly separated, or no data is
+	left after handling missing values.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+501 - status Internal Server Error:
+
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+*/
+func (server *Server) logisticRegression(ctx *gin.Context) {
+	server.serveAnalysis(ctx, &logisticRequest{})
+}
+
+func (req *logisticRequest) owner() string {
+	return req.Username
+}
+
+// run fits the logistic regression on the file of user `username` and
+// returns the response body.
+//
+// Returns a non-nil error along with the http status code to respond with if
+// the regression can't be performed.
+func (req *logisticRequest) run(
+	ctx context.Context, server *Server, username string,
+) (any, int, error) {
+	ds, status, err := server.loadDataset(ctx, username, req.FileID, req.Version)
+	if err != nil {
+		return nil, status, err
+	}
+
+	columns, err := resolveModelColumns(ds.names, req.Target, req.Predictors)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	modelData, _, err := statsanal.HandleMissing(
+		statsanal.SelectColumns(ds.data, columns),
+		statsanal.MissingStrategy(req.Missing),
+	)
+	if err != nil {
+		return nil, http.StatusUnprocessableEntity,
+			fmt.Errorf("Error handling missing values.\n%w", err)
+	}
+
+	result, err := statsanal.LogisticRegression(
+		modelData,
+		statsanal.LogisticOptions{
+			Threshold:     req.Threshold,
+			MaxIterations: req.MaxIterations,
+			NoIntercept:   req.NoIntercept,
+			Names:         ds.selectNames(columns),
+		},
+	)
+	if err != nil {
+		if errors.Is(err, statsanal.ErrNotBinary) ||
+			errors.Is(err, statsanal.ErrRankDeficient) ||
+			errors.Is(err, statsanal.ErrNotConverged) {
+			return nil, http.StatusUnprocessableEntity, err
+		}
+		return nil, http.StatusInternalServerError,
+			fmt.Errorf("Error during logistic regression analysis\n%w", err)
+	}
+
+	resp := logisticResp{Result: &result}
+	if req.Save {
+		resp.ModelID, err = server.saveModel(
+			ctx, username, req.FileID, ds, columns, result)
+		if err != nil {
+			return nil, http.StatusInternalServerError, err
+		}
+	}
+	return resp, http.StatusOK, nil
+}
@@ -0,0 +1,195 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	gomock "go.uber.org/mock/gomock"
+	"gonum.org/v1/gonum/mat"
+
+	mockdb "github.com/yodeman/analyses-api/dbase/mock"
+	db "github.com/yodeman/analyses-api/dbase/sqlc"
+	"github.com/yodeman/analyses-api/util"
+)
+
+func TestLogisticRegression(t *testing.T) {
+	user, _ := randomUser(t)
+	rows, cols, data, err := util.ParseCSVToFloatSlice(
+		strings.NewReader(util.RandomCSV(40, 3)))
+	require.NoError(t, err)
+	matrix := mat.NewDense(rows, cols, data)
+	// binary last column.
+	for i := 0; i < rows; i++ {
+		matrix.Set(i, cols-1, float64(i%2))
+	}
+	matData, err := matrix.MarshalBinary()
+	require.NoError(t, err)
+
+	fileID := util.RandomInt(1, 1000)
+	getFileParams := db.GetFileParams{ID: fileID, Username: user.Username}
+	file := db.File{
+		ID:          fileID,
+		Username:    user.Username,
+		Data:        matData,
+		ColumnNames: []string{"x1", "x2", "y"},
+	}
+
+	namedTarget := columnName("y")
+	notBinaryTarget := columnIndex(0)
+
+	testCases := []struct {
+		name          string
+		params        logisticRequest
+		username      string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			params:   logisticRequest{Username: user.Username, FileID: fileID},
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetFile(gomock.Any(), gomock.Eq(getFileParams)).
+					Times(1).
+					Return(file, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				resp := requireBodyMatchLogistic(t, recorder.Body, rows, cols)
+				require.Equal(t, []string{"intercept", "x1", "x2"}, resp.Result.Names)
+				require.Equal(t, 0.5, resp.Result.Threshold)
+			},
+		},
+		{
+			name: "PREDICTORS AND THRESHOLD",
+			params: logisticRequest{
+				Username:   user.Username,
+				FileID:     fileID,
+				Target:     &namedTarget,
+				Predictors: []columnRef{columnName("x2")},
+				Threshold:  0.7,
+			},
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetFile(gomock.Any(), gomock.Eq(getFileParams)).
+					Times(1).
+					Return(file, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				resp := requireBodyMatchLogistic(t, recorder.Body, rows, 2)
+				require.Equal(t, []string{"intercept", "x2"}, resp.Result.Names)
+				require.Equal(t, 0.7, resp.Result.Threshold)
+			},
+		},
+		{
+			name: "NOT BINARY",
+			params: logisticRequest{
+				Username: user.Username,
+				FileID:   fileID,
+				Target:   &notBinaryTarget,
+			},
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetFile(gomock.Any(), gomock.Eq(getFileParams)).
+					Times(1).
+					Return(file, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name: "INVALID THRESHOLD",
+			params: logisticRequest{
+				Username:  user.Username,
+				FileID:    fileID,
+				Threshold: 1.5,
+			},
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetFile(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "UNAUTHORIZED",
+			params:   logisticRequest{Username: user.Username, FileID: fileID},
+			username: "deidara",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetFile(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			encodedParams, err := json.Marshal(tc.params)
+			require.NoError(t, err)
+			request, err := http.NewRequest(
+				http.MethodGet, "/analyses/logistic", bytes.NewBuffer(encodedParams))
+			require.NoError(t, err)
+			addAuthorization(
+				t, request, server.tokenMaker, authorizationTypeToken,
+				tc.username, time.Minute,
+			)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func requireBodyMatchLogistic(
+	t *testing.T, responseBody *bytes.Buffer, rows, params int,
+) logisticResp {
+	var serverResp logisticResp
+
+	err := json.NewDecoder(responseBody).Decode(&serverResp)
+	require.NoError(t, err)
+
+	require.Empty(t, serverResp.Error)
+	require.NotNil(t, serverResp.Result)
+	require.Len(t, serverResp.Result.Coefficients, params)
+	require.Len(t, serverResp.Result.StdErrors, params)
+	require.Len(t, serverResp.Result.ZStats, params)
+	require.Len(t, serverResp.Result.PValues, params)
+	require.Len(t, serverResp.Result.OddsRatios, params)
+	require.Equal(t, rows, serverResp.Result.Observations)
+
+	cm := serverResp.Result.Confusion
+	require.Equal(t, rows,
+		cm.TruePositive+cm.FalsePositive+cm.TrueNegative+cm.FalseNegative)
+	require.Equal(t, rows/2, cm.TruePositive+cm.FalseNegative)
+
+	return serverResp
+}
@@ -12,13 +12,13 @@ import (
 	"github.com/yodeman/analyses-api/util"
 )
 
-func newTestServer(t *testing.T, querier db.Querier) *Server {
+func newTestServer(t *testing.T, store db.Store) *Server {
 	config := util.Config{
 		TokenSymmetricKey:   util.RandomString(32),
 		AccessTokenDuration: time.Minute,
 	}
 
-	server, err := NewServer(config, querier)
+	server, err := NewServer(config, store)
 	require.NoError(t, err)
 
 	return server
@@ -0,0 +1,565 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gonum.org/v1/gonum/mat"
+
+	db "github.com/yodeman/analyses-api/dbase/sqlc"
+	statsanal "github.com/yodeman/analyses-api/stats-analyses"
+	"github.com/yodeman/analyses-api/util"
+)
+
+// Response format for model
+type modelResp struct {
+	ID   int64  `json:"id"`
+	Kind string `json:"kind"`
+	// FileID is the id of the file the model was fitted on, null once the
+	// file is deleted.
+	FileID      *int64    `json:"file_id"`
+	FileVersion int32     `json:"file_version"`
+	Target      string    `json:"target"`
+	Predictors  []string  `json:"predictors"`
+	CreatedAt   time.Time `json:"created_at"`
+	// Model is only set when fetching a single model.
+	Model json.RawMessage `json:"model,omitempty"`
+}
+type modelResponse struct {
+	Model *modelResp `json:"model"`
+	Error string     `json:"error"`
+}
+
+// newModelResp hides the owner of the user's model.
+func newModelResp(model db.Model) *modelResp {
+	resp := &modelResp{
+		ID:          model.ID,
+		Kind:        model.Kind,
+		FileVersion: model.FileVersion,
+		Target:      model.Target,
+		Predictors:  model.Predictors,
+		CreatedAt:   model.CreatedAt,
+		Model:       model.Model,
+	}
+	if model.FileID.Valid {
+		resp.FileID = &model.FileID.Int64
+	}
+	return resp
+}
+
+// fittedModel is implemented by the results of the analyses which fit a model
+// that can be saved for later predictions.
+type fittedModel interface {
+	Model(predictors []string, target string) statsanal.Model
+}
+
+// saveModel stores the model fitted on the `columns` of version `ds.version`
+// of the user's file with id `fileID`, and returns its id. The last column is
+// the target of the model.
+func (server *Server) saveModel(
+	ctx context.Context, username string, fileID int64, ds dataset,
+	columns []int, fit fittedModel,
+) (int64, error) {
+	names := ds.selectNames(columns)
+	model := fit.Model(names[:len(names)-1], names[len(names)-1])
+
+	encoded, err := json.Marshal(model)
+	if err != nil {
+		return 0, fmt.Errorf("Error encoding model.\n%w", err)
+	}
+
+	saved, err := server.store.CreateModel(
+		ctx,
+		db.CreateModelParams{
+			Username:    username,
+			Kind:        string(model.Kind),
+			FileID:      sql.NullInt64{Int64: fileID, Valid: true},
+			FileVersion: ds.version,
+			Target:      model.Target,
+			Predictors:  model.Predictors,
+			Model:       encoded,
+		},
+	)
+	if err != nil {
+		return 0, fmt.Errorf("Error saving model.\n%w", err)
+	}
+	return saved.ID, nil
+}
+
+// Response format for listing models.
+type listModelsResponse struct {
+	Models []*modelResp `json:"models"`
+	Error  string       `json:"error"`
+}
+
+/*
+listModels lists the authenticated user's saved models, ordered by id. Models
+are saved by the regression endpoints when `save` is set. The endpoint
+expects a GET request with the following optional query parameters:
+
+	`page_id`    - page to list, starting from 1 (default).
+	`page_size`  - number of models in a page between 1 and 100, defaults
+	               to 10.
+
+The request returns response with the following http status codes:
+
+200 - status OK:
+
+	with response body:
+	    {
+	        "models": [
+	            {
+	                "id": *****,
+	                "kind": "*****",
+	                "file_id": *****,
+	                "file_version": *****,
+	                "target": "*****",
+	                "predictors": ["*****", ...],
+	                "created_at": "*****"
+	            },
+	            ...
+	        ],
+	        "error":""
+	     }
+
+400 - status Bad Request:
+
+	Error parsing query parameters.
+
+401 - status Unauthorized:
+
+	If access token is missing or has expired.
+
+501 - status Internal Server Error:
+
+	with response body:
+	    {
+	        "models": null,
+	        "error": "*****"
+	    }
+*/
+func (server *Server) listModels(ctx *gin.Context) {
+	var req listFilesRequest
+	var resp listModelsResponse
+
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		resp.Error = errResponse(fmt.Errorf("Error parsing query parameters.\n%w", err))
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+	if req.PageID == 0 {
+		req.PageID = 1
+	}
+	if req.PageSize == 0 {
+		req.PageSize = defaultPageSize
+	}
+
+	authPayload, err := getPayload(ctx)
+	if err != nil {
+		resp.Error = errResponse(
+			fmt.Errorf("Error getting authentication payload.\n%w", err))
+		ctx.JSON(http.StatusInternalServerError, resp)
+		return
+	}
+
+	models, err := server.store.ListModels(
+		ctx,
+		db.ListModelsParams{
+			Username: authPayload.Username,
+			Limit:    req.PageSize,
+			Offset:   (req.PageID - 1) * req.PageSize,
+		},
+	)
+	if err != nil {
+		resp.Error = errResponse(fmt.Errorf("Error fetching user's models.\n%w", err))
+		ctx.JSON(http.StatusInternalServerError, resp)
+		return
+	}
+
+	resp.Models = make([]*modelResp, len(models))
+	for i, model := range models {
+		resp.Models[i] = newModelResp(db.Model{
+			ID:          model.ID,
+			Kind:        model.Kind,
+			FileID:      model.FileID,
+			FileVersion: model.FileVersion,
+			Target:      model.Target,
+			Predictors:  model.Predictors,
+			CreatedAt:   model.CreatedAt,
+		})
+	}
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// Request format for the model in the url path.
+type modelURIRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+/*
+getModel fetches one of the authenticated user's saved models. The endpoint
+expects a GET request at `/models/:id`.
+
+The request returns response with the following http status codes:
+
+200 - status OK:
+
+	with response body:
+	    {
+	        "model": {
+	            "id": *****,
+	            "kind": "*****",
+	            "file_id": *****,
+	            "file_version": *****,
+	            "target": "*****",
+	            "predictors": ["*****", ...],
+	            "created_at": "*****",
+	            "model": {
+	                "kind": "*****",
+	                "target": "*****",
+	                "predictors": ["*****", ...],
+	                "intercept": *****,
+	                "coefficients": [*****],
+	                ...
+	            }
+	        },
+	        "error":""
+	     }
+
+400 - status Bad Request:
+
+	Invalid model id.
+
+401 - status Unauthorized:
+
+	If access token is missing or has expired.
+
+404 - status Not Found:
+
+	If the user has no model with the given id.
+
+501 - status Internal Server Error:
+
+	with response body:
+	    {
+	        "model": null,
+	        "error": "*****"
+	    }
+*/
+func (server *Server) getModel(ctx *gin.Context) {
+	var uri modelURIRequest
+	var resp modelResponse
+
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		resp.Error = errResponse(fmt.Errorf("Error parsing model id.\n%w", err))
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	authPayload, err := getPayload(ctx)
+	if err != nil {
+		resp.Error = errResponse(
+			fmt.Errorf("Error getting authentication payload.\n%w", err))
+		ctx.JSON(http.StatusInternalServerError, resp)
+		return
+	}
+
+	model, err := server.store.GetModel(
+		ctx,
+		db.GetModelParams{ID: uri.ID, Username: authPayload.Username},
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			resp.Error = errResponse(fmt.Errorf("Model does not exist.\n%w", err))
+			ctx.JSON(http.StatusNotFound, resp)
+			return
+		}
+
+		resp.Error = errResponse(fmt.Errorf("Error fetching user's model.\n%w", err))
+		ctx.JSON(http.StatusInternalServerError, resp)
+		return
+	}
+
+	resp.Model = newModelResp(model)
+	ctx.JSON(http.StatusOK, resp)
+}
+
+/*
+deleteModel deletes one of the authenticated user's saved models. The
+endpoint expects a DELETE request at `/models/:id`.
+
+The request returns response with the following http status codes:
+
+200 - status OK:
+
+	with response body:
+	    {
+	        "error":""
+	    }
+
+400 - status Bad Request:
+
+	Invalid model id.
+
+401 - status Unauthorized:
+
+	If access token is missing or has expired.
+
+404 - status Not Found:
+
+	If the user has no model with the given id.
+
+501 - status Internal Server Error:
+
+	with response body:
+	    {
+	        "error": "*****"
+	    }
+*/
+func (server *Server) deleteModel(ctx *gin.Context) {
+	var uri modelURIRequest
+	var resp deleteFileResponse
+
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		resp.Error = errResponse(fmt.Errorf("Error parsing model id.\n%w", err))
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	authPayload, err := getPayload(ctx)
+	if err != nil {
+		resp.Error = errResponse(
+			fmt.Errorf("Error getting authentication payload.\n%w", err))
+		ctx.JSON(http.StatusInternalServerError, resp)
+		return
+	}
+
+	deleted, err := server.store.DeleteModel(
+		ctx,
+		db.DeleteModelParams{ID: uri.ID, Username: authPayload.Username},
+	)
+	if err != nil {
+		resp.Error = errResponse(fmt.Errorf("Error deleting user's model.\n%w", err))
+		ctx.JSON(http.StatusInternalServerError, resp)
+		return
+	}
+	if deleted == 0 {
+		resp.Error = errResponse(fmt.Errorf("Model does not exist."))
+		ctx.JSON(http.StatusNotFound, resp)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// Response format for predict request.
+type predictResponse struct {
+	Result *statsanal.Prediction `json:"result"`
+	Error  string                `json:"error"`
+}
+
+// Request format for the query parameters of predict requests.
+type predictQuery struct {
+	Confidence float64 `form:"confidence" binding:"omitempty,gt=0,lt=1"`
+}
+
+// Request format for json predict requests. Rows hold the predictors' values
+// in the model's order, json has no NaN so missing values are null.
+type predictRequest struct {
+	Rows [][]*float64 `json:"rows" binding:"required,min=1"`
+}
+
+/*
+predict scores new rows with one of the authenticated user's saved models.
+The endpoint expects a POST request at `/models/:id/predict` with the
+following optional query parameter:
+
+	`confidence` - confidence level of the intervals in (0, 1), defaults to
+	               0.95.
+
+and either a json body with the following key:
+
+	`rows`       - list of rows, each holding the value of every predictor
+	               of the model in order.
+
+or, with the `text/csv` content type, a csv body. A csv with a header has its
+columns matched to the model's predictors by name, extra columns are ignored,
+while a csv without header should hold the predictors in order.
+
+Linear models return prediction intervals of new observations, logistic
+models return the probability of the positive class along with its
+confidence interval, and regularized models have no intervals.
+
+The request returns response with the following http status codes:
+
+200 - status OK:
+
+	with response body:
+	    {
+	        "result": {
+	            "predictions": [*****],
+	            "interval": "*****",
+	            "confidence": *****,
+	            "lower": [*****],
+	            "upper": [*****]
+	        },
+	        "error":""
+	     }
+
+400 - status Bad Request:
+
+	Invalid model id, error parsing the query parameters or the body, rows
+	not matching the model's predictors, or rows with missing values.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+401 - status Unauthorized:
+
+	If access token is missing or has expired.
+
+404 - status Not Found:
+
+	If the user has no model with the given id.
+
+501 - status Internal Server Error:
+
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+*/
+func (server *Server) predict(ctx *gin.Context) {
+	var uri modelURIRequest
+	var query predictQuery
+	var resp predictResponse
+
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		resp.Error = errResponse(fmt.Errorf("Error parsing model id.\n%w", err))
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		resp.Error = errResponse(fmt.Errorf("Error parsing query parameters.\n%w", err))
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	authPayload, err := getPayload(ctx)
+	if err != nil {
+		resp.Error = errResponse(
+			fmt.Errorf("Error getting authentication payload.\n%w", err))
+		ctx.JSON(http.StatusInternalServerError, resp)
+		return
+	}
+
+	saved, err := server.store.GetModel(
+		ctx,
+		db.GetModelParams{ID: uri.ID, Username: authPayload.Username},
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			resp.Error = errResponse(fmt.Errorf("Model does not exist.\n%w", err))
+			ctx.JSON(http.StatusNotFound, resp)
+			return
+		}
+
+		resp.Error = errResponse(fmt.Errorf("Error fetching user's model.\n%w", err))
+		ctx.JSON(http.StatusInternalServerError, resp)
+		return
+	}
+
+	var model statsanal.Model
+	if err := json.Unmarshal(saved.Model, &model); err != nil {
+		resp.Error = errResponse(fmt.Errorf("Error decoding user's model.\n%w", err))
+		ctx.JSON(http.StatusInternalServerError, resp)
+		return
+	}
+
+	var rows *mat.Dense
+	if ctx.ContentType() == "text/csv" {
+		rows, err = parsePredictCSV(ctx, model.Predictors)
+	} else {
+		rows, err = parsePredictJSON(ctx, model.Predictors)
+	}
+	if err != nil {
+		resp.Error = errResponse(fmt.Errorf("Error parsing request body.\n%w", err))
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	result, err := model.Predict(rows, query.Confidence)
+	if err != nil {
+		resp.Error = errResponse(fmt.Errorf("Error predicting rows.\n%w", err))
+		ctx.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	resp.Result = &result
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// parsePredictJSON parses the json rows of the request body, which should
+// hold a value for each of the `predictors`.
+func parsePredictJSON(ctx *gin.Context, predictors []string) (*mat.Dense, error) {
+	var req predictRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		return nil, err
+	}
+
+	rows := mat.NewDense(len(req.Rows), len(predictors), nil)
+	for i, row := range req.Rows {
+		if len(row) != len(predictors) {
+			return nil, fmt.Errorf(
+				"Row %d has %d values for %d predictors.", i, len(row), len(predictors))
+		}
+		for j, v := range row {
+			if v == nil {
+				return nil, fmt.Errorf("Row %d has missing values.", i)
+			}
+			rows.Set(i, j, *v)
+		}
+	}
+	return rows, nil
+}
+
+// parsePredictCSV parses the csv rows of the request body, selecting the
+// columns of the `predictors` by name when the csv has a header.
+func parsePredictCSV(ctx *gin.Context, predictors []string) (*mat.Dense, error) {
+	body := http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxFileSize)
+	csvData, err := util.ParseCSV(body, util.CSVOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if csvData.Rows == 0 {
+		return nil, fmt.Errorf("No row to predict.")
+	}
+	data := mat.NewDense(csvData.Rows, csvData.Cols, csvData.Data)
+
+	if len(csvData.Names) == 0 {
+		if csvData.Cols != len(predictors) {
+			return nil, fmt.Errorf(
+				"Got %d columns for %d predictors.", csvData.Cols, len(predictors))
+		}
+		return data, nil
+	}
+
+	refs := make([]columnRef, len(predictors))
+	for i, name := range predictors {
+		refs[i] = columnName(name)
+	}
+	cols, err := resolveColumns(csvData.Names, refs)
+	if err != nil {
+		return nil, fmt.Errorf("Missing predictor column.\n%w", err)
+	}
+	return statsanal.SelectColumns(data, cols), nil
+}
@@ -0,0 +1,502 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	gomock "go.uber.org/mock/gomock"
+
+	mockdb "github.com/yodeman/analyses-api/dbase/mock"
+	db "github.com/yodeman/analyses-api/dbase/sqlc"
+	statsanal "github.com/yodeman/analyses-api/stats-analyses"
+	"github.com/yodeman/analyses-api/util"
+)
+
+// randomModel returns a saved linear model `y = 1 + 2*x1 - x2` of user
+// `username`.
+func randomModel(t *testing.T, username string) db.Model {
+	model := statsanal.Model{
+		Kind:             statsanal.KindLinear,
+		Target:           "y",
+		Predictors:       []string{"x1", "x2"},
+		Intercept:        true,
+		Coefficients:     []float64{1, 2, -1},
+		Covariance:       [][]float64{{0.1, 0, 0}, {0, 0.01, 0}, {0, 0, 0.01}},
+		ResidualStdError: 0.5,
+		ResidualDF:       20,
+	}
+	encoded, err := json.Marshal(model)
+	require.NoError(t, err)
+
+	return db.Model{
+		ID:          util.RandomInt(1, 1000),
+		Username:    username,
+		Kind:        string(model.Kind),
+		FileID:      sql.NullInt64{Int64: util.RandomInt(1, 1000), Valid: true},
+		FileVersion: 1,
+		Target:      model.Target,
+		Predictors:  model.Predictors,
+		Model:       encoded,
+		CreatedAt:   time.Now().UTC().Truncate(time.Second),
+	}
+}
+
+func TestListModels(t *testing.T) {
+	user, _ := randomUser(t)
+	models := []db.ListModelsRow{
+		{ID: 1, Username: user.Username, Kind: "linear", Target: "y"},
+		{ID: 2, Username: user.Username, Kind: "logistic", Target: "z",
+			FileID: sql.NullInt64{Int64: 3, Valid: true}},
+	}
+
+	testCases := []struct {
+		name          string
+		query         string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "OK",
+			query: "?page_id=2&page_size=5",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListModels(gomock.Any(), gomock.Eq(db.ListModelsParams{
+						Username: user.Username,
+						Limit:    5,
+						Offset:   5,
+					})).
+					Times(1).
+					Return(models, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var serverResp listModelsResponse
+				err := json.NewDecoder(recorder.Body).Decode(&serverResp)
+				require.NoError(t, err)
+				require.Len(t, serverResp.Models, len(models))
+				for i, model := range models {
+					require.Equal(t, model.ID, serverResp.Models[i].ID)
+					require.Equal(t, model.Kind, serverResp.Models[i].Kind)
+					require.Equal(t, model.Target, serverResp.Models[i].Target)
+					require.Empty(t, serverResp.Models[i].Model)
+				}
+				require.Nil(t, serverResp.Models[0].FileID)
+				require.Equal(t, int64(3), *serverResp.Models[1].FileID)
+			},
+		},
+		{
+			name:  "DEFAULT PAGE",
+			query: "",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListModels(gomock.Any(), gomock.Eq(db.ListModelsParams{
+						Username: user.Username,
+						Limit:    defaultPageSize,
+						Offset:   0,
+					})).
+					Times(1).
+					Return([]db.ListModelsRow{}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:  "INVALID PAGE SIZE",
+			query: "?page_size=1000",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListModels(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "INTERNAL ERROR",
+			query: "",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListModels(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, "/models"+tc.query, nil)
+			require.NoError(t, err)
+			addAuthorization(
+				t, request, server.tokenMaker, authorizationTypeToken,
+				user.Username, time.Minute,
+			)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestModelByID(t *testing.T) {
+	user, _ := randomUser(t)
+	model := randomModel(t, user.Username)
+	modelParams := db.GetModelParams{ID: model.ID, Username: user.Username}
+
+	testCases := []struct {
+		name          string
+		method        string
+		modelID       int64
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:    "GET OK",
+			method:  http.MethodGet,
+			modelID: model.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetModel(gomock.Any(), gomock.Eq(modelParams)).
+					Times(1).
+					Return(model, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var serverResp modelResponse
+				err := json.NewDecoder(recorder.Body).Decode(&serverResp)
+				require.NoError(t, err)
+				require.Equal(t, model.ID, serverResp.Model.ID)
+				require.Equal(t, model.Kind, serverResp.Model.Kind)
+				require.Equal(t, model.FileID.Int64, *serverResp.Model.FileID)
+				require.Equal(t, model.Predictors, serverResp.Model.Predictors)
+				require.Equal(t, model.CreatedAt, serverResp.Model.CreatedAt)
+				require.JSONEq(t, string(model.Model), string(serverResp.Model.Model))
+			},
+		},
+		{
+			name:    "GET NOT FOUND",
+			method:  http.MethodGet,
+			modelID: model.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetModel(gomock.Any(), gomock.Eq(modelParams)).
+					Times(1).
+					Return(db.Model{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:    "GET INTERNAL ERROR",
+			method:  http.MethodGet,
+			modelID: model.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetModel(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Model{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name:    "GET INVALID ID",
+			method:  http.MethodGet,
+			modelID: 0,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetModel(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:    "DELETE OK",
+			method:  http.MethodDelete,
+			modelID: model.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					DeleteModel(gomock.Any(), gomock.Eq(db.DeleteModelParams{
+						ID:       model.ID,
+						Username: user.Username,
+					})).
+					Times(1).
+					Return(int64(1), nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:    "DELETE NOT FOUND",
+			method:  http.MethodDelete,
+			modelID: model.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					DeleteModel(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(int64(0), nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:    "DELETE INTERNAL ERROR",
+			method:  http.MethodDelete,
+			modelID: model.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					DeleteModel(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(int64(0), sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/models/%d", tc.modelID)
+			request, err := http.NewRequest(tc.method, url, nil)
+			require.NoError(t, err)
+			addAuthorization(
+				t, request, server.tokenMaker, authorizationTypeToken,
+				user.Username, time.Minute,
+			)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestPredict(t *testing.T) {
+	user, _ := randomUser(t)
+	model := randomModel(t, user.Username)
+	modelParams := db.GetModelParams{ID: model.ID, Username: user.Username}
+
+	getModel := func(store *mockdb.MockStore) {
+		store.EXPECT().
+			GetModel(gomock.Any(), gomock.Eq(modelParams)).
+			Times(1).
+			Return(model, nil)
+	}
+
+	testCases := []struct {
+		name          string
+		query         string
+		contentType   string
+		body          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:        "JSON",
+			contentType: "application/json",
+			body:        `{"rows": [[1, 2], [0, 0]]}`,
+			buildStubs:  getModel,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				resp := requireBodyMatchPrediction(t, recorder.Body, 2)
+				require.InDeltaSlice(t, []float64{1, 1}, resp.Result.Predictions, 1e-12)
+				require.Equal(t, "prediction", resp.Result.Interval)
+				require.Equal(t, statsanal.DefaultConfidence, resp.Result.Confidence)
+			},
+		},
+		{
+			name:        "CSV WITH HEADER",
+			query:       "?confidence=0.9",
+			contentType: "text/csv",
+			body:        "x2,extra,x1\n2,5,1\n1,5,3\n",
+			buildStubs:  getModel,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				resp := requireBodyMatchPrediction(t, recorder.Body, 2)
+				require.InDeltaSlice(t, []float64{1, 6}, resp.Result.Predictions, 1e-12)
+				require.Equal(t, 0.9, resp.Result.Confidence)
+			},
+		},
+		{
+			name:        "CSV WITHOUT HEADER",
+			contentType: "text/csv",
+			body:        "1,2\n3,1\n",
+			buildStubs:  getModel,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				resp := requireBodyMatchPrediction(t, recorder.Body, 2)
+				require.InDeltaSlice(t, []float64{1, 6}, resp.Result.Predictions, 1e-12)
+			},
+		},
+		{
+			name:        "CSV MISSING PREDICTOR",
+			contentType: "text/csv",
+			body:        "x1,extra\n1,2\n",
+			buildStubs:  getModel,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:        "WRONG ROW LENGTH",
+			contentType: "application/json",
+			body:        `{"rows": [[1, 2, 3]]}`,
+			buildStubs:  getModel,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:        "MISSING VALUE",
+			contentType: "application/json",
+			body:        `{"rows": [[1, null]]}`,
+			buildStubs:  getModel,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:        "NO ROWS",
+			contentType: "application/json",
+			body:        `{"rows": []}`,
+			buildStubs:  getModel,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:        "INVALID CONFIDENCE",
+			query:       "?confidence=2",
+			contentType: "application/json",
+			body:        `{"rows": [[1, 2]]}`,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetModel(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:        "NOT FOUND",
+			contentType: "application/json",
+			body:        `{"rows": [[1, 2]]}`,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetModel(gomock.Any(), gomock.Eq(modelParams)).
+					Times(1).
+					Return(db.Model{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:        "INTERNAL ERROR",
+			contentType: "application/json",
+			body:        `{"rows": [[1, 2]]}`,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetModel(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Model{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/models/%d/predict%s", model.ID, tc.query)
+			request, err := http.NewRequest(
+				http.MethodPost, url, strings.NewReader(tc.body))
+			require.NoError(t, err)
+			request.Header.Set("Content-Type", tc.contentType)
+			addAuthorization(
+				t, request, server.tokenMaker, authorizationTypeToken,
+				user.Username, time.Minute,
+			)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func requireBodyMatchPrediction(
+	t *testing.T, responseBody *bytes.Buffer, rows int,
+) predictResponse {
+	var serverResp predictResponse
+
+	err := json.NewDecoder(responseBody).Decode(&serverResp)
+	require.NoError(t, err)
+	require.Empty(t, serverResp.Error)
+	require.NotNil(t, serverResp.Result)
+	require.Len(t, serverResp.Result.Predictions, rows)
+	if serverResp.Result.Interval != "" {
+		require.Len(t, serverResp.Result.Lower, rows)
+		require.Len(t, serverResp.Result.Upper, rows)
+		for i, pred := range serverResp.Result.Predictions {
+			require.Less(t, serverResp.Result.Lower[i], pred)
+			require.Greater(t, serverResp.Result.Upper[i], pred)
+		}
+	}
+	return serverResp
+}
@@ -0,0 +1,636 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/gonum/stat/distuv"
+
+	statsanal "github.com/yodeman/analyses-api/stats-analyses"
+)
+
+// Request format for Mann-Whitney U and Wilcoxon signed-rank test queries.
+type rankTestRequest struct {
+	Username    string      `json:"username" binding:"required,alphanum"`
+	FileID      int64       `json:"file_id" binding:"required,min=1"`
+	Version     int32       `json:"version" binding:"omitempty,min=1"`
+	Columns     []columnRef `json:"columns" binding:"required,min=1,max=2"`
+	Mu          float64     `json:"mu"`
+	Alternative string      `json:"alternative" binding:"omitempty,oneof=two_sided less greater"`
+	Method      string      `json:"method" binding:"omitempty,oneof=exact asymptotic"`
+}
+
+// mannWhitneyRequest is the request of the Mann-Whitney U test.
+type mannWhitneyRequest struct {
+	rankTestRequest
+}
+
+// wilcoxonRequest is the request of the Wilcoxon signed-rank test.
+type wilcoxonRequest struct {
+	rankTestRequest
+}
+
+/*
+mannWhitney runs the Mann-Whitney U test, or Wilcoxon rank-sum test, on two
+columns of one of the user's files. The endpoint expects a GET request with a
+json body with the following key:
+
+	`username`    - alphanumeric user's username
+	`file_id`     - id of the user's file to analyse.
+	`version`     - optional, version of the file to analyse, defaults to
+	                the file's current version.
+	`columns`     - list of the indices or names of the two compared columns.
+	`mu`          - optional, location shift of the first column under the
+	                null hypothesis, defaults to 0.
+	`alternative` - optional, alternative hypothesis: `two_sided`
+	                (default), `less` or `greater`, the values of the first
+	                column tending to be less or greater.
+	`method`      - optional, `exact` or `asymptotic` p-value. Defaults to
+	                exact for columns of less than 50 values without ties.
+
+Missing values are ignored. The statistic is the U of the first column, the
+asymptotic p-value uses the normal approximation with continuity and tie
+corrections, and the effect size is the rank-biserial correlation.
+
+The request returns response with the following http status codes:
+
+200 - status OK:
+
+	with response body:
+	    {
+	        "result": {
+	            "test": "mann_whitney",
+	            "alternative": "*****",
+	            "statistic": *****,
+	            "p_value": *****,
+	            "method": "*****",
+	            "effect_size": *****,
+	            "effect_size_name": "rank_biserial",
+	            "observations": [*****, *****]
+	        },
+	        "names": ["*****", "*****"],
+	        "error":""
+	     }
+
+400 - status Bad Request:
+
+	Error parsing request body, invalid columns, or not two columns.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+401 - status Unauthorized:
+
+	If access token has expired or username in request body don't match username
+	in token payload.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+404 - status Not Found:
+
+	If the user has no file with id `file_id`, or the file has no such
+	version.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+422 - status Unprocessable Entity:
+
+	If a column has no observed value, or an exact p-value is requested with
+	ties or a column of more than 100 values.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+501 - status Internal Server Error:
+
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+*/
+func (server *Server) mannWhitney(ctx *gin.Context) {
+	server.serveAnalysis(ctx, &mannWhitneyRequest{})
+}
+
+/*
+wilcoxon runs the Wilcoxon signed-rank test on one or two columns of one of
+the user's files. The endpoint expects a GET request with a json body with
+the following key:
+
+	`username`    - alphanumeric user's username
+	`file_id`     - id of the user's file to analyse.
+	`version`     - optional, version of the file to analyse, defaults to
+	                the file's current version.
+	`columns`     - list of indices or names of the tested column, or of the
+	                two paired columns whose row-wise differences are tested.
+	`mu`          - optional, median, or median difference, under the null
+	                hypothesis, defaults to 0.
+	`alternative` - optional, alternative hypothesis: `two_sided`
+	                (default), `less` or `greater`.
+	`method`      - optional, `exact` or `asymptotic` p-value. Defaults to
+	                exact for less than 50 differences without ties or zeros.
+
+Rows with a missing value and zero differences are dropped. The statistic is
+the sum of the ranks of the positive differences, the asymptotic p-value uses
+the normal approximation with continuity and tie corrections, and the effect
+size is the matched-pairs rank-biserial correlation.
+
+The request returns response with the following http status codes:
+
+200 - status OK:
+
+	with response body:
+	    {
+	        "result": {
+	            "test": "wilcoxon",
+	            "alternative": "*****",
+	            "statistic": *****,
+	            "p_value": *****,
+	            "method": "*****",
+	            "effect_size": *****,
+	            "effect_size_name": "rank_biserial",
+	            "observations": [*****]
+	        },
+	        "names": ["*****", ...],
+	        "error":""
+	     }
+
+400 - status Bad Request:
+
+	Error parsing request body, or invalid columns.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+401 - status Unauthorized:
+
+	If access token has expired or username in request body don't match username
+	in token payload.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+404 - status Not Found:
+
+	If the user has no file with id `file_id`, or the file has no such
+	version.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+422 - status Unprocessable Entity:
+
+	If no non-zero difference is left, or an exact p-value is requested with
+	ties, zeros or more than 100 differences.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+501 - status Internal Server Error:
+
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+*/
+func (server *Server) wilcoxon(ctx *gin.Context) {
+	server.serveAnalysis(ctx, &wilcoxonRequest{})
+}
+
+func (req *rankTestRequest) owner() string {
+	return req.Username
+}
+
+// options returns the options of the test.
+func (req *rankTestRequest) options() statsanal.NonParametricOptions {
+	return statsanal.NonParametricOptions{
+		Mu:          req.Mu,
+		Alternative: statsanal.Alternative(req.Alternative),
+		Method:      statsanal.PValueMethod(req.Method),
+	}
+}
+
+// run performs the Mann-Whitney U test on the file of user `username` and
+// returns the response body.
+//
+// Returns a non-nil error along with the http status code to respond with if
+// the test can't be performed.
+func (req *mannWhitneyRequest) run(
+	ctx context.Context, server *Server, username string,
+) (any, int, error) {
+	if len(req.Columns) != 2 {
+		return nil, http.StatusBadRequest,
+			fmt.Errorf("Need 2 columns, got %d.", len(req.Columns))
+	}
+
+	ds, status, err := server.loadDataset(ctx, username, req.FileID, req.Version)
+	if err != nil {
+		return nil, status, err
+	}
+
+	columns, err := resolveColumns(ds.names, req.Columns)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	result, err := statsanal.MannWhitneyU(
+		mat.Col(nil, columns[0], ds.data),
+		mat.Col(nil, columns[1], ds.data),
+		req.options(),
+	)
+	if err != nil {
+		return nil, http.StatusUnprocessableEntity,
+			fmt.Errorf("Error during Mann-Whitney U test\n%w", err)
+	}
+
+	return testResp{Result: &result, Names: ds.selectNames(columns)}, http.StatusOK, nil
+}
+
+// run performs the Wilcoxon signed-rank test on the file of user `username`
+// and returns the response body.
+//
+// Returns a non-nil error along with the http status code to respond with if
+// the test can't be performed.
+func (req *wilcoxonRequest) run(
+	ctx context.Context, server *Server, username string,
+) (any, int, error) {
+	ds, status, err := server.loadDataset(ctx, username, req.FileID, req.Version)
+	if err != nil {
+		return nil, status, err
+	}
+
+	columns, err := resolveColumns(ds.names, req.Columns)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	var y []float64
+	if len(columns) == 2 {
+		y = mat.Col(nil, columns[1], ds.data)
+	}
+	result, err := statsanal.WilcoxonSignedRank(
+		mat.Col(nil, columns[0], ds.data), y, req.options())
+	if err != nil {
+		return nil, http.StatusUnprocessableEntity,
+			fmt.Errorf("Error during Wilcoxon signed-rank test\n%w", err)
+	}
+
+	return testResp{Result: &result, Names: ds.selectNames(columns)}, http.StatusOK, nil
+}
+
+// Request format for Kruskal-Wallis test queries.
+type kruskalRequest struct {
+	Username string      `json:"username" binding:"required,alphanum"`
+	FileID   int64       `json:"file_id" binding:"required,min=1"`
+	Version  int32       `json:"version" binding:"omitempty,min=1"`
+	Columns  []columnRef `json:"columns"`
+	Value    *columnRef  `json:"value"`
+	Group    *columnRef  `json:"group"`
+}
+
+/*
+kruskal runs the Kruskal-Wallis H test on one of the user's files. The
+endpoint expects a GET request with a json body with the following key:
+
+	`username` - alphanumeric user's username
+	`file_id`  - id of the user's file to analyse.
+	`version`  - optional, version of the file to analyse, defaults to the
+	             file's current version.
+	`columns`  - optional, list of indices or names of the columns holding
+	             the values of each group, defaults to every column.
+	`value`    - optional, index or name of the column holding the values,
+	             along with `group` instead of `columns`.
+	`group`    - optional, index or name of the column holding the group of
+	             each value, coded as numbers.
+
+Missing values are ignored. The statistic is corrected for ties, its p-value
+follows the chi-square distribution, and the effect size is epsilon squared.
+
+The request returns response with the following http status codes:
+
+200 - status OK:
+
+	with response body:
+	    {
+	        "result": {
+	            "test": "kruskal_wallis",
+	            "statistic": *****,
+	            "df": [*****],
+	            "p_value": *****,
+	            "method": "asymptotic",
+	            "effect_size": *****,
+	            "effect_size_name": "epsilon_squared",
+	            "observations": [*****]
+	        },
+	        "names": ["*****", ...],
+	        "error":""
+	     }
+
+400 - status Bad Request:
+
+	Error parsing request body, invalid columns, or only one of `value` and
+	`group`.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+401 - status Unauthorized:
+
+	If access token has expired or username in request body don't match username
+	in token payload.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+404 - status Not Found:
+
+	If the user has no file with id `file_id`, or the file has no such
+	version.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+422 - status Unprocessable Entity:
+
+	If there are less than 2 groups, a group without observed values, or
+	every value is tied.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+501 - status Internal Server Error:
+
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+*/
+func (server *Server) kruskal(ctx *gin.Context) {
+	server.serveAnalysis(ctx, &kruskalRequest{})
+}
+
+func (req *kruskalRequest) owner() string {
+	return req.Username
+}
+
+// run performs the Kruskal-Wallis test on the file of user `username` and
+// returns the response body.
+//
+// Returns a non-nil error along with the http status code to respond with if
+// the test can't be performed.
+func (req *kruskalRequest) run(
+	ctx context.Context, server *Server, username string,
+) (any, int, error) {
+	if (req.Value == nil) != (req.Group == nil) {
+		return nil, http.StatusBadRequest,
+			fmt.Errorf("`value` and `group` should be given together.")
+	}
+
+	ds, status, err := server.loadDataset(ctx, username, req.FileID, req.Version)
+	if err != nil {
+		return nil, status, err
+	}
+
+	groups, names, err := ds.selectGroups(req.Columns, req.Value, req.Group)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	result, err := statsanal.KruskalWallis(groups)
+	if err != nil {
+		return nil, http.StatusUnprocessableEntity,
+			fmt.Errorf("Error during Kruskal-Wallis test\n%w", err)
+	}
+
+	return testResp{Result: &result, Names: names}, http.StatusOK, nil
+}
+
+// Request format for Kolmogorov-Smirnov test queries.
+type ksRequest struct {
+	Username     string      `json:"username" binding:"required,alphanum"`
+	FileID       int64       `json:"file_id" binding:"required,min=1"`
+	Version      int32       `json:"version" binding:"omitempty,min=1"`
+	Columns      []columnRef `json:"columns" binding:"required,min=1,max=2"`
+	Distribution string      `json:"distribution" binding:"omitempty,oneof=normal uniform exponential"`
+	Params       []float64   `json:"params"`
+	Alternative  string      `json:"alternative" binding:"omitempty,oneof=two_sided less greater"`
+	Method       string      `json:"method" binding:"omitempty,oneof=exact asymptotic"`
+}
+
+/*
+ks runs the one-sample Kolmogorov-Smirnov test of a column of one of the
+user's files against a distribution, or the two-sample test of two of its
+columns. The endpoint expects a GET request with a json body with the
+following key:
+
+	`username`     - alphanumeric user's username
+	`file_id`      - id of the user's file to analyse.
+	`version`      - optional, version of the file to analyse, defaults to
+	                 the file's current version.
+	`columns`      - list of indices or names of the tested column, or of
+	                 the two compared columns.
+	`distribution` - optional, distribution of the one-sample test:
+	                 `normal` (default), `uniform` or `exponential`.
+	`params`       - optional, parameters of the distribution: mean and
+	                 standard deviation for `normal`, defaulting to [0, 1],
+	                 bounds for `uniform`, defaulting to [0, 1], and rate for
+	                 `exponential`, defaulting to [1].
+	`alternative`  - optional, alternative hypothesis: `two_sided`
+	                 (default), `less` or `greater`, the empirical
+	                 distribution function of the first column lying below
+	                 or above the other one.
+	`method`       - optional, `exact` or `asymptotic` p-value. Defaults to
+	                 exact for columns of less than 50 values without ties.
+
+Missing values are ignored. The statistic is the largest distance between
+the distribution functions, whose asymptotic p-value follows the Kolmogorov
+distribution.
+
+The request returns response with the following http status codes:
+
+200 - status OK:
+
+	with response body:
+	    {
+	        "result": {
+	            "test": "*****",
+	            "alternative": "*****",
+	            "statistic": *****,
+	            "p_value": *****,
+	            "method": "*****",
+	            "observations": [*****]
+	        },
+	        "names": ["*****", ...],
+	        "error":""
+	     }
+
+400 - status Bad Request:
+
+	Error parsing request body, invalid columns, or invalid parameters of
+	the distribution.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+401 - status Unauthorized:
+
+	If access token has expired or username in request body don't match username
+	in token payload.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+404 - status Not Found:
+
+	If the user has no file with id `file_id`, or the file has no such
+	version.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+422 - status Unprocessable Entity:
+
+	If a column has no observed value, or an exact p-value is requested with
+	ties or a column of more than 100 values.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+501 - status Internal Server Error:
+
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+*/
+func (server *Server) ks(ctx *gin.Context) {
+	server.serveAnalysis(ctx, &ksRequest{})
+}
+
+func (req *ksRequest) owner() string {
+	return req.Username
+}
+
+// run performs the Kolmogorov-Smirnov test on the file of user `username` and
+// returns the response body.
+//
+// Returns a non-nil error along with the http status code to respond with if
+// the test can't be performed.
+func (req *ksRequest) run(
+	ctx context.Context, server *Server, username string,
+) (any, int, error) {
+	var cdf func(float64) float64
+	if len(req.Columns) == 1 {
+		var err error
+		if cdf, err = req.cdf(); err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+	}
+
+	ds, status, err := server.loadDataset(ctx, username, req.FileID, req.Version)
+	if err != nil {
+		return nil, status, err
+	}
+
+	columns, err := resolveColumns(ds.names, req.Columns)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	opts := statsanal.NonParametricOptions{
+		Alternative: statsanal.Alternative(req.Alternative),
+		Method:      statsanal.PValueMethod(req.Method),
+	}
+	x := mat.Col(nil, columns[0], ds.data)
+
+	var result statsanal.TestResult
+	if cdf != nil {
+		result, err = statsanal.OneSampleKS(x, cdf, opts)
+	} else {
+		result, err = statsanal.TwoSampleKS(x, mat.Col(nil, columns[1], ds.data), opts)
+	}
+	if err != nil {
+		return nil, http.StatusUnprocessableEntity,
+			fmt.Errorf("Error during Kolmogorov-Smirnov test\n%w", err)
+	}
+
+	return testResp{Result: &result, Names: ds.selectNames(columns)}, http.StatusOK, nil
+}
+
+// cdf returns the distribution function of the one-sample test.
+//
+// Returns an error if the parameters don't match the distribution.
+func (req *ksRequest) cdf() (func(float64) float64, error) {
+	params := req.Params
+	switch req.Distribution {
+	case "uniform":
+		if params == nil {
+			params = []float64{0, 1}
+		}
+		if len(params) != 2 || params[0] >= params[1] {
+			return nil, fmt.Errorf(
+				"uniform distribution needs increasing bounds, got %v.", params)
+		}
+		return distuv.Uniform{Min: params[0], Max: params[1]}.CDF, nil
+	case "exponential":
+		if params == nil {
+			params = []float64{1}
+		}
+		if len(params) != 1 || params[0] <= 0 {
+			return nil, fmt.Errorf(
+				"exponential distribution needs a positive rate, got %v.", params)
+		}
+		return distuv.Exponential{Rate: params[0]}.CDF, nil
+	default:
+		if params == nil {
+			params = []float64{0, 1}
+		}
+		if len(params) != 2 || params[1] <= 0 {
+			return nil, fmt.Errorf(
+				"normal distribution needs a mean and a positive standard deviation, got %v.",
+				params)
+		}
+		return distuv.Normal{Mu: params[0], Sigma: params[1]}.CDF, nil
+	}
+}
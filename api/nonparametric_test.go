@@ -0,0 +1,344 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	gomock "go.uber.org/mock/gomock"
+
+	mockdb "github.com/yodeman/analyses-api/dbase/mock"
+	db "github.com/yodeman/analyses-api/dbase/sqlc"
+)
+
+func TestMannWhitney(t *testing.T) {
+	user, _ := randomUser(t)
+	file := hypothesisFile(t, user.Username)
+	getFile := func(store *mockdb.MockStore) {
+		store.EXPECT().
+			GetFile(gomock.Any(), gomock.Eq(db.GetFileParams{
+				ID:       file.ID,
+				Username: user.Username,
+			})).
+			Times(1).
+			Return(file, nil)
+	}
+	noFile := func(store *mockdb.MockStore) {
+		store.EXPECT().
+			GetFile(gomock.Any(), gomock.Any()).
+			Times(0)
+	}
+
+	testCases := []hypothesisCase{
+		{
+			name: "OK",
+			params: mannWhitneyRequest{rankTestRequest{
+				Username: user.Username,
+				FileID:   file.ID,
+				Columns:  []columnRef{columnName("drug1"), columnName("drug2")},
+			}},
+			username:   user.Username,
+			buildStubs: getFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				resp := requireBodyMatchTest(t, recorder.Body, "mann_whitney")
+				require.Equal(t, 25.5, *resp.Result.Statistic)
+				require.InDelta(t, 0.06933, *resp.Result.PValue, 1e-5)
+				require.Equal(t, "asymptotic", resp.Result.Method)
+				require.Equal(t, []string{"drug1", "drug2"}, resp.Names)
+			},
+		},
+		{
+			name: "EXACT WITH TIES",
+			params: mannWhitneyRequest{rankTestRequest{
+				Username: user.Username,
+				FileID:   file.ID,
+				Columns:  []columnRef{columnName("drug1"), columnName("drug2")},
+				Method:   "exact",
+			}},
+			username:   user.Username,
+			buildStubs: getFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name: "ONE COLUMN",
+			params: mannWhitneyRequest{rankTestRequest{
+				Username: user.Username,
+				FileID:   file.ID,
+				Columns:  []columnRef{columnName("drug1")},
+			}},
+			username:   user.Username,
+			buildStubs: noFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "INVALID METHOD",
+			params: mannWhitneyRequest{rankTestRequest{
+				Username: user.Username,
+				FileID:   file.ID,
+				Columns:  []columnRef{columnName("drug1"), columnName("drug2")},
+				Method:   "bootstrap",
+			}},
+			username:   user.Username,
+			buildStubs: noFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "UNAUTHORIZED",
+			params: mannWhitneyRequest{rankTestRequest{
+				Username: user.Username,
+				FileID:   file.ID,
+				Columns:  []columnRef{columnName("drug1"), columnName("drug2")},
+			}},
+			username:   "deidara",
+			buildStubs: noFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	runHypothesisCases(t, "/analyses/tests/mannwhitney", testCases)
+}
+
+func TestWilcoxon(t *testing.T) {
+	user, _ := randomUser(t)
+	file := hypothesisFile(t, user.Username)
+	getFile := func(store *mockdb.MockStore) {
+		store.EXPECT().
+			GetFile(gomock.Any(), gomock.Eq(db.GetFileParams{
+				ID:       file.ID,
+				Username: user.Username,
+			})).
+			Times(1).
+			Return(file, nil)
+	}
+
+	testCases := []hypothesisCase{
+		{
+			name: "PAIRED",
+			params: wilcoxonRequest{rankTestRequest{
+				Username: user.Username,
+				FileID:   file.ID,
+				Columns:  []columnRef{columnName("drug1"), columnName("drug2")},
+			}},
+			username:   user.Username,
+			buildStubs: getFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				resp := requireBodyMatchTest(t, recorder.Body, "wilcoxon")
+				require.Equal(t, 0.0, *resp.Result.Statistic)
+				require.InDelta(t, 0.009091, *resp.Result.PValue, 1e-6)
+				require.Equal(t, []int{9}, resp.Result.Observations)
+			},
+		},
+		{
+			name: "ONE SAMPLE",
+			params: wilcoxonRequest{rankTestRequest{
+				Username: user.Username,
+				FileID:   file.ID,
+				Columns:  []columnRef{columnIndex(1)},
+				Mu:       -1,
+			}},
+			username:   user.Username,
+			buildStubs: getFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				resp := requireBodyMatchTest(t, recorder.Body, "wilcoxon")
+				require.Equal(t, 55.0, *resp.Result.Statistic)
+				require.Equal(t, []string{"drug2"}, resp.Names)
+			},
+		},
+		{
+			name: "UNKNOWN COLUMN",
+			params: wilcoxonRequest{rankTestRequest{
+				Username: user.Username,
+				FileID:   file.ID,
+				Columns:  []columnRef{columnName("drug3")},
+			}},
+			username:   user.Username,
+			buildStubs: getFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	runHypothesisCases(t, "/analyses/tests/wilcoxon", testCases)
+}
+
+func TestKruskal(t *testing.T) {
+	user, _ := randomUser(t)
+	file := hypothesisFile(t, user.Username)
+	getFile := func(store *mockdb.MockStore) {
+		store.EXPECT().
+			GetFile(gomock.Any(), gomock.Eq(db.GetFileParams{
+				ID:       file.ID,
+				Username: user.Username,
+			})).
+			Times(1).
+			Return(file, nil)
+	}
+	noFile := func(store *mockdb.MockStore) {
+		store.EXPECT().
+			GetFile(gomock.Any(), gomock.Any()).
+			Times(0)
+	}
+	value, group := columnName("drug1"), columnName("group")
+
+	testCases := []hypothesisCase{
+		{
+			name: "COLUMNS",
+			params: kruskalRequest{
+				Username: user.Username,
+				FileID:   file.ID,
+				Columns:  []columnRef{columnName("drug1"), columnName("drug2")},
+			},
+			username:   user.Username,
+			buildStubs: getFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				resp := requireBodyMatchTest(t, recorder.Body, "kruskal_wallis")
+				require.Equal(t, []float64{1}, resp.Result.DF)
+				require.Equal(t, []int{10, 10}, resp.Result.Observations)
+			},
+		},
+		{
+			name: "GROUPED",
+			params: kruskalRequest{
+				Username: user.Username,
+				FileID:   file.ID,
+				Value:    &value,
+				Group:    &group,
+			},
+			username:   user.Username,
+			buildStubs: getFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				resp := requireBodyMatchTest(t, recorder.Body, "kruskal_wallis")
+				require.Equal(t, []float64{2}, resp.Result.DF)
+				require.Equal(t, []string{"1", "2", "3"}, resp.Names)
+			},
+		},
+		{
+			name: "VALUE WITHOUT GROUP",
+			params: kruskalRequest{
+				Username: user.Username,
+				FileID:   file.ID,
+				Value:    &value,
+			},
+			username:   user.Username,
+			buildStubs: noFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "ONE GROUP",
+			params: kruskalRequest{
+				Username: user.Username,
+				FileID:   file.ID,
+				Columns:  []columnRef{columnName("drug1")},
+			},
+			username:   user.Username,
+			buildStubs: getFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+	}
+
+	runHypothesisCases(t, "/analyses/tests/kruskal", testCases)
+}
+
+func TestKS(t *testing.T) {
+	user, _ := randomUser(t)
+	file := hypothesisFile(t, user.Username)
+	getFile := func(store *mockdb.MockStore) {
+		store.EXPECT().
+			GetFile(gomock.Any(), gomock.Eq(db.GetFileParams{
+				ID:       file.ID,
+				Username: user.Username,
+			})).
+			Times(1).
+			Return(file, nil)
+	}
+	noFile := func(store *mockdb.MockStore) {
+		store.EXPECT().
+			GetFile(gomock.Any(), gomock.Any()).
+			Times(0)
+	}
+
+	testCases := []hypothesisCase{
+		{
+			name: "ONE SAMPLE",
+			params: ksRequest{
+				Username: user.Username,
+				FileID:   file.ID,
+				Columns:  []columnRef{columnName("drug2")},
+				Params:   []float64{2, 2},
+			},
+			username:   user.Username,
+			buildStubs: getFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				resp := requireBodyMatchTest(t, recorder.Body, "ks_one_sample")
+				require.Equal(t, "exact", resp.Result.Method)
+				require.Equal(t, []string{"drug2"}, resp.Names)
+			},
+		},
+		{
+			name: "TWO SAMPLE",
+			params: ksRequest{
+				Username: user.Username,
+				FileID:   file.ID,
+				Columns:  []columnRef{columnName("drug1"), columnName("drug2")},
+			},
+			username:   user.Username,
+			buildStubs: getFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				resp := requireBodyMatchTest(t, recorder.Body, "ks_two_sample")
+				require.InDelta(t, 0.4, *resp.Result.Statistic, 1e-12)
+			},
+		},
+		{
+			name: "INVALID PARAMS",
+			params: ksRequest{
+				Username:     user.Username,
+				FileID:       file.ID,
+				Columns:      []columnRef{columnName("drug2")},
+				Distribution: "exponential",
+				Params:       []float64{-1},
+			},
+			username:   user.Username,
+			buildStubs: noFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "UNKNOWN DISTRIBUTION",
+			params: ksRequest{
+				Username:     user.Username,
+				FileID:       file.ID,
+				Columns:      []columnRef{columnName("drug2")},
+				Distribution: "cauchy",
+			},
+			username:   user.Username,
+			buildStubs: noFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	runHypothesisCases(t, "/analyses/tests/ks", testCases)
+}
@@ -0,0 +1,190 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	statsanal "github.com/yodeman/analyses-api/stats-analyses"
+)
+
+// Response format for outliers request.
+type outliersResp struct {
+	Result *statsanal.OutlierResult `json:"result"`
+	// Rows holds the index in the file of each scored row, and OutlierRows
+	// the one of each flagged row.
+	Rows        []int  `json:"rows,omitempty"`
+	OutlierRows []int  `json:"outlier_rows,omitempty"`
+	Error       string `json:"error"`
+}
+
+// Request format for outliers queries.
+type outliersRequest struct {
+	Username   string      `json:"username" binding:"required,alphanum"`
+	FileID     int64       `json:"file_id" binding:"required,min=1"`
+	Version    int32       `json:"version" binding:"omitempty,min=1"`
+	Columns    []columnRef `json:"columns"`
+	Method     string      `json:"method" binding:"omitempty,oneof=zscore modified_zscore iqr mahalanobis isolation_forest"`
+	Threshold  float64     `json:"threshold" binding:"omitempty,gt=0"`
+	Trees      int         `json:"trees" binding:"omitempty,min=1,max=1000"`
+	SampleSize int         `json:"sample_size" binding:"omitempty,min=2"`
+	Seed       int64       `json:"seed"`
+	Missing    string      `json:"missing" binding:"omitempty,oneof=listwise mean median ffill"`
+}
+
+/*
+outliers scores the rows of one of the user's files by how much they stand
+out from the others, and flags the outlying ones. The endpoint expects a GET
+request with a json body with the following key:
+
+	`username`    - alphanumeric user's username
+	`file_id`     - id of the user's file to analyse.
+	`version`     - optional, version of the file to analyse, defaults to the
+	                file's current version.
+	`columns`     - optional, list of indices or names of the columns to
+	                score, defaults to every column.
+	`method`      - optional, `zscore` (default) for the distance to the mean
+	                in standard deviations, `modified_zscore` for the distance
+	                to the median in scaled median absolute deviations, `iqr`
+	                for the distance beyond the quartiles in interquartile
+	                ranges, `mahalanobis` for the squared Mahalanobis distance
+	                of the rows, or `isolation_forest` for the anomaly score
+	                of an isolation forest.
+	`threshold`   - optional, score above which a row is flagged, defaults
+	                to 3 for `zscore`, 3.5 for `modified_zscore`, 1.5 for
+	                `iqr`, 0.6 for `isolation_forest`, and for `mahalanobis`
+	                to the 97.5% quantile of the chi-squared distribution
+	                with a degree of freedom per column.
+	`trees`       - optional, number of trees of the isolation forest, at
+	                most 1000, defaults to 100.
+	`sample_size` - optional, number of rows each tree is grown on, defaults
+	                to 256 or the number of rows if smaller.
+	`seed`        - optional, seed of the random trees.
+	`missing`     - optional, how missing values are handled: `listwise`
+	                (default), `mean`, `median` or `ffill`, see
+	                /analyses/regression.
+
+The `zscore`, `modified_zscore` and `iqr` methods score each column on its
+own: `column_scores` holds the signed score of each value, one list per
+column, and the score of a row is the largest absolute score of its values.
+The other methods score the rows as a whole. Scores and flags are ordered
+like `rows`, the indices in the file of the scored rows, which skip the rows
+dropped for missing values, and `outlier_rows` holds the index in the file of
+each flagged row. See /analyses/regression with `influence` for the leverage
+and Cook's distance of the rows of a regression.
+
+The request returns response with the following http status codes:
+
+200 - status OK:
+
+	with response body:
+	    {
+	        "result": {
+	            "method": "*****",
+	            "names": ["*****", ...],
+	            "threshold": *****,
+	            "scores": [*****],
+	            "column_scores": [[*****], ...],
+	            "flags": [*****],
+	            "outliers": [*****]
+	        },
+	        "rows": [*****],
+	        "outlier_rows": [*****],
+	        "error":""
+	     }
+
+400 - status Bad Request:
+
+	Error parsing request body, or invalid columns.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+401 - status Unauthorized:
+
+	If access token has expired or username in request body don't match username
+	in token payload.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+404 - status Not Found:
+
+	If the user has no file with id `file_id`, or the file has no such
+	version.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+422 - status Unprocessable Entity:
+
+	If there are less than 3 rows, a column has no spread, the covariance of
+	the columns is singular for `mahalanobis`, the sample size exceeds the
+	number of rows, or no data is left after handling missing values.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+501 - status Internal Server Error:
+
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+*/
+func (server *Server) outliers(ctx *gin.Context) {
+	server.serveAnalysis(ctx, &outliersRequest{})
+}
+
+func (req *outliersRequest) owner() string {
+	return req.Username
+}
+
+// run performs the outlier detection on the file of user `username` and
+// returns the response body.
+//
+// Returns a non-nil error along with the http status code to respond with if
+// the outliers can't be detected.
+func (req *outliersRequest) run(
+	ctx context.Context, server *Server, username string,
+) (any, int, error) {
+	data, names, rows, status, err := server.clusteringData(
+		ctx, username, req.FileID, req.Version, req.Columns, req.Missing)
+	if err != nil {
+		return nil, status, err
+	}
+
+	result, err := statsanal.Outliers(
+		ctx,
+		data,
+		statsanal.OutlierOptions{
+			Method:     statsanal.OutlierMethod(req.Method),
+			Threshold:  req.Threshold,
+			Trees:      req.Trees,
+			SampleSize: req.SampleSize,
+			Seed:       req.Seed,
+			Names:      names,
+		},
+	)
+	if err != nil {
+		return nil, http.StatusUnprocessableEntity,
+			fmt.Errorf("Error detecting outliers\n%w", err)
+	}
+
+	resp := outliersResp{Result: &result, Rows: rows}
+	for _, i := range result.Outliers {
+		resp.OutlierRows = append(resp.OutlierRows, rows[i])
+	}
+	return resp, http.StatusOK, nil
+}
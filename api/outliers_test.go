@@ -0,0 +1,194 @@
+package api
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	gomock "go.uber.org/mock/gomock"
+	"gonum.org/v1/gonum/mat"
+
+	mockdb "github.com/yodeman/analyses-api/dbase/mock"
+	db "github.com/yodeman/analyses-api/dbase/sqlc"
+	"github.com/yodeman/analyses-api/util"
+)
+
+// outlierFile returns a file of 20 rows whose `y` lies close to 2 * `x` but
+// at the 15th row, whose `latency` spikes at the 11th row, and with a missing
+// value in the fourth row.
+func outlierFile(t *testing.T, username string) db.File {
+	m := mat.NewDense(20, 3, nil)
+	for i := 0; i < 20; i++ {
+		x := float64(i)
+		m.Set(i, 0, x)
+		m.Set(i, 1, 2*x+0.3*math.Sin(x*x))
+		m.Set(i, 2, 1+0.2*math.Sin(x*x))
+	}
+	m.Set(14, 1, 2*14+15)
+	m.Set(10, 2, 50)
+	m.Set(3, 2, math.NaN())
+	data, err := m.MarshalBinary()
+	require.NoError(t, err)
+
+	return db.File{
+		ID:          util.RandomInt(1, 1000),
+		Username:    username,
+		Data:        data,
+		ColumnNames: []string{"x", "y", "latency"},
+	}
+}
+
+func TestOutliers(t *testing.T) {
+	user, _ := randomUser(t)
+	file := outlierFile(t, user.Username)
+	getFile := func(store *mockdb.MockStore) {
+		store.EXPECT().
+			GetFile(gomock.Any(), gomock.Eq(db.GetFileParams{
+				ID:       file.ID,
+				Username: user.Username,
+			})).
+			Times(1).
+			Return(file, nil)
+	}
+	noFile := func(store *mockdb.MockStore) {
+		store.EXPECT().
+			GetFile(gomock.Any(), gomock.Any()).
+			Times(0)
+	}
+	latency := []columnRef{columnName("latency")}
+	xy := []columnRef{columnName("x"), columnIndex(1)}
+
+	testCases := []hypothesisCase{
+		{
+			name: "ZSCORE",
+			params: outliersRequest{
+				Username: user.Username,
+				FileID:   file.ID,
+				Columns:  latency,
+			},
+			username:   user.Username,
+			buildStubs: getFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var resp outliersResp
+				require.NoError(t, json.NewDecoder(recorder.Body).Decode(&resp))
+				require.Empty(t, resp.Error)
+				require.Equal(t, "zscore", string(resp.Result.Method))
+				require.Equal(t, []string{"latency"}, resp.Result.Names)
+				require.Len(t, resp.Rows, 19)
+				require.Len(t, resp.Result.ColumnScores, 1)
+				// the dropped fourth row shifts the positions by one.
+				require.Equal(t, []int{9}, resp.Result.Outliers)
+				require.Equal(t, []int{10}, resp.OutlierRows)
+				require.True(t, resp.Result.Flags[9])
+			},
+		},
+		{
+			name: "IQR",
+			params: outliersRequest{
+				Username:  user.Username,
+				FileID:    file.ID,
+				Columns:   latency,
+				Method:    "iqr",
+				Threshold: 3,
+				Missing:   "median",
+			},
+			username:   user.Username,
+			buildStubs: getFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var resp outliersResp
+				require.NoError(t, json.NewDecoder(recorder.Body).Decode(&resp))
+				require.Equal(t, 3.0, resp.Result.Threshold)
+				require.Len(t, resp.Rows, 20)
+				require.Equal(t, []int{10}, resp.OutlierRows)
+			},
+		},
+		{
+			name: "MAHALANOBIS",
+			params: outliersRequest{
+				Username: user.Username,
+				FileID:   file.ID,
+				Columns:  xy,
+				Method:   "mahalanobis",
+			},
+			username:   user.Username,
+			buildStubs: getFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var resp outliersResp
+				require.NoError(t, json.NewDecoder(recorder.Body).Decode(&resp))
+				require.Equal(t, []string{"x", "y"}, resp.Result.Names)
+				require.Nil(t, resp.Result.ColumnScores)
+				require.Equal(t, []int{14}, resp.OutlierRows)
+			},
+		},
+		{
+			name: "ISOLATION FOREST",
+			params: outliersRequest{
+				Username:   user.Username,
+				FileID:     file.ID,
+				Columns:    latency,
+				Method:     "isolation_forest",
+				Trees:      50,
+				SampleSize: 16,
+				Seed:       7,
+			},
+			username:   user.Username,
+			buildStubs: getFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var resp outliersResp
+				require.NoError(t, json.NewDecoder(recorder.Body).Decode(&resp))
+				require.Len(t, resp.Result.Scores, 19)
+				require.Contains(t, resp.OutlierRows, 10)
+			},
+		},
+		{
+			name: "SAMPLE TOO LARGE",
+			params: outliersRequest{
+				Username:   user.Username,
+				FileID:     file.ID,
+				Method:     "isolation_forest",
+				SampleSize: 25,
+			},
+			username:   user.Username,
+			buildStubs: getFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name: "TOO MANY TREES",
+			params: outliersRequest{
+				Username: user.Username,
+				FileID:   file.ID,
+				Method:   "isolation_forest",
+				Trees:    1001,
+			},
+			username:   user.Username,
+			buildStubs: noFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "INVALID METHOD",
+			params: outliersRequest{
+				Username: user.Username,
+				FileID:   file.ID,
+				Method:   "grubbs",
+			},
+			username:   user.Username,
+			buildStubs: noFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	runHypothesisCases(t, "/analyses/outliers", testCases)
+}
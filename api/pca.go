@@ -0,0 +1,166 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	statsanal "github.com/yodeman/analyses-api/stats-analyses"
+)
+
+// Response format for pca request.
+type pcaResp struct {
+	Result *statsanal.PCAResult `json:"result"`
+	Error  string               `json:"error"`
+}
+
+// Request format for pca queries.
+type pcaRequest struct {
+	Username    string      `json:"username" binding:"required,alphanum"`
+	FileID      int64       `json:"file_id" binding:"required,min=1"`
+	Version     int32       `json:"version" binding:"omitempty,min=1"`
+	Columns     []columnRef `json:"columns"`
+	Standardize bool        `json:"standardize"`
+	Components  int         `json:"components" binding:"omitempty,min=1"`
+	Scores      bool        `json:"scores"`
+	Missing     string      `json:"missing" binding:"omitempty,oneof=listwise mean median ffill"`
+}
+
+/*
+pca performs the principal component analysis of the columns of one of the
+user's files. The endpoint expects a GET request with a json body with the
+following key:
+
+	`username`    - alphanumeric user's username
+	`file_id`     - id of the user's file to analyse.
+	`version`     - optional, version of the file to analyse, defaults to the
+	                file's current version.
+	`columns`     - optional, list of indices or names of the columns to
+	                analyse, defaults to every column.
+	`standardize` - optional, scale the columns to unit variance first.
+	`components`  - optional, number of components whose loadings and scores
+	                are returned, defaults to every component.
+	`scores`      - optional, also return the projection of each row on the
+	                components.
+	`missing`     - optional, how missing values are handled: `listwise`
+	                (default), `mean`, `median` or `ffill`, see
+	                /analyses/regression.
+
+The request returns response with the following http status codes:
+
+200 - status OK:
+
+	with response body:
+	    {
+	        "result": {
+	            "names": ["*****", ...],
+	            "eigenvalues": [*****],
+	            "explained_variance_ratio": [*****],
+	            "cumulative_variance_ratio": [*****],
+	            "loadings": [[*****], ...],
+	            "scores": [[*****], ...]
+	        },
+	        "error":""
+	     }
+
+400 - status Bad Request:
+
+	Error parsing request body, or invalid columns.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+401 - status Unauthorized:
+
+	If access token has expired or username in request body don't match username
+	in token payload.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+404 - status Not Found:
+
+	If the user has no file with id `file_id`, or the file has no such
+	version.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+422 - status Unprocessable Entity:
+
+	If there are too few rows or columns, more components than available
+	are requested, a column is constant while standardizing, or no data is
+	left after handling missing values.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+501 - status Internal Server Error:
+
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+*/
+func (server *Server) pca(ctx *gin.Context) {
+	server.serveAnalysis(ctx, &pcaRequest{})
+}
+
+func (req *pcaRequest) owner() string {
+	return req.Username
+}
+
+// run performs the principal component analysis on the file of user
+// `username` and returns the response body.
+//
+// Returns a non-nil error along with the http status code to respond with if
+// the analysis can't be performed.
+func (req *pcaRequest) run(
+	ctx context.Context, server *Server, username string,
+) (any, int, error) {
+	ds, status, err := server.loadDataset(ctx, username, req.FileID, req.Version)
+	if err != nil {
+		return nil, status, err
+	}
+
+	columns, err := resolveColumns(ds.names, req.Columns)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	data, _, err := statsanal.HandleMissing(
+		statsanal.SelectColumns(ds.data, columns),
+		statsanal.MissingStrategy(req.Missing),
+	)
+	if err != nil {
+		return nil, http.StatusUnprocessableEntity,
+			fmt.Errorf("Error handling missing values.\n%w", err)
+	}
+
+	result, err := statsanal.PCA(
+		data,
+		statsanal.PCAOptions{
+			Standardize: req.Standardize,
+			Components:  req.Components,
+			Scores:      req.Scores,
+			Names:       ds.selectNames(columns),
+		},
+	)
+	if err != nil {
+		return nil, http.StatusUnprocessableEntity,
+			fmt.Errorf("Error during principal component analysis\n%w", err)
+	}
+
+	return pcaResp{Result: &result}, http.StatusOK, nil
+}
@@ -0,0 +1,186 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	gomock "go.uber.org/mock/gomock"
+	"gonum.org/v1/gonum/mat"
+
+	mockdb "github.com/yodeman/analyses-api/dbase/mock"
+	db "github.com/yodeman/analyses-api/dbase/sqlc"
+	"github.com/yodeman/analyses-api/util"
+)
+
+func TestPCA(t *testing.T) {
+	user, _ := randomUser(t)
+	rows, cols, data, err := util.ParseCSVToFloatSlice(
+		strings.NewReader(util.RandomCSV(30, 5)))
+	require.NoError(t, err)
+	matData, err := mat.NewDense(rows, cols, data).MarshalBinary()
+	require.NoError(t, err)
+
+	fileID := util.RandomInt(1, 1000)
+	getFileParams := db.GetFileParams{ID: fileID, Username: user.Username}
+	file := db.File{ID: fileID, Username: user.Username, Data: matData}
+
+	testCases := []struct {
+		name          string
+		params        pcaRequest
+		username      string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			params:   pcaRequest{Username: user.Username, FileID: fileID},
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetFile(gomock.Any(), gomock.Eq(getFileParams)).
+					Times(1).
+					Return(file, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				resp := requireBodyMatchPCA(t, recorder.Body, cols, cols)
+				require.Nil(t, resp.Result.Scores)
+			},
+		},
+		{
+			name: "STANDARDIZED SCORES",
+			params: pcaRequest{
+				Username:    user.Username,
+				FileID:      fileID,
+				Columns:     []columnRef{columnIndex(0), columnIndex(2), columnIndex(4)},
+				Standardize: true,
+				Components:  2,
+				Scores:      true,
+			},
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetFile(gomock.Any(), gomock.Eq(getFileParams)).
+					Times(1).
+					Return(file, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				resp := requireBodyMatchPCA(t, recorder.Body, 3, 2)
+				require.Equal(t, []string{"col_0", "col_2", "col_4"}, resp.Result.Names)
+				// standardized columns have a total variance of 3.
+				var total float64
+				for _, v := range resp.Result.Eigenvalues {
+					total += v
+				}
+				require.InDelta(t, 3, total, 1e-9)
+				require.Len(t, resp.Result.Scores, rows)
+				require.Len(t, resp.Result.Scores[0], 2)
+			},
+		},
+		{
+			name: "TOO MANY COMPONENTS",
+			params: pcaRequest{
+				Username:   user.Username,
+				FileID:     fileID,
+				Components: cols + 1,
+			},
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetFile(gomock.Any(), gomock.Eq(getFileParams)).
+					Times(1).
+					Return(file, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name: "INVALID MISSING STRATEGY",
+			params: pcaRequest{
+				Username: user.Username,
+				FileID:   fileID,
+				Missing:  "drop",
+			},
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetFile(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "UNAUTHORIZED",
+			params:   pcaRequest{Username: user.Username, FileID: fileID},
+			username: "deidara",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetFile(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			encodedParams, err := json.Marshal(tc.params)
+			require.NoError(t, err)
+			request, err := http.NewRequest(
+				http.MethodGet, "/analyses/pca", bytes.NewBuffer(encodedParams))
+			require.NoError(t, err)
+			addAuthorization(
+				t, request, server.tokenMaker, authorizationTypeToken,
+				tc.username, time.Minute,
+			)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func requireBodyMatchPCA(
+	t *testing.T, responseBody *bytes.Buffer, cols, components int,
+) pcaResp {
+	var serverResp pcaResp
+
+	err := json.NewDecoder(responseBody).Decode(&serverResp)
+	require.NoError(t, err)
+
+	require.Empty(t, serverResp.Error)
+	require.NotNil(t, serverResp.Result)
+	require.Len(t, serverResp.Result.Names, cols)
+	require.Len(t, serverResp.Result.Eigenvalues, cols)
+	require.Len(t, serverResp.Result.ExplainedRatio, cols)
+	require.InDelta(t, 1, serverResp.Result.CumulativeRatio[cols-1], 1e-9)
+	require.Len(t, serverResp.Result.Loadings, components)
+	for _, loadings := range serverResp.Result.Loadings {
+		require.Len(t, loadings, cols)
+	}
+
+	return serverResp
+}
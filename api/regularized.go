@@ -0,0 +1,214 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	statsanal "github.com/yodeman/analyses-api/stats-analyses"
+)
+
+// Response format for regularized regression request.
+type regularizedResp struct {
+	Result *statsanal.RegularizedResult `json:"result"`
+	// ModelID is the id of the saved model, only set when `save` is requested.
+	ModelID int64  `json:"model_id,omitempty"`
+	Error   string `json:"error"`
+}
+
+// Request format for regularized regression queries.
+type regularizedRequest struct {
+	Username      string      `json:"username" binding:"required,alphanum"`
+	FileID        int64       `json:"file_id" binding:"required,min=1"`
+	Version       int32       `json:"version" binding:"omitempty,min=1"`
+	Penalty       string      `json:"penalty" binding:"required,oneof=ridge lasso elastic_net"`
+	Alpha         float64     `json:"alpha" binding:"omitempty,gt=0,lt=1"`
+	Lambda        float64     `json:"lambda" binding:"omitempty,gt=0"`
+	PathLength    int         `json:"path_length" binding:"omitempty,min=2,max=500"`
+	Folds         int         `json:"folds" binding:"omitempty,min=2"`
+	NoStandardize bool        `json:"no_standardize"`
+	Target        *columnRef  `json:"target"`
+	Predictors    []columnRef `json:"predictors"`
+	Missing       string      `json:"missing" binding:"omitempty,oneof=listwise mean median ffill"`
+	Save          bool        `json:"save"`
+}
+
+/*
+regularizedRegression fits a ridge, lasso or elastic net regression on one of
+the user's files, and returns its regularization path. The endpoint expects a
+GET request with a json body with the following key:
+
+	`username`    - alphanumeric user's username
+	`file_id`     - id of the user's file to analyse.
+	`version`     - optional, version of the file to analyse, defaults to the
+	                file's current version.
+	`penalty`     - `ridge`, `lasso` or `elastic_net`.
+	`alpha`       - optional, L1 share of the elastic net penalty in (0, 1),
+	                defaults to 0.5.
+	`lambda`      - optional, strength of the penalty, chosen by
+	                cross-validation along the path when not given.
+	`path_length` - optional, number of penalties of the regularization
+	                path, defaults to 50.
+	`folds`       - optional, number of cross-validation folds, defaults
+	                to 5.
+	`no_standardize` - optional, fit on the raw predictors instead of
+	                scaling them to unit variance first.
+	`target`      - optional, index or name of the target column, defaults to
+	                the last column.
+	`predictors`  - optional, list of indices or names of the predictor
+	                columns, defaults to every column but the target.
+	`missing`     - optional, how missing values in the selected columns are
+	                handled: `listwise` (default), `mean`, `median` or
+	                `ffill`, see /analyses/regression.
+	`save`        - optional, save the fitted model for predictions at
+	                /models/:id/predict.
+
+The request returns response with the following http status codes:
+
+200 - status OK:
+
+	with response body:
+	    {
+	        "result": {
+	            "names": ["intercept", "*****", ...],
+	            "penalty": "*****",
+	            "alpha": *****,
+	            "lambda": *****,
+	            "coefficients": [*****],
+	            "non_zero": *****,
+	            "observations": *****,
+	            "r_squared": *****,
+	            "cross_validated": *****,
+	            "lambda_min": *****,
+	            "lambda_1se": *****,
+	            "path": [
+	                {
+	                    "lambda": *****,
+	                    "coefficients": [*****],
+	                    "non_zero": *****,
+	                    "cv_error": *****,
+	                    "cv_std_error": *****
+	                },
+	                ...
+	            ]
+	        },
+	        "model_id": *****,
+	        "error":""
+	     }
+
+400 - status Bad Request:
+
+	Error parsing request body, or invalid target or predictor columns.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+401 - status Unauthorized:
+
+	If access token has expired or username in request body don't match username
+	in token payload.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+404 - status Not Found:
+
+	If the user has no file with id `file_id`, or the file has no such
+	version.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+422 - status Unprocessable Entity:
+
+	If there are less rows than folds, the fit doesn't converge, or no data
+	is left after handling missing values.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+501 - status Internal Server Error:
+
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+*/
+func (server *Server) regularizedRegression(ctx *gin.Context) {
+	server.serveAnalysis(ctx, &regularizedRequest{})
+}
+
+func (req *regularizedRequest) owner() string {
+	return req.Username
+}
+
+// run fits the regularized regression on the file of user `username` and
+// returns the response body.
+//
+// Returns a non-nil error along with the http status code to respond with if
+// the regression can't be performed.
+func (req *regularizedRequest) run(
+	ctx context.Context, server *Server, username string,
+) (any, int, error) {
+	ds, status, err := server.loadDataset(ctx, username, req.FileID, req.Version)
+	if err != nil {
+		return nil, status, err
+	}
+
+	columns, err := resolveModelColumns(ds.names, req.Target, req.Predictors)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	modelData, _, err := statsanal.HandleMissing(
+		statsanal.SelectColumns(ds.data, columns),
+		statsanal.MissingStrategy(req.Missing),
+	)
+	if err != nil {
+		return nil, http.StatusUnprocessableEntity,
+			fmt.Errorf("Error handling missing values.\n%w", err)
+	}
+
+	result, err := statsanal.RegularizedRegression(
+		modelData,
+		statsanal.RegularizedOptions{
+			Penalty:       statsanal.Penalty(req.Penalty),
+			Alpha:         req.Alpha,
+			Lambda:        req.Lambda,
+			PathLength:    req.PathLength,
+			Folds:         req.Folds,
+			NoStandardize: req.NoStandardize,
+			Names:         ds.selectNames(columns),
+		},
+	)
+	if err != nil {
+		if errors.Is(err, statsanal.ErrEmptySplit) ||
+			errors.Is(err, statsanal.ErrNotConverged) {
+			return nil, http.StatusUnprocessableEntity, err
+		}
+		return nil, http.StatusInternalServerError,
+			fmt.Errorf("Error during regularized regression analysis\n%w", err)
+	}
+
+	resp := regularizedResp{Result: &result}
+	if req.Save {
+		resp.ModelID, err = server.saveModel(
+			ctx, username, req.FileID, ds, columns, result)
+		if err != nil {
+			return nil, http.StatusInternalServerError, err
+		}
+	}
+	return resp, http.StatusOK, nil
+}
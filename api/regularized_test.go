@@ -0,0 +1,186 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	gomock "go.uber.org/mock/gomock"
+	"gonum.org/v1/gonum/mat"
+
+	mockdb "github.com/yodeman/analyses-api/dbase/mock"
+	db "github.com/yodeman/analyses-api/dbase/sqlc"
+	"github.com/yodeman/analyses-api/util"
+)
+
+func TestRegularizedRegression(t *testing.T) {
+	user, _ := randomUser(t)
+	rows, cols, data, err := util.ParseCSVToFloatSlice(
+		strings.NewReader(util.RandomCSV(30, 5)))
+	require.NoError(t, err)
+	matData, err := mat.NewDense(rows, cols, data).MarshalBinary()
+	require.NoError(t, err)
+
+	fileID := util.RandomInt(1, 1000)
+	getFileParams := db.GetFileParams{ID: fileID, Username: user.Username}
+	file := db.File{ID: fileID, Username: user.Username, Data: matData}
+
+	testCases := []struct {
+		name          string
+		params        regularizedRequest
+		username      string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "CROSS VALIDATED LASSO",
+			params: regularizedRequest{
+				Username:   user.Username,
+				FileID:     fileID,
+				Penalty:    "lasso",
+				PathLength: 10,
+			},
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetFile(gomock.Any(), gomock.Eq(getFileParams)).
+					Times(1).
+					Return(file, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				resp := requireBodyMatchRegularized(t, recorder.Body, cols, 10)
+				require.True(t, resp.Result.CrossValidated)
+				require.Equal(t, resp.Result.LambdaMin, resp.Result.Lambda)
+			},
+		},
+		{
+			name: "RIDGE WITH LAMBDA",
+			params: regularizedRequest{
+				Username: user.Username,
+				FileID:   fileID,
+				Penalty:  "ridge",
+				Lambda:   0.5,
+			},
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetFile(gomock.Any(), gomock.Eq(getFileParams)).
+					Times(1).
+					Return(file, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				resp := requireBodyMatchRegularized(t, recorder.Body, cols, 50)
+				require.False(t, resp.Result.CrossValidated)
+				require.Equal(t, 0.5, resp.Result.Lambda)
+				require.Equal(t, "ridge", resp.Result.Penalty)
+			},
+		},
+		{
+			name: "TOO MANY FOLDS",
+			params: regularizedRequest{
+				Username: user.Username,
+				FileID:   fileID,
+				Penalty:  "elastic_net",
+				Folds:    rows + 1,
+			},
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetFile(gomock.Any(), gomock.Eq(getFileParams)).
+					Times(1).
+					Return(file, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name: "MISSING PENALTY",
+			params: regularizedRequest{
+				Username: user.Username,
+				FileID:   fileID,
+			},
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetFile(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "UNAUTHORIZED",
+			params: regularizedRequest{
+				Username: user.Username,
+				FileID:   fileID,
+				Penalty:  "ridge",
+			},
+			username: "deidara",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetFile(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			encodedParams, err := json.Marshal(tc.params)
+			require.NoError(t, err)
+			request, err := http.NewRequest(
+				http.MethodGet, "/analyses/regularized", bytes.NewBuffer(encodedParams))
+			require.NoError(t, err)
+			addAuthorization(
+				t, request, server.tokenMaker, authorizationTypeToken,
+				tc.username, time.Minute,
+			)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func requireBodyMatchRegularized(
+	t *testing.T, responseBody *bytes.Buffer, cols, pathLength int,
+) regularizedResp {
+	var serverResp regularizedResp
+
+	err := json.NewDecoder(responseBody).Decode(&serverResp)
+	require.NoError(t, err)
+
+	require.Empty(t, serverResp.Error)
+	require.NotNil(t, serverResp.Result)
+	require.Len(t, serverResp.Result.Names, cols)
+	require.Len(t, serverResp.Result.Coefficients, cols)
+	require.Len(t, serverResp.Result.Path, pathLength)
+	for _, step := range serverResp.Result.Path {
+		require.Len(t, step.Coefficients, cols)
+	}
+
+	return serverResp
+}
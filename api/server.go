@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/gin-gonic/gin"
@@ -8,27 +9,31 @@ import (
 	db "github.com/yodeman/analyses-api/dbase/sqlc"
 	"github.com/yodeman/analyses-api/token"
 	"github.com/yodeman/analyses-api/util"
+	"github.com/yodeman/analyses-api/worker"
 )
 
 const maxFileSize = 10 << 20 // 10MB
 
 type Server struct {
 	config     util.Config
-	querier    db.Querier
+	store      db.Store
 	router     *gin.Engine
 	tokenMaker *token.PasetoMaker
+	jobs       *worker.Pool
 }
 
-func NewServer(config util.Config, querier db.Querier) (*Server, error) {
+func NewServer(config util.Config, store db.Store) (*Server, error) {
 	tokenMaker, err := token.NewPasetoMaker(config.TokenSymmetricKey)
 	if err != nil {
 		return nil, fmt.Errorf("Error creating server.\n%w", err)
 	}
 	server := &Server{
 		config:     config,
-		querier:    querier,
+		store:      store,
 		tokenMaker: tokenMaker,
 	}
+	server.jobs = worker.NewPool(
+		store, config.Workers, config.JobPollInterval, server.runJob)
 
 	router := gin.Default()
 	router.MaxMultipartMemory = maxFileSize
@@ -48,18 +53,104 @@ func NewServer(config util.Config, querier db.Querier) (*Server, error) {
 
 	// upload file
 	authRoutes.POST("/files/upload", server.uploadFile)
+	// list user's files
+	authRoutes.GET("/files", server.listFiles)
+	// get file's metadata
+	authRoutes.GET("/files/:id", server.getFile)
+	// rename file
+	authRoutes.PATCH("/files/:id", server.renameFile)
+	// delete file
+	authRoutes.DELETE("/files/:id", server.deleteFile)
+	// list file's versions
+	authRoutes.GET("/files/:id/versions", server.listFileVersions)
+	// roll file back to an earlier version
+	authRoutes.POST("/files/:id/rollback", server.rollbackFile)
 
 	// analyses endpoints
 
 	// linear regression endpoint
 	authRoutes.GET("/analyses/regression", server.linearRegression)
+	// logistic regression endpoint
+	authRoutes.GET("/analyses/logistic", server.logisticRegression)
+	// ridge, lasso and elastic net regression endpoint
+	authRoutes.GET("/analyses/regularized", server.regularizedRegression)
+	// k-fold and holdout cross-validation endpoint
+	authRoutes.GET("/analyses/crossvalidation", server.crossValidation)
+	// descriptive statistics endpoint
+	authRoutes.GET("/analyses/describe", server.describe)
+	// correlation and covariance matrices endpoint
+	authRoutes.GET("/analyses/correlation", server.correlation)
+	// principal component analysis endpoint
+	authRoutes.GET("/analyses/pca", server.pca)
+	// k-means clustering endpoint
+	authRoutes.GET("/analyses/kmeans", server.kmeans)
+	// agglomerative clustering endpoint
+	authRoutes.GET("/analyses/hierarchical", server.hierarchical)
+	// DBSCAN clustering endpoint
+	authRoutes.GET("/analyses/dbscan", server.dbscan)
+	// outlier detection endpoint
+	authRoutes.GET("/analyses/outliers", server.outliers)
+	// moving average endpoint
+	authRoutes.GET("/analyses/timeseries/movingaverage", server.movingAverage)
+	// exponential smoothing and forecasting endpoint
+	authRoutes.GET("/analyses/timeseries/smoothing", server.smoothing)
+	// differencing endpoint
+	authRoutes.GET("/analyses/timeseries/difference", server.difference)
+	// autocorrelation and partial autocorrelation endpoint
+	authRoutes.GET("/analyses/timeseries/autocorrelation", server.autocorrelation)
+	// augmented Dickey-Fuller test endpoint
+	authRoutes.GET("/analyses/timeseries/adf", server.adf)
+	// ARIMA modelling and forecasting endpoint
+	authRoutes.GET("/analyses/timeseries/arima", server.arima)
+	// seasonal decomposition endpoint
+	authRoutes.GET("/analyses/timeseries/decomposition", server.decomposition)
+	// change point detection endpoint
+	authRoutes.GET("/analyses/timeseries/changepoints", server.changePoints)
+	// t-test endpoint
+	authRoutes.GET("/analyses/tests/ttest", server.tTest)
+	// chi-square test of independence endpoint
+	authRoutes.GET("/analyses/tests/chisquare", server.chiSquare)
+	// one-way analysis of variance endpoint
+	authRoutes.GET("/analyses/tests/anova", server.anova)
+	// Mann-Whitney U test endpoint
+	authRoutes.GET("/analyses/tests/mannwhitney", server.mannWhitney)
+	// Wilcoxon signed-rank test endpoint
+	authRoutes.GET("/analyses/tests/wilcoxon", server.wilcoxon)
+	// Kruskal-Wallis test endpoint
+	authRoutes.GET("/analyses/tests/kruskal", server.kruskal)
+	// Kolmogorov-Smirnov test endpoint
+	authRoutes.GET("/analyses/tests/ks", server.ks)
+
+	// models endpoints
+
+	// list saved models
+	authRoutes.GET("/models", server.listModels)
+	// get saved model
+	authRoutes.GET("/models/:id", server.getModel)
+	// delete saved model
+	authRoutes.DELETE("/models/:id", server.deleteModel)
+	// predict new rows with saved model
+	authRoutes.POST("/models/:id/predict", server.predict)
+
+	// jobs endpoints
+
+	// submit analysis job
+	authRoutes.POST("/jobs", server.submitJob)
+	// get job's status and result
+	authRoutes.GET("/jobs/:id", server.getJob)
+	// cancel job
+	authRoutes.POST("/jobs/:id/cancel", server.cancelJob)
 
 	server.router = router
 
 	return server, nil
 }
 
+// Start starts the job workers and runs the server on `addr`.
 func (server *Server) Start(addr string) error {
+	if err := server.jobs.Start(context.Background()); err != nil {
+		return fmt.Errorf("Error starting job workers.\n%w", err)
+	}
 	return server.router.Run(addr)
 }
 
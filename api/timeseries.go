@@ -0,0 +1,364 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gonum.org/v1/gonum/mat"
+
+	statsanal "github.com/yodeman/analyses-api/stats-analyses"
+)
+
+// Response format for moving average request.
+type movingAverageResp struct {
+	Result *statsanal.MovingAverageResult `json:"result"`
+	Name   string                         `json:"name,omitempty"`
+	// Rows holds the index in the file of each value of the series.
+	Rows  []int  `json:"rows,omitempty"`
+	Error string `json:"error"`
+}
+
+// Request format for moving average queries.
+type movingAverageRequest struct {
+	Username string     `json:"username" binding:"required,alphanum"`
+	FileID   int64      `json:"file_id" binding:"required,min=1"`
+	Version  int32      `json:"version" binding:"omitempty,min=1"`
+	Column   *columnRef `json:"column" binding:"required"`
+	Window   int        `json:"window" binding:"required,min=1"`
+	Weights  []float64  `json:"weights"`
+	Missing  string     `json:"missing" binding:"omitempty,oneof=listwise mean median ffill"`
+}
+
+/*
+movingAverage smooths a column of one of the user's files, ordered like its
+rows, with a moving average. The endpoint expects a GET request with a json
+body with the following key:
+
+	`username` - alphanumeric user's username
+	`file_id`  - id of the user's file to analyse.
+	`version`  - optional, version of the file to analyse, defaults to the
+	             file's current version.
+	`column`   - index or name of the column holding the series.
+	`window`   - number of values averaged.
+	`weights`  - optional, weight of each value of the window, oldest value
+	             first, defaults to equal weights.
+	`missing`  - optional, how missing values are handled: `listwise`
+	             (default), `mean`, `median` or `ffill`, see
+	             /analyses/regression.
+
+The values are ordered like `rows`, the indices in the file of the values of
+the series, which skip the rows dropped for missing values. Each value is the
+average of the window ending at it, null for the first values without a full
+window.
+
+The request returns response with the following http status codes:
+
+200 - status OK:
+
+	with response body:
+	    {
+	        "result": {
+	            "window": *****,
+	            "weights": [*****],
+	            "values": [*****]
+	        },
+	        "name": "*****",
+	        "rows": [*****],
+	        "error":""
+	     }
+
+400 - status Bad Request:
+
+	Error parsing request body, or invalid column.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+401 - status Unauthorized:
+
+	If access token has expired or username in request body don't match username
+	in token payload.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+404 - status Not Found:
+
+	If the user has no file with id `file_id`, or the file has no such
+	version.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+422 - status Unprocessable Entity:
+
+	If the window is longer than the series, the weights don't match the
+	window or aren't positive, or no data is left after handling missing
+	values.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+501 - status Internal Server Error:
+
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+*/
+func (server *Server) movingAverage(ctx *gin.Context) {
+	server.serveAnalysis(ctx, &movingAverageRequest{})
+}
+
+func (req *movingAverageRequest) owner() string {
+	return req.Username
+}
+
+// run computes the moving average on the file of user `username` and
+// returns the response body.
+//
+// Returns a non-nil error along with the http status code to respond with if
+// the moving average can't be computed.
+func (req *movingAverageRequest) run(
+	ctx context.Context, server *Server, username string,
+) (any, int, error) {
+	series, name, rows, status, err := server.seriesData(
+		ctx, username, req.FileID, req.Version, *req.Column, req.Missing)
+	if err != nil {
+		return nil, status, err
+	}
+
+	result, err := statsanal.MovingAverage(series, req.Window, req.Weights)
+	if err != nil {
+		return nil, http.StatusUnprocessableEntity,
+			fmt.Errorf("Error computing moving average\n%w", err)
+	}
+
+	return movingAverageResp{Result: &result, Name: name, Rows: rows}, http.StatusOK, nil
+}
+
+// Response format for smoothing request.
+type smoothingResp struct {
+	Result *statsanal.SmoothingResult `json:"result"`
+	Name   string                     `json:"name,omitempty"`
+	// Rows holds the index in the file of each value of the series.
+	Rows  []int  `json:"rows,omitempty"`
+	Error string `json:"error"`
+}
+
+// Request format for smoothing queries.
+type smoothingRequest struct {
+	Username    string     `json:"username" binding:"required,alphanum"`
+	FileID      int64      `json:"file_id" binding:"required,min=1"`
+	Version     int32      `json:"version" binding:"omitempty,min=1"`
+	Column      *columnRef `json:"column" binding:"required"`
+	Trend       bool       `json:"trend"`
+	Seasonality string     `json:"seasonality" binding:"omitempty,oneof=additive multiplicative"`
+	Period      int        `json:"period" binding:"omitempty,min=2"`
+	Alpha       float64    `json:"alpha" binding:"omitempty,gt=0,lte=1"`
+	Beta        float64    `json:"beta" binding:"omitempty,gt=0,lte=1"`
+	Gamma       float64    `json:"gamma" binding:"omitempty,gt=0,lte=1"`
+	Horizon     int        `json:"horizon" binding:"omitempty,min=1,max=1000"`
+	Confidence  float64    `json:"confidence" binding:"omitempty,gt=0,lt=1"`
+	Missing     string     `json:"missing" binding:"omitempty,oneof=listwise mean median ffill"`
+}
+
+/*
+smoothing smooths a column of one of the user's files, ordered like its rows,
+with exponential smoothing, and forecasts its next values. The endpoint
+expects a GET request with a json body with the following key:
+
+	`username`    - alphanumeric user's username
+	`file_id`     - id of the user's file to analyse.
+	`version`     - optional, version of the file to analyse, defaults to the
+	                file's current version.
+	`column`      - index or name of the column holding the series.
+	`trend`       - optional, add a linear trend, Holt's double exponential
+	                smoothing.
+	`seasonality` - optional, add a seasonal component, `additive` or
+	                `multiplicative`, Holt-Winters' triple exponential
+	                smoothing along with `trend`.
+	`period`      - number of values of a season, required with
+	                `seasonality`.
+	`alpha`       - optional, smoothing parameter of the level, in (0, 1].
+	`beta`        - optional, smoothing parameter of the trend, in (0, 1].
+	`gamma`       - optional, smoothing parameter of the seasonal component,
+	                in (0, 1].
+	`horizon`     - optional, number of values to forecast after the series,
+	                at most 1000.
+	`confidence`  - optional, confidence level of the forecast intervals,
+	                defaults to 0.95.
+	`missing`     - optional, how missing values are handled: `listwise`
+	                (default), `mean`, `median` or `ffill`, see
+	                /analyses/regression.
+
+The smoothing parameters not given are fitted by minimizing the sum of the
+squared one-step errors. The components start from the first two values, or
+from the first two periods with a seasonal component, which need no less
+than 2 * `period` + 1 values. The fitted values are ordered like `rows`, the
+indices in the file of the values of the series, which skip the rows dropped
+for missing values.
+
+The request returns response with the following http status codes:
+
+200 - status OK:
+
+	with response body:
+	    {
+	        "result": {
+	            "method": "*****",
+	            "seasonality": "*****",
+	            "period": *****,
+	            "alpha": *****,
+	            "beta": *****,
+	            "gamma": *****,
+	            "level": *****,
+	            "trend": *****,
+	            "seasonals": [*****],
+	            "fitted": [*****],
+	            "sse": *****,
+	            "rmse": *****,
+	            "confidence": *****,
+	            "forecast": [*****],
+	            "lower": [*****],
+	            "upper": [*****]
+	        },
+	        "name": "*****",
+	        "rows": [*****],
+	        "error":""
+	     }
+
+400 - status Bad Request:
+
+	Error parsing request body, or invalid column.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+401 - status Unauthorized:
+
+	If access token has expired or username in request body don't match username
+	in token payload.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+404 - status Not Found:
+
+	If the user has no file with id `file_id`, or the file has no such
+	version.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+422 - status Unprocessable Entity:
+
+	If the series is too short, `seasonality` and `period` aren't given
+	together, a multiplicative seasonal series has non-positive values, or no
+	data is left after handling missing values.
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+
+501 - status Internal Server Error:
+
+	with response body:
+	    {
+	        "result": null,
+	        "error": "*****"
+	    }
+*/
+func (server *Server) smoothing(ctx *gin.Context) {
+	server.serveAnalysis(ctx, &smoothingRequest{})
+}
+
+func (req *smoothingRequest) owner() string {
+	return req.Username
+}
+
+// run performs the exponential smoothing on the file of user `username` and
+// returns the response body.
+//
+// Returns a non-nil error along with the http status code to respond with if
+// the smoothing can't be performed.
+func (req *smoothingRequest) run(
+	ctx context.Context, server *Server, username string,
+) (any, int, error) {
+	series, name, rows, status, err := server.seriesData(
+		ctx, username, req.FileID, req.Version, *req.Column, req.Missing)
+	if err != nil {
+		return nil, status, err
+	}
+
+	result, err := statsanal.ExponentialSmoothing(
+		series,
+		statsanal.SmoothingOptions{
+			Trend:       req.Trend,
+			Seasonality: statsanal.Seasonality(req.Seasonality),
+			Period:      req.Period,
+			Alpha:       req.Alpha,
+			Beta:        req.Beta,
+			Gamma:       req.Gamma,
+			Horizon:     req.Horizon,
+			Confidence:  req.Confidence,
+		},
+	)
+	if err != nil {
+		return nil, http.StatusUnprocessableEntity,
+			fmt.Errorf("Error during exponential smoothing\n%w", err)
+	}
+
+	return smoothingResp{Result: &result, Name: name, Rows: rows}, http.StatusOK, nil
+}
+
+// seriesData loads `column` of version `version` of file `fileID` of user
+// `username` as a series ordered like the rows, handling its missing values
+// with strategy `missing`. Returns the series along with the column's name
+// and the index in the file of its values.
+//
+// Returns a non-nil error along with the http status code to respond with if
+// the series can't be loaded.
+func (server *Server) seriesData(
+	ctx context.Context, username string, fileID int64, version int32,
+	column columnRef, missing string,
+) ([]float64, string, []int, int, error) {
+	ds, status, err := server.loadDataset(ctx, username, fileID, version)
+	if err != nil {
+		return nil, "", nil, status, err
+	}
+
+	col, err := column.resolve(ds.names)
+	if err != nil {
+		return nil, "", nil, http.StatusBadRequest, err
+	}
+
+	data, rows, err := statsanal.HandleMissing(
+		statsanal.SelectColumns(ds.data, []int{col}),
+		statsanal.MissingStrategy(missing),
+	)
+	if err != nil {
+		return nil, "", nil, http.StatusUnprocessableEntity,
+			fmt.Errorf("Error handling missing values.\n%w", err)
+	}
+
+	return mat.Col(nil, 0, data), ds.names[col], rows, http.StatusOK, nil
+}
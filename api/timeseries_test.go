@@ -0,0 +1,286 @@
+package api
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	gomock "go.uber.org/mock/gomock"
+	"gonum.org/v1/gonum/mat"
+
+	mockdb "github.com/yodeman/analyses-api/dbase/mock"
+	db "github.com/yodeman/analyses-api/dbase/sqlc"
+	"github.com/yodeman/analyses-api/util"
+)
+
+// seriesFile returns a file of 16 monthly readings with a trend and a
+// seasonal pattern of period 4, with a missing value in the second row.
+func seriesFile(t *testing.T, username string) db.File {
+	pattern := []float64{3, -1, -4, 2}
+	m := mat.NewDense(16, 2, nil)
+	for i := 0; i < 16; i++ {
+		m.Set(i, 0, float64(i+1))
+		m.Set(i, 1, 10+0.5*float64(i)+pattern[i%4])
+	}
+	m.Set(1, 1, math.NaN())
+	data, err := m.MarshalBinary()
+	require.NoError(t, err)
+
+	return db.File{
+		ID:          util.RandomInt(1, 1000),
+		Username:    username,
+		Data:        data,
+		ColumnNames: []string{"month", "reading"},
+	}
+}
+
+func TestMovingAverage(t *testing.T) {
+	user, _ := randomUser(t)
+	file := seriesFile(t, user.Username)
+	getFile := func(store *mockdb.MockStore) {
+		store.EXPECT().
+			GetFile(gomock.Any(), gomock.Eq(db.GetFileParams{
+				ID:       file.ID,
+				Username: user.Username,
+			})).
+			Times(1).
+			Return(file, nil)
+	}
+	noFile := func(store *mockdb.MockStore) {
+		store.EXPECT().
+			GetFile(gomock.Any(), gomock.Any()).
+			Times(0)
+	}
+	month, reading := columnName("month"), columnName("reading")
+
+	testCases := []hypothesisCase{
+		{
+			name: "OK",
+			params: movingAverageRequest{
+				Username: user.Username,
+				FileID:   file.ID,
+				Column:   &month,
+				Window:   3,
+				Weights:  []float64{1, 1, 2},
+			},
+			username:   user.Username,
+			buildStubs: getFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var resp movingAverageResp
+				require.NoError(t, json.NewDecoder(recorder.Body).Decode(&resp))
+				require.Empty(t, resp.Error)
+				require.Equal(t, "month", resp.Name)
+				require.Len(t, resp.Rows, 16)
+				require.Nil(t, resp.Result.Values[1])
+				require.InDelta(t, 2.25, *resp.Result.Values[2], 1e-12)
+			},
+		},
+		{
+			name: "MISSING DROPPED",
+			params: movingAverageRequest{
+				Username: user.Username,
+				FileID:   file.ID,
+				Column:   &reading,
+				Window:   4,
+			},
+			username:   user.Username,
+			buildStubs: getFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var resp movingAverageResp
+				require.NoError(t, json.NewDecoder(recorder.Body).Decode(&resp))
+				require.Len(t, resp.Rows, 15)
+				require.Equal(t, 2, resp.Rows[1])
+				require.Len(t, resp.Result.Values, 15)
+			},
+		},
+		{
+			name: "WINDOW TOO LONG",
+			params: movingAverageRequest{
+				Username: user.Username,
+				FileID:   file.ID,
+				Column:   &month,
+				Window:   17,
+			},
+			username:   user.Username,
+			buildStubs: getFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name: "MISSING COLUMN",
+			params: movingAverageRequest{
+				Username: user.Username,
+				FileID:   file.ID,
+				Window:   3,
+			},
+			username:   user.Username,
+			buildStubs: noFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "UNAUTHORIZED",
+			params: movingAverageRequest{
+				Username: user.Username,
+				FileID:   file.ID,
+				Column:   &month,
+				Window:   3,
+			},
+			username:   "deidara",
+			buildStubs: noFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	runHypothesisCases(t, "/analyses/timeseries/movingaverage", testCases)
+}
+
+func TestSmoothing(t *testing.T) {
+	user, _ := randomUser(t)
+	file := seriesFile(t, user.Username)
+	getFile := func(store *mockdb.MockStore) {
+		store.EXPECT().
+			GetFile(gomock.Any(), gomock.Eq(db.GetFileParams{
+				ID:       file.ID,
+				Username: user.Username,
+			})).
+			Times(1).
+			Return(file, nil)
+	}
+	noFile := func(store *mockdb.MockStore) {
+		store.EXPECT().
+			GetFile(gomock.Any(), gomock.Any()).
+			Times(0)
+	}
+	month, reading := columnIndex(0), columnName("reading")
+
+	testCases := []hypothesisCase{
+		{
+			name: "HOLT",
+			params: smoothingRequest{
+				Username: user.Username,
+				FileID:   file.ID,
+				Column:   &month,
+				Trend:    true,
+				Horizon:  2,
+			},
+			username:   user.Username,
+			buildStubs: getFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				resp := requireBodyMatchSmoothing(t, recorder, "double")
+				require.InDeltaSlice(t, []float64{17, 18}, resp.Result.Forecast, 1e-6)
+				require.Len(t, resp.Result.Lower, 2)
+			},
+		},
+		{
+			name: "HOLT WINTERS",
+			params: smoothingRequest{
+				Username:    user.Username,
+				FileID:      file.ID,
+				Column:      &reading,
+				Trend:       true,
+				Seasonality: "additive",
+				Period:      4,
+				Alpha:       0.5,
+				Beta:        0.1,
+				Gamma:       0.1,
+				Horizon:     4,
+				Confidence:  0.8,
+				Missing:     "mean",
+			},
+			username:   user.Username,
+			buildStubs: getFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				resp := requireBodyMatchSmoothing(t, recorder, "holt_winters")
+				require.Equal(t, 0.5, resp.Result.Alpha)
+				require.Equal(t, 0.8, resp.Result.Confidence)
+				require.Len(t, resp.Result.Seasonals, 4)
+				require.Len(t, resp.Result.Fitted, 16)
+			},
+		},
+		{
+			name: "PERIOD WITHOUT SEASONALITY",
+			params: smoothingRequest{
+				Username: user.Username,
+				FileID:   file.ID,
+				Column:   &month,
+				Period:   4,
+			},
+			username:   user.Username,
+			buildStubs: getFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name: "INVALID ALPHA",
+			params: smoothingRequest{
+				Username: user.Username,
+				FileID:   file.ID,
+				Column:   &month,
+				Alpha:    2,
+			},
+			username:   user.Username,
+			buildStubs: noFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "HORIZON TOO LONG",
+			params: smoothingRequest{
+				Username: user.Username,
+				FileID:   file.ID,
+				Column:   &month,
+				Horizon:  1001,
+			},
+			username:   user.Username,
+			buildStubs: noFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "UNKNOWN COLUMN",
+			params: smoothingRequest{
+				Username: user.Username,
+				FileID:   file.ID,
+				Column:   &columnRef{index: 5},
+			},
+			username:   user.Username,
+			buildStubs: getFile,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	runHypothesisCases(t, "/analyses/timeseries/smoothing", testCases)
+}
+
+func requireBodyMatchSmoothing(
+	t *testing.T, recorder *httptest.ResponseRecorder, method string,
+) smoothingResp {
+	var serverResp smoothingResp
+
+	err := json.NewDecoder(recorder.Body).Decode(&serverResp)
+	require.NoError(t, err)
+
+	require.Empty(t, serverResp.Error)
+	require.NotNil(t, serverResp.Result)
+	require.Equal(t, method, serverResp.Result.Method)
+	require.Len(t, serverResp.Result.Fitted, len(serverResp.Rows))
+
+	return serverResp
+}
@@ -102,7 +102,7 @@ func (server *Server) createUser(ctx *gin.Context) {
 		return
 	}
 
-	user, err := server.querier.CreateUser(
+	user, err := server.store.CreateUser(
 		ctx,
 		db.CreateUserParams{
 			Username:       req.Username,
@@ -221,7 +221,7 @@ func (server *Server) loginUser(ctx *gin.Context) {
 		return
 	}
 
-	user, err := server.querier.GetUser(ctx, req.Username)
+	user, err := server.store.GetUser(ctx, req.Username)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			resp.Error = errResponse(fmt.Errorf("User does not exist.\n%w", err))
@@ -62,14 +62,14 @@ func TestCreateUser(t *testing.T) {
 	testCases := []struct {
 		name          string
 		params        createUserRequest
-		buildStubs    func(querier *mockdb.MockQuerier)
+		buildStubs    func(store *mockdb.MockStore)
 		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
 	}{
 		{
 			name:   "OK",
 			params: req,
-			buildStubs: func(querier *mockdb.MockQuerier) {
-				querier.EXPECT().
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
 					CreateUser(
 						gomock.Any(),
 						createUserParamsMatcher{
@@ -91,8 +91,8 @@ func TestCreateUser(t *testing.T) {
 				Email:    user.Email,
 				Password: "123",
 			},
-			buildStubs: func(querier *mockdb.MockQuerier) {
-				querier.EXPECT().
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
 					CreateUser(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
@@ -103,11 +103,11 @@ func TestCreateUser(t *testing.T) {
 		{
 			name:   "DUPLICATE",
 			params: req,
-			buildStubs: func(querier *mockdb.MockQuerier) {
+			buildStubs: func(store *mockdb.MockStore) {
 				err := &pq.Error{
 					Code: "23505", // postgress unique violation error code
 				}
-				querier.EXPECT().
+				store.EXPECT().
 					CreateUser(
 						gomock.Any(),
 						createUserParamsMatcher{
@@ -124,8 +124,8 @@ func TestCreateUser(t *testing.T) {
 		{
 			name:   "INTERNAL ERROR",
 			params: req,
-			buildStubs: func(querier *mockdb.MockQuerier) {
-				querier.EXPECT().
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
 					CreateUser(
 						gomock.Any(),
 						createUserParamsMatcher{
@@ -148,11 +148,11 @@ func TestCreateUser(t *testing.T) {
 			ctrl := gomock.NewController(t)
 			defer ctrl.Finish()
 
-			querier := mockdb.NewMockQuerier(ctrl)
+			store := mockdb.NewMockStore(ctrl)
 			// build stubs
-			tc.buildStubs(querier)
+			tc.buildStubs(store)
 			// start test server and send request
-			server := newTestServer(t, querier)
+			server := newTestServer(t, store)
 			recorder := httptest.NewRecorder()
 
 			url := fmt.Sprint("/users/register")
@@ -179,14 +179,14 @@ func TestLoginUser(t *testing.T) {
 	testCases := []struct {
 		name          string
 		params        loginUserRequest
-		buildStubs    func(querier *mockdb.MockQuerier)
+		buildStubs    func(store *mockdb.MockStore)
 		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
 	}{
 		{
 			name:   "OK",
 			params: req,
-			buildStubs: func(querier *mockdb.MockQuerier) {
-				querier.EXPECT().
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
 					GetUser(gomock.Any(), req.Username).
 					Times(1).
 					Return(user, nil)
@@ -202,8 +202,8 @@ func TestLoginUser(t *testing.T) {
 				Username: user.Username,
 				Password: "123",
 			},
-			buildStubs: func(querier *mockdb.MockQuerier) {
-				querier.EXPECT().
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
 					GetUser(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
@@ -217,8 +217,8 @@ func TestLoginUser(t *testing.T) {
 				Username: user.Username,
 				Password: util.RandomPassword(),
 			},
-			buildStubs: func(querier *mockdb.MockQuerier) {
-				querier.EXPECT().
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
 					GetUser(gomock.Any(), gomock.Eq(user.Username)).
 					Times(1).
 					Return(user, nil)
@@ -230,8 +230,8 @@ func TestLoginUser(t *testing.T) {
 		{
 			name:   "INTERNAL ERROR",
 			params: req,
-			buildStubs: func(querier *mockdb.MockQuerier) {
-				querier.EXPECT().
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
 					GetUser(gomock.Any(), gomock.Eq(user.Username)).
 					Times(1).
 					Return(db.User{}, sql.ErrConnDone)
@@ -243,8 +243,8 @@ func TestLoginUser(t *testing.T) {
 		{
 			name:   "NOT FOUND",
 			params: req,
-			buildStubs: func(querier *mockdb.MockQuerier) {
-				querier.EXPECT().
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
 					GetUser(gomock.Any(), gomock.Eq(user.Username)).
 					Times(1).
 					Return(db.User{}, sql.ErrNoRows)
@@ -262,11 +262,11 @@ func TestLoginUser(t *testing.T) {
 			ctrl := gomock.NewController(t)
 			defer ctrl.Finish()
 
-			querier := mockdb.NewMockQuerier(ctrl)
+			store := mockdb.NewMockStore(ctrl)
 			// build stubs
-			tc.buildStubs(querier)
+			tc.buildStubs(store)
 			// start test server and send request
-			server := newTestServer(t, querier)
+			server := newTestServer(t, store)
 			recorder := httptest.NewRecorder()
 
 			url := fmt.Sprint("/users/login")
@@ -9,13 +9,71 @@ CREATE TABLE "users" (
 CREATE TABLE "files" (
   "id" bigserial PRIMARY KEY,
   "username" varchar NOT NULL,
-  "data" text NOT NULL,
+  "name" varchar NOT NULL,
+  "data" bytea NOT NULL,
+  "column_names" varchar[] NOT NULL DEFAULT '{}',
+  "missing_counts" integer[] NOT NULL DEFAULT '{}',
+  "version" integer NOT NULL DEFAULT 1,
   "changed_at" timestamptz NOT NULL DEFAULT (now()),
   "created_at" timestamptz NOT NULL DEFAULT (now())
 );
 
-CREATE UNIQUE INDEX ON "files" ("username");
+CREATE TABLE "file_versions" (
+  "id" bigserial PRIMARY KEY,
+  "file_id" bigint NOT NULL,
+  "version" integer NOT NULL,
+  "data" bytea NOT NULL,
+  "column_names" varchar[] NOT NULL DEFAULT '{}',
+  "missing_counts" integer[] NOT NULL DEFAULT '{}',
+  "rows" integer NOT NULL,
+  "cols" integer NOT NULL,
+  "hash" varchar NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "jobs" (
+  "id" bigserial PRIMARY KEY,
+  "username" varchar NOT NULL,
+  "kind" varchar NOT NULL,
+  "params" jsonb NOT NULL,
+  "status" varchar NOT NULL DEFAULT 'queued',
+  "result" jsonb NOT NULL DEFAULT 'null',
+  "error" varchar NOT NULL DEFAULT '',
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "started_at" timestamptz,
+  "finished_at" timestamptz
+);
+
+CREATE TABLE "models" (
+  "id" bigserial PRIMARY KEY,
+  "username" varchar NOT NULL,
+  "kind" varchar NOT NULL,
+  "file_id" bigint,
+  "file_version" integer NOT NULL,
+  "target" varchar NOT NULL,
+  "predictors" varchar[] NOT NULL,
+  "model" jsonb NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE UNIQUE INDEX ON "files" ("username", "name");
+
+CREATE UNIQUE INDEX ON "file_versions" ("file_id", "version");
+
+CREATE INDEX ON "jobs" ("username");
+
+CREATE INDEX ON "jobs" ("status", "id");
+
+CREATE INDEX ON "models" ("username");
 
 ALTER TABLE "users" ADD CONSTRAINT "user_email_constraint" UNIQUE ("username", "email");
 
 ALTER TABLE "files" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+ALTER TABLE "file_versions" ADD FOREIGN KEY ("file_id") REFERENCES "files" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "jobs" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+ALTER TABLE "models" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+ALTER TABLE "models" ADD FOREIGN KEY ("file_id") REFERENCES "files" ("id") ON DELETE SET NULL;
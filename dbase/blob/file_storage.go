@@ -0,0 +1,93 @@
+package blob
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// filePrefix starts the references to data kept on the filesystem.
+var filePrefix = []byte("fs:")
+
+// FileStorage keeps the data, optionally gzip compressed, in files of a
+// local directory. Files are named after the hash of their content, so
+// identical uploads share a file and files are never overwritten.
+type FileStorage struct {
+	dir      string
+	compress bool
+}
+
+// NewFileStorage creates a storage in directory `dir`, creating the directory
+// if needed.
+func NewFileStorage(dir string, compress bool) (*FileStorage, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("Blob directory is required for the %s storage.", FileSystem)
+	}
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("Error creating blob directory.\n%w", err)
+	}
+	return &FileStorage{dir: dir, compress: compress}, nil
+}
+
+func (s *FileStorage) Put(ctx context.Context, data []byte) ([]byte, error) {
+	var err error
+	if s.compress {
+		data, err = compress(data)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sum := sha256.Sum256(data)
+	name := hex.EncodeToString(sum[:])
+	path := filepath.Join(s.dir, name)
+
+	if _, err = os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+		// write to a temporary file first so readers never see partial data.
+		tmp, err := os.CreateTemp(s.dir, name+".tmp*")
+		if err != nil {
+			return nil, fmt.Errorf("Error creating blob file.\n%w", err)
+		}
+		defer os.Remove(tmp.Name())
+
+		if _, err = tmp.Write(data); err != nil {
+			tmp.Close()
+			return nil, fmt.Errorf("Error writing blob file.\n%w", err)
+		}
+		if err = tmp.Close(); err != nil {
+			return nil, fmt.Errorf("Error writing blob file.\n%w", err)
+		}
+		if err = os.Rename(tmp.Name(), path); err != nil {
+			return nil, fmt.Errorf("Error writing blob file.\n%w", err)
+		}
+	} else if err != nil {
+		return nil, fmt.Errorf("Error checking blob file.\n%w", err)
+	}
+
+	return append(append([]byte{}, filePrefix...), name...), nil
+}
+
+// Get reads the data referenced by `ref`. Data kept inline, e.g. stored
+// before switching to the filesystem storage, is returned as is.
+func (s *FileStorage) Get(ctx context.Context, ref []byte) ([]byte, error) {
+	if !bytes.HasPrefix(ref, filePrefix) {
+		return decompress(ref)
+	}
+
+	name := string(ref[len(filePrefix):])
+	if filepath.Base(name) != name {
+		return nil, fmt.Errorf("Invalid blob reference %q.", ref)
+	}
+
+	data, err := os.ReadFile(filepath.Join(s.dir, name))
+	if err != nil {
+		return nil, fmt.Errorf("Error reading blob file.\n%w", err)
+	}
+	return decompress(data)
+}
@@ -0,0 +1,102 @@
+// Package blob keeps the binary data of the users' datasets, either inline in
+// the database rows or on the local filesystem with the rows only holding a
+// reference to the data.
+package blob
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+)
+
+const (
+	// Inline keeps the data in the database rows.
+	Inline = "postgres"
+	// FileSystem keeps the data in files of a local directory.
+	FileSystem = "fs"
+)
+
+// gzipMagic starts every gzip stream, while stored matrices start with
+// their format version.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// Storage stores binary data. Put returns the value kept in the database
+// row, which is handed back to Get to read the data.
+type Storage interface {
+	Put(ctx context.Context, data []byte) (ref []byte, err error)
+	Get(ctx context.Context, ref []byte) ([]byte, error)
+}
+
+// NewStorage creates the storage of the given `kind`, Inline or FileSystem,
+// compressing the data when `compress` is set. `dir` is the directory of the
+// FileSystem storage.
+//
+// Returns an error for an unknown kind.
+func NewStorage(kind, dir string, compress bool) (Storage, error) {
+	switch kind {
+	case "", Inline:
+		return &InlineStorage{Compress: compress}, nil
+	case FileSystem:
+		return NewFileStorage(dir, compress)
+	default:
+		return nil, fmt.Errorf("Unknown blob storage %q, expected %s or %s.",
+			kind, Inline, FileSystem)
+	}
+}
+
+// InlineStorage keeps the data, optionally gzip compressed, in the database
+// rows.
+type InlineStorage struct {
+	Compress bool
+}
+
+func (s *InlineStorage) Put(ctx context.Context, data []byte) ([]byte, error) {
+	if !s.Compress {
+		return data, nil
+	}
+	return compress(data)
+}
+
+// Get returns the data of `ref`, which is decompressed if needed, so rows
+// stored with or without compression can be read alike.
+func (s *InlineStorage) Get(ctx context.Context, ref []byte) ([]byte, error) {
+	if bytes.HasPrefix(ref, filePrefix) {
+		return nil, fmt.Errorf("Data is stored on the filesystem.")
+	}
+	return decompress(ref)
+}
+
+// compress returns the gzip compressed `data`.
+func compress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		return nil, fmt.Errorf("Error compressing data.\n%w", err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("Error compressing data.\n%w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// decompress returns `data` uncompressed if it is gzip compressed, or as is.
+func decompress(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, gzipMagic) {
+		return data, nil
+	}
+
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("Error decompressing data.\n%w", err)
+	}
+	defer r.Close()
+
+	res, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("Error decompressing data.\n%w", err)
+	}
+	return res, nil
+}
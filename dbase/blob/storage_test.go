@@ -0,0 +1,81 @@
+package blob
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/yodeman/analyses-api/util"
+)
+
+func TestInlineStorage(t *testing.T) {
+	data, err := util.RandomData()
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	for _, compressed := range []bool{false, true} {
+		storage, err := NewStorage(Inline, "", compressed)
+		require.NoError(t, err)
+
+		ref, err := storage.Put(ctx, data)
+		require.NoError(t, err)
+		if compressed {
+			require.NotEqual(t, data, ref)
+		} else {
+			require.Equal(t, data, ref)
+		}
+
+		res, err := storage.Get(ctx, ref)
+		require.NoError(t, err)
+		require.Equal(t, data, res)
+	}
+
+	_, err = (&InlineStorage{}).Get(ctx, []byte("fs:abc"))
+	require.Error(t, err)
+}
+
+func TestFileStorage(t *testing.T) {
+	data, err := util.RandomData()
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	dir := t.TempDir()
+	storage, err := NewStorage(FileSystem, dir, true)
+	require.NoError(t, err)
+
+	ref, err := storage.Put(ctx, data)
+	require.NoError(t, err)
+	require.Contains(t, string(ref), "fs:")
+
+	res, err := storage.Get(ctx, ref)
+	require.NoError(t, err)
+	require.Equal(t, data, res)
+
+	// identical data share a file.
+	again, err := storage.Put(ctx, data)
+	require.NoError(t, err)
+	require.Equal(t, ref, again)
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+
+	// data stored inline before switching storage is still readable.
+	res, err = storage.Get(ctx, data)
+	require.NoError(t, err)
+	require.Equal(t, data, res)
+
+	_, err = storage.Get(ctx, []byte("fs:../secret"))
+	require.Error(t, err)
+	_, err = storage.Get(ctx, []byte("fs:missing"))
+	require.Error(t, err)
+}
+
+func TestNewStorage(t *testing.T) {
+	_, err := NewStorage("s3", "", false)
+	require.Error(t, err)
+
+	_, err = NewStorage(FileSystem, "", false)
+	require.Error(t, err)
+}
@@ -0,0 +1 @@
+ALTER TABLE "files" ADD COLUMN "column_names" varchar[] NOT NULL DEFAULT '{}';
@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS "files_username_name_idx";
+
+-- keep the most recent file of each user.
+DELETE FROM "files" f
+USING "files" newer
+WHERE f.username = newer.username AND f.id < newer.id;
+
+ALTER TABLE "files" DROP COLUMN IF EXISTS "name";
+
+CREATE UNIQUE INDEX ON "files" ("username");
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS "files_username_idx";
+
+ALTER TABLE "files" ADD COLUMN "name" varchar NOT NULL DEFAULT 'default';
+ALTER TABLE "files" ALTER COLUMN "name" DROP DEFAULT;
+
+CREATE UNIQUE INDEX ON "files" ("username", "name");
@@ -0,0 +1,3 @@
+ALTER TABLE "files" DROP COLUMN IF EXISTS "version";
+
+DROP TABLE IF EXISTS "file_versions";
@@ -0,0 +1,35 @@
+CREATE TABLE "file_versions" (
+  "id" bigserial PRIMARY KEY,
+  "file_id" bigint NOT NULL,
+  "version" integer NOT NULL,
+  "data" text NOT NULL,
+  "column_names" varchar[] NOT NULL DEFAULT '{}',
+  "missing_counts" integer[] NOT NULL DEFAULT '{}',
+  "rows" integer NOT NULL,
+  "cols" integer NOT NULL,
+  "hash" varchar NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE UNIQUE INDEX ON "file_versions" ("file_id", "version");
+
+ALTER TABLE "file_versions" ADD FOREIGN KEY ("file_id") REFERENCES "files" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "files" ADD COLUMN "version" integer NOT NULL DEFAULT 1;
+
+-- existing files become their first version. The data is a base64 encoded
+-- gonum matrix whose little-endian header holds the number of rows at bytes
+-- 8 to 15 and the number of columns at bytes 16 to 23.
+INSERT INTO "file_versions" (
+  "file_id", "version", "data", "column_names", "missing_counts",
+  "rows", "cols", "hash", "created_at"
+)
+SELECT
+  "id", 1, "data", "column_names", "missing_counts",
+  get_byte(raw, 8) + (get_byte(raw, 9) << 8)
+    + (get_byte(raw, 10) << 16) + (get_byte(raw, 11) << 24),
+  get_byte(raw, 16) + (get_byte(raw, 17) << 8)
+    + (get_byte(raw, 18) << 16) + (get_byte(raw, 19) << 24),
+  encode(sha256(raw), 'hex'),
+  "changed_at"
+FROM (SELECT *, decode("data", 'base64') AS raw FROM "files") f;
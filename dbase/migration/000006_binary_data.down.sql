@@ -0,0 +1,3 @@
+-- only data stored inline without compression can be read back after this.
+ALTER TABLE "files" ALTER COLUMN "data" TYPE text USING encode("data", 'base64');
+ALTER TABLE "file_versions" ALTER COLUMN "data" TYPE text USING encode("data", 'base64');
@@ -0,0 +1,3 @@
+-- the data was a base64 encoded gonum matrix, decode it in place.
+ALTER TABLE "files" ALTER COLUMN "data" TYPE bytea USING decode("data", 'base64');
+ALTER TABLE "file_versions" ALTER COLUMN "data" TYPE bytea USING decode("data", 'base64');
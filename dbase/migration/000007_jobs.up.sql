@@ -0,0 +1,18 @@
+CREATE TABLE "jobs" (
+  "id" bigserial PRIMARY KEY,
+  "username" varchar NOT NULL,
+  "kind" varchar NOT NULL,
+  "params" jsonb NOT NULL,
+  "status" varchar NOT NULL DEFAULT 'queued',
+  "result" jsonb NOT NULL DEFAULT 'null',
+  "error" varchar NOT NULL DEFAULT '',
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "started_at" timestamptz,
+  "finished_at" timestamptz
+);
+
+CREATE INDEX ON "jobs" ("username");
+
+CREATE INDEX ON "jobs" ("status", "id");
+
+ALTER TABLE "jobs" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
@@ -0,0 +1,17 @@
+CREATE TABLE "models" (
+  "id" bigserial PRIMARY KEY,
+  "username" varchar NOT NULL,
+  "kind" varchar NOT NULL,
+  "file_id" bigint,
+  "file_version" integer NOT NULL,
+  "target" varchar NOT NULL,
+  "predictors" varchar[] NOT NULL,
+  "model" jsonb NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "models" ("username");
+
+ALTER TABLE "models" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+ALTER TABLE "models" ADD FOREIGN KEY ("file_id") REFERENCES "files" ("id") ON DELETE SET NULL;
@@ -1,9 +1,9 @@
 // Code generated by MockGen. DO NOT EDIT.
-// Source: github.com/yodeman/analyses-api/dbase/sqlc (interfaces: Querier)
+// Source: github.com/yodeman/analyses-api/dbase/sqlc (interfaces: Querier,Store)
 //
 // Generated by this command:
 //
-//	mockgen -package mockdb -destination dbase/mock/store.go github.com/yodeman/analyses-api/dbase/sqlc Querier
+//	mockgen -package mockdb -destination dbase/mock/store.go github.com/yodeman/analyses-api/dbase/sqlc Querier,Store
 //
 // Package mockdb is a generated GoMock package.
 package mockdb
@@ -39,6 +39,36 @@ func (m *MockQuerier) EXPECT() *MockQuerierMockRecorder {
 	return m.recorder
 }
 
+// CancelJob mocks base method.
+func (m *MockQuerier) CancelJob(arg0 context.Context, arg1 db.CancelJobParams) (db.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelJob", arg0, arg1)
+	ret0, _ := ret[0].(db.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CancelJob indicates an expected call of CancelJob.
+func (mr *MockQuerierMockRecorder) CancelJob(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelJob", reflect.TypeOf((*MockQuerier)(nil).CancelJob), arg0, arg1)
+}
+
+// ClaimJob mocks base method.
+func (m *MockQuerier) ClaimJob(arg0 context.Context) (db.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimJob", arg0)
+	ret0, _ := ret[0].(db.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimJob indicates an expected call of ClaimJob.
+func (mr *MockQuerierMockRecorder) ClaimJob(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimJob", reflect.TypeOf((*MockQuerier)(nil).ClaimJob), arg0)
+}
+
 // CreateFile mocks base method.
 func (m *MockQuerier) CreateFile(arg0 context.Context, arg1 db.CreateFileParams) (db.File, error) {
 	m.ctrl.T.Helper()
@@ -54,6 +84,51 @@ func (mr *MockQuerierMockRecorder) CreateFile(arg0, arg1 any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateFile", reflect.TypeOf((*MockQuerier)(nil).CreateFile), arg0, arg1)
 }
 
+// CreateFileVersion mocks base method.
+func (m *MockQuerier) CreateFileVersion(arg0 context.Context, arg1 db.CreateFileVersionParams) (db.FileVersion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateFileVersion", arg0, arg1)
+	ret0, _ := ret[0].(db.FileVersion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateFileVersion indicates an expected call of CreateFileVersion.
+func (mr *MockQuerierMockRecorder) CreateFileVersion(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateFileVersion", reflect.TypeOf((*MockQuerier)(nil).CreateFileVersion), arg0, arg1)
+}
+
+// CreateJob mocks base method.
+func (m *MockQuerier) CreateJob(arg0 context.Context, arg1 db.CreateJobParams) (db.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateJob", arg0, arg1)
+	ret0, _ := ret[0].(db.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateJob indicates an expected call of CreateJob.
+func (mr *MockQuerierMockRecorder) CreateJob(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateJob", reflect.TypeOf((*MockQuerier)(nil).CreateJob), arg0, arg1)
+}
+
+// CreateModel mocks base method.
+func (m *MockQuerier) CreateModel(arg0 context.Context, arg1 db.CreateModelParams) (db.Model, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateModel", arg0, arg1)
+	ret0, _ := ret[0].(db.Model)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateModel indicates an expected call of CreateModel.
+func (mr *MockQuerierMockRecorder) CreateModel(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateModel", reflect.TypeOf((*MockQuerier)(nil).CreateModel), arg0, arg1)
+}
+
 // CreateUser mocks base method.
 func (m *MockQuerier) CreateUser(arg0 context.Context, arg1 db.CreateUserParams) (db.User, error) {
 	m.ctrl.T.Helper()
@@ -69,8 +144,53 @@ func (mr *MockQuerierMockRecorder) CreateUser(arg0, arg1 any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockQuerier)(nil).CreateUser), arg0, arg1)
 }
 
+// DeleteFile mocks base method.
+func (m *MockQuerier) DeleteFile(arg0 context.Context, arg1 db.DeleteFileParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteFile", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteFile indicates an expected call of DeleteFile.
+func (mr *MockQuerierMockRecorder) DeleteFile(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteFile", reflect.TypeOf((*MockQuerier)(nil).DeleteFile), arg0, arg1)
+}
+
+// DeleteModel mocks base method.
+func (m *MockQuerier) DeleteModel(arg0 context.Context, arg1 db.DeleteModelParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteModel", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteModel indicates an expected call of DeleteModel.
+func (mr *MockQuerierMockRecorder) DeleteModel(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteModel", reflect.TypeOf((*MockQuerier)(nil).DeleteModel), arg0, arg1)
+}
+
+// FinishJob mocks base method.
+func (m *MockQuerier) FinishJob(arg0 context.Context, arg1 db.FinishJobParams) (db.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FinishJob", arg0, arg1)
+	ret0, _ := ret[0].(db.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FinishJob indicates an expected call of FinishJob.
+func (mr *MockQuerierMockRecorder) FinishJob(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FinishJob", reflect.TypeOf((*MockQuerier)(nil).FinishJob), arg0, arg1)
+}
+
 // GetFile mocks base method.
-func (m *MockQuerier) GetFile(arg0 context.Context, arg1 string) (db.File, error) {
+func (m *MockQuerier) GetFile(arg0 context.Context, arg1 db.GetFileParams) (db.File, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetFile", arg0, arg1)
 	ret0, _ := ret[0].(db.File)
@@ -84,6 +204,66 @@ func (mr *MockQuerierMockRecorder) GetFile(arg0, arg1 any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFile", reflect.TypeOf((*MockQuerier)(nil).GetFile), arg0, arg1)
 }
 
+// GetFileForUpdate mocks base method.
+func (m *MockQuerier) GetFileForUpdate(arg0 context.Context, arg1 db.GetFileForUpdateParams) (db.File, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFileForUpdate", arg0, arg1)
+	ret0, _ := ret[0].(db.File)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFileForUpdate indicates an expected call of GetFileForUpdate.
+func (mr *MockQuerierMockRecorder) GetFileForUpdate(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFileForUpdate", reflect.TypeOf((*MockQuerier)(nil).GetFileForUpdate), arg0, arg1)
+}
+
+// GetFileVersion mocks base method.
+func (m *MockQuerier) GetFileVersion(arg0 context.Context, arg1 db.GetFileVersionParams) (db.FileVersion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFileVersion", arg0, arg1)
+	ret0, _ := ret[0].(db.FileVersion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFileVersion indicates an expected call of GetFileVersion.
+func (mr *MockQuerierMockRecorder) GetFileVersion(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFileVersion", reflect.TypeOf((*MockQuerier)(nil).GetFileVersion), arg0, arg1)
+}
+
+// GetJob mocks base method.
+func (m *MockQuerier) GetJob(arg0 context.Context, arg1 db.GetJobParams) (db.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetJob", arg0, arg1)
+	ret0, _ := ret[0].(db.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetJob indicates an expected call of GetJob.
+func (mr *MockQuerierMockRecorder) GetJob(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetJob", reflect.TypeOf((*MockQuerier)(nil).GetJob), arg0, arg1)
+}
+
+// GetModel mocks base method.
+func (m *MockQuerier) GetModel(arg0 context.Context, arg1 db.GetModelParams) (db.Model, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetModel", arg0, arg1)
+	ret0, _ := ret[0].(db.Model)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetModel indicates an expected call of GetModel.
+func (mr *MockQuerierMockRecorder) GetModel(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetModel", reflect.TypeOf((*MockQuerier)(nil).GetModel), arg0, arg1)
+}
+
 // GetUser mocks base method.
 func (m *MockQuerier) GetUser(arg0 context.Context, arg1 string) (db.User, error) {
 	m.ctrl.T.Helper()
@@ -99,6 +279,66 @@ func (mr *MockQuerierMockRecorder) GetUser(arg0, arg1 any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockQuerier)(nil).GetUser), arg0, arg1)
 }
 
+// ListFileVersions mocks base method.
+func (m *MockQuerier) ListFileVersions(arg0 context.Context, arg1 db.ListFileVersionsParams) ([]db.ListFileVersionsRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListFileVersions", arg0, arg1)
+	ret0, _ := ret[0].([]db.ListFileVersionsRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListFileVersions indicates an expected call of ListFileVersions.
+func (mr *MockQuerierMockRecorder) ListFileVersions(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListFileVersions", reflect.TypeOf((*MockQuerier)(nil).ListFileVersions), arg0, arg1)
+}
+
+// ListFiles mocks base method.
+func (m *MockQuerier) ListFiles(arg0 context.Context, arg1 db.ListFilesParams) ([]db.ListFilesRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListFiles", arg0, arg1)
+	ret0, _ := ret[0].([]db.ListFilesRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListFiles indicates an expected call of ListFiles.
+func (mr *MockQuerierMockRecorder) ListFiles(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListFiles", reflect.TypeOf((*MockQuerier)(nil).ListFiles), arg0, arg1)
+}
+
+// ListModels mocks base method.
+func (m *MockQuerier) ListModels(arg0 context.Context, arg1 db.ListModelsParams) ([]db.ListModelsRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListModels", arg0, arg1)
+	ret0, _ := ret[0].([]db.ListModelsRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListModels indicates an expected call of ListModels.
+func (mr *MockQuerierMockRecorder) ListModels(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListModels", reflect.TypeOf((*MockQuerier)(nil).ListModels), arg0, arg1)
+}
+
+// RequeueRunningJobs mocks base method.
+func (m *MockQuerier) RequeueRunningJobs(arg0 context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RequeueRunningJobs", arg0)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RequeueRunningJobs indicates an expected call of RequeueRunningJobs.
+func (mr *MockQuerierMockRecorder) RequeueRunningJobs(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RequeueRunningJobs", reflect.TypeOf((*MockQuerier)(nil).RequeueRunningJobs), arg0)
+}
+
 // UpdateFile mocks base method.
 func (m *MockQuerier) UpdateFile(arg0 context.Context, arg1 db.UpdateFileParams) (db.File, error) {
 	m.ctrl.T.Helper()
@@ -113,3 +353,416 @@ func (mr *MockQuerierMockRecorder) UpdateFile(arg0, arg1 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateFile", reflect.TypeOf((*MockQuerier)(nil).UpdateFile), arg0, arg1)
 }
+
+// UpdateFileName mocks base method.
+func (m *MockQuerier) UpdateFileName(arg0 context.Context, arg1 db.UpdateFileNameParams) (db.File, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateFileName", arg0, arg1)
+	ret0, _ := ret[0].(db.File)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateFileName indicates an expected call of UpdateFileName.
+func (mr *MockQuerierMockRecorder) UpdateFileName(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateFileName", reflect.TypeOf((*MockQuerier)(nil).UpdateFileName), arg0, arg1)
+}
+
+// MockStore is a mock of Store interface.
+type MockStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockStoreMockRecorder
+}
+
+// MockStoreMockRecorder is the mock recorder for MockStore.
+type MockStoreMockRecorder struct {
+	mock *MockStore
+}
+
+// NewMockStore creates a new mock instance.
+func NewMockStore(ctrl *gomock.Controller) *MockStore {
+	mock := &MockStore{ctrl: ctrl}
+	mock.recorder = &MockStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockStore) EXPECT() *MockStoreMockRecorder {
+	return m.recorder
+}
+
+// CancelJob mocks base method.
+func (m *MockStore) CancelJob(arg0 context.Context, arg1 db.CancelJobParams) (db.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelJob", arg0, arg1)
+	ret0, _ := ret[0].(db.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CancelJob indicates an expected call of CancelJob.
+func (mr *MockStoreMockRecorder) CancelJob(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelJob", reflect.TypeOf((*MockStore)(nil).CancelJob), arg0, arg1)
+}
+
+// ClaimJob mocks base method.
+func (m *MockStore) ClaimJob(arg0 context.Context) (db.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimJob", arg0)
+	ret0, _ := ret[0].(db.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimJob indicates an expected call of ClaimJob.
+func (mr *MockStoreMockRecorder) ClaimJob(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimJob", reflect.TypeOf((*MockStore)(nil).ClaimJob), arg0)
+}
+
+// CreateFile mocks base method.
+func (m *MockStore) CreateFile(arg0 context.Context, arg1 db.CreateFileParams) (db.File, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateFile", arg0, arg1)
+	ret0, _ := ret[0].(db.File)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateFile indicates an expected call of CreateFile.
+func (mr *MockStoreMockRecorder) CreateFile(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateFile", reflect.TypeOf((*MockStore)(nil).CreateFile), arg0, arg1)
+}
+
+// CreateFileTx mocks base method.
+func (m *MockStore) CreateFileTx(arg0 context.Context, arg1 db.CreateFileTxParams) (db.FileTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateFileTx", arg0, arg1)
+	ret0, _ := ret[0].(db.FileTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateFileTx indicates an expected call of CreateFileTx.
+func (mr *MockStoreMockRecorder) CreateFileTx(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateFileTx", reflect.TypeOf((*MockStore)(nil).CreateFileTx), arg0, arg1)
+}
+
+// CreateFileVersion mocks base method.
+func (m *MockStore) CreateFileVersion(arg0 context.Context, arg1 db.CreateFileVersionParams) (db.FileVersion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateFileVersion", arg0, arg1)
+	ret0, _ := ret[0].(db.FileVersion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateFileVersion indicates an expected call of CreateFileVersion.
+func (mr *MockStoreMockRecorder) CreateFileVersion(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateFileVersion", reflect.TypeOf((*MockStore)(nil).CreateFileVersion), arg0, arg1)
+}
+
+// CreateJob mocks base method.
+func (m *MockStore) CreateJob(arg0 context.Context, arg1 db.CreateJobParams) (db.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateJob", arg0, arg1)
+	ret0, _ := ret[0].(db.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateJob indicates an expected call of CreateJob.
+func (mr *MockStoreMockRecorder) CreateJob(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateJob", reflect.TypeOf((*MockStore)(nil).CreateJob), arg0, arg1)
+}
+
+// CreateModel mocks base method.
+func (m *MockStore) CreateModel(arg0 context.Context, arg1 db.CreateModelParams) (db.Model, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateModel", arg0, arg1)
+	ret0, _ := ret[0].(db.Model)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateModel indicates an expected call of CreateModel.
+func (mr *MockStoreMockRecorder) CreateModel(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateModel", reflect.TypeOf((*MockStore)(nil).CreateModel), arg0, arg1)
+}
+
+// CreateUser mocks base method.
+func (m *MockStore) CreateUser(arg0 context.Context, arg1 db.CreateUserParams) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUser", arg0, arg1)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateUser indicates an expected call of CreateUser.
+func (mr *MockStoreMockRecorder) CreateUser(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockStore)(nil).CreateUser), arg0, arg1)
+}
+
+// DeleteFile mocks base method.
+func (m *MockStore) DeleteFile(arg0 context.Context, arg1 db.DeleteFileParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteFile", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteFile indicates an expected call of DeleteFile.
+func (mr *MockStoreMockRecorder) DeleteFile(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteFile", reflect.TypeOf((*MockStore)(nil).DeleteFile), arg0, arg1)
+}
+
+// DeleteModel mocks base method.
+func (m *MockStore) DeleteModel(arg0 context.Context, arg1 db.DeleteModelParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteModel", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteModel indicates an expected call of DeleteModel.
+func (mr *MockStoreMockRecorder) DeleteModel(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteModel", reflect.TypeOf((*MockStore)(nil).DeleteModel), arg0, arg1)
+}
+
+// FinishJob mocks base method.
+func (m *MockStore) FinishJob(arg0 context.Context, arg1 db.FinishJobParams) (db.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FinishJob", arg0, arg1)
+	ret0, _ := ret[0].(db.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FinishJob indicates an expected call of FinishJob.
+func (mr *MockStoreMockRecorder) FinishJob(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FinishJob", reflect.TypeOf((*MockStore)(nil).FinishJob), arg0, arg1)
+}
+
+// GetFile mocks base method.
+func (m *MockStore) GetFile(arg0 context.Context, arg1 db.GetFileParams) (db.File, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFile", arg0, arg1)
+	ret0, _ := ret[0].(db.File)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFile indicates an expected call of GetFile.
+func (mr *MockStoreMockRecorder) GetFile(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFile", reflect.TypeOf((*MockStore)(nil).GetFile), arg0, arg1)
+}
+
+// GetFileForUpdate mocks base method.
+func (m *MockStore) GetFileForUpdate(arg0 context.Context, arg1 db.GetFileForUpdateParams) (db.File, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFileForUpdate", arg0, arg1)
+	ret0, _ := ret[0].(db.File)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFileForUpdate indicates an expected call of GetFileForUpdate.
+func (mr *MockStoreMockRecorder) GetFileForUpdate(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFileForUpdate", reflect.TypeOf((*MockStore)(nil).GetFileForUpdate), arg0, arg1)
+}
+
+// GetFileVersion mocks base method.
+func (m *MockStore) GetFileVersion(arg0 context.Context, arg1 db.GetFileVersionParams) (db.FileVersion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFileVersion", arg0, arg1)
+	ret0, _ := ret[0].(db.FileVersion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFileVersion indicates an expected call of GetFileVersion.
+func (mr *MockStoreMockRecorder) GetFileVersion(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFileVersion", reflect.TypeOf((*MockStore)(nil).GetFileVersion), arg0, arg1)
+}
+
+// GetJob mocks base method.
+func (m *MockStore) GetJob(arg0 context.Context, arg1 db.GetJobParams) (db.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetJob", arg0, arg1)
+	ret0, _ := ret[0].(db.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetJob indicates an expected call of GetJob.
+func (mr *MockStoreMockRecorder) GetJob(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetJob", reflect.TypeOf((*MockStore)(nil).GetJob), arg0, arg1)
+}
+
+// GetModel mocks base method.
+func (m *MockStore) GetModel(arg0 context.Context, arg1 db.GetModelParams) (db.Model, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetModel", arg0, arg1)
+	ret0, _ := ret[0].(db.Model)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetModel indicates an expected call of GetModel.
+func (mr *MockStoreMockRecorder) GetModel(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetModel", reflect.TypeOf((*MockStore)(nil).GetModel), arg0, arg1)
+}
+
+// GetUser mocks base method.
+func (m *MockStore) GetUser(arg0 context.Context, arg1 string) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUser", arg0, arg1)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUser indicates an expected call of GetUser.
+func (mr *MockStoreMockRecorder) GetUser(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockStore)(nil).GetUser), arg0, arg1)
+}
+
+// ListFileVersions mocks base method.
+func (m *MockStore) ListFileVersions(arg0 context.Context, arg1 db.ListFileVersionsParams) ([]db.ListFileVersionsRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListFileVersions", arg0, arg1)
+	ret0, _ := ret[0].([]db.ListFileVersionsRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListFileVersions indicates an expected call of ListFileVersions.
+func (mr *MockStoreMockRecorder) ListFileVersions(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListFileVersions", reflect.TypeOf((*MockStore)(nil).ListFileVersions), arg0, arg1)
+}
+
+// ListFiles mocks base method.
+func (m *MockStore) ListFiles(arg0 context.Context, arg1 db.ListFilesParams) ([]db.ListFilesRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListFiles", arg0, arg1)
+	ret0, _ := ret[0].([]db.ListFilesRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListFiles indicates an expected call of ListFiles.
+func (mr *MockStoreMockRecorder) ListFiles(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListFiles", reflect.TypeOf((*MockStore)(nil).ListFiles), arg0, arg1)
+}
+
+// ListModels mocks base method.
+func (m *MockStore) ListModels(arg0 context.Context, arg1 db.ListModelsParams) ([]db.ListModelsRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListModels", arg0, arg1)
+	ret0, _ := ret[0].([]db.ListModelsRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListModels indicates an expected call of ListModels.
+func (mr *MockStoreMockRecorder) ListModels(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListModels", reflect.TypeOf((*MockStore)(nil).ListModels), arg0, arg1)
+}
+
+// RequeueRunningJobs mocks base method.
+func (m *MockStore) RequeueRunningJobs(arg0 context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RequeueRunningJobs", arg0)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RequeueRunningJobs indicates an expected call of RequeueRunningJobs.
+func (mr *MockStoreMockRecorder) RequeueRunningJobs(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RequeueRunningJobs", reflect.TypeOf((*MockStore)(nil).RequeueRunningJobs), arg0)
+}
+
+// RollbackFileTx mocks base method.
+func (m *MockStore) RollbackFileTx(arg0 context.Context, arg1 db.RollbackFileTxParams) (db.FileTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RollbackFileTx", arg0, arg1)
+	ret0, _ := ret[0].(db.FileTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RollbackFileTx indicates an expected call of RollbackFileTx.
+func (mr *MockStoreMockRecorder) RollbackFileTx(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RollbackFileTx", reflect.TypeOf((*MockStore)(nil).RollbackFileTx), arg0, arg1)
+}
+
+// UpdateFile mocks base method.
+func (m *MockStore) UpdateFile(arg0 context.Context, arg1 db.UpdateFileParams) (db.File, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateFile", arg0, arg1)
+	ret0, _ := ret[0].(db.File)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateFile indicates an expected call of UpdateFile.
+func (mr *MockStoreMockRecorder) UpdateFile(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateFile", reflect.TypeOf((*MockStore)(nil).UpdateFile), arg0, arg1)
+}
+
+// UpdateFileName mocks base method.
+func (m *MockStore) UpdateFileName(arg0 context.Context, arg1 db.UpdateFileNameParams) (db.File, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateFileName", arg0, arg1)
+	ret0, _ := ret[0].(db.File)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateFileName indicates an expected call of UpdateFileName.
+func (mr *MockStoreMockRecorder) UpdateFileName(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateFileName", reflect.TypeOf((*MockStore)(nil).UpdateFileName), arg0, arg1)
+}
+
+// UpdateFileTx mocks base method.
+func (m *MockStore) UpdateFileTx(arg0 context.Context, arg1 db.UpdateFileTxParams) (db.FileTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateFileTx", arg0, arg1)
+	ret0, _ := ret[0].(db.FileTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateFileTx indicates an expected call of UpdateFileTx.
+func (mr *MockStoreMockRecorder) UpdateFileTx(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateFileTx", reflect.TypeOf((*MockStore)(nil).UpdateFileTx), arg0, arg1)
+}
@@ -0,0 +1,32 @@
+-- name: CreateFileVersion :one
+INSERT INTO file_versions (
+    file_id,
+    version,
+    data,
+    column_names,
+    missing_counts,
+    rows,
+    cols,
+    hash
+) VALUES (
+    $1,
+    (SELECT COALESCE(MAX(version), 0) + 1 FROM file_versions WHERE file_id = $1),
+    $2, $3, $4, $5, $6, $7
+)
+RETURNING *;
+
+-- name: GetFileVersion :one
+SELECT fv.* FROM file_versions fv
+JOIN files f ON f.id = fv.file_id
+WHERE fv.file_id = $1 AND fv.version = $2 AND f.username = $3
+LIMIT 1;
+
+-- name: ListFileVersions :many
+SELECT fv.id, fv.file_id, fv.version, fv.column_names, fv.missing_counts,
+    fv.rows, fv.cols, fv.hash, fv.created_at
+FROM file_versions fv
+JOIN files f ON f.id = fv.file_id
+WHERE fv.file_id = $1 AND f.username = $2
+ORDER BY fv.version DESC
+LIMIT $3
+OFFSET $4;
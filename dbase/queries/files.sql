@@ -1,19 +1,46 @@
 -- name: CreateFile :one
 INSERT INTO files (
     username,
-    data
+    name,
+    data,
+    column_names,
+    missing_counts
 ) VALUES (
-    $1, $2
+    $1, $2, $3, $4, $5
 )
 RETURNING *;
 
 -- name: GetFile :one
 SELECT * FROM files
-WHERE username = $1
+WHERE id = $1 AND username = $2
 LIMIT 1;
 
+-- name: GetFileForUpdate :one
+SELECT * FROM files
+WHERE id = $1 AND username = $2
+LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: ListFiles :many
+SELECT id, username, name, column_names, missing_counts, version, changed_at, created_at
+FROM files
+WHERE username = $1
+ORDER BY id
+LIMIT $2
+OFFSET $3;
+
 -- name: UpdateFile :one
 UPDATE files
-SET data = $1
-WHERE username = $2
+SET data = $1, column_names = $2, missing_counts = $3, version = $4, changed_at = now()
+WHERE id = $5 AND username = $6
 RETURNING *;
+
+-- name: UpdateFileName :one
+UPDATE files
+SET name = $1
+WHERE id = $2 AND username = $3
+RETURNING *;
+
+-- name: DeleteFile :execrows
+DELETE FROM files
+WHERE id = $1 AND username = $2;
@@ -0,0 +1,43 @@
+-- name: CreateJob :one
+INSERT INTO jobs (
+    username,
+    kind,
+    params
+) VALUES (
+    $1, $2, $3
+)
+RETURNING *;
+
+-- name: GetJob :one
+SELECT * FROM jobs
+WHERE id = $1 AND username = $2
+LIMIT 1;
+
+-- name: ClaimJob :one
+UPDATE jobs
+SET status = 'running', started_at = now()
+WHERE id = (
+    SELECT id FROM jobs
+    WHERE status = 'queued'
+    ORDER BY id
+    LIMIT 1
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: FinishJob :one
+UPDATE jobs
+SET status = $2, result = $3, error = $4, finished_at = now()
+WHERE id = $1 AND status = 'running'
+RETURNING *;
+
+-- name: CancelJob :one
+UPDATE jobs
+SET status = 'cancelled', finished_at = now()
+WHERE id = $1 AND username = $2 AND status IN ('queued', 'running')
+RETURNING *;
+
+-- name: RequeueRunningJobs :execrows
+UPDATE jobs
+SET status = 'queued', started_at = NULL
+WHERE status = 'running';
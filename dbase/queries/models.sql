@@ -0,0 +1,30 @@
+-- name: CreateModel :one
+INSERT INTO models (
+    username,
+    kind,
+    file_id,
+    file_version,
+    target,
+    predictors,
+    model
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+)
+RETURNING *;
+
+-- name: GetModel :one
+SELECT * FROM models
+WHERE id = $1 AND username = $2
+LIMIT 1;
+
+-- name: ListModels :many
+SELECT id, username, kind, file_id, file_version, target, predictors, created_at
+FROM models
+WHERE username = $1
+ORDER BY id
+LIMIT $2
+OFFSET $3;
+
+-- name: DeleteModel :execrows
+DELETE FROM models
+WHERE id = $1 AND username = $2;
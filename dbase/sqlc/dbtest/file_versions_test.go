@@ -0,0 +1,165 @@
+package dbtest
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	gomock "go.uber.org/mock/gomock"
+
+	mockdb "github.com/yodeman/analyses-api/dbase/mock"
+	db "github.com/yodeman/analyses-api/dbase/sqlc"
+	"github.com/yodeman/analyses-api/util"
+)
+
+func TestCreateFileVersion(t *testing.T) {
+	data, err := util.RandomData()
+	require.NoError(t, err)
+
+	createFileVersionParams := db.CreateFileVersionParams{
+		FileID: util.RandomInt(1, 1000),
+		Data:   data,
+		Rows:   30,
+		Cols:   10,
+		Hash:   util.RandomString(64),
+	}
+
+	version := db.FileVersion{
+		FileID:  createFileVersionParams.FileID,
+		Version: 2,
+		Data:    data,
+		Rows:    createFileVersionParams.Rows,
+		Cols:    createFileVersionParams.Cols,
+		Hash:    createFileVersionParams.Hash,
+	}
+
+	var ctx context.Context
+
+	testCases := []struct {
+		name        string
+		buildStubs  func(querier *mockdb.MockQuerier)
+		checkResult func(t *testing.T, result db.FileVersion, err error)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(querier *mockdb.MockQuerier) {
+				querier.EXPECT().
+					CreateFileVersion(gomock.Any(), gomock.Eq(createFileVersionParams)).
+					Times(1).
+					Return(version, nil)
+			},
+			checkResult: func(t *testing.T, result db.FileVersion, err error) {
+				require.NoError(t, err)
+				require.Equal(t, version.FileID, result.FileID)
+				require.Equal(t, version.Version, result.Version)
+				require.Equal(t, version.Data, result.Data)
+				require.Equal(t, version.Hash, result.Hash)
+			},
+		},
+		{
+			name: "INTERNAL ERROR",
+			buildStubs: func(querier *mockdb.MockQuerier) {
+				querier.EXPECT().
+					CreateFileVersion(gomock.Any(), gomock.Eq(createFileVersionParams)).
+					Times(1).
+					Return(db.FileVersion{}, sql.ErrConnDone)
+			},
+			checkResult: func(t *testing.T, result db.FileVersion, err error) {
+				require.Error(t, err)
+				require.Empty(t, result)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			testQuerier := mockdb.NewMockQuerier(ctrl)
+
+			//build stubs
+			tc.buildStubs(testQuerier)
+
+			result, err := testQuerier.CreateFileVersion(ctx, createFileVersionParams)
+
+			tc.checkResult(t, result, err)
+		})
+	}
+}
+
+func TestGetFileVersion(t *testing.T) {
+	user, _ := randomUser(t)
+	data, err := util.RandomData()
+	require.NoError(t, err)
+
+	getFileVersionParams := db.GetFileVersionParams{
+		FileID:   util.RandomInt(1, 1000),
+		Version:  1,
+		Username: user.Username,
+	}
+
+	version := db.FileVersion{
+		FileID:  getFileVersionParams.FileID,
+		Version: getFileVersionParams.Version,
+		Data:    data,
+	}
+
+	var ctx context.Context
+
+	testCases := []struct {
+		name        string
+		buildStubs  func(querier *mockdb.MockQuerier)
+		checkResult func(t *testing.T, result db.FileVersion, err error)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(querier *mockdb.MockQuerier) {
+				querier.EXPECT().
+					GetFileVersion(gomock.Any(), gomock.Eq(getFileVersionParams)).
+					Times(1).
+					Return(version, nil)
+			},
+			checkResult: func(t *testing.T, result db.FileVersion, err error) {
+				require.NoError(t, err)
+				require.Equal(t, version.FileID, result.FileID)
+				require.Equal(t, version.Version, result.Version)
+				require.Equal(t, version.Data, result.Data)
+			},
+		},
+		{
+			name: "NOT FOUND",
+			buildStubs: func(querier *mockdb.MockQuerier) {
+				querier.EXPECT().
+					GetFileVersion(gomock.Any(), gomock.Eq(getFileVersionParams)).
+					Times(1).
+					Return(db.FileVersion{}, sql.ErrNoRows)
+			},
+			checkResult: func(t *testing.T, result db.FileVersion, err error) {
+				require.ErrorIs(t, err, sql.ErrNoRows)
+				require.Empty(t, result)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			testQuerier := mockdb.NewMockQuerier(ctrl)
+
+			//build stubs
+			tc.buildStubs(testQuerier)
+
+			result, err := testQuerier.GetFileVersion(ctx, getFileVersionParams)
+
+			tc.checkResult(t, result, err)
+		})
+	}
+}
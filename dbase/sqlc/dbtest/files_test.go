@@ -96,24 +96,26 @@ func TestGetFile(t *testing.T) {
 	require.NoError(t, err)
 
 	file := db.File{
+		ID:       util.RandomInt(1, 1000),
 		Username: user.Username,
 		Data:     data,
 	}
+	getFileParams := db.GetFileParams{ID: file.ID, Username: user.Username}
 
 	var ctx context.Context
 
 	testCases := []struct {
 		name        string
-		param       string
+		param       db.GetFileParams
 		buildStubs  func(querier *mockdb.MockQuerier)
 		checkResult func(t *testing.T, result db.File, err error)
 	}{
 		{
 			name:  "OK",
-			param: user.Username,
+			param: getFileParams,
 			buildStubs: func(querier *mockdb.MockQuerier) {
 				querier.EXPECT().
-					GetFile(gomock.Any(), gomock.Eq(user.Username)).
+					GetFile(gomock.Any(), gomock.Eq(getFileParams)).
 					Times(1).
 					Return(file, nil)
 			},
@@ -127,11 +129,11 @@ func TestGetFile(t *testing.T) {
 		},
 		{
 			name:  "INTERNAL ERROR",
-			param: user.Username,
+			param: getFileParams,
 			buildStubs: func(querier *mockdb.MockQuerier) {
 
 				querier.EXPECT().
-					GetFile(gomock.Any(), gomock.Eq(user.Username)).
+					GetFile(gomock.Any(), gomock.Eq(getFileParams)).
 					Times(1).
 					Return(db.File{}, sql.ErrConnDone)
 			},
@@ -145,7 +147,7 @@ func TestGetFile(t *testing.T) {
 		},
 		{
 			name:  "NOT FOUND",
-			param: "",
+			param: db.GetFileParams{ID: file.ID},
 			buildStubs: func(querier *mockdb.MockQuerier) {
 
 				querier.EXPECT().
@@ -188,6 +190,7 @@ func TestUpdateFile(t *testing.T) {
 	require.NoError(t, err)
 
 	updateFileParams := db.UpdateFileParams{
+		ID:       util.RandomInt(1, 1000),
 		Username: user.Username,
 		Data:     data,
 	}
@@ -284,3 +287,209 @@ func TestUpdateFile(t *testing.T) {
 		})
 	}
 }
+
+func TestListFiles(t *testing.T) {
+	user, _ := randomUser(t)
+
+	listFilesParams := db.ListFilesParams{
+		Username: user.Username,
+		Limit:    5,
+		Offset:   0,
+	}
+
+	files := make([]db.ListFilesRow, 5)
+	for i := range files {
+		files[i] = db.ListFilesRow{
+			ID:       int64(i + 1),
+			Username: user.Username,
+			Name:     util.RandomString(6),
+		}
+	}
+
+	var ctx context.Context
+
+	testCases := []struct {
+		name        string
+		buildStubs  func(querier *mockdb.MockQuerier)
+		checkResult func(t *testing.T, result []db.ListFilesRow, err error)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(querier *mockdb.MockQuerier) {
+				querier.EXPECT().
+					ListFiles(gomock.Any(), gomock.Eq(listFilesParams)).
+					Times(1).
+					Return(files, nil)
+			},
+			checkResult: func(t *testing.T, result []db.ListFilesRow, err error) {
+				require.NoError(t, err)
+				require.Len(t, result, len(files))
+				for _, file := range result {
+					require.Equal(t, user.Username, file.Username)
+				}
+			},
+		},
+		{
+			name: "INTERNAL ERROR",
+			buildStubs: func(querier *mockdb.MockQuerier) {
+				querier.EXPECT().
+					ListFiles(gomock.Any(), gomock.Eq(listFilesParams)).
+					Times(1).
+					Return(nil, sql.ErrConnDone)
+			},
+			checkResult: func(t *testing.T, result []db.ListFilesRow, err error) {
+				require.Error(t, err)
+				require.Empty(t, result)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			testQuerier := mockdb.NewMockQuerier(ctrl)
+
+			//build stubs
+			tc.buildStubs(testQuerier)
+
+			result, err := testQuerier.ListFiles(ctx, listFilesParams)
+
+			tc.checkResult(t, result, err)
+		})
+	}
+}
+
+func TestUpdateFileName(t *testing.T) {
+	user, _ := randomUser(t)
+
+	updateFileNameParams := db.UpdateFileNameParams{
+		Name:     util.RandomString(6),
+		ID:       util.RandomInt(1, 1000),
+		Username: user.Username,
+	}
+
+	file := db.File{
+		ID:       updateFileNameParams.ID,
+		Username: user.Username,
+		Name:     updateFileNameParams.Name,
+	}
+
+	var ctx context.Context
+
+	testCases := []struct {
+		name        string
+		buildStubs  func(querier *mockdb.MockQuerier)
+		checkResult func(t *testing.T, result db.File, err error)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(querier *mockdb.MockQuerier) {
+				querier.EXPECT().
+					UpdateFileName(gomock.Any(), gomock.Eq(updateFileNameParams)).
+					Times(1).
+					Return(file, nil)
+			},
+			checkResult: func(t *testing.T, result db.File, err error) {
+				require.NoError(t, err)
+				require.Equal(t, updateFileNameParams.Name, result.Name)
+			},
+		},
+		{
+			name: "NOT FOUND",
+			buildStubs: func(querier *mockdb.MockQuerier) {
+				querier.EXPECT().
+					UpdateFileName(gomock.Any(), gomock.Eq(updateFileNameParams)).
+					Times(1).
+					Return(db.File{}, sql.ErrNoRows)
+			},
+			checkResult: func(t *testing.T, result db.File, err error) {
+				require.ErrorIs(t, err, sql.ErrNoRows)
+				require.Empty(t, result)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			testQuerier := mockdb.NewMockQuerier(ctrl)
+
+			//build stubs
+			tc.buildStubs(testQuerier)
+
+			result, err := testQuerier.UpdateFileName(ctx, updateFileNameParams)
+
+			tc.checkResult(t, result, err)
+		})
+	}
+}
+
+func TestDeleteFile(t *testing.T) {
+	user, _ := randomUser(t)
+
+	deleteFileParams := db.DeleteFileParams{
+		ID:       util.RandomInt(1, 1000),
+		Username: user.Username,
+	}
+
+	var ctx context.Context
+
+	testCases := []struct {
+		name        string
+		buildStubs  func(querier *mockdb.MockQuerier)
+		checkResult func(t *testing.T, deleted int64, err error)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(querier *mockdb.MockQuerier) {
+				querier.EXPECT().
+					DeleteFile(gomock.Any(), gomock.Eq(deleteFileParams)).
+					Times(1).
+					Return(int64(1), nil)
+			},
+			checkResult: func(t *testing.T, deleted int64, err error) {
+				require.NoError(t, err)
+				require.Equal(t, int64(1), deleted)
+			},
+		},
+		{
+			name: "NOT FOUND",
+			buildStubs: func(querier *mockdb.MockQuerier) {
+				querier.EXPECT().
+					DeleteFile(gomock.Any(), gomock.Eq(deleteFileParams)).
+					Times(1).
+					Return(int64(0), nil)
+			},
+			checkResult: func(t *testing.T, deleted int64, err error) {
+				require.NoError(t, err)
+				require.Zero(t, deleted)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			testQuerier := mockdb.NewMockQuerier(ctrl)
+
+			//build stubs
+			tc.buildStubs(testQuerier)
+
+			deleted, err := testQuerier.DeleteFile(ctx, deleteFileParams)
+
+			tc.checkResult(t, deleted, err)
+		})
+	}
+}
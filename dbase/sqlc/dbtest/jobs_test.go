@@ -0,0 +1,162 @@
+package dbtest
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	gomock "go.uber.org/mock/gomock"
+
+	mockdb "github.com/yodeman/analyses-api/dbase/mock"
+	db "github.com/yodeman/analyses-api/dbase/sqlc"
+	"github.com/yodeman/analyses-api/util"
+)
+
+func TestCreateJob(t *testing.T) {
+	user, _ := randomUser(t)
+
+	createJobParams := db.CreateJobParams{
+		Username: user.Username,
+		Kind:     "regression",
+		Params:   json.RawMessage(`{"file_id": 1}`),
+	}
+
+	job := db.Job{
+		ID:       util.RandomInt(1, 1000),
+		Username: createJobParams.Username,
+		Kind:     createJobParams.Kind,
+		Params:   createJobParams.Params,
+		Status:   "queued",
+		Result:   json.RawMessage("null"),
+	}
+
+	var ctx context.Context
+
+	testCases := []struct {
+		name        string
+		buildStubs  func(querier *mockdb.MockQuerier)
+		checkResult func(t *testing.T, result db.Job, err error)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(querier *mockdb.MockQuerier) {
+				querier.EXPECT().
+					CreateJob(gomock.Any(), gomock.Eq(createJobParams)).
+					Times(1).
+					Return(job, nil)
+			},
+			checkResult: func(t *testing.T, result db.Job, err error) {
+				require.NoError(t, err)
+				require.Equal(t, job.Username, result.Username)
+				require.Equal(t, job.Kind, result.Kind)
+				require.Equal(t, job.Params, result.Params)
+				require.Equal(t, "queued", result.Status)
+				require.False(t, result.StartedAt.Valid)
+			},
+		},
+		{
+			name: "INTERNAL ERROR",
+			buildStubs: func(querier *mockdb.MockQuerier) {
+				querier.EXPECT().
+					CreateJob(gomock.Any(), gomock.Eq(createJobParams)).
+					Times(1).
+					Return(db.Job{}, sql.ErrConnDone)
+			},
+			checkResult: func(t *testing.T, result db.Job, err error) {
+				require.Error(t, err)
+				require.Empty(t, result)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			testQuerier := mockdb.NewMockQuerier(ctrl)
+
+			//build stubs
+			tc.buildStubs(testQuerier)
+
+			result, err := testQuerier.CreateJob(ctx, createJobParams)
+
+			tc.checkResult(t, result, err)
+		})
+	}
+}
+
+func TestCancelJob(t *testing.T) {
+	user, _ := randomUser(t)
+
+	cancelJobParams := db.CancelJobParams{
+		ID:       util.RandomInt(1, 1000),
+		Username: user.Username,
+	}
+
+	job := db.Job{
+		ID:       cancelJobParams.ID,
+		Username: cancelJobParams.Username,
+		Kind:     "regression",
+		Status:   "cancelled",
+		Result:   json.RawMessage("null"),
+	}
+
+	var ctx context.Context
+
+	testCases := []struct {
+		name        string
+		buildStubs  func(querier *mockdb.MockQuerier)
+		checkResult func(t *testing.T, result db.Job, err error)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(querier *mockdb.MockQuerier) {
+				querier.EXPECT().
+					CancelJob(gomock.Any(), gomock.Eq(cancelJobParams)).
+					Times(1).
+					Return(job, nil)
+			},
+			checkResult: func(t *testing.T, result db.Job, err error) {
+				require.NoError(t, err)
+				require.Equal(t, job.ID, result.ID)
+				require.Equal(t, "cancelled", result.Status)
+			},
+		},
+		{
+			name: "ALREADY FINISHED",
+			buildStubs: func(querier *mockdb.MockQuerier) {
+				querier.EXPECT().
+					CancelJob(gomock.Any(), gomock.Eq(cancelJobParams)).
+					Times(1).
+					Return(db.Job{}, sql.ErrNoRows)
+			},
+			checkResult: func(t *testing.T, result db.Job, err error) {
+				require.ErrorIs(t, err, sql.ErrNoRows)
+				require.Empty(t, result)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			testQuerier := mockdb.NewMockQuerier(ctrl)
+
+			//build stubs
+			tc.buildStubs(testQuerier)
+
+			result, err := testQuerier.CancelJob(ctx, cancelJobParams)
+
+			tc.checkResult(t, result, err)
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package dbtest
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	gomock "go.uber.org/mock/gomock"
+
+	mockdb "github.com/yodeman/analyses-api/dbase/mock"
+	db "github.com/yodeman/analyses-api/dbase/sqlc"
+	"github.com/yodeman/analyses-api/util"
+)
+
+func TestCreateModel(t *testing.T) {
+	user, _ := randomUser(t)
+
+	createModelParams := db.CreateModelParams{
+		Username:    user.Username,
+		Kind:        "linear",
+		FileID:      sql.NullInt64{Int64: util.RandomInt(1, 1000), Valid: true},
+		FileVersion: 2,
+		Target:      "y",
+		Predictors:  []string{"x1", "x2"},
+		Model:       json.RawMessage(`{"kind": "linear"}`),
+	}
+
+	model := db.Model{
+		ID:          util.RandomInt(1, 1000),
+		Username:    createModelParams.Username,
+		Kind:        createModelParams.Kind,
+		FileID:      createModelParams.FileID,
+		FileVersion: createModelParams.FileVersion,
+		Target:      createModelParams.Target,
+		Predictors:  createModelParams.Predictors,
+		Model:       createModelParams.Model,
+	}
+
+	var ctx context.Context
+
+	testCases := []struct {
+		name        string
+		buildStubs  func(querier *mockdb.MockQuerier)
+		checkResult func(t *testing.T, result db.Model, err error)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(querier *mockdb.MockQuerier) {
+				querier.EXPECT().
+					CreateModel(gomock.Any(), gomock.Eq(createModelParams)).
+					Times(1).
+					Return(model, nil)
+			},
+			checkResult: func(t *testing.T, result db.Model, err error) {
+				require.NoError(t, err)
+				require.Equal(t, model.Username, result.Username)
+				require.Equal(t, model.Kind, result.Kind)
+				require.Equal(t, model.FileID, result.FileID)
+				require.Equal(t, model.FileVersion, result.FileVersion)
+				require.Equal(t, model.Predictors, result.Predictors)
+				require.Equal(t, model.Model, result.Model)
+			},
+		},
+		{
+			name: "INTERNAL ERROR",
+			buildStubs: func(querier *mockdb.MockQuerier) {
+				querier.EXPECT().
+					CreateModel(gomock.Any(), gomock.Eq(createModelParams)).
+					Times(1).
+					Return(db.Model{}, sql.ErrConnDone)
+			},
+			checkResult: func(t *testing.T, result db.Model, err error) {
+				require.Error(t, err)
+				require.Empty(t, result)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			testQuerier := mockdb.NewMockQuerier(ctrl)
+
+			//build stubs
+			tc.buildStubs(testQuerier)
+
+			result, err := testQuerier.CreateModel(ctx, createModelParams)
+
+			tc.checkResult(t, result, err)
+		})
+	}
+}
+
+func TestDeleteModel(t *testing.T) {
+	user, _ := randomUser(t)
+
+	deleteModelParams := db.DeleteModelParams{
+		ID:       util.RandomInt(1, 1000),
+		Username: user.Username,
+	}
+
+	var ctx context.Context
+
+	testCases := []struct {
+		name        string
+		buildStubs  func(querier *mockdb.MockQuerier)
+		checkResult func(t *testing.T, deleted int64, err error)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(querier *mockdb.MockQuerier) {
+				querier.EXPECT().
+					DeleteModel(gomock.Any(), gomock.Eq(deleteModelParams)).
+					Times(1).
+					Return(int64(1), nil)
+			},
+			checkResult: func(t *testing.T, deleted int64, err error) {
+				require.NoError(t, err)
+				require.Equal(t, int64(1), deleted)
+			},
+		},
+		{
+			name: "NOT FOUND",
+			buildStubs: func(querier *mockdb.MockQuerier) {
+				querier.EXPECT().
+					DeleteModel(gomock.Any(), gomock.Eq(deleteModelParams)).
+					Times(1).
+					Return(int64(0), nil)
+			},
+			checkResult: func(t *testing.T, deleted int64, err error) {
+				require.NoError(t, err)
+				require.Zero(t, deleted)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			testQuerier := mockdb.NewMockQuerier(ctrl)
+
+			//build stubs
+			tc.buildStubs(testQuerier)
+
+			deleted, err := testQuerier.DeleteModel(ctx, deleteModelParams)
+
+			tc.checkResult(t, deleted, err)
+		})
+	}
+}
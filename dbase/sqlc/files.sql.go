@@ -7,25 +7,40 @@ package db
 
 import (
 	"context"
+	"time"
+
+	"github.com/lib/pq"
 )
 
 const createFile = `-- name: CreateFile :one
 INSERT INTO files (
     username,
-    data
+    name,
+    data,
+    column_names,
+    missing_counts
 ) VALUES (
-    $1, $2
+    $1, $2, $3, $4, $5
 )
-RETURNING id, username, data, changed_at, created_at
+RETURNING id, username, data, changed_at, created_at, column_names, missing_counts, name, version
 `
 
 type CreateFileParams struct {
-	Username string `json:"username"`
-	Data     string `json:"data"`
+	Username      string   `json:"username"`
+	Name          string   `json:"name"`
+	Data          []byte   `json:"data"`
+	ColumnNames   []string `json:"column_names"`
+	MissingCounts []int32  `json:"missing_counts"`
 }
 
 func (q *Queries) CreateFile(ctx context.Context, arg CreateFileParams) (File, error) {
-	row := q.db.QueryRowContext(ctx, createFile, arg.Username, arg.Data)
+	row := q.db.QueryRowContext(ctx, createFile,
+		arg.Username,
+		arg.Name,
+		arg.Data,
+		pq.Array(arg.ColumnNames),
+		pq.Array(arg.MissingCounts),
+	)
 	var i File
 	err := row.Scan(
 		&i.ID,
@@ -33,18 +48,74 @@ func (q *Queries) CreateFile(ctx context.Context, arg CreateFileParams) (File, e
 		&i.Data,
 		&i.ChangedAt,
 		&i.CreatedAt,
+		pq.Array(&i.ColumnNames),
+		pq.Array(&i.MissingCounts),
+		&i.Name,
+		&i.Version,
 	)
 	return i, err
 }
 
+const deleteFile = `-- name: DeleteFile :execrows
+DELETE FROM files
+WHERE id = $1 AND username = $2
+`
+
+type DeleteFileParams struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+}
+
+func (q *Queries) DeleteFile(ctx context.Context, arg DeleteFileParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteFile, arg.ID, arg.Username)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const getFile = `-- name: GetFile :one
-SELECT id, username, data, changed_at, created_at FROM files
-WHERE username = $1
+SELECT id, username, data, changed_at, created_at, column_names, missing_counts, name, version FROM files
+WHERE id = $1 AND username = $2
+LIMIT 1
+`
+
+type GetFileParams struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+}
+
+func (q *Queries) GetFile(ctx context.Context, arg GetFileParams) (File, error) {
+	row := q.db.QueryRowContext(ctx, getFile, arg.ID, arg.Username)
+	var i File
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Data,
+		&i.ChangedAt,
+		&i.CreatedAt,
+		pq.Array(&i.ColumnNames),
+		pq.Array(&i.MissingCounts),
+		&i.Name,
+		&i.Version,
+	)
+	return i, err
+}
+
+const getFileForUpdate = `-- name: GetFileForUpdate :one
+SELECT id, username, data, changed_at, created_at, column_names, missing_counts, name, version FROM files
+WHERE id = $1 AND username = $2
 LIMIT 1
+FOR NO KEY UPDATE
 `
 
-func (q *Queries) GetFile(ctx context.Context, username string) (File, error) {
-	row := q.db.QueryRowContext(ctx, getFile, username)
+type GetFileForUpdateParams struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+}
+
+func (q *Queries) GetFileForUpdate(ctx context.Context, arg GetFileForUpdateParams) (File, error) {
+	row := q.db.QueryRowContext(ctx, getFileForUpdate, arg.ID, arg.Username)
 	var i File
 	err := row.Scan(
 		&i.ID,
@@ -52,24 +123,127 @@ func (q *Queries) GetFile(ctx context.Context, username string) (File, error) {
 		&i.Data,
 		&i.ChangedAt,
 		&i.CreatedAt,
+		pq.Array(&i.ColumnNames),
+		pq.Array(&i.MissingCounts),
+		&i.Name,
+		&i.Version,
 	)
 	return i, err
 }
 
+const listFiles = `-- name: ListFiles :many
+SELECT id, username, name, column_names, missing_counts, version, changed_at, created_at
+FROM files
+WHERE username = $1
+ORDER BY id
+LIMIT $2
+OFFSET $3
+`
+
+type ListFilesParams struct {
+	Username string `json:"username"`
+	Limit    int32  `json:"limit"`
+	Offset   int32  `json:"offset"`
+}
+
+type ListFilesRow struct {
+	ID            int64     `json:"id"`
+	Username      string    `json:"username"`
+	Name          string    `json:"name"`
+	ColumnNames   []string  `json:"column_names"`
+	MissingCounts []int32   `json:"missing_counts"`
+	Version       int32     `json:"version"`
+	ChangedAt     time.Time `json:"changed_at"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+func (q *Queries) ListFiles(ctx context.Context, arg ListFilesParams) ([]ListFilesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listFiles, arg.Username, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListFilesRow{}
+	for rows.Next() {
+		var i ListFilesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.Name,
+			pq.Array(&i.ColumnNames),
+			pq.Array(&i.MissingCounts),
+			&i.Version,
+			&i.ChangedAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const updateFile = `-- name: UpdateFile :one
 UPDATE files
-SET data = $1
-WHERE username = $2
-RETURNING id, username, data, changed_at, created_at
+SET data = $1, column_names = $2, missing_counts = $3, version = $4, changed_at = now()
+WHERE id = $5 AND username = $6
+RETURNING id, username, data, changed_at, created_at, column_names, missing_counts, name, version
 `
 
 type UpdateFileParams struct {
-	Data     string `json:"data"`
-	Username string `json:"username"`
+	Data          []byte   `json:"data"`
+	ColumnNames   []string `json:"column_names"`
+	MissingCounts []int32  `json:"missing_counts"`
+	Version       int32    `json:"version"`
+	ID            int64    `json:"id"`
+	Username      string   `json:"username"`
 }
 
 func (q *Queries) UpdateFile(ctx context.Context, arg UpdateFileParams) (File, error) {
-	row := q.db.QueryRowContext(ctx, updateFile, arg.Data, arg.Username)
+	row := q.db.QueryRowContext(ctx, updateFile,
+		arg.Data,
+		pq.Array(arg.ColumnNames),
+		pq.Array(arg.MissingCounts),
+		arg.Version,
+		arg.ID,
+		arg.Username,
+	)
+	var i File
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Data,
+		&i.ChangedAt,
+		&i.CreatedAt,
+		pq.Array(&i.ColumnNames),
+		pq.Array(&i.MissingCounts),
+		&i.Name,
+		&i.Version,
+	)
+	return i, err
+}
+
+const updateFileName = `-- name: UpdateFileName :one
+UPDATE files
+SET name = $1
+WHERE id = $2 AND username = $3
+RETURNING id, username, data, changed_at, created_at, column_names, missing_counts, name, version
+`
+
+type UpdateFileNameParams struct {
+	Name     string `json:"name"`
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+}
+
+func (q *Queries) UpdateFileName(ctx context.Context, arg UpdateFileNameParams) (File, error) {
+	row := q.db.QueryRowContext(ctx, updateFileName, arg.Name, arg.ID, arg.Username)
 	var i File
 	err := row.Scan(
 		&i.ID,
@@ -77,6 +251,10 @@ func (q *Queries) UpdateFile(ctx context.Context, arg UpdateFileParams) (File, e
 		&i.Data,
 		&i.ChangedAt,
 		&i.CreatedAt,
+		pq.Array(&i.ColumnNames),
+		pq.Array(&i.MissingCounts),
+		&i.Name,
+		&i.Version,
 	)
 	return i, err
 }
@@ -5,15 +5,59 @@
 package db
 
 import (
+	"database/sql"
+	"encoding/json"
 	"time"
 )
 
 type File struct {
-	ID        int64     `json:"id"`
-	Username  string    `json:"username"`
-	Data      string    `json:"data"`
-	ChangedAt time.Time `json:"changed_at"`
-	CreatedAt time.Time `json:"created_at"`
+	ID            int64     `json:"id"`
+	Username      string    `json:"username"`
+	Data          []byte    `json:"data"`
+	ChangedAt     time.Time `json:"changed_at"`
+	CreatedAt     time.Time `json:"created_at"`
+	ColumnNames   []string  `json:"column_names"`
+	MissingCounts []int32   `json:"missing_counts"`
+	Name          string    `json:"name"`
+	Version       int32     `json:"version"`
+}
+
+type FileVersion struct {
+	ID            int64     `json:"id"`
+	FileID        int64     `json:"file_id"`
+	Version       int32     `json:"version"`
+	Data          []byte    `json:"data"`
+	ColumnNames   []string  `json:"column_names"`
+	MissingCounts []int32   `json:"missing_counts"`
+	Rows          int32     `json:"rows"`
+	Cols          int32     `json:"cols"`
+	Hash          string    `json:"hash"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type Job struct {
+	ID         int64           `json:"id"`
+	Username   string          `json:"username"`
+	Kind       string          `json:"kind"`
+	Params     json.RawMessage `json:"params"`
+	Status     string          `json:"status"`
+	Result     json.RawMessage `json:"result"`
+	Error      string          `json:"error"`
+	CreatedAt  time.Time       `json:"created_at"`
+	StartedAt  sql.NullTime    `json:"started_at"`
+	FinishedAt sql.NullTime    `json:"finished_at"`
+}
+
+type Model struct {
+	ID          int64           `json:"id"`
+	Username    string          `json:"username"`
+	Kind        string          `json:"kind"`
+	FileID      sql.NullInt64   `json:"file_id"`
+	FileVersion int32           `json:"file_version"`
+	Target      string          `json:"target"`
+	Predictors  []string        `json:"predictors"`
+	Model       json.RawMessage `json:"model"`
+	CreatedAt   time.Time       `json:"created_at"`
 }
 
 type User struct {
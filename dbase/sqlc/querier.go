@@ -9,11 +9,28 @@ import (
 )
 
 type Querier interface {
+	CancelJob(ctx context.Context, arg CancelJobParams) (Job, error)
+	ClaimJob(ctx context.Context) (Job, error)
 	CreateFile(ctx context.Context, arg CreateFileParams) (File, error)
+	CreateFileVersion(ctx context.Context, arg CreateFileVersionParams) (FileVersion, error)
+	CreateJob(ctx context.Context, arg CreateJobParams) (Job, error)
+	CreateModel(ctx context.Context, arg CreateModelParams) (Model, error)
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
-	GetFile(ctx context.Context, username string) (File, error)
+	DeleteFile(ctx context.Context, arg DeleteFileParams) (int64, error)
+	DeleteModel(ctx context.Context, arg DeleteModelParams) (int64, error)
+	FinishJob(ctx context.Context, arg FinishJobParams) (Job, error)
+	GetFile(ctx context.Context, arg GetFileParams) (File, error)
+	GetFileForUpdate(ctx context.Context, arg GetFileForUpdateParams) (File, error)
+	GetFileVersion(ctx context.Context, arg GetFileVersionParams) (FileVersion, error)
+	GetJob(ctx context.Context, arg GetJobParams) (Job, error)
+	GetModel(ctx context.Context, arg GetModelParams) (Model, error)
 	GetUser(ctx context.Context, username string) (User, error)
+	ListFileVersions(ctx context.Context, arg ListFileVersionsParams) ([]ListFileVersionsRow, error)
+	ListFiles(ctx context.Context, arg ListFilesParams) ([]ListFilesRow, error)
+	ListModels(ctx context.Context, arg ListModelsParams) ([]ListModelsRow, error)
+	RequeueRunningJobs(ctx context.Context) (int64, error)
 	UpdateFile(ctx context.Context, arg UpdateFileParams) (File, error)
+	UpdateFileName(ctx context.Context, arg UpdateFileNameParams) (File, error)
 }
 
 var _ Querier = (*Queries)(nil)
@@ -0,0 +1,81 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/yodeman/analyses-api/dbase/blob"
+)
+
+// Store provides all functions to execute db queries and transactions.
+type Store interface {
+	Querier
+	CreateFileTx(ctx context.Context, arg CreateFileTxParams) (FileTxResult, error)
+	UpdateFileTx(ctx context.Context, arg UpdateFileTxParams) (FileTxResult, error)
+	RollbackFileTx(ctx context.Context, arg RollbackFileTxParams) (FileTxResult, error)
+}
+
+// SQLStore provides all functions to execute SQL queries and transactions.
+// The files' data is kept in `blobs`, the data columns only hold what the
+// blob storage returns, and is read back transparently by GetFile and
+// GetFileVersion.
+type SQLStore struct {
+	*Queries
+	db    *sql.DB
+	blobs blob.Storage
+}
+
+// NewStore creates a new store keeping the files' data in `blobs`.
+func NewStore(db *sql.DB, blobs blob.Storage) Store {
+	return &SQLStore{
+		Queries: New(db),
+		db:      db,
+		blobs:   blobs,
+	}
+}
+
+// GetFile fetches the user's file along with its data from the blob storage.
+func (store *SQLStore) GetFile(ctx context.Context, arg GetFileParams) (File, error) {
+	file, err := store.Queries.GetFile(ctx, arg)
+	if err != nil {
+		return file, err
+	}
+
+	file.Data, err = store.blobs.Get(ctx, file.Data)
+	return file, err
+}
+
+// GetFileVersion fetches a version of the user's file along with its data
+// from the blob storage.
+func (store *SQLStore) GetFileVersion(
+	ctx context.Context, arg GetFileVersionParams,
+) (FileVersion, error) {
+	version, err := store.Queries.GetFileVersion(ctx, arg)
+	if err != nil {
+		return version, err
+	}
+
+	version.Data, err = store.blobs.Get(ctx, version.Data)
+	return version, err
+}
+
+// execTx executes a function within a database transaction. The transaction
+// is rolled back if the function returns an error.
+func (store *SQLStore) execTx(ctx context.Context, fn func(*Queries) error) error {
+	tx, err := store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	q := New(tx)
+	err = fn(q)
+	if err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return fmt.Errorf("tx err: %w, rb err: %v", err, rbErr)
+		}
+		return err
+	}
+
+	return tx.Commit()
+}
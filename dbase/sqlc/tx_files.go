@@ -0,0 +1,179 @@
+package db
+
+import (
+	"context"
+)
+
+// FileTxResult is the result of the file transactions. The data fields hold
+// what the blob storage returned rather than the data.
+type FileTxResult struct {
+	File    File        `json:"file"`
+	Version FileVersion `json:"version"`
+}
+
+// CreateFileTxParams contains the input parameters of the create file
+// transaction.
+type CreateFileTxParams struct {
+	Username      string   `json:"username"`
+	Name          string   `json:"name"`
+	Data          []byte   `json:"data"`
+	ColumnNames   []string `json:"column_names"`
+	MissingCounts []int32  `json:"missing_counts"`
+	Rows          int32    `json:"rows"`
+	Cols          int32    `json:"cols"`
+	Hash          string   `json:"hash"`
+}
+
+// CreateFileTx creates a new file along with its first version, `arg.Data`
+// is kept in the store's blob storage.
+func (store *SQLStore) CreateFileTx(
+	ctx context.Context, arg CreateFileTxParams,
+) (FileTxResult, error) {
+	var result FileTxResult
+
+	ref, err := store.blobs.Put(ctx, arg.Data)
+	if err != nil {
+		return result, err
+	}
+
+	err = store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result.File, err = q.CreateFile(ctx, CreateFileParams{
+			Username:      arg.Username,
+			Name:          arg.Name,
+			Data:          ref,
+			ColumnNames:   arg.ColumnNames,
+			MissingCounts: arg.MissingCounts,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.Version, err = q.CreateFileVersion(ctx, CreateFileVersionParams{
+			FileID:        result.File.ID,
+			Data:          ref,
+			ColumnNames:   arg.ColumnNames,
+			MissingCounts: arg.MissingCounts,
+			Rows:          arg.Rows,
+			Cols:          arg.Cols,
+			Hash:          arg.Hash,
+		})
+		return err
+	})
+
+	return result, err
+}
+
+// UpdateFileTxParams contains the input parameters of the update file
+// transaction.
+type UpdateFileTxParams struct {
+	ID            int64    `json:"id"`
+	Username      string   `json:"username"`
+	Data          []byte   `json:"data"`
+	ColumnNames   []string `json:"column_names"`
+	MissingCounts []int32  `json:"missing_counts"`
+	Rows          int32    `json:"rows"`
+	Cols          int32    `json:"cols"`
+	Hash          string   `json:"hash"`
+}
+
+// UpdateFileTx adds a new version to the user's file and makes it the
+// file's current version, `arg.Data` is kept in the store's blob storage.
+//
+// Returns sql.ErrNoRows if the user has no such file.
+func (store *SQLStore) UpdateFileTx(
+	ctx context.Context, arg UpdateFileTxParams,
+) (FileTxResult, error) {
+	var result FileTxResult
+
+	ref, err := store.blobs.Put(ctx, arg.Data)
+	if err != nil {
+		return result, err
+	}
+
+	err = store.execTx(ctx, func(q *Queries) error {
+		// lock the file so that concurrent uploads get distinct versions.
+		_, err := q.GetFileForUpdate(ctx, GetFileForUpdateParams{
+			ID:       arg.ID,
+			Username: arg.Username,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.Version, err = q.CreateFileVersion(ctx, CreateFileVersionParams{
+			FileID:        arg.ID,
+			Data:          ref,
+			ColumnNames:   arg.ColumnNames,
+			MissingCounts: arg.MissingCounts,
+			Rows:          arg.Rows,
+			Cols:          arg.Cols,
+			Hash:          arg.Hash,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.File, err = q.UpdateFile(ctx, UpdateFileParams{
+			Data:          ref,
+			ColumnNames:   arg.ColumnNames,
+			MissingCounts: arg.MissingCounts,
+			Version:       result.Version.Version,
+			ID:            arg.ID,
+			Username:      arg.Username,
+		})
+		return err
+	})
+
+	return result, err
+}
+
+// RollbackFileTxParams contains the input parameters of the rollback file
+// transaction.
+type RollbackFileTxParams struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+	Version  int32  `json:"version"`
+}
+
+// RollbackFileTx makes an earlier version the user's file current version.
+// Versions are immutable, so later versions are kept and can be restored.
+//
+// Returns sql.ErrNoRows if the user has no such file or version.
+func (store *SQLStore) RollbackFileTx(
+	ctx context.Context, arg RollbackFileTxParams,
+) (FileTxResult, error) {
+	var result FileTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		_, err := q.GetFileForUpdate(ctx, GetFileForUpdateParams{
+			ID:       arg.ID,
+			Username: arg.Username,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.Version, err = q.GetFileVersion(ctx, GetFileVersionParams{
+			FileID:   arg.ID,
+			Version:  arg.Version,
+			Username: arg.Username,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.File, err = q.UpdateFile(ctx, UpdateFileParams{
+			Data:          result.Version.Data,
+			ColumnNames:   result.Version.ColumnNames,
+			MissingCounts: result.Version.MissingCounts,
+			Version:       result.Version.Version,
+			ID:            arg.ID,
+			Username:      arg.Username,
+		})
+		return err
+	})
+
+	return result, err
+}
@@ -9,6 +9,7 @@ import (
 	_ "github.com/lib/pq"
 
 	"github.com/yodeman/analyses-api/api"
+	"github.com/yodeman/analyses-api/dbase/blob"
 	db "github.com/yodeman/analyses-api/dbase/sqlc"
 	"github.com/yodeman/analyses-api/util"
 )
@@ -36,7 +37,13 @@ func main() {
 		log.Fatalln(err)
 	}
 
-	server, err := api.NewServer(config, db.New(conn))
+	blobs, err := blob.NewStorage(
+		config.BlobStorage, config.BlobDir, config.BlobCompress)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	server, err := api.NewServer(config, db.NewStore(conn, blobs))
 	if err != nil {
 		log.Fatalln(err)
 	}
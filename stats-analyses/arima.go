@@ -0,0 +1,497 @@
+package statsanal
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"gonum.org/v1/gonum/diff/fd"
+	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/gonum/optimize"
+	"gonum.org/v1/gonum/stat"
+	"gonum.org/v1/gonum/stat/distuv"
+)
+
+const (
+	// DefaultMaxOrder is the default largest AR and MA order of an ARIMA
+	// order search.
+	DefaultMaxOrder = 3
+	// DefaultMaxDifferences is the default largest number of differences of
+	// an ARIMA order search.
+	DefaultMaxDifferences = 2
+	// searchLevel is the significance level of the augmented Dickey-Fuller
+	// tests choosing the number of differences of an ARIMA order search.
+	searchLevel = 0.05
+)
+
+// ARIMAOptions configures an ARIMA model.
+type ARIMAOptions struct {
+	// P, D and Q are the AR order, the number of differences and the MA
+	// order.
+	P int
+	D int
+	Q int
+	// Search chooses the orders instead: D as the smallest number of
+	// differences, up to MaxD, after which the augmented Dickey-Fuller test
+	// rejects a unit root at the 5% level, then P and Q, up to MaxP and MaxQ,
+	// with the smallest AIC. Every candidate, and the chosen model, is
+	// conditioned on the first MaxP differenced values, so their AICs are
+	// computed on the same values.
+	Search bool
+	// MaxP and MaxQ default to DefaultMaxOrder, and MaxD to
+	// DefaultMaxDifferences, when nil. Zero limits the search to pure MA or
+	// AR models, or to no differences.
+	MaxP *int
+	MaxD *int
+	MaxQ *int
+	// Horizon is the number of values forecast after the series.
+	Horizon int
+	// Confidence level of the forecast intervals. Defaults to
+	// DefaultConfidence.
+	Confidence float64
+}
+
+// ARIMAOrder is a candidate order of an ARIMA order search.
+type ARIMAOrder struct {
+	P   int      `json:"p"`
+	D   int      `json:"d"`
+	Q   int      `json:"q"`
+	AIC *float64 `json:"aic"`
+}
+
+// ARIMAResult holds a fitted ARIMA model.
+type ARIMAResult struct {
+	P int `json:"p"`
+	D int `json:"d"`
+	Q int `json:"q"`
+	// AR and MA hold the coefficients of the model
+	//   w_t - μ = Σ AR_i (w_{t-i} - μ) + e_t + Σ MA_j e_{t-j}
+	// of the series w differenced D times, where the mean μ is only fitted
+	// without differences.
+	AR   []float64 `json:"ar"`
+	MA   []float64 `json:"ma"`
+	Mean *float64  `json:"mean,omitempty"`
+	// StdErrors holds the standard errors of AR, MA then Mean, null when the
+	// information matrix isn't positive definite.
+	StdErrors     []*float64 `json:"std_errors"`
+	Sigma2        float64    `json:"sigma2"`
+	LogLikelihood float64    `json:"log_likelihood"`
+	AIC           float64    `json:"aic"`
+	BIC           float64    `json:"bic"`
+	// Residuals holds the one-step error of each value, null for the first
+	// D + P values the model is conditioned on, or D + MaxP values after an
+	// order search.
+	Residuals []*float64 `json:"residuals"`
+	// LjungBox tests whether the residuals are white noise.
+	LjungBox *TestResult `json:"ljung_box,omitempty"`
+	// Candidates holds the orders compared by an order search.
+	Candidates []ARIMAOrder `json:"candidates,omitempty"`
+	// Forecast holds the values forecast after the series, within the
+	// bounds Lower and Upper at the Confidence level.
+	Confidence float64   `json:"confidence,omitempty"`
+	Forecast   []float64 `json:"forecast,omitempty"`
+	Lower      []float64 `json:"lower,omitempty"`
+	Upper      []float64 `json:"upper,omitempty"`
+}
+
+// ARIMA fits an ARIMA(P, D, Q) model to the series `x` by minimizing the
+// conditional sum of squares, the sum of the squared one-step errors given
+// the first P differenced values and zero earlier errors, and forecasts its
+// next values. The coefficients are kept stationary and invertible.
+//
+// Returns an error if the options are invalid, the series has missing values
+// or is too short for the model, the fit fails, or `ctx` is done before the
+// end of an order search, or an error wrapping ErrNoVariance if the model
+// fits the series exactly. Such candidates are skipped by an order search.
+func ARIMA(ctx context.Context, x []float64, opts ARIMAOptions) (res ARIMAResult, err error) {
+	if err = checkSeries(x, 3); err != nil {
+		return
+	}
+	if opts.P < 0 || opts.D < 0 || opts.Q < 0 {
+		err = fmt.Errorf("Orders should not be negative, got (%d, %d, %d).",
+			opts.P, opts.D, opts.Q)
+		return
+	}
+	maxP, maxD, maxQ := DefaultMaxOrder, DefaultMaxDifferences, DefaultMaxOrder
+	if opts.MaxP != nil {
+		maxP = *opts.MaxP
+	}
+	if opts.MaxD != nil {
+		maxD = *opts.MaxD
+	}
+	if opts.MaxQ != nil {
+		maxQ = *opts.MaxQ
+	}
+	if maxP < 0 || maxD < 0 || maxQ < 0 {
+		err = fmt.Errorf("Largest orders should not be negative, got (%d, %d, %d).",
+			maxP, maxD, maxQ)
+		return
+	}
+	if opts.Horizon < 0 {
+		err = fmt.Errorf("Horizon should not be negative, got %d.", opts.Horizon)
+		return
+	}
+	if opts.Confidence == 0 {
+		opts.Confidence = DefaultConfidence
+	}
+	if opts.Confidence <= 0 || opts.Confidence >= 1 {
+		err = fmt.Errorf("Confidence level should be in (0, 1), got %v.", opts.Confidence)
+		return
+	}
+
+	var candidates []ARIMAOrder
+	start := opts.P
+	if opts.Search {
+		opts.P, opts.D, opts.Q, candidates, err = searchARIMA(ctx, x, maxP, maxD, maxQ)
+		if err != nil {
+			return
+		}
+		start = maxP
+	}
+
+	res, err = fitARIMA(x, opts.P, opts.D, opts.Q, start)
+	if err != nil {
+		return
+	}
+	res.Candidates = candidates
+
+	// test the residuals at 10 lags, less the fitted coefficients.
+	var resid []float64
+	for _, e := range res.Residuals {
+		if e != nil {
+			resid = append(resid, *e)
+		}
+	}
+	lags := min(10, len(resid)-1)
+	if fitted := opts.P + opts.Q; lags > fitted {
+		if test, testErr := LjungBox(resid, lags, fitted); testErr == nil {
+			res.LjungBox = &test
+		}
+	}
+
+	if opts.Horizon > 0 {
+		res.forecast(x, opts.Horizon, opts.Confidence)
+	}
+	return
+}
+
+// searchARIMA chooses the orders of an ARIMA model of the series `x`, up to
+// `maxP`, `maxD` and `maxQ`, as described by ARIMAOptions.Search. Returns the
+// orders along with the candidates compared.
+//
+// Returns an error if no candidate can be fitted, or `ctx` is done before
+// the end.
+func searchARIMA(ctx context.Context, x []float64, maxP, maxD, maxQ int) (p, d, q int, candidates []ARIMAOrder, err error) {
+	for ; d < maxD; d++ {
+		w, diffErr := Difference(x, d, 1)
+		if diffErr != nil {
+			break
+		}
+		test, testErr := ADF(w, ADFOptions{AutoLags: true})
+		if testErr != nil || test.PValue == nil || *test.PValue < searchLevel {
+			break
+		}
+	}
+
+	best := math.Inf(1)
+	for i := 0; i <= maxP; i++ {
+		for j := 0; j <= maxQ; j++ {
+			if err = ctx.Err(); err != nil {
+				return
+			}
+			order := ARIMAOrder{P: i, D: d, Q: j}
+			if fit, fitErr := fitARIMA(x, i, d, j, maxP); fitErr == nil {
+				order.AIC = finite(fit.AIC)
+				if fit.AIC < best {
+					best, p, q = fit.AIC, i, j
+				}
+			}
+			candidates = append(candidates, order)
+		}
+	}
+	if math.IsInf(best, 1) {
+		err = fmt.Errorf("No ARIMA model with %d differences can be fitted.", d)
+	}
+	return
+}
+
+// fitARIMA fits an ARIMA(`p`, `d`, `q`) model to the series `x` by
+// conditional sum of squares, given the first `start` differenced values,
+// `start` being at least `p` so models of up to `start` AR terms share their
+// errors.
+//
+// Returns an error if the series is too short for the model or the fit
+// fails, or an error wrapping ErrNoVariance if the model fits the series
+// exactly.
+func fitARIMA(x []float64, p, d, q, start int) (res ARIMAResult, err error) {
+	w, err := Difference(x, d, 1)
+	if err != nil {
+		return
+	}
+	c := &css{p: p, q: q, start: start, mean: d == 0}
+	// besides the coefficients, the variance of the errors is estimated.
+	k := p + q + 1
+	if c.mean {
+		k++
+	}
+	m := len(w) - start
+	if m <= k {
+		err = fmt.Errorf(
+			"Need more than %d values for an ARIMA(%d, %d, %d) model, got %d.",
+			k+start+d, p, d, q, len(x))
+		return
+	}
+
+	// fit the standardized series, the coefficients don't depend on the
+	// scale. The series is only centered along with its mean, which would
+	// otherwise add a drift to the differences.
+	center, scale := stat.MeanStdDev(w, nil)
+	if !c.mean {
+		center = 0
+	}
+	if scale == 0 || math.IsNaN(scale) {
+		scale = 1
+	}
+	c.w = make([]float64, len(w))
+	for t, v := range w {
+		c.w[t] = (v - center) / scale
+	}
+
+	params, err := c.fit()
+	if err != nil {
+		return
+	}
+	e, sse := c.residuals(params)
+	if sse == 0 {
+		// the log-likelihood would be infinite.
+		err = fmt.Errorf("%w: an ARIMA(%d, %d, %d) model fits the series exactly.",
+			ErrNoVariance, p, d, q)
+		return
+	}
+	sigma2 := sse / float64(m)
+
+	res.P, res.D, res.Q = p, d, q
+	res.AR = append([]float64{}, params[:p]...)
+	res.MA = append([]float64{}, params[p:p+q]...)
+	if c.mean {
+		res.Mean = finite(center + scale*params[p+q])
+	}
+	res.Sigma2 = sigma2 * scale * scale
+	res.LogLikelihood = -float64(m) / 2 * (math.Log(2*math.Pi*res.Sigma2) + 1)
+	res.AIC = -2*res.LogLikelihood + 2*float64(k)
+	res.BIC = -2*res.LogLikelihood + math.Log(float64(m))*float64(k)
+
+	res.Residuals = make([]*float64, len(x))
+	for t := start; t < len(w); t++ {
+		res.Residuals[t+d] = finite(e[t] * scale)
+	}
+	res.StdErrors = c.stdErrors(params, m)
+	if c.mean {
+		if se := res.StdErrors[p+q]; se != nil {
+			*se *= scale
+		}
+	}
+	return
+}
+
+// css is the conditional sum of squares of an ARMA(p, q) model of the
+// series w.
+type css struct {
+	w    []float64
+	p, q int
+	// start is the first value of w whose error is summed, at least p.
+	start int
+	// mean fits the mean of the series.
+	mean bool
+}
+
+// residuals returns the one-step errors of the series for the AR
+// coefficients, MA coefficients then mean `params`, zero for the first start
+// values, along with their sum of squares.
+func (c *css) residuals(params []float64) ([]float64, float64) {
+	ar, ma := params[:c.p], params[c.p:c.p+c.q]
+	var mu float64
+	if c.mean {
+		mu = params[c.p+c.q]
+	}
+
+	e := make([]float64, len(c.w))
+	var sse float64
+	for t := c.start; t < len(c.w); t++ {
+		v := c.w[t] - mu
+		for i, a := range ar {
+			v -= a * (c.w[t-1-i] - mu)
+		}
+		for j, b := range ma {
+			if t-1-j >= 0 {
+				v -= b * e[t-1-j]
+			}
+		}
+		e[t] = v
+		sse += v * v
+	}
+	return e, sse
+}
+
+// params maps the unconstrained values `u` to stationary AR and invertible
+// MA coefficients, followed by the mean.
+func (c *css) params(u []float64) []float64 {
+	res := make([]float64, len(u))
+	copy(res, fromPartials(u[:c.p]))
+	for j, b := range fromPartials(u[c.p : c.p+c.q]) {
+		res[c.p+j] = -b
+	}
+	copy(res[c.p+c.q:], u[c.p+c.q:])
+	return res
+}
+
+// fit minimizes the conditional sum of squares, returning the AR
+// coefficients, MA coefficients then mean.
+//
+// Returns an error if the minimization fails.
+func (c *css) fit() ([]float64, error) {
+	n := c.p + c.q
+	if c.mean {
+		n++
+	}
+	if n == 0 {
+		return nil, nil
+	}
+
+	problem := optimize.Problem{
+		Func: func(u []float64) float64 {
+			_, sse := c.residuals(c.params(u))
+			if math.IsNaN(sse) {
+				return math.Inf(1)
+			}
+			return sse
+		},
+	}
+	result, err := optimize.Minimize(problem, make([]float64, n), nil, &optimize.NelderMead{})
+	if err != nil {
+		return nil, fmt.Errorf("Error fitting the ARIMA coefficients.\n%w", err)
+	}
+	return c.params(result.X), nil
+}
+
+// stdErrors returns the standard errors of the coefficients `params` from
+// the numerical hessian of the negative log-likelihood of the `m` errors,
+// null when it isn't positive definite.
+func (c *css) stdErrors(params []float64, m int) []*float64 {
+	res := make([]*float64, len(params))
+	if len(params) == 0 {
+		return res
+	}
+
+	var hess mat.SymDense
+	fd.Hessian(&hess, func(b []float64) float64 {
+		_, sse := c.residuals(b)
+		return float64(m) / 2 * math.Log(sse)
+	}, params, nil)
+
+	var chol mat.Cholesky
+	if ok := chol.Factorize(&hess); !ok {
+		return res
+	}
+	var cov mat.SymDense
+	if err := chol.InverseTo(&cov); err != nil {
+		return res
+	}
+	for i := range res {
+		res[i] = finite(math.Sqrt(cov.At(i, i)))
+	}
+	return res
+}
+
+// fromPartials maps the unconstrained values `u` to the coefficients of a
+// stationary autoregression whose partial autocorrelations are tanh(u),
+// following Jones (1980), "Maximum likelihood fitting of ARMA models to
+// time series with missing observations".
+func fromPartials(u []float64) []float64 {
+	phi := make([]float64, 0, len(u))
+	for k, v := range u {
+		a := math.Tanh(v)
+		next := make([]float64, k+1)
+		for j, f := range phi {
+			next[j] = f - a*phi[k-1-j]
+		}
+		next[k] = a
+		phi = next
+	}
+	return phi
+}
+
+// forecast forecasts the `horizon` values after the series `x` the model was
+// fitted to, with intervals at the `confidence` level from the variance of
+// the errors and the model's psi weights.
+func (res *ARIMAResult) forecast(x []float64, horizon int, confidence float64) {
+	// the series follows the ARMA model of its AR polynomial times (1-B)^D.
+	poly := make([]float64, len(res.AR)+1)
+	poly[0] = 1
+	for i, a := range res.AR {
+		poly[i+1] = -a
+	}
+	for k := 0; k < res.D; k++ {
+		next := make([]float64, len(poly)+1)
+		for i, v := range poly {
+			next[i] += v
+			next[i+1] -= v
+		}
+		poly = next
+	}
+	phi := make([]float64, len(poly)-1)
+	for i := range phi {
+		phi[i] = -poly[i+1]
+	}
+
+	var mu float64
+	if res.Mean != nil {
+		mu = *res.Mean
+	}
+	n := len(x)
+	y := make([]float64, n+horizon)
+	e := make([]float64, n+horizon)
+	for t, v := range x {
+		y[t] = v - mu
+		if res.Residuals[t] != nil {
+			e[t] = *res.Residuals[t]
+		}
+	}
+	for t := n; t < n+horizon; t++ {
+		for i, f := range phi {
+			if t-1-i >= 0 {
+				y[t] += f * y[t-1-i]
+			}
+		}
+		for j, b := range res.MA {
+			if t-1-j >= 0 {
+				y[t] += b * e[t-1-j]
+			}
+		}
+	}
+
+	psi := make([]float64, horizon)
+	psi[0] = 1
+	for j := 1; j < horizon; j++ {
+		if j <= len(res.MA) {
+			psi[j] = res.MA[j-1]
+		}
+		for i := 1; i <= min(j, len(phi)); i++ {
+			psi[j] += phi[i-1] * psi[j-i]
+		}
+	}
+
+	z := distuv.UnitNormal.Quantile((1 + confidence) / 2)
+	res.Confidence = confidence
+	res.Forecast = make([]float64, horizon)
+	res.Lower = make([]float64, horizon)
+	res.Upper = make([]float64, horizon)
+	var variance float64
+	for h := 0; h < horizon; h++ {
+		variance += res.Sigma2 * psi[h] * psi[h]
+		half := z * math.Sqrt(variance)
+		res.Forecast[h] = y[n+h] + mu
+		res.Lower[h] = res.Forecast[h] - half
+		res.Upper[h] = res.Forecast[h] + half
+	}
+}
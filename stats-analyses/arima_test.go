@@ -0,0 +1,215 @@
+package statsanal
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gonum.org/v1/gonum/stat/distuv"
+)
+
+func TestARIMA(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+	x := make([]float64, 300)
+	x[0] = 10
+	for i := 1; i < len(x); i++ {
+		x[i] = 10 + 0.6*(x[i-1]-10) + r.NormFloat64()
+	}
+
+	// the conditional sum of squares of an AR(1) model is minimized by the
+	// least squares regression on the lagged values.
+	res, err := ARIMA(context.Background(), x, ARIMAOptions{P: 1})
+	require.NoError(t, err)
+	var mx, my float64
+	n := len(x) - 1
+	for i := 0; i < n; i++ {
+		mx += x[i] / float64(n)
+		my += x[i+1] / float64(n)
+	}
+	var sxx, sxy float64
+	for i := 0; i < n; i++ {
+		sxx += (x[i] - mx) * (x[i] - mx)
+		sxy += (x[i] - mx) * (x[i+1] - my)
+	}
+	slope := sxy / sxx
+	mu := (my - slope*mx) / (1 - slope)
+	require.InDelta(t, slope, res.AR[0], 1e-4)
+	require.InDelta(t, mu, *res.Mean, 1e-3)
+	require.Empty(t, res.MA)
+	require.Len(t, res.StdErrors, 2)
+	require.InDelta(t, math.Sqrt((1-slope*slope)/float64(n)), *res.StdErrors[0], 0.01)
+	require.Nil(t, res.Residuals[0])
+	require.Len(t, res.Residuals, len(x))
+	var sse float64
+	for _, e := range res.Residuals[1:] {
+		sse += *e * *e
+	}
+	require.InDelta(t, sse/float64(n), res.Sigma2, 1e-9)
+	ll := -float64(n) / 2 * (math.Log(2*math.Pi*res.Sigma2) + 1)
+	require.InDelta(t, ll, res.LogLikelihood, 1e-9)
+	require.InDelta(t, -2*ll+6, res.AIC, 1e-9)
+	require.InDelta(t, -2*ll+3*math.Log(float64(n)), res.BIC, 1e-9)
+	require.NotNil(t, res.LjungBox)
+	require.Equal(t, []float64{9}, res.LjungBox.DF)
+	require.Greater(t, *res.LjungBox.PValue, 0.01)
+	require.Nil(t, res.Forecast)
+
+	// an MA(1) process.
+	e := make([]float64, 500)
+	y := make([]float64, len(e))
+	for i := range e {
+		e[i] = r.NormFloat64()
+		y[i] = e[i]
+		if i > 0 {
+			y[i] += 0.5 * e[i-1]
+		}
+	}
+	res, err = ARIMA(context.Background(), y, ARIMAOptions{Q: 1})
+	require.NoError(t, err)
+	require.Empty(t, res.AR)
+	require.InDelta(t, 0.5, res.MA[0], 0.1)
+	require.InDelta(t, 1, res.Sigma2, 0.15)
+	require.NotNil(t, res.Residuals[0])
+
+	_, err = ARIMA(context.Background(), x, ARIMAOptions{P: -1})
+	require.Error(t, err)
+
+	_, err = ARIMA(context.Background(), x[:5], ARIMAOptions{P: 2, Q: 1})
+	require.Error(t, err)
+
+	_, err = ARIMA(context.Background(), x, ARIMAOptions{Horizon: -1})
+	require.Error(t, err)
+
+	_, err = ARIMA(context.Background(), []float64{1, math.NaN(), 3, 4}, ARIMAOptions{})
+	require.Error(t, err)
+}
+
+func TestARIMAForecast(t *testing.T) {
+	// a random walk forecasts its last value, within intervals widening with
+	// the square root of the horizon.
+	x := []float64{1, 3, 2, 5, 4, 6}
+	res, err := ARIMA(context.Background(), x, ARIMAOptions{D: 1, Horizon: 3})
+	require.NoError(t, err)
+	require.Nil(t, res.Mean)
+	require.Empty(t, res.StdErrors)
+	require.Nil(t, res.Residuals[0])
+	require.InDeltaSlice(t, []float64{2, -1, 3, -1, 2}, derefs(res.Residuals[1:]), 1e-12)
+	require.InDelta(t, 19.0/5, res.Sigma2, 1e-12)
+	require.Equal(t, DefaultConfidence, res.Confidence)
+	z := distuv.UnitNormal.Quantile(0.975)
+	for h := 0; h < 3; h++ {
+		require.InDelta(t, 6, res.Forecast[h], 1e-12)
+		half := z * math.Sqrt(19.0/5*float64(h+1))
+		require.InDelta(t, 6-half, res.Lower[h], 1e-9)
+		require.InDelta(t, 6+half, res.Upper[h], 1e-9)
+	}
+
+	// an ARIMA(1, 1, 0) model forecasts its differences with the AR
+	// coefficient, worked from the fitted one.
+	r := rand.New(rand.NewSource(9))
+	y := make([]float64, 200)
+	var dy float64
+	for i := 1; i < len(y); i++ {
+		dy = 0.5*dy + r.NormFloat64()
+		y[i] = y[i-1] + dy
+	}
+	res, err = ARIMA(context.Background(), y, ARIMAOptions{P: 1, D: 1, Horizon: 2, Confidence: 0.9})
+	require.NoError(t, err)
+	require.InDelta(t, 0.5, res.AR[0], 0.15)
+	phi := res.AR[0]
+	n := len(y)
+	d1 := phi * (y[n-1] - y[n-2])
+	d2 := phi * d1
+	require.InDelta(t, y[n-1]+d1, res.Forecast[0], 1e-9)
+	require.InDelta(t, y[n-1]+d1+d2, res.Forecast[1], 1e-9)
+	// psi weights 1 and 1 + phi.
+	z = distuv.UnitNormal.Quantile(0.95)
+	half := z * math.Sqrt(res.Sigma2*(1+(1+phi)*(1+phi)))
+	require.InDelta(t, res.Forecast[1]+half, res.Upper[1], 1e-9)
+	require.Nil(t, res.Residuals[1])
+	require.NotNil(t, res.Residuals[2])
+}
+
+func TestARIMASearch(t *testing.T) {
+	r := rand.New(rand.NewSource(5))
+	x := make([]float64, 300)
+	walk := make([]float64, len(x))
+	for i := 1; i < len(x); i++ {
+		x[i] = 0.7*x[i-1] + r.NormFloat64()
+		walk[i] = walk[i-1] + r.NormFloat64()
+	}
+
+	one, two := 1, 2
+	res, err := ARIMA(context.Background(), x, ARIMAOptions{Search: true, MaxP: &two, MaxQ: &two})
+	require.NoError(t, err)
+	require.Equal(t, 0, res.D)
+	require.Len(t, res.Candidates, 9)
+	for _, c := range res.Candidates {
+		require.Equal(t, 0, c.D)
+		require.NotNil(t, c.AIC)
+		require.GreaterOrEqual(t, *c.AIC, res.AIC)
+		if c.P == res.P && c.Q == res.Q {
+			require.InDelta(t, res.AIC, *c.AIC, 1e-12)
+		}
+	}
+	require.GreaterOrEqual(t, res.P, 1)
+	// every candidate is conditioned on the first MaxP values.
+	require.Nil(t, res.Residuals[1])
+	require.NotNil(t, res.Residuals[2])
+	white, err := fitARIMA(x, 0, 0, 0, 2)
+	require.NoError(t, err)
+	require.InDelta(t, white.AIC, *res.Candidates[0].AIC, 1e-12)
+
+	res, err = ARIMA(context.Background(), walk, ARIMAOptions{Search: true, MaxP: &one, MaxQ: &one})
+	require.NoError(t, err)
+	require.Equal(t, 1, res.D)
+	require.Nil(t, res.Mean)
+	require.Len(t, res.Candidates, 4)
+
+	// zero limits the search to undifferenced pure AR models.
+	zero := 0
+	res, err = ARIMA(context.Background(), walk, ARIMAOptions{Search: true, MaxP: &two, MaxD: &zero, MaxQ: &zero})
+	require.NoError(t, err)
+	require.Equal(t, 0, res.D)
+	require.Equal(t, 0, res.Q)
+	require.Len(t, res.Candidates, 3)
+
+	negative := -1
+	_, err = ARIMA(context.Background(), walk, ARIMAOptions{Search: true, MaxP: &negative})
+	require.Error(t, err)
+}
+
+func TestARIMAExactFit(t *testing.T) {
+	constant := make([]float64, 30)
+	line := make([]float64, 30)
+	for i := range constant {
+		constant[i] = 3
+		line[i] = 2*float64(i) + 1
+	}
+
+	_, err := ARIMA(context.Background(), constant, ARIMAOptions{P: 1})
+	require.ErrorIs(t, err, ErrNoVariance)
+	_, err = ARIMA(context.Background(), line, ARIMAOptions{D: 2})
+	require.ErrorIs(t, err, ErrNoVariance)
+
+	// exact fits are skipped by the search.
+	_, err = ARIMA(context.Background(), constant, ARIMAOptions{Search: true})
+	require.Error(t, err)
+
+	res, err := ARIMA(context.Background(), line, ARIMAOptions{Search: true})
+	require.NoError(t, err)
+	require.False(t, math.IsInf(res.AIC, 0))
+	_, err = json.Marshal(res)
+	require.NoError(t, err)
+}
+
+func TestFromPartials(t *testing.T) {
+	require.Empty(t, fromPartials(nil))
+
+	// partial autocorrelations a and b give coefficients a(1-b) and b.
+	a, b := math.Tanh(0.3), math.Tanh(-0.8)
+	require.InDeltaSlice(t, []float64{a * (1 - b), b}, fromPartials([]float64{0.3, -0.8}), 1e-12)
+}
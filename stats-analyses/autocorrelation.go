@@ -0,0 +1,370 @@
+package statsanal
+
+import (
+	"fmt"
+	"math"
+
+	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/gonum/stat"
+	"gonum.org/v1/gonum/stat/distuv"
+)
+
+// Difference differences the series `x` `order` times at lag `lag`, each
+// time replacing every value from the lag-th on by its difference with the
+// value `lag` steps before. A `lag` of the seasonal period removes a
+// seasonal pattern.
+//
+// Returns an error if the series has missing values, the order is negative,
+// the lag isn't positive, or no value is left.
+func Difference(x []float64, order, lag int) ([]float64, error) {
+	if err := checkSeries(x, 1); err != nil {
+		return nil, err
+	}
+	if order < 0 || lag < 1 {
+		return nil, fmt.Errorf(
+			"Order should not be negative and lag should be positive, got %d and %d.",
+			order, lag)
+	}
+	if order*lag >= len(x) {
+		return nil, fmt.Errorf(
+			"Can't difference %d values %d times at lag %d.", len(x), order, lag)
+	}
+
+	res := append([]float64(nil), x...)
+	for k := 0; k < order; k++ {
+		for t := len(res) - 1; t >= lag; t-- {
+			res[t] -= res[t-lag]
+		}
+		res = res[lag:]
+	}
+	return res, nil
+}
+
+// AutocorrelationResult holds the autocorrelations of a series.
+type AutocorrelationResult struct {
+	// ACF and PACF hold the autocorrelation and partial autocorrelation at
+	// lags 1 to len(ACF).
+	ACF  []float64 `json:"acf"`
+	PACF []float64 `json:"pacf"`
+	// Bound is the half width of the band around 0 holding the
+	// autocorrelations of white noise at the Confidence level.
+	Bound      float64 `json:"bound"`
+	Confidence float64 `json:"confidence"`
+}
+
+// Autocorrelation finds the autocorrelations and partial autocorrelations
+// of the series `x` at lags 1 to `lags`, which defaults to 10*log10(n)
+// bounded by n-1, along with the band of white noise at the `confidence`
+// level, which defaults to DefaultConfidence.
+//
+// Returns an error if the series is constant, too short or has missing
+// values, or the lags or confidence level are invalid.
+func Autocorrelation(x []float64, lags int, confidence float64) (res AutocorrelationResult, err error) {
+	if err = checkSeries(x, 2); err != nil {
+		return
+	}
+	n := len(x)
+	if lags == 0 {
+		lags = min(int(10*math.Log10(float64(n))), n-1)
+	}
+	if lags < 1 || lags >= n {
+		err = fmt.Errorf("Lags should be in [1, %d], got %d.", n-1, lags)
+		return
+	}
+	if confidence == 0 {
+		confidence = DefaultConfidence
+	}
+	if confidence <= 0 || confidence >= 1 {
+		err = fmt.Errorf("Confidence level should be in (0, 1), got %v.", confidence)
+		return
+	}
+
+	if res.ACF, err = acf(x, lags); err != nil {
+		return
+	}
+	res.PACF = pacf(res.ACF)
+	res.Confidence = confidence
+	res.Bound = distuv.UnitNormal.Quantile((1+confidence)/2) / math.Sqrt(float64(n))
+	return
+}
+
+// acf finds the autocorrelations of the series `x` at lags 1 to `lags`.
+//
+// Returns an error if the series is constant.
+func acf(x []float64, lags int) ([]float64, error) {
+	mu := stat.Mean(x, nil)
+	var denom float64
+	for _, v := range x {
+		denom += (v - mu) * (v - mu)
+	}
+	if denom == 0 {
+		return nil, fmt.Errorf("Series is constant.")
+	}
+
+	res := make([]float64, lags)
+	for k := 1; k <= lags; k++ {
+		var num float64
+		for t := k; t < len(x); t++ {
+			num += (x[t] - mu) * (x[t-k] - mu)
+		}
+		res[k-1] = num / denom
+	}
+	return res, nil
+}
+
+// pacf finds the partial autocorrelations matching the autocorrelations `r`
+// at lags 1 to len(r) with the Durbin-Levinson recursion.
+func pacf(r []float64) []float64 {
+	res := make([]float64, len(r))
+	phi := make([]float64, 0, len(r))
+	for k := range r {
+		num, den := r[k], 1.0
+		for j, p := range phi {
+			num -= p * r[k-1-j]
+			den -= p * r[j]
+		}
+		a := num / den
+		next := make([]float64, k+1)
+		for j, p := range phi {
+			next[j] = p - a*phi[k-1-j]
+		}
+		next[k] = a
+		phi = next
+		res[k] = a
+	}
+	return res
+}
+
+// LjungBox tests whether the series `x` is white noise from its
+// autocorrelations at lags 1 to `lags`. The statistic follows the
+// chi-square distribution with `lags` - `fitted` degrees of freedom, where
+// `fitted` is the number of parameters of the model whose residuals `x`
+// are.
+//
+// Returns an error if the series is constant, too short or has missing
+// values, or the lags are invalid.
+func LjungBox(x []float64, lags, fitted int) (res TestResult, err error) {
+	if err = checkSeries(x, 2); err != nil {
+		return
+	}
+	n := len(x)
+	if lags < 1 || lags >= n {
+		err = fmt.Errorf("Lags should be in [1, %d], got %d.", n-1, lags)
+		return
+	}
+	if fitted < 0 || fitted >= lags {
+		err = fmt.Errorf("Fitted parameters should be in [0, %d), got %d.", lags, fitted)
+		return
+	}
+
+	r, err := acf(x, lags)
+	if err != nil {
+		return
+	}
+	var q float64
+	for k, v := range r {
+		q += v * v / float64(n-k-1)
+	}
+	q *= float64(n * (n + 2))
+	df := float64(lags - fitted)
+
+	res.Test = "ljung_box"
+	res.Statistic = finite(q)
+	res.DF = []float64{df}
+	res.PValue = finite(distuv.ChiSquared{K: df}.Survival(q))
+	res.Observations = []int{n}
+	return
+}
+
+// ADFRegression is the deterministic part of the regression of an augmented
+// Dickey-Fuller test.
+type ADFRegression string
+
+const (
+	// ADFConstant regresses on a constant, testing for a stationary series
+	// around a mean.
+	ADFConstant ADFRegression = "constant"
+	// ADFTrend regresses on a constant and a linear trend, testing for a
+	// stationary series around a trend.
+	ADFTrend ADFRegression = "trend"
+	// ADFNone regresses on no deterministic term, testing for a stationary
+	// series around 0.
+	ADFNone ADFRegression = "none"
+)
+
+// ADFOptions configures an augmented Dickey-Fuller test.
+type ADFOptions struct {
+	// Regression defaults to ADFConstant.
+	Regression ADFRegression
+	// Lags is the number of lagged differences in the regression.
+	Lags int
+	// AutoLags chooses the number of lagged differences, up to Lags, with the
+	// smallest AIC. Lags then defaults to 12*(n/100)^(1/4).
+	AutoLags bool
+}
+
+// ADF performs the augmented Dickey-Fuller test of a unit root in the
+// series `x`, whose null hypothesis is that the series isn't stationary. The
+// statistic is the t statistic of the lagged level in the regression of the
+// differences on it, the deterministic terms and the lagged differences,
+// and its p-value follows MacKinnon's (1994) approximation of its
+// distribution.
+//
+// Returns an error if the options are invalid, the series is too short or
+// has missing values, or the regression is rank deficient.
+func ADF(x []float64, opts ADFOptions) (res TestResult, err error) {
+	if opts.Regression == "" {
+		opts.Regression = ADFConstant
+	}
+	var deterministic int
+	switch opts.Regression {
+	case ADFNone:
+	case ADFConstant:
+		deterministic = 1
+	case ADFTrend:
+		deterministic = 2
+	default:
+		err = fmt.Errorf("Unknown regression %q.", opts.Regression)
+		return
+	}
+	if err = checkSeries(x, 4); err != nil {
+		return
+	}
+	n := len(x)
+	if opts.AutoLags && opts.Lags == 0 {
+		opts.Lags = int(12 * math.Pow(float64(n)/100, 0.25))
+		// keep enough observations to fit the largest regression.
+		opts.Lags = max(0, min(opts.Lags, (n-deterministic-4)/2))
+	}
+	if opts.Lags < 0 || n-opts.Lags-1 < opts.Lags+deterministic+2 {
+		err = fmt.Errorf("Can't fit %d lags on %d values.", opts.Lags, n)
+		return
+	}
+
+	lags := opts.Lags
+	if opts.AutoLags {
+		// compare the AIC of the lags on the same observations.
+		best := math.Inf(1)
+		for k := 0; k <= opts.Lags; k++ {
+			fit, fitErr := adfRegression(x, k, opts.Lags, deterministic)
+			if fitErr != nil {
+				continue
+			}
+			if fit.aic < best {
+				best, lags = fit.aic, k
+			}
+		}
+	}
+
+	fit, err := adfRegression(x, lags, lags, deterministic)
+	if err != nil {
+		return
+	}
+
+	res.Test = "adf"
+	res.Statistic = finite(fit.tstat)
+	res.PValue = finite(mackinnonP(fit.tstat, opts.Regression))
+	res.Method = "asymptotic"
+	res.Lags = &lags
+	res.Observations = []int{fit.n}
+	return
+}
+
+// adfFit holds the outcome of an augmented Dickey-Fuller regression.
+type adfFit struct {
+	tstat float64
+	aic   float64
+	n     int
+}
+
+// adfRegression regresses the differences of the series `x` on its lagged
+// level, `deterministic` terms and `lags` lagged differences, starting at
+// the difference `skip` + 1 so regressions of up to `skip` lags share their
+// observations.
+func adfRegression(x []float64, lags, skip, deterministic int) (fit adfFit, err error) {
+	dx := make([]float64, len(x)-1)
+	for t := range dx {
+		dx[t] = x[t+1] - x[t]
+	}
+
+	// row i regresses dx[skip+i] on x[skip+i], the deterministic terms and
+	// dx[skip+i-1], ..., dx[skip+i-lags].
+	rows := len(dx) - skip
+	cols := 1 + deterministic + lags
+	if rows <= cols {
+		err = fmt.Errorf("Need more than %d observations, got %d.", cols, rows)
+		return
+	}
+	X := mat.NewDense(rows, cols, nil)
+	Y := mat.NewVecDense(rows, nil)
+	for i := 0; i < rows; i++ {
+		t := skip + i
+		Y.SetVec(i, dx[t])
+		X.Set(i, 0, x[t])
+		if deterministic > 0 {
+			X.Set(i, 1, 1)
+		}
+		if deterministic > 1 {
+			X.Set(i, 2, float64(t+1))
+		}
+		for k := 1; k <= lags; k++ {
+			X.Set(i, deterministic+k, dx[t-k])
+		}
+	}
+
+	ls, err := leastSquares(X, Y, false)
+	if err != nil {
+		return
+	}
+	var resid mat.VecDense
+	resid.MulVec(X, ls.beta.ColView(0))
+	resid.SubVec(Y, &resid)
+	sse := mat.Dot(&resid, &resid)
+	s2 := sse / float64(rows-cols)
+
+	fit.n = rows
+	fit.tstat = ls.beta.At(0, 0) / math.Sqrt(s2*ls.covUnscaled.At(0, 0))
+	fit.aic = float64(rows)*math.Log(sse/float64(rows)) + 2*float64(cols)
+	return
+}
+
+// mackinnonP approximates the p-value of the augmented Dickey-Fuller
+// statistic `tau` of a single series with the response surfaces of
+// MacKinnon (1994), "Approximate asymptotic distribution functions for
+// unit-root and cointegration tests".
+func mackinnonP(tau float64, regression ADFRegression) float64 {
+	// tauMin, tauStar and tauMax bound the surfaces, the small p one applies
+	// below tauStar.
+	var tauMin, tauStar, tauMax float64
+	var small, large []float64
+	switch regression {
+	case ADFNone:
+		tauMin, tauStar, tauMax = -19.04, -1.04, math.Inf(1)
+		small = []float64{0.6344, 1.2378, 0.032496}
+		large = []float64{0.4797, 0.93557, -0.06999, 0.033066}
+	case ADFConstant:
+		tauMin, tauStar, tauMax = -18.83, -1.61, 2.74
+		small = []float64{2.1659, 1.4412, 0.038269}
+		large = []float64{1.7339, 0.93202, -0.12745, -0.010368}
+	case ADFTrend:
+		tauMin, tauStar, tauMax = -16.18, -2.89, 0.70
+		small = []float64{3.2512, 1.6047, 0.049588}
+		large = []float64{2.5261, 0.61654, -0.37956, -0.060285}
+	}
+
+	switch {
+	case tau > tauMax:
+		return 1
+	case tau < tauMin:
+		return 0
+	}
+	coefs := large
+	if tau <= tauStar {
+		coefs = small
+	}
+	var z float64
+	for i := len(coefs) - 1; i >= 0; i-- {
+		z = z*tau + coefs[i]
+	}
+	return distuv.UnitNormal.CDF(z)
+}
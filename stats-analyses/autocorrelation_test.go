@@ -0,0 +1,173 @@
+package statsanal
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gonum.org/v1/gonum/stat/distuv"
+)
+
+func TestDifference(t *testing.T) {
+	x := []float64{1, 4, 9, 16, 25}
+
+	res, err := Difference(x, 1, 1)
+	require.NoError(t, err)
+	require.Equal(t, []float64{3, 5, 7, 9}, res)
+
+	res, err = Difference(x, 2, 1)
+	require.NoError(t, err)
+	require.Equal(t, []float64{2, 2, 2}, res)
+
+	res, err = Difference(x, 1, 2)
+	require.NoError(t, err)
+	require.Equal(t, []float64{8, 12, 16}, res)
+
+	// the series is left untouched.
+	res, err = Difference(x, 0, 1)
+	require.NoError(t, err)
+	require.Equal(t, x, res)
+	require.Equal(t, []float64{1, 4, 9, 16, 25}, x)
+
+	_, err = Difference(x, 5, 1)
+	require.Error(t, err)
+
+	_, err = Difference(x, -1, 1)
+	require.Error(t, err)
+
+	_, err = Difference(x, 1, 0)
+	require.Error(t, err)
+
+	_, err = Difference([]float64{1, math.NaN()}, 1, 1)
+	require.Error(t, err)
+}
+
+func TestAutocorrelation(t *testing.T) {
+	// deviations -2, -1, 0, 1, 2 summing to 10 squared, worked by hand.
+	x := []float64{1, 2, 3, 4, 5}
+	res, err := Autocorrelation(x, 3, 0)
+	require.NoError(t, err)
+	require.InDeltaSlice(t, []float64{0.4, -0.1, -0.4}, res.ACF, 1e-12)
+	require.InDelta(t, 0.4, res.PACF[0], 1e-12)
+	require.InDelta(t, (-0.1-0.16)/(1-0.16), res.PACF[1], 1e-12)
+	require.Equal(t, DefaultConfidence, res.Confidence)
+	require.InDelta(t, distuv.UnitNormal.Quantile(0.975)/math.Sqrt(5), res.Bound, 1e-12)
+
+	// the partial autocorrelations of an AR(1) process vanish after lag 1.
+	r := rand.New(rand.NewSource(7))
+	y := make([]float64, 2000)
+	for i := 1; i < len(y); i++ {
+		y[i] = 0.7*y[i-1] + r.NormFloat64()
+	}
+	res, err = Autocorrelation(y, 0, 0.99)
+	require.NoError(t, err)
+	require.Len(t, res.ACF, 33)
+	require.InDelta(t, 0.7, res.ACF[0], 0.05)
+	require.InDelta(t, 0.49, res.ACF[1], 0.05)
+	require.InDelta(t, 0.7, res.PACF[0], 0.05)
+	for _, v := range res.PACF[1:5] {
+		require.Less(t, math.Abs(v), res.Bound)
+	}
+
+	_, err = Autocorrelation(x, 5, 0)
+	require.Error(t, err)
+
+	_, err = Autocorrelation(x, 2, 1)
+	require.Error(t, err)
+
+	_, err = Autocorrelation([]float64{2, 2, 2}, 1, 0)
+	require.Error(t, err)
+}
+
+func TestLjungBox(t *testing.T) {
+	// autocorrelations 0.4 and -0.1 of 5 values.
+	x := []float64{1, 2, 3, 4, 5}
+	res, err := LjungBox(x, 2, 0)
+	require.NoError(t, err)
+	q := 5 * 7 * (0.16/4 + 0.01/3)
+	require.Equal(t, "ljung_box", res.Test)
+	require.InDelta(t, q, *res.Statistic, 1e-12)
+	require.Equal(t, []float64{2}, res.DF)
+	require.InDelta(t, math.Exp(-q/2), *res.PValue, 1e-12)
+
+	res, err = LjungBox(x, 2, 1)
+	require.NoError(t, err)
+	require.Equal(t, []float64{1}, res.DF)
+
+	_, err = LjungBox(x, 2, 2)
+	require.Error(t, err)
+
+	_, err = LjungBox(x, 5, 0)
+	require.Error(t, err)
+}
+
+func TestADF(t *testing.T) {
+	r := rand.New(rand.NewSource(11))
+	noise := make([]float64, 200)
+	walk := make([]float64, 200)
+	for i := range noise {
+		noise[i] = r.NormFloat64()
+		walk[i] = noise[i]
+		if i > 0 {
+			walk[i] += walk[i-1]
+		}
+	}
+
+	// without lags the statistic is the t statistic of the slope of the
+	// differences on the lagged values.
+	res, err := ADF(walk, ADFOptions{})
+	require.NoError(t, err)
+	var mx, my float64
+	n := len(walk) - 1
+	for i := 0; i < n; i++ {
+		mx += walk[i] / float64(n)
+		my += (walk[i+1] - walk[i]) / float64(n)
+	}
+	var sxx, sxy float64
+	for i := 0; i < n; i++ {
+		sxx += (walk[i] - mx) * (walk[i] - mx)
+		sxy += (walk[i] - mx) * (walk[i+1] - walk[i] - my)
+	}
+	slope := sxy / sxx
+	var sse float64
+	for i := 0; i < n; i++ {
+		e := walk[i+1] - walk[i] - my - slope*(walk[i]-mx)
+		sse += e * e
+	}
+	tstat := slope / math.Sqrt(sse/float64(n-2)/sxx)
+	require.Equal(t, "adf", res.Test)
+	require.InDelta(t, tstat, *res.Statistic, 1e-9)
+	require.Equal(t, 0, *res.Lags)
+	require.Equal(t, []int{n}, res.Observations)
+	require.Greater(t, *res.PValue, 0.1)
+
+	for _, regression := range []ADFRegression{ADFNone, ADFConstant, ADFTrend} {
+		res, err = ADF(noise, ADFOptions{Regression: regression, AutoLags: true})
+		require.NoError(t, err)
+		require.Less(t, *res.PValue, 0.01)
+		require.LessOrEqual(t, float64(*res.Lags), 12*math.Pow(2, 0.25))
+
+		res, err = ADF(walk, ADFOptions{Regression: regression, Lags: 2})
+		require.NoError(t, err)
+		require.Greater(t, *res.PValue, 0.05)
+		require.Equal(t, 2, *res.Lags)
+		require.Equal(t, []int{n - 2}, res.Observations)
+	}
+
+	_, err = ADF(walk, ADFOptions{Regression: "quadratic"})
+	require.Error(t, err)
+
+	_, err = ADF(walk[:10], ADFOptions{Lags: 5})
+	require.Error(t, err)
+}
+
+func TestMackinnonP(t *testing.T) {
+	// asymptotic critical values at the 5% and 1% levels.
+	require.InDelta(t, 0.05, mackinnonP(-1.94, ADFNone), 0.005)
+	require.InDelta(t, 0.05, mackinnonP(-2.86, ADFConstant), 0.005)
+	require.InDelta(t, 0.01, mackinnonP(-3.43, ADFConstant), 0.002)
+	require.InDelta(t, 0.05, mackinnonP(-3.41, ADFTrend), 0.005)
+	require.Equal(t, 1.0, mackinnonP(3, ADFConstant))
+	require.Equal(t, 0.0, mackinnonP(-20, ADFTrend))
+}
@@ -0,0 +1,314 @@
+package statsanal
+
+import (
+	"fmt"
+	"math"
+	"slices"
+
+	"gonum.org/v1/gonum/stat"
+)
+
+// ChangePointMethod is the search method of a change point detection.
+type ChangePointMethod string
+
+const (
+	// PELT finds the segmentation with the smallest penalized cost, pruning
+	// the candidate change points that can't be optimal, following
+	// Killick, Fearnhead & Eckley (2012), "Optimal detection of changepoints
+	// with a linear computational cost".
+	PELT ChangePointMethod = "pelt"
+	// BinarySegmentation repeatedly splits the segment whose best split
+	// lowers the cost the most, while the gain exceeds the penalty.
+	BinarySegmentation ChangePointMethod = "binary"
+)
+
+// ChangePointCost is the change in distribution a change point detection
+// looks for.
+type ChangePointCost string
+
+const (
+	// MeanCost detects changes in the mean of normal values with a constant
+	// variance, estimated from the median absolute deviation of the
+	// differences of the series, or as its variance when that is about
+	// zero.
+	MeanCost ChangePointCost = "mean"
+	// VarianceCost detects changes in the variance of normal values around
+	// the mean of the series.
+	VarianceCost ChangePointCost = "variance"
+	// MeanVarianceCost detects changes in the mean and variance of normal
+	// values.
+	MeanVarianceCost ChangePointCost = "meanvar"
+)
+
+// DefaultMinSegment is the default smallest number of values of a segment.
+const DefaultMinSegment = 2
+
+// ChangePointOptions configures a change point detection.
+type ChangePointOptions struct {
+	// Method defaults to PELT and Cost to MeanCost.
+	Method ChangePointMethod
+	Cost   ChangePointCost
+	// Penalty is added to the cost of each change point. Defaults to the
+	// BIC penalty, log(n) for each parameter of a segment plus one for the
+	// change point.
+	Penalty float64
+	// MinSegment is the smallest number of values of a segment, defaulting
+	// to DefaultMinSegment.
+	MinSegment int
+	// MaxChangePoints bounds the number of change points of a binary
+	// segmentation when positive.
+	MaxChangePoints int
+}
+
+// Segment is a run of values between change points.
+type Segment struct {
+	// Start and End are the indices of the first value and the one after
+	// the last.
+	Start    int     `json:"start"`
+	End      int     `json:"end"`
+	Mean     float64 `json:"mean"`
+	Variance float64 `json:"variance"`
+}
+
+// ChangePointResult holds the change points of a series.
+type ChangePointResult struct {
+	Method  ChangePointMethod `json:"method"`
+	Cost    ChangePointCost   `json:"cost"`
+	Penalty float64           `json:"penalty"`
+	// ChangePoints holds the index of the first value of each segment but
+	// the first.
+	ChangePoints []int     `json:"change_points"`
+	Segments     []Segment `json:"segments"`
+	// TotalCost is the cost of the segments plus the penalty of the change
+	// points.
+	TotalCost float64 `json:"total_cost"`
+}
+
+// ChangePoints detects the changes in the distribution of the series `x`
+// by minimizing the cost of its segments, twice their negative normal
+// log-likelihood, plus a penalty for each change point.
+//
+// Returns an error if the options are invalid, or the series has missing
+// values or less than two segments' worth of values.
+func ChangePoints(x []float64, opts ChangePointOptions) (res ChangePointResult, err error) {
+	if opts.Method == "" {
+		opts.Method = PELT
+	}
+	if opts.Cost == "" {
+		opts.Cost = MeanCost
+	}
+	if opts.MinSegment == 0 {
+		opts.MinSegment = DefaultMinSegment
+	}
+	if opts.MinSegment < 1 {
+		err = fmt.Errorf("Segments should have at least 1 value, got %d.", opts.MinSegment)
+		return
+	}
+	if opts.Penalty < 0 || math.IsNaN(opts.Penalty) {
+		err = fmt.Errorf("Penalty should not be negative, got %v.", opts.Penalty)
+		return
+	}
+	if opts.MaxChangePoints < 0 {
+		err = fmt.Errorf(
+			"Maximum number of change points should not be negative, got %d.",
+			opts.MaxChangePoints)
+		return
+	}
+	if err = checkSeries(x, 2*opts.MinSegment); err != nil {
+		return
+	}
+
+	c, params, err := newSegmentCost(x, opts.Cost)
+	if err != nil {
+		return
+	}
+	if opts.Penalty == 0 {
+		opts.Penalty = float64(params+1) * math.Log(float64(len(x)))
+	}
+
+	var points []int
+	switch opts.Method {
+	case PELT:
+		points = c.pelt(opts.Penalty, opts.MinSegment)
+	case BinarySegmentation:
+		points = c.binary(opts.Penalty, opts.MinSegment, opts.MaxChangePoints)
+	default:
+		err = fmt.Errorf("Unknown change point method %q.", opts.Method)
+		return
+	}
+
+	res.Method = opts.Method
+	res.Cost = opts.Cost
+	res.Penalty = opts.Penalty
+	res.ChangePoints = points
+	res.TotalCost = float64(len(points)) * opts.Penalty
+	bounds := append(append([]int{0}, points...), len(x))
+	for i := 1; i < len(bounds); i++ {
+		start, end := bounds[i-1], bounds[i]
+		seg := Segment{Start: start, End: end}
+		seg.Mean, seg.Variance = stat.PopMeanVariance(x[start:end], nil)
+		res.Segments = append(res.Segments, seg)
+		res.TotalCost += c.cost(start, end)
+	}
+	return
+}
+
+// segmentCost finds the cost of the segments of a series in constant time
+// from the cumulative sums of its values and squares.
+type segmentCost struct {
+	kind     ChangePointCost
+	sums     []float64
+	squares  []float64
+	mean     float64
+	variance float64
+	// floor bounds the variance of a segment away from zero.
+	floor float64
+}
+
+// newSegmentCost returns the cost `kind` of the segments of the series `x`,
+// along with the number of parameters of a segment.
+//
+// Returns an error if the cost is unknown or the series is constant.
+func newSegmentCost(x []float64, kind ChangePointCost) (*segmentCost, int, error) {
+	c := &segmentCost{
+		kind:    kind,
+		sums:    make([]float64, len(x)+1),
+		squares: make([]float64, len(x)+1),
+	}
+	c.mean, c.variance = stat.PopMeanVariance(x, nil)
+	if c.variance == 0 {
+		return nil, 0, fmt.Errorf("Series is constant.")
+	}
+	c.floor = 1e-8 * c.variance
+	for t, v := range x {
+		c.sums[t+1] = c.sums[t] + v
+		c.squares[t+1] = c.squares[t] + v*v
+	}
+
+	switch kind {
+	case MeanCost:
+		// the differences cancel out the changes in mean, their variance is
+		// twice the one of the values.
+		diffs := make([]float64, len(x)-1)
+		for t := range diffs {
+			diffs[t] = x[t+1] - x[t]
+		}
+		slices.Sort(diffs)
+		center := median(diffs)
+		for t := range diffs {
+			diffs[t] = math.Abs(diffs[t] - center)
+		}
+		slices.Sort(diffs)
+		// rounding errors of regular differences don't make a spread.
+		sd := median(diffs) / 0.6744897501960817 / math.Sqrt2
+		if sd > 1e-8*math.Sqrt(c.variance) {
+			c.variance = sd * sd
+		}
+		return c, 1, nil
+	case VarianceCost:
+		return c, 1, nil
+	case MeanVarianceCost:
+		return c, 2, nil
+	}
+	return nil, 0, fmt.Errorf("Unknown change point cost %q.", kind)
+}
+
+// cost returns the cost of the values `start` to `end`, excluded.
+func (c *segmentCost) cost(start, end int) float64 {
+	m := float64(end - start)
+	sum := c.sums[end] - c.sums[start]
+	squares := c.squares[end] - c.squares[start]
+	switch c.kind {
+	case MeanCost:
+		return max(0, squares-sum*sum/m) / c.variance
+	case VarianceCost:
+		dev := squares - 2*c.mean*sum + m*c.mean*c.mean
+		return m * math.Log(max(dev/m, c.floor))
+	default:
+		return m * math.Log(max((squares-sum*sum/m)/m, c.floor))
+	}
+}
+
+// pelt returns the change points of the segmentation of the series with
+// segments of at least `minSegment` values minimizing its cost plus
+// `penalty` per change point.
+func (c *segmentCost) pelt(penalty float64, minSegment int) []int {
+	n := len(c.sums) - 1
+	// best[t] is the smallest penalized cost of the first t values, whose
+	// last segment starts at last[t].
+	best := make([]float64, n+1)
+	last := make([]int, n+1)
+	best[0] = -penalty
+	var candidates []int
+	for t := minSegment; t <= n; t++ {
+		// the last segment may start at the end of any admissible
+		// segmentation.
+		if s := t - minSegment; s == 0 || s >= minSegment {
+			candidates = append(candidates, s)
+		}
+		best[t] = math.Inf(1)
+		for _, s := range candidates {
+			if v := best[s] + c.cost(s, t) + penalty; v < best[t] {
+				best[t], last[t] = v, s
+			}
+		}
+		// a start that can't beat the best one ending at w never will for
+		// the segments ending from w + minSegment on, so from the next one.
+		w := t + 1 - minSegment
+		if w != 0 && w < minSegment {
+			continue
+		}
+		candidates = slices.DeleteFunc(candidates, func(s int) bool {
+			return s < w && best[s]+c.cost(s, w) > best[w]
+		})
+	}
+
+	var points []int
+	for t := last[n]; t > 0; t = last[t] {
+		points = append(points, t)
+	}
+	slices.Reverse(points)
+	return points
+}
+
+// binary returns the change points of the series found by binary
+// segmentation with segments of at least `minSegment` values, splitting
+// while the gain exceeds `penalty` and, when positive, up to `maxPoints`
+// times.
+func (c *segmentCost) binary(penalty float64, minSegment, maxPoints int) []int {
+	n := len(c.sums) - 1
+	type split struct {
+		start, end, at int
+		gain           float64
+	}
+	bestSplit := func(start, end int) split {
+		res := split{start: start, end: end, at: -1}
+		total := c.cost(start, end)
+		for s := start + minSegment; s <= end-minSegment; s++ {
+			if gain := total - c.cost(start, s) - c.cost(s, end); res.at < 0 || gain > res.gain {
+				res.at, res.gain = s, gain
+			}
+		}
+		return res
+	}
+
+	var points []int
+	splits := []split{bestSplit(0, n)}
+	for maxPoints <= 0 || len(points) < maxPoints {
+		i := -1
+		for j, s := range splits {
+			if s.at >= 0 && (i < 0 || s.gain > splits[i].gain) {
+				i = j
+			}
+		}
+		if i < 0 || splits[i].gain <= penalty {
+			break
+		}
+		s := splits[i]
+		points = append(points, s.at)
+		splits = slices.Delete(splits, i, i+1)
+		splits = append(splits, bestSplit(s.start, s.at), bestSplit(s.at, s.end))
+	}
+	slices.Sort(points)
+	return points
+}
@@ -0,0 +1,144 @@
+package statsanal
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// shiftedSeries returns normal values with standard deviations `sds` and
+// means `means` over consecutive segments of `size` values.
+func shiftedSeries(r *rand.Rand, size int, means, sds []float64) []float64 {
+	var x []float64
+	for i := range means {
+		for j := 0; j < size; j++ {
+			x = append(x, means[i]+sds[i]*r.NormFloat64())
+		}
+	}
+	return x
+}
+
+func TestChangePoints(t *testing.T) {
+	r := rand.New(rand.NewSource(4))
+	x := shiftedSeries(r, 50, []float64{0, 5, 0}, []float64{1, 1, 1})
+
+	for _, method := range []ChangePointMethod{PELT, BinarySegmentation} {
+		res, err := ChangePoints(x, ChangePointOptions{Method: method})
+		require.NoError(t, err)
+		require.Equal(t, method, res.Method)
+		require.Equal(t, MeanCost, res.Cost)
+		require.InDelta(t, 2*math.Log(150), res.Penalty, 1e-12)
+		require.Equal(t, []int{50, 100}, res.ChangePoints)
+		require.Len(t, res.Segments, 3)
+		require.Equal(t, Segment{Start: 50, End: 100}, Segment{
+			Start: res.Segments[1].Start,
+			End:   res.Segments[1].End,
+		})
+		require.InDelta(t, 5, res.Segments[1].Mean, 0.5)
+		require.InDelta(t, 1, res.Segments[1].Variance, 0.5)
+	}
+
+	// a binary segmentation stops at the largest change.
+	res, err := ChangePoints(x, ChangePointOptions{
+		Method:          BinarySegmentation,
+		MaxChangePoints: 1,
+	})
+	require.NoError(t, err)
+	require.Len(t, res.ChangePoints, 1)
+
+	// a huge penalty keeps a single segment.
+	res, err = ChangePoints(x, ChangePointOptions{Penalty: 1e9})
+	require.NoError(t, err)
+	require.Empty(t, res.ChangePoints)
+	require.Equal(t, []Segment{{Start: 0, End: 150, Mean: res.Segments[0].Mean,
+		Variance: res.Segments[0].Variance}}, res.Segments)
+
+	// a change in variance goes unnoticed by the mean cost.
+	x = shiftedSeries(r, 100, []float64{0, 0}, []float64{1, 5})
+	for _, cost := range []ChangePointCost{VarianceCost, MeanVarianceCost} {
+		res, err = ChangePoints(x, ChangePointOptions{Cost: cost, MinSegment: 5})
+		require.NoError(t, err)
+		require.Len(t, res.ChangePoints, 1)
+		require.InDelta(t, 100, res.ChangePoints[0], 5)
+	}
+
+	_, err = ChangePoints(x, ChangePointOptions{Method: "window"})
+	require.Error(t, err)
+
+	_, err = ChangePoints(x, ChangePointOptions{Cost: "poisson"})
+	require.Error(t, err)
+
+	_, err = ChangePoints(x, ChangePointOptions{Penalty: -1})
+	require.Error(t, err)
+
+	_, err = ChangePoints(x[:5], ChangePointOptions{MinSegment: 3})
+	require.Error(t, err)
+
+	_, err = ChangePoints([]float64{1, 1, 1, 1}, ChangePointOptions{})
+	require.Error(t, err)
+}
+
+func TestSegmentCost(t *testing.T) {
+	x := []float64{1, 2, 4, 8, 16}
+	for _, kind := range []ChangePointCost{MeanCost, VarianceCost, MeanVarianceCost} {
+		c, _, err := newSegmentCost(x, kind)
+		require.NoError(t, err)
+
+		// values 2, 4 and 8 with mean 14/3 and squares summing to 84.
+		dev := 84 - 14.0*14/3
+		switch kind {
+		case MeanCost:
+			// the differences 1, 2, 4 and 8 deviate from their median 3
+			// by 2, 1, 1 and 5.
+			sd := 1.5 / 0.6744897501960817 / math.Sqrt2
+			require.InDelta(t, dev/(sd*sd), c.cost(1, 4), 1e-9)
+		case VarianceCost:
+			mu := 31.0 / 5
+			around := (2-mu)*(2-mu) + (4-mu)*(4-mu) + (8-mu)*(8-mu)
+			require.InDelta(t, 3*math.Log(around/3), c.cost(1, 4), 1e-9)
+		case MeanVarianceCost:
+			require.InDelta(t, 3*math.Log(dev/3), c.cost(1, 4), 1e-9)
+		}
+	}
+}
+
+func TestPELTOptimal(t *testing.T) {
+	// PELT finds the same optimum as the exhaustive dynamic program.
+	r := rand.New(rand.NewSource(8))
+	x := shiftedSeries(r, 15, []float64{0, 2, -1, 3, 3}, []float64{1, 0.5, 2, 1, 1})
+	for _, kind := range []ChangePointCost{MeanCost, VarianceCost, MeanVarianceCost} {
+		for _, minSegment := range []int{1, 2, 4} {
+			if kind != MeanCost && minSegment < 2 {
+				continue
+			}
+			c, _, err := newSegmentCost(x, kind)
+			require.NoError(t, err)
+			penalty := 3.0
+
+			n := len(x)
+			best := make([]float64, n+1)
+			for t := 1; t <= n; t++ {
+				best[t] = math.Inf(1)
+			}
+			best[0] = -penalty
+			for t := minSegment; t <= n; t++ {
+				for s := 0; s <= t-minSegment; s++ {
+					best[t] = math.Min(best[t], best[s]+c.cost(s, t)+penalty)
+				}
+			}
+
+			res, err := ChangePoints(x, ChangePointOptions{
+				Cost:       kind,
+				Penalty:    penalty,
+				MinSegment: minSegment,
+			})
+			require.NoError(t, err)
+			require.InDelta(t, best[n], res.TotalCost, 1e-9)
+			for _, seg := range res.Segments {
+				require.GreaterOrEqual(t, seg.End-seg.Start, minSegment)
+			}
+		}
+	}
+}
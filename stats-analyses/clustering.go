@@ -0,0 +1,466 @@
+package statsanal
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// Linkage is the distance between clusters of an agglomerative clustering.
+type Linkage string
+
+const (
+	// WardLinkage merges the clusters increasing the least the sum of the
+	// squared distances of the rows to the mean of their cluster.
+	WardLinkage Linkage = "ward"
+	// SingleLinkage is the distance between the closest rows of the
+	// clusters.
+	SingleLinkage Linkage = "single"
+	// CompleteLinkage is the distance between the farthest rows of the
+	// clusters.
+	CompleteLinkage Linkage = "complete"
+	// AverageLinkage is the mean distance between the rows of the clusters.
+	AverageLinkage Linkage = "average"
+)
+
+const (
+	// Noise is the label of the rows of no cluster.
+	Noise = -1
+	// DefaultMinPoints is the number of rows within reach of a core row,
+	// itself included, when none is given.
+	DefaultMinPoints = 5
+	// MaxAgglomerativeRows is the largest number of rows clustered by
+	// Agglomerative, which keeps the distances between every pair of rows,
+	// about 100MB for this many.
+	MaxAgglomerativeRows = 5000
+)
+
+// AgglomerativeOptions configures an agglomerative clustering.
+type AgglomerativeOptions struct {
+	// Linkage defaults to WardLinkage.
+	Linkage Linkage
+	// Clusters is the number of clusters the rows are labelled with, by
+	// cutting the dendrogram. No labels are returned when zero.
+	Clusters int
+	// Standardize scales the columns to unit variance before clustering, so
+	// every column weighs the same in the distances.
+	Standardize bool
+	// Names of the columns of the matrix. Defaults to ColumnNames.
+	Names []string
+}
+
+// Merge is a merge of two clusters of the dendrogram. Rows are the clusters
+// 0 to n-1, and the i-th merge forms cluster n+i.
+type Merge struct {
+	Left  int `json:"left"`
+	Right int `json:"right"`
+	// Distance between the merged clusters, the euclidean distance between
+	// their means scaled by their sizes for WardLinkage.
+	Distance float64 `json:"distance"`
+	// Size is the number of rows of the formed cluster.
+	Size int `json:"size"`
+}
+
+// AgglomerativeResult holds the outcome of an agglomerative clustering.
+type AgglomerativeResult struct {
+	Names   []string `json:"names"`
+	Linkage Linkage  `json:"linkage"`
+	// Merges is the dendrogram, ordered by increasing distance.
+	Merges []Merge `json:"merges"`
+	// Labels holds the cluster of each row when cutting the dendrogram in
+	// Clusters clusters, numbered from 0 in the order of their first row.
+	Clusters int   `json:"clusters,omitempty"`
+	Labels   []int `json:"labels,omitempty"`
+	Sizes    []int `json:"sizes,omitempty"`
+}
+
+// Agglomerative clusters the rows of matrix `m` bottom-up, starting from a
+// cluster per row and repeatedly merging the two closest clusters, using the
+// nearest-neighbor chain algorithm on euclidean distances.
+//
+// Returns an error if `m` has more than MaxAgglomerativeRows rows, the options
+// or names are invalid, a column is constant while standardizing, or `ctx` is
+// done before the end.
+func Agglomerative(ctx context.Context, m mat.Matrix, opts AgglomerativeOptions) (res AgglomerativeResult, err error) {
+	r, c := m.Dims()
+	if r < 2 || c == 0 {
+		err = fmt.Errorf("Need at least 2 rows and 1 column, got %dx%d.", r, c)
+		return
+	}
+	if r > MaxAgglomerativeRows {
+		err = fmt.Errorf(
+			"Can't cluster more than %d rows, got %d.", MaxAgglomerativeRows, r)
+		return
+	}
+	if opts.Linkage == "" {
+		opts.Linkage = WardLinkage
+	}
+	switch opts.Linkage {
+	case WardLinkage, SingleLinkage, CompleteLinkage, AverageLinkage:
+	default:
+		err = fmt.Errorf("Unknown linkage %q.", opts.Linkage)
+		return
+	}
+	if opts.Clusters < 0 || opts.Clusters > r {
+		err = fmt.Errorf("Number of clusters should be in [1, %d], got %d.", r, opts.Clusters)
+		return
+	}
+	data, err := clusteringData(m, opts.Standardize, &opts.Names)
+	if err != nil {
+		return
+	}
+
+	res.Names = opts.Names
+	res.Linkage = opts.Linkage
+	if res.Merges, err = nnChain(ctx, data, opts.Linkage); err != nil {
+		return
+	}
+
+	if opts.Clusters > 0 {
+		res.Clusters = opts.Clusters
+		res.Labels, res.Sizes = cutDendrogram(res.Merges, r, opts.Clusters)
+	}
+	return
+}
+
+// clusteringData returns a copy of matrix `m` to cluster, scaled when
+// `scale` is set, defaulting the `names` of its columns.
+//
+// Returns an error if the names don't match the columns, or a column is
+// constant while scaling.
+func clusteringData(m mat.Matrix, scale bool, names *[]string) (*mat.Dense, error) {
+	_, c := m.Dims()
+	if *names == nil {
+		*names = ColumnNames(c)
+	}
+	if len(*names) != c {
+		return nil, fmt.Errorf("Got %d names for %d columns.", len(*names), c)
+	}
+
+	data := mat.DenseCopyOf(m)
+	if scale {
+		if err := scaleColumns(data, *names); err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// condensed holds the distances between `n` clusters, the distance between
+// clusters i < j at index n*i - i*(i+1)/2 + j - i - 1.
+type condensed struct {
+	n    int
+	dist []float64
+}
+
+func (d condensed) index(i, j int) int {
+	if i > j {
+		i, j = j, i
+	}
+	return d.n*i - i*(i+1)/2 + j - i - 1
+}
+
+func (d condensed) at(i, j int) float64 {
+	return d.dist[d.index(i, j)]
+}
+
+func (d condensed) set(i, j int, v float64) {
+	d.dist[d.index(i, j)] = v
+}
+
+// nnChain builds the dendrogram of the rows of `data` with `linkage`. It
+// follows a chain of nearest neighbors until two clusters are each other's
+// nearest neighbor, merges them, and updates their distances to the other
+// clusters with the Lance-Williams formula. The merges are then sorted by
+// distance, which is valid since these linkages never decrease when
+// merging.
+//
+// Returns the error of `ctx` if it is done before the end.
+func nnChain(ctx context.Context, data *mat.Dense, linkage Linkage) ([]Merge, error) {
+	n, _ := data.Dims()
+
+	// Ward's linkage is updated on squared distances.
+	d := condensed{n: n, dist: make([]float64, n*(n-1)/2)}
+	for i := 0; i < n; i++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		for j := i + 1; j < n; j++ {
+			dist := squaredDistance(data.RawRowView(i), data.RawRowView(j))
+			if linkage != WardLinkage {
+				dist = math.Sqrt(dist)
+			}
+			d.set(i, j, dist)
+		}
+	}
+
+	// a merged cluster takes the slot of its second cluster.
+	sizes := make([]int, n)
+	active := make([]bool, n)
+	for i := range sizes {
+		sizes[i] = 1
+		active[i] = true
+	}
+
+	type slotMerge struct {
+		a, b int
+		dist float64
+		size int
+	}
+	merges := make([]slotMerge, 0, n-1)
+	var chain []int
+	for len(merges) < n-1 {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if len(chain) == 0 {
+			for i := range active {
+				if active[i] {
+					chain = append(chain, i)
+					break
+				}
+			}
+		}
+
+		a := chain[len(chain)-1]
+		// the previous cluster of the chain wins ties, so it stops.
+		b, best := -1, math.Inf(1)
+		if len(chain) > 1 {
+			b = chain[len(chain)-2]
+			best = d.at(a, b)
+		}
+		for k := range active {
+			if active[k] && k != a && d.at(a, k) < best {
+				b, best = k, d.at(a, k)
+			}
+		}
+
+		if len(chain) < 2 || b != chain[len(chain)-2] {
+			chain = append(chain, b)
+			continue
+		}
+		chain = chain[:len(chain)-2]
+
+		na, nb := float64(sizes[a]), float64(sizes[b])
+		for k := range active {
+			if !active[k] || k == a || k == b {
+				continue
+			}
+			dak, dbk := d.at(a, k), d.at(b, k)
+			var v float64
+			switch linkage {
+			case SingleLinkage:
+				v = math.Min(dak, dbk)
+			case CompleteLinkage:
+				v = math.Max(dak, dbk)
+			case AverageLinkage:
+				v = (na*dak + nb*dbk) / (na + nb)
+			case WardLinkage:
+				nk := float64(sizes[k])
+				v = ((na+nk)*dak + (nb+nk)*dbk - nk*best) / (na + nb + nk)
+			}
+			d.set(b, k, v)
+		}
+		if linkage == WardLinkage {
+			best = math.Sqrt(best)
+		}
+		active[a] = false
+		sizes[b] += sizes[a]
+		merges = append(merges, slotMerge{a: a, b: b, dist: best, size: sizes[b]})
+	}
+
+	sort.SliceStable(merges, func(i, j int) bool {
+		return merges[i].dist < merges[j].dist
+	})
+
+	// number the clusters formed by the sorted merges.
+	parent := make([]int, n)
+	ids := make([]int, n)
+	for i := range parent {
+		parent[i], ids[i] = i, i
+	}
+	find := func(i int) int {
+		for parent[i] != i {
+			parent[i] = parent[parent[i]]
+			i = parent[i]
+		}
+		return i
+	}
+
+	res := make([]Merge, len(merges))
+	for k, mg := range merges {
+		ra, rb := find(mg.a), find(mg.b)
+		left, right := ids[ra], ids[rb]
+		if left > right {
+			left, right = right, left
+		}
+		res[k] = Merge{Left: left, Right: right, Distance: mg.dist, Size: mg.size}
+		parent[ra] = rb
+		ids[rb] = n + k
+	}
+	return res, nil
+}
+
+// cutDendrogram labels the `n` rows with their cluster after the first
+// merges of the dendrogram `merges` leaving `k` clusters, numbered from 0 in
+// the order of their first row. Returns the labels and the cluster sizes.
+func cutDendrogram(merges []Merge, n, k int) (labels, sizes []int) {
+	// parent holds the cluster each cluster was merged into.
+	parent := make([]int, 2*n-k)
+	for i := range parent {
+		parent[i] = i
+	}
+	for i, mg := range merges[:n-k] {
+		parent[mg.Left], parent[mg.Right] = n+i, n+i
+	}
+
+	labels = make([]int, n)
+	numbers := map[int]int{}
+	for i := range labels {
+		root := i
+		for parent[root] != root {
+			root = parent[root]
+		}
+		l, ok := numbers[root]
+		if !ok {
+			l = len(numbers)
+			numbers[root] = l
+			sizes = append(sizes, 0)
+		}
+		labels[i] = l
+		sizes[l]++
+	}
+	return
+}
+
+// DBSCANOptions configures a DBSCAN clustering.
+type DBSCANOptions struct {
+	// Eps is the largest distance between two rows within reach of each
+	// other.
+	Eps float64
+	// MinPoints is the number of rows within reach of a core row, itself
+	// included. Defaults to DefaultMinPoints.
+	MinPoints int
+	// Standardize scales the columns to unit variance before clustering, so
+	// every column weighs the same in the distances.
+	Standardize bool
+	// Names of the columns of the matrix. Defaults to ColumnNames.
+	Names []string
+}
+
+// DBSCANResult holds the outcome of a DBSCAN clustering.
+type DBSCANResult struct {
+	Names     []string `json:"names"`
+	Eps       float64  `json:"eps"`
+	MinPoints int      `json:"min_points"`
+	Clusters  int      `json:"clusters"`
+	// Labels holds the cluster of each row, numbered from 0 in the order of
+	// their first core row, or Noise.
+	Labels []int `json:"labels"`
+	Sizes  []int `json:"sizes"`
+	// Core holds whether each row is a core row.
+	Core []bool `json:"core"`
+	// Noise holds the rows of no cluster.
+	Noise []int `json:"noise"`
+}
+
+// DBSCAN clusters the rows of matrix `m` by density: a core row has at
+// least `opts.MinPoints` rows within euclidean distance `opts.Eps`, a
+// cluster gathers the core rows within reach of each other along with the
+// rows within reach of them, and the other rows are noise. A row within
+// reach of the core rows of several clusters joins the first one.
+//
+// Returns an error if the options or names are invalid, a column is
+// constant while standardizing, or `ctx` is done before the end.
+func DBSCAN(ctx context.Context, m mat.Matrix, opts DBSCANOptions) (res DBSCANResult, err error) {
+	r, c := m.Dims()
+	if r == 0 || c == 0 {
+		err = fmt.Errorf("Need at least 1 row and 1 column, got %dx%d.", r, c)
+		return
+	}
+	if !(opts.Eps > 0) {
+		err = fmt.Errorf("Eps should be positive, got %v.", opts.Eps)
+		return
+	}
+	if opts.MinPoints == 0 {
+		opts.MinPoints = DefaultMinPoints
+	}
+	if opts.MinPoints < 1 {
+		err = fmt.Errorf("Minimum points should be at least 1, got %d.", opts.MinPoints)
+		return
+	}
+	data, err := clusteringData(m, opts.Standardize, &opts.Names)
+	if err != nil {
+		return
+	}
+
+	eps2 := opts.Eps * opts.Eps
+	neighbors := func(i int) []int {
+		var res []int
+		for j := 0; j < r; j++ {
+			if squaredDistance(data.RawRowView(i), data.RawRowView(j)) <= eps2 {
+				res = append(res, j)
+			}
+		}
+		return res
+	}
+
+	res.Names = opts.Names
+	res.Eps = opts.Eps
+	res.MinPoints = opts.MinPoints
+	res.Labels = make([]int, r)
+	res.Core = make([]bool, r)
+	visited := make([]bool, r)
+	for i := range res.Labels {
+		res.Labels[i] = Noise
+	}
+
+	for i := 0; i < r; i++ {
+		if err = ctx.Err(); err != nil {
+			return
+		}
+		if visited[i] {
+			continue
+		}
+		visited[i] = true
+		reach := neighbors(i)
+		if len(reach) < opts.MinPoints {
+			continue
+		}
+
+		// expand a new cluster from core row i.
+		l := res.Clusters
+		res.Clusters++
+		res.Sizes = append(res.Sizes, 0)
+		res.Core[i] = true
+		res.Labels[i] = l
+		res.Sizes[l]++
+		for len(reach) > 0 {
+			j := reach[0]
+			reach = reach[1:]
+			if res.Labels[j] == Noise {
+				res.Labels[j] = l
+				res.Sizes[l]++
+			}
+			if visited[j] {
+				continue
+			}
+			visited[j] = true
+			if next := neighbors(j); len(next) >= opts.MinPoints {
+				res.Core[j] = true
+				reach = append(reach, next...)
+			}
+		}
+	}
+
+	res.Noise = []int{}
+	for i, l := range res.Labels {
+		if l == Noise {
+			res.Noise = append(res.Noise, i)
+		}
+	}
+	return
+}
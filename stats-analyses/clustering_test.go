@@ -0,0 +1,225 @@
+package statsanal
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gonum.org/v1/gonum/floats"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestAgglomerative(t *testing.T) {
+	m := mat.NewDense(4, 1, []float64{0, 1, 3, 7})
+
+	testCases := []struct {
+		linkage Linkage
+		merges  []Merge
+	}{
+		{
+			linkage: SingleLinkage,
+			merges: []Merge{
+				{Left: 0, Right: 1, Distance: 1, Size: 2},
+				{Left: 2, Right: 4, Distance: 2, Size: 3},
+				{Left: 3, Right: 5, Distance: 4, Size: 4},
+			},
+		},
+		{
+			linkage: CompleteLinkage,
+			merges: []Merge{
+				{Left: 0, Right: 1, Distance: 1, Size: 2},
+				{Left: 2, Right: 4, Distance: 3, Size: 3},
+				{Left: 3, Right: 5, Distance: 7, Size: 4},
+			},
+		},
+		{
+			linkage: AverageLinkage,
+			merges: []Merge{
+				{Left: 0, Right: 1, Distance: 1, Size: 2},
+				{Left: 2, Right: 4, Distance: 2.5, Size: 3},
+				{Left: 3, Right: 5, Distance: 17.0 / 3, Size: 4},
+			},
+		},
+		{
+			// sqrt(2*na*nb/(na+nb)) times the distance between the means.
+			linkage: WardLinkage,
+			merges: []Merge{
+				{Left: 0, Right: 1, Distance: 1, Size: 2},
+				{Left: 2, Right: 4, Distance: math.Sqrt(4.0/3) * 2.5, Size: 3},
+				{Left: 3, Right: 5, Distance: math.Sqrt(1.5) * (7 - 4.0/3), Size: 4},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		res, err := Agglomerative(context.Background(), m, AgglomerativeOptions{Linkage: tc.linkage})
+		require.NoError(t, err)
+		require.Equal(t, tc.linkage, res.Linkage)
+		require.Equal(t, []string{"col_0"}, res.Names)
+		require.Len(t, res.Merges, 3)
+		for i, mg := range res.Merges {
+			require.Equal(t, tc.merges[i].Left, mg.Left, tc.linkage)
+			require.Equal(t, tc.merges[i].Right, mg.Right, tc.linkage)
+			require.Equal(t, tc.merges[i].Size, mg.Size, tc.linkage)
+			require.InDelta(t, tc.merges[i].Distance, mg.Distance, 1e-12, tc.linkage)
+		}
+		require.Nil(t, res.Labels)
+	}
+
+	// defaults to Ward's linkage.
+	res, err := Agglomerative(context.Background(), m, AgglomerativeOptions{Clusters: 2})
+	require.NoError(t, err)
+	require.Equal(t, WardLinkage, res.Linkage)
+	require.Equal(t, []int{0, 0, 0, 1}, res.Labels)
+	require.Equal(t, []int{3, 1}, res.Sizes)
+
+	res, err = Agglomerative(context.Background(), m, AgglomerativeOptions{Clusters: 4})
+	require.NoError(t, err)
+	require.Equal(t, []int{0, 1, 2, 3}, res.Labels)
+
+	res, err = Agglomerative(context.Background(), m, AgglomerativeOptions{Clusters: 1})
+	require.NoError(t, err)
+	require.Equal(t, []int{0, 0, 0, 0}, res.Labels)
+	require.Equal(t, []int{4}, res.Sizes)
+
+	_, err = Agglomerative(context.Background(), m, AgglomerativeOptions{Linkage: "centroid"})
+	require.Error(t, err)
+
+	_, err = Agglomerative(context.Background(), m, AgglomerativeOptions{Clusters: 5})
+	require.Error(t, err)
+
+	_, err = Agglomerative(context.Background(), m, AgglomerativeOptions{Names: []string{"a", "b"}})
+	require.Error(t, err)
+
+	_, err = Agglomerative(context.Background(), mat.NewDense(1, 1, []float64{1}), AgglomerativeOptions{})
+	require.Error(t, err)
+
+	large := mat.NewDense(MaxAgglomerativeRows+1, 1, nil)
+	_, err = Agglomerative(context.Background(), large, AgglomerativeOptions{})
+	require.Error(t, err)
+
+	constant := mat.NewDense(3, 1, []float64{1, 1, 1})
+	_, err = Agglomerative(context.Background(), constant, AgglomerativeOptions{Standardize: true})
+	require.Error(t, err)
+}
+
+func TestAgglomerativeNaive(t *testing.T) {
+	// the dendrogram matches the one of merging the closest clusters by the
+	// definition of their linkage.
+	r := rand.New(rand.NewSource(5))
+	m := mat.NewDense(25, 2, nil)
+	for i := 0; i < 25; i++ {
+		m.Set(i, 0, r.NormFloat64())
+		m.Set(i, 1, r.NormFloat64())
+	}
+
+	for _, linkage := range []Linkage{SingleLinkage, CompleteLinkage, AverageLinkage, WardLinkage} {
+		res, err := Agglomerative(context.Background(), m, AgglomerativeOptions{Linkage: linkage})
+		require.NoError(t, err)
+		want := naiveAgglomerative(m, linkage)
+		require.Len(t, res.Merges, len(want))
+		for i := range want {
+			require.InDelta(t, want[i].Distance, res.Merges[i].Distance, 1e-9, linkage)
+			require.Equal(t, want[i].Size, res.Merges[i].Size, linkage)
+			require.Less(t, res.Merges[i].Left, res.Merges[i].Right)
+			require.Less(t, res.Merges[i].Right, 25+i)
+		}
+	}
+}
+
+// naiveAgglomerative merges the closest clusters of the rows of `m` by the
+// definition of `linkage`, returning the sorted merges without their
+// clusters.
+func naiveAgglomerative(m *mat.Dense, linkage Linkage) []Merge {
+	n, _ := m.Dims()
+	clusters := make([][]int, n)
+	for i := range clusters {
+		clusters[i] = []int{i}
+	}
+	dist := func(a, b []int) float64 {
+		switch linkage {
+		case WardLinkage:
+			ma, mb := make([]float64, 2), make([]float64, 2)
+			for _, i := range a {
+				floats.AddScaled(ma, 1/float64(len(a)), m.RawRowView(i))
+			}
+			for _, i := range b {
+				floats.AddScaled(mb, 1/float64(len(b)), m.RawRowView(i))
+			}
+			na, nb := float64(len(a)), float64(len(b))
+			return math.Sqrt(2*na*nb/(na+nb)) * floats.Distance(ma, mb, 2)
+		}
+		var ds []float64
+		for _, i := range a {
+			for _, j := range b {
+				ds = append(ds, floats.Distance(m.RawRowView(i), m.RawRowView(j), 2))
+			}
+		}
+		switch linkage {
+		case SingleLinkage:
+			return floats.Min(ds)
+		case CompleteLinkage:
+			return floats.Max(ds)
+		}
+		return floats.Sum(ds) / float64(len(ds))
+	}
+
+	var merges []Merge
+	for len(clusters) > 1 {
+		a, b, best := 0, 1, math.Inf(1)
+		for i := range clusters {
+			for j := i + 1; j < len(clusters); j++ {
+				if d := dist(clusters[i], clusters[j]); d < best {
+					a, b, best = i, j, d
+				}
+			}
+		}
+		merged := append(append([]int{}, clusters[a]...), clusters[b]...)
+		merges = append(merges, Merge{Distance: best, Size: len(merged)})
+		clusters = append(clusters[:b], clusters[b+1:]...)
+		clusters[a] = merged
+	}
+	sort.SliceStable(merges, func(i, j int) bool {
+		return merges[i].Distance < merges[j].Distance
+	})
+	return merges
+}
+
+func TestDBSCAN(t *testing.T) {
+	m := mat.NewDense(7, 1, []float64{0, 0.5, 1, 10, 10.5, 11, 50})
+
+	res, err := DBSCAN(context.Background(), m, DBSCANOptions{Eps: 1, MinPoints: 3})
+	require.NoError(t, err)
+	require.Equal(t, 2, res.Clusters)
+	require.Equal(t, []int{0, 0, 0, 1, 1, 1, Noise}, res.Labels)
+	require.Equal(t, []int{3, 3}, res.Sizes)
+	require.Equal(t, []int{6}, res.Noise)
+	require.Equal(t, []bool{true, true, true, true, true, true, false}, res.Core)
+	require.Equal(t, 3, res.MinPoints)
+
+	// the ends of each blob are border rows of the cluster of the middle
+	// core row.
+	res, err = DBSCAN(context.Background(), m, DBSCANOptions{Eps: 0.6, MinPoints: 3})
+	require.NoError(t, err)
+	require.Equal(t, []int{0, 0, 0, 1, 1, 1, Noise}, res.Labels)
+	require.Equal(t, []bool{false, true, false, false, true, false, false}, res.Core)
+
+	// every row is noise.
+	res, err = DBSCAN(context.Background(), m, DBSCANOptions{Eps: 0.1})
+	require.NoError(t, err)
+	require.Equal(t, 0, res.Clusters)
+	require.Equal(t, DefaultMinPoints, res.MinPoints)
+	require.Equal(t, []int{0, 1, 2, 3, 4, 5, 6}, res.Noise)
+
+	_, err = DBSCAN(context.Background(), m, DBSCANOptions{})
+	require.Error(t, err)
+
+	_, err = DBSCAN(context.Background(), m, DBSCANOptions{Eps: 1, MinPoints: -1})
+	require.Error(t, err)
+
+	_, err = DBSCAN(context.Background(), m, DBSCANOptions{Eps: 1, Names: []string{"a", "b"}})
+	require.Error(t, err)
+}
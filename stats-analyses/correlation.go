@@ -0,0 +1,221 @@
+package statsanal
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/gonum/stat"
+	"gonum.org/v1/gonum/stat/distuv"
+)
+
+// CorrelationMethod is the correlation coefficient computed between columns.
+type CorrelationMethod string
+
+const (
+	// Pearson is the linear correlation coefficient.
+	Pearson CorrelationMethod = "pearson"
+	// Spearman is the Pearson correlation of the columns' ranks.
+	Spearman CorrelationMethod = "spearman"
+	// Kendall is the tau-b rank correlation, which accounts for ties.
+	Kendall CorrelationMethod = "kendall"
+)
+
+// CorrelationOptions configures a correlation analysis.
+type CorrelationOptions struct {
+	// Method defaults to Pearson.
+	Method CorrelationMethod
+	// Pairwise computes each pair's statistics from the rows where both
+	// columns are observed, instead of dropping every row with a missing
+	// value.
+	Pairwise bool
+	// Names of the columns of the matrix. Defaults to ColumnNames.
+	Names []string
+}
+
+// CorrelationResult holds the correlation and covariance matrices of a set
+// of columns, ordered like `Names`. Statistics that can't be computed, e.g.
+// the correlation with a constant column, are nil.
+type CorrelationResult struct {
+	Names       []string     `json:"names"`
+	Method      string       `json:"method"`
+	Correlation [][]*float64 `json:"correlation"`
+	// PValues of the two-sided tests of no correlation.
+	PValues    [][]*float64 `json:"p_values"`
+	Covariance [][]*float64 `json:"covariance"`
+	// Observations used for each pair of columns.
+	Observations [][]int `json:"observations"`
+}
+
+// Correlation computes the correlation matrix of the columns of matrix `m`
+// with the method in `opts`, along with the p-values of the correlations and
+// the sample covariance matrix.
+//
+// The p-values of the Pearson and Spearman correlations come from the
+// Student's t distribution with n-2 degrees of freedom, while those of the
+// Kendall correlation come from its normal approximation.
+//
+// Returns an error if the method is unknown, the number of names doesn't
+// match the number of columns, or no row is left after dropping missing
+// values.
+func Correlation(m mat.Matrix, opts CorrelationOptions) (res CorrelationResult, err error) {
+	if opts.Method == "" {
+		opts.Method = Pearson
+	}
+	switch opts.Method {
+	case Pearson, Spearman, Kendall:
+	default:
+		err = fmt.Errorf("Unknown correlation method %q.", opts.Method)
+		return
+	}
+
+	_, c := m.Dims()
+	if opts.Names == nil {
+		opts.Names = ColumnNames(c)
+	}
+	if len(opts.Names) != c {
+		err = fmt.Errorf("Got %d names for %d columns.", len(opts.Names), c)
+		return
+	}
+
+	data := mat.DenseCopyOf(m)
+	if !opts.Pairwise {
+		data, _, err = HandleMissing(data, MissingListwise)
+		if err != nil {
+			return
+		}
+	}
+
+	cols := make([][]float64, c)
+	for j := range cols {
+		cols[j] = mat.Col(nil, j, data)
+	}
+
+	res.Names = opts.Names
+	res.Method = string(opts.Method)
+	res.Correlation = squareMatrix[*float64](c)
+	res.PValues = squareMatrix[*float64](c)
+	res.Covariance = squareMatrix[*float64](c)
+	res.Observations = squareMatrix[int](c)
+	for i := 0; i < c; i++ {
+		for j := i; j < c; j++ {
+			x, y := completePairs(cols[i], cols[j])
+			n := len(x)
+
+			cov, r, p := math.NaN(), math.NaN(), math.NaN()
+			if n > 1 {
+				cov = stat.Covariance(x, y, nil)
+				r, p = correlate(x, y, opts.Method)
+			}
+
+			res.Observations[i][j], res.Observations[j][i] = n, n
+			res.Covariance[i][j], res.Covariance[j][i] = finite(cov), finite(cov)
+			res.Correlation[i][j], res.Correlation[j][i] = finite(r), finite(r)
+			res.PValues[i][j], res.PValues[j][i] = finite(p), finite(p)
+		}
+	}
+
+	return
+}
+
+// correlate finds the correlation of `x` and `y` with `method`, and the
+// p-value of the two-sided test of no correlation.
+func correlate(x, y []float64, method CorrelationMethod) (r, p float64) {
+	n := float64(len(x))
+
+	if method == Kendall {
+		r = kendallTau(x, y)
+		if n < 3 {
+			return r, math.NaN()
+		}
+		z := 3 * r * math.Sqrt(n*(n-1)) / math.Sqrt(2*(2*n+5))
+		return r, 2 * distuv.UnitNormal.Survival(math.Abs(z))
+	}
+
+	if method == Spearman {
+		x, y = rank(x), rank(y)
+	}
+	r = stat.Correlation(x, y, nil)
+	if n < 3 {
+		return r, math.NaN()
+	}
+	if math.Abs(r) >= 1 {
+		return r, 0
+	}
+	t := r * math.Sqrt((n-2)/(1-r*r))
+	dist := distuv.StudentsT{Mu: 0, Sigma: 1, Nu: n - 2}
+	return r, 2 * dist.Survival(math.Abs(t))
+}
+
+// kendallTau finds the tau-b rank correlation of `x` and `y`.
+func kendallTau(x, y []float64) float64 {
+	var concordant, discordant, tiesX, tiesY float64
+	for i := range x {
+		for j := i + 1; j < len(x); j++ {
+			dx, dy := x[i]-x[j], y[i]-y[j]
+			switch {
+			case dx == 0 && dy == 0:
+			case dx == 0:
+				tiesX++
+			case dy == 0:
+				tiesY++
+			case dx*dy > 0:
+				concordant++
+			default:
+				discordant++
+			}
+		}
+	}
+
+	return (concordant - discordant) /
+		math.Sqrt((concordant+discordant+tiesX)*(concordant+discordant+tiesY))
+}
+
+// rank returns the ranks of the values of `x`, starting at 1. Tied values
+// get the average of their ranks.
+func rank(x []float64) []float64 {
+	idx := make([]int, len(x))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(a, b int) bool { return x[idx[a]] < x[idx[b]] })
+
+	ranks := make([]float64, len(x))
+	for i := 0; i < len(idx); {
+		j := i
+		for j+1 < len(idx) && x[idx[j+1]] == x[idx[i]] {
+			j++
+		}
+		// positions i to j hold tied values.
+		avg := float64(i+j)/2 + 1
+		for k := i; k <= j; k++ {
+			ranks[idx[k]] = avg
+		}
+		i = j + 1
+	}
+	return ranks
+}
+
+// completePairs returns the values of `x` and `y` at the indices where both
+// are observed.
+func completePairs(x, y []float64) ([]float64, []float64) {
+	px := make([]float64, 0, len(x))
+	py := make([]float64, 0, len(y))
+	for i := range x {
+		if !math.IsNaN(x[i]) && !math.IsNaN(y[i]) {
+			px = append(px, x[i])
+			py = append(py, y[i])
+		}
+	}
+	return px, py
+}
+
+// squareMatrix allocates a `n`x`n` slice of slices.
+func squareMatrix[T any](n int) [][]T {
+	res := make([][]T, n)
+	for i := range res {
+		res[i] = make([]T, n)
+	}
+	return res
+}
@@ -0,0 +1,120 @@
+package statsanal
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestCorrelation(t *testing.T) {
+	nan := math.NaN()
+	m := mat.NewDense(6, 3, []float64{
+		1, 2, 10,
+		2, 1, 8,
+		3, 4, 6,
+		4, 3, 4,
+		5, 5, 2,
+		nan, 7, 0,
+	})
+
+	testCases := []struct {
+		name     string
+		opts     CorrelationOptions
+		expected float64
+		pValue   float64
+		obs      int
+		hasErr   bool
+	}{
+		{
+			name:     "PEARSON",
+			expected: 0.8,
+			pValue:   0.10408,
+			obs:      5,
+		},
+		{
+			name:     "SPEARMAN",
+			opts:     CorrelationOptions{Method: Spearman},
+			expected: 0.8,
+			pValue:   0.10408,
+			obs:      5,
+		},
+		{
+			name:     "KENDALL",
+			opts:     CorrelationOptions{Method: Kendall},
+			expected: 0.6,
+			pValue:   0.14164,
+			obs:      5,
+		},
+		{
+			name:   "UNKNOWN",
+			opts:   CorrelationOptions{Method: "cosine"},
+			hasErr: true,
+		},
+		{
+			name:   "INVALID NAMES",
+			opts:   CorrelationOptions{Names: []string{"a"}},
+			hasErr: true,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := Correlation(m, tc.opts)
+			if tc.hasErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, []string{"col_0", "col_1", "col_2"}, res.Names)
+
+			require.InDelta(t, tc.expected, *res.Correlation[0][1], 1e-9)
+			require.InDelta(t, tc.expected, *res.Correlation[1][0], 1e-9)
+			require.InDelta(t, tc.pValue, *res.PValues[0][1], 1e-5)
+			require.InDelta(t, 1, *res.Correlation[0][0], 1e-9)
+			require.InDelta(t, -1, *res.Correlation[0][2], 1e-9)
+			require.Equal(t, tc.obs, res.Observations[0][1])
+			require.InDelta(t, 2.5, *res.Covariance[0][0], 1e-9)
+		})
+	}
+}
+
+func TestCorrelationPairwise(t *testing.T) {
+	nan := math.NaN()
+	m := mat.NewDense(5, 3, []float64{
+		1, nan, 5,
+		2, 1, 4,
+		3, 2, 3,
+		4, 3, nan,
+		nan, 4, 1,
+	})
+
+	res, err := Correlation(m, CorrelationOptions{Pairwise: true})
+	require.NoError(t, err)
+	require.Equal(t, 3, res.Observations[0][1])
+	require.Equal(t, 3, res.Observations[0][2])
+	require.Equal(t, 3, res.Observations[1][2])
+	require.Equal(t, 4, res.Observations[1][1])
+	require.InDelta(t, 1, *res.Correlation[0][1], 1e-9)
+	require.InDelta(t, -1, *res.Correlation[0][2], 1e-9)
+
+	// listwise deletion leaves 2 rows, too few for p-values.
+	res, err = Correlation(m, CorrelationOptions{})
+	require.NoError(t, err)
+	require.Equal(t, 2, res.Observations[0][1])
+	require.Nil(t, res.PValues[0][1])
+
+	// a constant column has no correlation.
+	res, err = Correlation(mat.NewDense(3, 2, []float64{1, 1, 2, 1, 3, 1}), CorrelationOptions{})
+	require.NoError(t, err)
+	require.Nil(t, res.Correlation[0][1])
+	require.InDelta(t, 0, *res.Covariance[0][1], 1e-12)
+}
+
+func TestRank(t *testing.T) {
+	require.Equal(t, []float64{3, 1, 3, 3, 5}, rank([]float64{2, 1, 2, 2, 7}))
+	require.Equal(t, []float64{2.5, 1, 2.5}, rank([]float64{4, 0, 4}))
+}
@@ -0,0 +1,242 @@
+package statsanal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+
+	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/gonum/stat"
+)
+
+// ErrEmptySplit is returned when splitting the rows leaves no training or
+// test rows.
+var ErrEmptySplit = errors.New("split leaves no training or test rows")
+
+// Fitter fits a model on the training rows of a matrix, whose last column is
+// the target and the rest of the columns the predictors.
+type Fitter func(train *mat.Dense) (Model, error)
+
+// CrossValidationOptions configures a cross-validation.
+type CrossValidationOptions struct {
+	// Folds is the number of k-fold splits. Defaults to DefaultFolds.
+	Folds int
+	// Holdout is the share of rows, in the open interval (0, 1), held out to
+	// test a single fit instead of the k-fold splits.
+	Holdout float64
+	// Seed of the random shuffle of the rows before splitting them.
+	Seed int64
+	// Threshold is the probability above which a classifier predicts the
+	// positive class. Defaults to DefaultThreshold.
+	Threshold float64
+}
+
+// Scores holds the test scores of a model. Regressors are scored by RMSE,
+// MAE and RSquared, classifiers by Accuracy and AUC. A score is nil, and left
+// out of the json, when it is undefined, like the AUC of a test set with a
+// single class.
+type Scores struct {
+	RMSE     *float64 `json:"rmse,omitempty"`
+	MAE      *float64 `json:"mae,omitempty"`
+	RSquared *float64 `json:"r_squared,omitempty"`
+	Accuracy *float64 `json:"accuracy,omitempty"`
+	AUC      *float64 `json:"auc,omitempty"`
+}
+
+// FoldScores holds the test scores of the model fitted on one split.
+type FoldScores struct {
+	Fold      int `json:"fold"`
+	TrainRows int `json:"train_rows"`
+	TestRows  int `json:"test_rows"`
+	Scores
+}
+
+// CrossValidationResult holds the outcome of a cross-validation.
+type CrossValidationResult struct {
+	Kind ModelKind `json:"kind"`
+	// Method is `kfold` or `holdout`.
+	Method string       `json:"method"`
+	Seed   int64        `json:"seed"`
+	Folds  []FoldScores `json:"folds"`
+	// Mean and StdDev of every score over the folds where it is defined.
+	Mean   Scores `json:"mean"`
+	StdDev Scores `json:"std_dev"`
+}
+
+// CrossValidate estimates how the model fitted by `fit` generalizes to new
+// rows of matrix `m`, whose last column is the target. The rows are shuffled
+// with `opts.Seed`, then either split into `opts.Folds` folds, each one
+// scoring the model fitted on the other folds, or split once holding out
+// `opts.Holdout` of the rows for testing.
+//
+// Returns a non-nil error if the options are invalid, a split leaves no
+// training or test rows, a fit fails, or `ctx` is done before the end.
+func CrossValidate(ctx context.Context, m *mat.Dense, fit Fitter, opts CrossValidationOptions) (res CrossValidationResult, err error) {
+	r, c := m.Dims()
+
+	if opts.Threshold == 0 {
+		opts.Threshold = DefaultThreshold
+	}
+	if opts.Threshold <= 0 || opts.Threshold >= 1 {
+		err = fmt.Errorf("Threshold should be in (0, 1), got %v.", opts.Threshold)
+		return
+	}
+
+	perm := rand.New(rand.NewSource(opts.Seed)).Perm(r)
+
+	// splits[f] holds the test rows of split f.
+	var splits [][]int
+	if opts.Holdout != 0 {
+		if opts.Holdout <= 0 || opts.Holdout >= 1 {
+			err = fmt.Errorf("Holdout share should be in (0, 1), got %v.", opts.Holdout)
+			return
+		}
+		test := int(math.Round(opts.Holdout * float64(r)))
+		if test == 0 || test == r {
+			err = fmt.Errorf(
+				"Can't hold out %v of %d rows.\n%w", opts.Holdout, r, ErrEmptySplit)
+			return
+		}
+		res.Method = "holdout"
+		splits = [][]int{perm[:test]}
+	} else {
+		if opts.Folds == 0 {
+			opts.Folds = DefaultFolds
+		}
+		if opts.Folds < 2 {
+			err = fmt.Errorf("Folds should be at least 2, got %d.", opts.Folds)
+			return
+		}
+		if opts.Folds > r {
+			err = fmt.Errorf(
+				"Can't split %d rows into %d folds.\n%w", r, opts.Folds, ErrEmptySplit)
+			return
+		}
+		res.Method = "kfold"
+		splits = make([][]int, opts.Folds)
+		for k, i := range perm {
+			splits[k%opts.Folds] = append(splits[k%opts.Folds], i)
+		}
+	}
+	res.Seed = opts.Seed
+
+	for f, test := range splits {
+		if err = ctx.Err(); err != nil {
+			return
+		}
+		isTest := make([]bool, r)
+		for _, i := range test {
+			isTest[i] = true
+		}
+		train := mat.NewDense(r-len(test), c, nil)
+		testX := mat.NewDense(len(test), c-1, nil)
+		testY := make([]float64, len(test))
+		var k int
+		for i := 0; i < r; i++ {
+			if !isTest[i] {
+				train.SetRow(k, mat.Row(nil, i, m))
+				k++
+			}
+		}
+		for k, i := range test {
+			testX.SetRow(k, mat.Row(nil, i, m)[:c-1])
+			testY[k] = m.At(i, c-1)
+		}
+
+		var model Model
+		model, err = fit(train)
+		if err != nil {
+			err = fmt.Errorf("Error fitting fold %d.\n%w", f, err)
+			return
+		}
+		var pred Prediction
+		pred, err = model.Predict(testX, 0)
+		if err != nil {
+			err = fmt.Errorf("Error predicting fold %d.\n%w", f, err)
+			return
+		}
+
+		res.Kind = model.Kind
+		fold := FoldScores{Fold: f, TrainRows: r - len(test), TestRows: len(test)}
+		if model.Kind == KindLogistic {
+			fold.Scores = classifierScores(testY, pred.Predictions, opts.Threshold)
+		} else {
+			fold.Scores = regressorScores(testY, pred.Predictions)
+		}
+		res.Folds = append(res.Folds, fold)
+	}
+
+	res.Mean, res.StdDev = aggregateScores(res.Folds)
+	return
+}
+
+// regressorScores scores the predictions `pred` of the targets `y`.
+func regressorScores(y, pred []float64) Scores {
+	yMean := stat.Mean(y, nil)
+	var sse, sae, sst float64
+	for i := range y {
+		d := y[i] - pred[i]
+		sse += d * d
+		sae += math.Abs(d)
+		sst += (y[i] - yMean) * (y[i] - yMean)
+	}
+	n := float64(len(y))
+
+	return Scores{
+		RMSE:     finite(math.Sqrt(sse / n)),
+		MAE:      finite(sae / n),
+		RSquared: finite(1 - sse/sst),
+	}
+}
+
+// classifierScores scores the predicted probabilities `prob` of the binary
+// targets `y`. The AUC is the probability that a random positive row is
+// ranked above a random negative one.
+func classifierScores(y, prob []float64, threshold float64) Scores {
+	var correct, positives float64
+	var positiveRanks float64
+	ranks := rank(prob)
+	for i := range y {
+		if (prob[i] > threshold) == (y[i] == 1) {
+			correct++
+		}
+		if y[i] == 1 {
+			positives++
+			positiveRanks += ranks[i]
+		}
+	}
+	n := float64(len(y))
+	negatives := n - positives
+
+	return Scores{
+		Accuracy: finite(correct / n),
+		AUC: finite(
+			(positiveRanks - positives*(positives+1)/2) / (positives * negatives)),
+	}
+}
+
+// aggregateScores returns the mean and standard deviation of each score of
+// the folds, skipping the folds where it is undefined.
+func aggregateScores(folds []FoldScores) (avg, std Scores) {
+	field := func(s *Scores) []**float64 {
+		return []**float64{&s.RMSE, &s.MAE, &s.RSquared, &s.Accuracy, &s.AUC}
+	}
+
+	avgFields, stdFields := field(&avg), field(&std)
+	for j := range avgFields {
+		var values []float64
+		for f := range folds {
+			if v := *field(&folds[f].Scores)[j]; v != nil {
+				values = append(values, *v)
+			}
+		}
+		if len(values) == 0 {
+			continue
+		}
+		m, s := stat.MeanStdDev(values, nil)
+		*avgFields[j], *stdFields[j] = finite(m), finite(s)
+	}
+	return
+}
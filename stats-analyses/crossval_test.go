@@ -0,0 +1,141 @@
+package statsanal
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gonum.org/v1/gonum/mat"
+)
+
+// linearFitter fits a linear regression on the training rows.
+func linearFitter(train *mat.Dense) (Model, error) {
+	res, err := LinearRegression(train, RegressionOptions{})
+	if err != nil {
+		return Model{}, err
+	}
+	_, c := train.Dims()
+	return res.Model(ColumnNames(c)[:c-1], "y"), nil
+}
+
+func TestCrossValidateKFold(t *testing.T) {
+	m := randomRegression(40, 2)
+
+	res, err := CrossValidate(context.Background(), m, linearFitter, CrossValidationOptions{Seed: 7})
+	require.NoError(t, err)
+	require.Equal(t, KindLinear, res.Kind)
+	require.Equal(t, "kfold", res.Method)
+	require.Equal(t, int64(7), res.Seed)
+	require.Len(t, res.Folds, DefaultFolds)
+
+	var tested int
+	for f, fold := range res.Folds {
+		require.Equal(t, f, fold.Fold)
+		require.Equal(t, 8, fold.TestRows)
+		require.Equal(t, 32, fold.TrainRows)
+		require.NotNil(t, fold.RMSE)
+		require.Nil(t, fold.Accuracy)
+		tested += fold.TestRows
+	}
+	require.Equal(t, 40, tested)
+
+	// the target has a noise of standard deviation 0.1.
+	require.InDelta(t, 0.1, *res.Mean.RMSE, 0.05)
+	require.Greater(t, *res.Mean.RSquared, 0.99)
+	require.Less(t, *res.Mean.MAE, *res.Mean.RMSE)
+	require.NotNil(t, res.StdDev.RMSE)
+	require.Nil(t, res.Mean.AUC)
+
+	// the splits only depend on the seed.
+	again, err := CrossValidate(context.Background(), m, linearFitter, CrossValidationOptions{Seed: 7})
+	require.NoError(t, err)
+	require.Equal(t, res, again)
+	other, err := CrossValidate(context.Background(), m, linearFitter, CrossValidationOptions{Seed: 8})
+	require.NoError(t, err)
+	require.NotEqual(t, *res.Folds[0].RMSE, *other.Folds[0].RMSE)
+}
+
+func TestCrossValidateHoldout(t *testing.T) {
+	m := randomRegression(40, 2)
+
+	res, err := CrossValidate(context.Background(), m, linearFitter, CrossValidationOptions{Holdout: 0.25})
+	require.NoError(t, err)
+	require.Equal(t, "holdout", res.Method)
+	require.Len(t, res.Folds, 1)
+	require.Equal(t, 10, res.Folds[0].TestRows)
+	require.Equal(t, 30, res.Folds[0].TrainRows)
+	require.Equal(t, res.Folds[0].RMSE, res.Mean.RMSE)
+	// a single split has no spread.
+	require.Nil(t, res.StdDev.RMSE)
+}
+
+func TestCrossValidateClassifier(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	m := mat.NewDense(60, 2, nil)
+	for i := 0; i < 60; i++ {
+		x := rng.NormFloat64()
+		m.Set(i, 0, x)
+		if x+0.5*rng.NormFloat64() > 0 {
+			m.Set(i, 1, 1)
+		}
+	}
+	fit := func(train *mat.Dense) (Model, error) {
+		res, err := LogisticRegression(train, LogisticOptions{})
+		if err != nil {
+			return Model{}, err
+		}
+		return res.Model([]string{"x"}, "y"), nil
+	}
+
+	res, err := CrossValidate(context.Background(), m, fit, CrossValidationOptions{Folds: 3})
+	require.NoError(t, err)
+	require.Equal(t, KindLogistic, res.Kind)
+	require.Len(t, res.Folds, 3)
+	require.Nil(t, res.Mean.RMSE)
+	require.Greater(t, *res.Mean.Accuracy, 0.7)
+	require.Greater(t, *res.Mean.AUC, 0.8)
+	require.LessOrEqual(t, *res.Mean.AUC, 1.0)
+}
+
+func TestClassifierScores(t *testing.T) {
+	y := []float64{0, 0, 1, 1}
+	prob := []float64{0.1, 0.4, 0.35, 0.8}
+
+	scores := classifierScores(y, prob, 0.5)
+	require.Equal(t, 0.75, *scores.Accuracy)
+	// 3 of the 4 positive and negative pairs are ranked right.
+	require.Equal(t, 0.75, *scores.AUC)
+
+	scores = classifierScores(y, prob, 0.3)
+	require.Equal(t, 0.75, *scores.Accuracy)
+
+	// the AUC is undefined with a single class.
+	scores = classifierScores([]float64{1, 1}, []float64{0.2, 0.9}, 0.5)
+	require.Nil(t, scores.AUC)
+	require.Equal(t, 0.5, *scores.Accuracy)
+}
+
+func TestCrossValidateErrors(t *testing.T) {
+	m := randomRegression(10, 2)
+
+	_, err := CrossValidate(context.Background(), m, linearFitter, CrossValidationOptions{Folds: 11})
+	require.ErrorIs(t, err, ErrEmptySplit)
+
+	_, err = CrossValidate(context.Background(), m, linearFitter, CrossValidationOptions{Folds: 1})
+	require.Error(t, err)
+
+	_, err = CrossValidate(context.Background(), m, linearFitter, CrossValidationOptions{Holdout: 0.01})
+	require.ErrorIs(t, err, ErrEmptySplit)
+
+	_, err = CrossValidate(context.Background(), m, linearFitter, CrossValidationOptions{Holdout: 1.5})
+	require.Error(t, err)
+
+	_, err = CrossValidate(context.Background(), m, linearFitter, CrossValidationOptions{Threshold: 1})
+	require.Error(t, err)
+
+	// fitting errors are kept.
+	failing := func(*mat.Dense) (Model, error) { return Model{}, ErrRankDeficient }
+	_, err = CrossValidate(context.Background(), m, failing, CrossValidationOptions{})
+	require.ErrorIs(t, err, ErrRankDeficient)
+}
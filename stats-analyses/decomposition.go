@@ -0,0 +1,427 @@
+package statsanal
+
+import (
+	"fmt"
+	"math"
+	"slices"
+
+	"gonum.org/v1/gonum/stat"
+)
+
+// DecompositionMethod is the method of a seasonal decomposition.
+type DecompositionMethod string
+
+const (
+	// ClassicalDecomposition estimates the trend with a centered moving
+	// average over a period and the seasonal component with the average
+	// detrended value at each position of the period.
+	ClassicalDecomposition DecompositionMethod = "classical"
+	// STLDecomposition estimates the components by repeated loess smoothing
+	// of the seasonal cycles and the trend, following Cleveland et al.
+	// (1990), "STL: A Seasonal-Trend Decomposition Procedure Based on
+	// Loess".
+	STLDecomposition DecompositionMethod = "stl"
+)
+
+const (
+	// DefaultSeasonalWindow is the default number of cycles smoothed together
+	// by STL.
+	DefaultSeasonalWindow = 7
+	// stlRobustIterations is the number of robustness iterations of a robust
+	// STL decomposition.
+	stlRobustIterations = 15
+)
+
+// DecompositionOptions configures a seasonal decomposition.
+type DecompositionOptions struct {
+	// Method defaults to ClassicalDecomposition.
+	Method DecompositionMethod
+	// Period is the number of values of a season.
+	Period int
+	// Seasonality is AdditiveSeasonality, the default, or
+	// MultiplicativeSeasonality for a classical decomposition, whose
+	// components then multiply.
+	Seasonality Seasonality
+	// SeasonalWindow is the odd number of cycles each seasonal value is
+	// smoothed over by STL, defaulting to DefaultSeasonalWindow, and
+	// TrendWindow the odd number of values the trend is smoothed over,
+	// defaulting to the smallest odd integer not below
+	// 1.5 * Period / (1 - 1.5 / SeasonalWindow).
+	SeasonalWindow int
+	TrendWindow    int
+	// Robust makes STL downweight outlying values.
+	Robust bool
+}
+
+// DecompositionResult holds the components of a seasonal decomposition.
+type DecompositionResult struct {
+	Method      DecompositionMethod `json:"method"`
+	Seasonality Seasonality         `json:"seasonality"`
+	Period      int                 `json:"period"`
+	// SeasonalWindow and TrendWindow are the windows of STL.
+	SeasonalWindow int `json:"seasonal_window,omitempty"`
+	TrendWindow    int `json:"trend_window,omitempty"`
+	// Trend, Seasonal and Residual hold the components of each value, adding
+	// or multiplying up to it, the trend and residual being null at the ends
+	// of a classical decomposition.
+	Trend    []*float64 `json:"trend"`
+	Seasonal []float64  `json:"seasonal"`
+	Residual []*float64 `json:"residual"`
+	// Figure holds the seasonal component of each position of the period of
+	// a classical decomposition, starting with the first value.
+	Figure []float64 `json:"figure,omitempty"`
+	// TrendStrength and SeasonalStrength measure in [0, 1] how much of the
+	// variation the trend and seasonal components explain beside the
+	// residuals, on the log scale for multiplicative components.
+	TrendStrength    *float64 `json:"trend_strength"`
+	SeasonalStrength *float64 `json:"seasonal_strength"`
+}
+
+// Decompose splits the series `x` into a trend, a seasonal component of
+// `opts.Period` values and residuals.
+//
+// Returns an error if the options are invalid, the series has missing values
+// or less than two periods, or a multiplicative series has non-positive
+// values.
+func Decompose(x []float64, opts DecompositionOptions) (res DecompositionResult, err error) {
+	if opts.Method == "" {
+		opts.Method = ClassicalDecomposition
+	}
+	if opts.Seasonality == NoSeasonality {
+		opts.Seasonality = AdditiveSeasonality
+	}
+	if opts.Period < 2 {
+		err = fmt.Errorf("Period should be at least 2, got %d.", opts.Period)
+		return
+	}
+	if err = checkSeries(x, 2*opts.Period); err != nil {
+		return
+	}
+
+	switch opts.Seasonality {
+	case AdditiveSeasonality:
+	case MultiplicativeSeasonality:
+		if opts.Method == STLDecomposition {
+			err = fmt.Errorf("STL only decomposes additive series.")
+			return
+		}
+		for _, v := range x {
+			if v <= 0 {
+				err = fmt.Errorf("Multiplicative series should be positive, got %v.", v)
+				return
+			}
+		}
+	default:
+		err = fmt.Errorf("Unknown seasonality %q.", opts.Seasonality)
+		return
+	}
+
+	res.Method = opts.Method
+	res.Seasonality = opts.Seasonality
+	res.Period = opts.Period
+	switch opts.Method {
+	case ClassicalDecomposition:
+		res.classical(x)
+	case STLDecomposition:
+		err = res.stl(x, opts)
+	default:
+		err = fmt.Errorf("Unknown decomposition method %q.", opts.Method)
+	}
+	if err != nil {
+		return
+	}
+
+	res.strengths()
+	return
+}
+
+// classical decomposes the series `x` with moving averages.
+func (res *DecompositionResult) classical(x []float64) {
+	n, period := len(x), res.Period
+	multiplicative := res.Seasonality == MultiplicativeSeasonality
+
+	// an even period averages period + 1 values, halving the outer ones.
+	half := period / 2
+	weights := make([]float64, 2*half+1)
+	for i := range weights {
+		weights[i] = 1 / float64(period)
+	}
+	if period%2 == 0 {
+		weights[0] /= 2
+		weights[2*half] /= 2
+	}
+	res.Trend = make([]*float64, n)
+	for t := half; t < n-half; t++ {
+		var v float64
+		for i, w := range weights {
+			v += w * x[t-half+i]
+		}
+		res.Trend[t] = &v
+	}
+
+	sums := make([]float64, period)
+	counts := make([]float64, period)
+	for t, trend := range res.Trend {
+		if trend == nil {
+			continue
+		}
+		if multiplicative {
+			sums[t%period] += x[t] / *trend
+		} else {
+			sums[t%period] += x[t] - *trend
+		}
+		counts[t%period]++
+	}
+	res.Figure = make([]float64, period)
+	for k := range sums {
+		res.Figure[k] = sums[k] / counts[k]
+	}
+	center := stat.Mean(res.Figure, nil)
+	for k := range res.Figure {
+		if multiplicative {
+			res.Figure[k] /= center
+		} else {
+			res.Figure[k] -= center
+		}
+	}
+
+	res.Seasonal = make([]float64, n)
+	res.Residual = make([]*float64, n)
+	for t := range x {
+		res.Seasonal[t] = res.Figure[t%period]
+		if res.Trend[t] == nil {
+			continue
+		}
+		if multiplicative {
+			res.Residual[t] = finite(x[t] / (*res.Trend[t] * res.Seasonal[t]))
+		} else {
+			res.Residual[t] = finite(x[t] - *res.Trend[t] - res.Seasonal[t])
+		}
+	}
+}
+
+// stl decomposes the series `x` with STL.
+//
+// Returns an error if the windows are invalid.
+func (res *DecompositionResult) stl(x []float64, opts DecompositionOptions) error {
+	n, np := len(x), opts.Period
+	ns := opts.SeasonalWindow
+	if ns == 0 {
+		ns = DefaultSeasonalWindow
+	}
+	nt := opts.TrendWindow
+	if nt == 0 {
+		nt = nextOdd(1.5 * float64(np) / (1 - 1.5/float64(ns)))
+	}
+	if ns < 3 || ns%2 == 0 || nt < 3 || nt%2 == 0 {
+		return fmt.Errorf(
+			"Seasonal and trend windows should be odd and at least 3, got %d and %d.",
+			ns, nt)
+	}
+	nl := nextOdd(float64(np))
+	inner, outer := 2, 0
+	if opts.Robust {
+		inner, outer = 2, stlRobustIterations
+	}
+
+	trend := make([]float64, n)
+	seasonal := make([]float64, n)
+	var rho []float64
+	for o := 0; o <= outer; o++ {
+		for i := 0; i < inner; i++ {
+			// smooth each cycle-subseries of the detrended series, one cycle
+			// beyond both ends.
+			cycles := make([]float64, n+2*np)
+			for k := 0; k < np; k++ {
+				var sub, subRho []float64
+				for t := k; t < n; t += np {
+					sub = append(sub, x[t]-trend[t])
+					if rho != nil {
+						subRho = append(subRho, rho[t])
+					}
+				}
+				for j := -1; j <= len(sub); j++ {
+					cycles[(j+1)*np+k] = loess(sub, subRho, ns, float64(j))
+				}
+			}
+
+			// remove the low frequencies leaking into the cycles.
+			low := movingMean(movingMean(movingMean(cycles, np), np), 3)
+			for t := range seasonal {
+				seasonal[t] = cycles[np+t] - loess(low, nil, nl, float64(t))
+			}
+
+			deseasonalized := make([]float64, n)
+			for t := range x {
+				deseasonalized[t] = x[t] - seasonal[t]
+			}
+			for t := range trend {
+				trend[t] = loess(deseasonalized, rho, nt, float64(t))
+			}
+		}
+		if o < outer {
+			rho = robustnessWeights(x, trend, seasonal)
+		}
+	}
+
+	res.SeasonalWindow, res.TrendWindow = ns, nt
+	res.Trend = make([]*float64, n)
+	res.Seasonal = seasonal
+	res.Residual = make([]*float64, n)
+	for t := range x {
+		res.Trend[t] = finite(trend[t])
+		res.Residual[t] = finite(x[t] - trend[t] - seasonal[t])
+	}
+	return nil
+}
+
+// strengths measures the strength of the trend and seasonal components as
+// one minus the variance of the residuals over the variance of the
+// component plus residuals, following Wang, Smith & Hyndman (2006),
+// "Characteristic-based clustering for time series data".
+func (res *DecompositionResult) strengths() {
+	scale := func(v float64) float64 { return v }
+	if res.Seasonality == MultiplicativeSeasonality {
+		scale = math.Log
+	}
+
+	var resid, trend, seasonal []float64
+	for t, r := range res.Residual {
+		if r == nil || res.Trend[t] == nil {
+			continue
+		}
+		e := scale(*r)
+		resid = append(resid, e)
+		trend = append(trend, scale(*res.Trend[t])+e)
+		seasonal = append(seasonal, scale(res.Seasonal[t])+e)
+	}
+	if len(resid) < 2 {
+		return
+	}
+	v := stat.Variance(resid, nil)
+	strength := func(total []float64) *float64 {
+		vt := stat.Variance(total, nil)
+		if vt == 0 {
+			return nil
+		}
+		return finite(max(0, 1-v/vt))
+	}
+	res.TrendStrength = strength(trend)
+	res.SeasonalStrength = strength(seasonal)
+}
+
+// robustnessWeights returns the bisquare weights of the residuals of the
+// series `x` from its `trend` and `seasonal` components, scaled by 6 times
+// their median absolute value.
+func robustnessWeights(x, trend, seasonal []float64) []float64 {
+	abs := make([]float64, len(x))
+	var size float64
+	for t := range x {
+		abs[t] = math.Abs(x[t] - trend[t] - seasonal[t])
+		size = max(size, math.Abs(x[t]))
+	}
+	sorted := slices.Clone(abs)
+	slices.Sort(sorted)
+	// rounding errors of an exact fit don't make outliers.
+	h := max(6*median(sorted), 1e-10*size)
+
+	rho := make([]float64, len(x))
+	for t, r := range abs {
+		switch {
+		case h == 0 || r <= 0.001*h:
+			rho[t] = 1
+		case r < 0.999*h:
+			u := r / h
+			rho[t] = (1 - u*u) * (1 - u*u)
+		}
+	}
+	return rho
+}
+
+// loess evaluates at `at` the locally linear fit of the values `y`, indexed
+// from 0, over their `q` nearest indices, weighted by the tricube of the
+// distance to `at` times the robustness weights `rho` when given. Falls back
+// to the nearest value when every weight is zero.
+func loess(y, rho []float64, q int, at float64) float64 {
+	n := len(y)
+	lo, hi := 0, n-1
+	if q < n {
+		lo = int(math.Floor(at)) - (q-1)/2
+		lo = max(0, min(lo, n-q))
+		hi = lo + q - 1
+	}
+	h := max(at-float64(lo), float64(hi)-at)
+	if q > n {
+		h += float64(q-n) / 2
+	}
+
+	weights := make([]float64, hi-lo+1)
+	var total float64
+	for i := range weights {
+		r := math.Abs(float64(lo+i) - at)
+		switch {
+		case r <= 0.001*h:
+			weights[i] = 1
+		case r < 0.999*h:
+			u := r / h
+			u = 1 - u*u*u
+			weights[i] = u * u * u
+		}
+		if rho != nil {
+			weights[i] *= rho[lo+i]
+		}
+		total += weights[i]
+	}
+	if total <= 0 {
+		return y[max(0, min(int(math.Round(at)), n-1))]
+	}
+
+	// tilt the weights to fit a line through the weighted mean.
+	var center float64
+	for i, w := range weights {
+		weights[i] = w / total
+		center += weights[i] * float64(lo+i)
+	}
+	var spread float64
+	for i, w := range weights {
+		d := float64(lo+i) - center
+		spread += w * d * d
+	}
+	if h > 0 && math.Sqrt(spread) > 0.001*float64(n-1) {
+		slope := (at - center) / spread
+		for i := range weights {
+			weights[i] *= slope*(float64(lo+i)-center) + 1
+		}
+	}
+
+	var v float64
+	for i, w := range weights {
+		v += w * y[lo+i]
+	}
+	return v
+}
+
+// movingMean returns the means of the `window` consecutive values of `x`.
+func movingMean(x []float64, window int) []float64 {
+	res := make([]float64, len(x)-window+1)
+	var total float64
+	for t, v := range x {
+		total += v
+		if t >= window {
+			total -= x[t-window]
+		}
+		if t >= window-1 {
+			res[t-window+1] = total / float64(window)
+		}
+	}
+	return res
+}
+
+// nextOdd returns the smallest odd integer not below `x`.
+func nextOdd(x float64) int {
+	v := int(math.Ceil(x))
+	if v%2 == 0 {
+		v++
+	}
+	return v
+}
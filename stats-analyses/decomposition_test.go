@@ -0,0 +1,160 @@
+package statsanal
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassicalDecomposition(t *testing.T) {
+	// a linear trend plus a zero-mean pattern is recovered exactly.
+	pattern := []float64{3, -1, -4, 2}
+	x := make([]float64, 16)
+	for i := range x {
+		x[i] = 10 + 0.5*float64(i) + pattern[i%4]
+	}
+	res, err := Decompose(x, DecompositionOptions{Period: 4})
+	require.NoError(t, err)
+	require.Equal(t, ClassicalDecomposition, res.Method)
+	require.Equal(t, AdditiveSeasonality, res.Seasonality)
+	require.Equal(t, 4, res.Period)
+	require.InDeltaSlice(t, pattern, res.Figure, 1e-12)
+	for i := range x {
+		require.InDelta(t, pattern[i%4], res.Seasonal[i], 1e-12)
+		if i < 2 || i > 13 {
+			require.Nil(t, res.Trend[i])
+			require.Nil(t, res.Residual[i])
+			continue
+		}
+		require.InDelta(t, 10+0.5*float64(i), *res.Trend[i], 1e-12)
+		require.InDelta(t, 0, *res.Residual[i], 1e-12)
+	}
+	require.InDelta(t, 1, *res.TrendStrength, 1e-12)
+	require.InDelta(t, 1, *res.SeasonalStrength, 1e-12)
+	require.Zero(t, res.TrendWindow)
+
+	// an odd period averages a period around each value.
+	x = []float64{2, 5, 1, 3, 6, 2, 4, 7, 3}
+	res, err = Decompose(x, DecompositionOptions{Period: 3})
+	require.NoError(t, err)
+	require.Nil(t, res.Trend[0])
+	require.InDelta(t, 8.0/3, *res.Trend[1], 1e-12)
+	require.InDelta(t, 14.0/3, *res.Trend[7], 1e-12)
+	require.Nil(t, res.Trend[8])
+	// detrended values average -1/3 at the first position, 7/3 at the
+	// second and -2 at the third, which sum to zero.
+	require.InDeltaSlice(t, []float64{-1.0 / 3, 7.0 / 3, -2}, res.Figure, 1e-12)
+	require.InDelta(t, 5-8.0/3-7.0/3, *res.Residual[1], 1e-12)
+
+	// multiplicative components divide out.
+	factors := []float64{1.2, 0.8, 1.1, 0.9}
+	x = make([]float64, 12)
+	for i := range x {
+		x[i] = 10 * factors[i%4]
+	}
+	res, err = Decompose(x, DecompositionOptions{
+		Period:      4,
+		Seasonality: MultiplicativeSeasonality,
+	})
+	require.NoError(t, err)
+	require.InDeltaSlice(t, factors, res.Figure, 1e-12)
+	require.InDelta(t, 10, *res.Trend[5], 1e-12)
+	require.InDelta(t, 1, *res.Residual[5], 1e-12)
+
+	_, err = Decompose(x, DecompositionOptions{Period: 1})
+	require.Error(t, err)
+
+	_, err = Decompose(x, DecompositionOptions{Period: 7})
+	require.Error(t, err)
+
+	_, err = Decompose([]float64{1, -1, 2, 3}, DecompositionOptions{
+		Period:      2,
+		Seasonality: MultiplicativeSeasonality,
+	})
+	require.Error(t, err)
+
+	_, err = Decompose(x, DecompositionOptions{Period: 4, Method: "x11"})
+	require.Error(t, err)
+
+	_, err = Decompose([]float64{1, math.NaN(), 2, 3}, DecompositionOptions{Period: 2})
+	require.Error(t, err)
+}
+
+func TestSTLDecomposition(t *testing.T) {
+	pattern := []float64{3, -1, -4, 2}
+	x := make([]float64, 48)
+	for i := range x {
+		x[i] = 10 + 0.5*float64(i) + pattern[i%4]
+	}
+
+	// the local lines fit a linear trend and constant cycles exactly.
+	res, err := Decompose(x, DecompositionOptions{Method: STLDecomposition, Period: 4})
+	require.NoError(t, err)
+	require.Equal(t, STLDecomposition, res.Method)
+	require.Equal(t, DefaultSeasonalWindow, res.SeasonalWindow)
+	require.Equal(t, 9, res.TrendWindow)
+	require.Nil(t, res.Figure)
+	for i := range x {
+		require.InDelta(t, 10+0.5*float64(i), *res.Trend[i], 1e-9)
+		require.InDelta(t, pattern[i%4], res.Seasonal[i], 1e-9)
+		require.InDelta(t, 0, *res.Residual[i], 1e-9)
+	}
+
+	// a robust decomposition leaves an outlier in the residuals.
+	r := rand.New(rand.NewSource(1))
+	for i := range x {
+		x[i] += 0.3 * r.NormFloat64()
+	}
+	x[20] += 30
+	res, err = Decompose(x, DecompositionOptions{Method: STLDecomposition, Period: 4})
+	require.NoError(t, err)
+	require.Less(t, *res.Residual[20], 20.0)
+	res, err = Decompose(x, DecompositionOptions{
+		Method:         STLDecomposition,
+		Period:         4,
+		SeasonalWindow: 11,
+		TrendWindow:    7,
+		Robust:         true,
+	})
+	require.NoError(t, err)
+	require.InDelta(t, 30, *res.Residual[20], 2)
+	require.Equal(t, 11, res.SeasonalWindow)
+	require.Equal(t, 7, res.TrendWindow)
+	for i := range x {
+		require.InDelta(t, pattern[i%4], res.Seasonal[i], 1)
+	}
+
+	_, err = Decompose(x, DecompositionOptions{
+		Method:         STLDecomposition,
+		Period:         4,
+		SeasonalWindow: 6,
+	})
+	require.Error(t, err)
+
+	_, err = Decompose(x, DecompositionOptions{
+		Method:      STLDecomposition,
+		Period:      4,
+		Seasonality: MultiplicativeSeasonality,
+	})
+	require.Error(t, err)
+}
+
+func TestLoess(t *testing.T) {
+	// a line is fitted exactly, inside and beyond the values.
+	y := []float64{1, 3, 5, 7, 9, 11}
+	for _, at := range []float64{-1, 0, 2.5, 5, 6} {
+		require.InDelta(t, 1+2*at, loess(y, nil, 3, at), 1e-12)
+		require.InDelta(t, 1+2*at, loess(y, nil, 9, at), 1e-12)
+	}
+
+	// a zero weight drops a value.
+	y[2] = 100
+	rho := []float64{1, 1, 0, 1, 1, 1}
+	require.InDelta(t, 5, loess(y, rho, 5, 2), 1e-12)
+
+	require.InDeltaSlice(t, []float64{2, 3, 4}, movingMean([]float64{1, 2, 3, 4, 5}, 3), 1e-12)
+	require.Equal(t, 9, nextOdd(7.6))
+	require.Equal(t, 7, nextOdd(7))
+}
@@ -0,0 +1,115 @@
+package statsanal
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/gonum/stat"
+)
+
+// ColumnSummary holds the descriptive statistics of a column. Statistics
+// that can't be computed from the column's observed values, e.g. the
+// variance of a single value or the skewness of a constant column, are nil.
+type ColumnSummary struct {
+	Name     string   `json:"name"`
+	Count    int      `json:"count"`
+	Missing  int      `json:"missing"`
+	Mean     *float64 `json:"mean"`
+	Variance *float64 `json:"variance"`
+	StdDev   *float64 `json:"std_dev"`
+	Min      *float64 `json:"min"`
+	Q1       *float64 `json:"q1"`
+	Median   *float64 `json:"median"`
+	Q3       *float64 `json:"q3"`
+	Max      *float64 `json:"max"`
+	Skewness *float64 `json:"skewness"`
+	// Kurtosis is the excess kurtosis, 0 for a normal distribution.
+	Kurtosis *float64 `json:"kurtosis"`
+	// Mode is the most frequent value, the smallest one on ties, and
+	// ModeCount its number of occurrences.
+	Mode      *float64 `json:"mode"`
+	ModeCount int      `json:"mode_count"`
+}
+
+// Describe computes the descriptive statistics of each column of matrix `m`,
+// ignoring missing values. `names` label the columns and default to
+// ColumnNames.
+//
+// Quartiles are linearly interpolated between the closest ranks, like numpy
+// and R's default, and the skewness and kurtosis are the sample adjusted
+// estimates.
+//
+// Returns an error if the number of names doesn't match the number of
+// columns.
+func Describe(m mat.Matrix, names []string) ([]ColumnSummary, error) {
+	r, c := m.Dims()
+	if names == nil {
+		names = ColumnNames(c)
+	}
+	if len(names) != c {
+		return nil, fmt.Errorf("Got %d names for %d columns.", len(names), c)
+	}
+
+	means := mean(m)
+	variances := variance(m)
+
+	res := make([]ColumnSummary, c)
+	for j := 0; j < c; j++ {
+		col := observed(mat.Col(nil, j, m))
+		sort.Float64s(col)
+
+		summary := ColumnSummary{
+			Name:     names[j],
+			Count:    len(col),
+			Missing:  r - len(col),
+			Mean:     finite(means[j]),
+			Variance: finite(variances[j]),
+			StdDev:   finite(math.Sqrt(variances[j])),
+		}
+		if len(col) > 0 {
+			summary.Min = finite(col[0])
+			summary.Q1 = finite(quantile(col, 0.25))
+			summary.Median = finite(median(col))
+			summary.Q3 = finite(quantile(col, 0.75))
+			summary.Max = finite(col[len(col)-1])
+			if len(col) > 2 {
+				summary.Skewness = finite(stat.Skew(col, nil))
+			}
+			if len(col) > 3 {
+				summary.Kurtosis = finite(stat.ExKurtosis(col, nil))
+			}
+
+			mode, count := sortedMode(col)
+			summary.Mode, summary.ModeCount = finite(mode), count
+		}
+		res[j] = summary
+	}
+
+	return res, nil
+}
+
+// finite returns a pointer to `v`, or nil if `v` is NaN or infinite.
+func finite(v float64) *float64 {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return nil
+	}
+	return &v
+}
+
+// sortedMode finds the most frequent value of the sorted slice `x`, the
+// smallest one on ties, and its number of occurrences.
+func sortedMode(x []float64) (mode float64, count int) {
+	for i := 0; i < len(x); {
+		j := i
+		for j < len(x) && x[j] == x[i] {
+			j++
+		}
+		if j-i > count {
+			mode, count = x[i], j-i
+		}
+		i = j
+	}
+	return
+}
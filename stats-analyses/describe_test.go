@@ -0,0 +1,78 @@
+package statsanal
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestDescribe(t *testing.T) {
+	nan := math.NaN()
+	m := mat.NewDense(5, 3, []float64{
+		1, 7, nan,
+		2, 7, nan,
+		3, 7, nan,
+		4, 7, nan,
+		10, nan, 2,
+	})
+
+	res, err := Describe(m, []string{"a", "b", "c"})
+	require.NoError(t, err)
+	require.Len(t, res, 3)
+
+	a := res[0]
+	require.Equal(t, "a", a.Name)
+	require.Equal(t, 5, a.Count)
+	require.Equal(t, 0, a.Missing)
+	require.InDelta(t, 4, *a.Mean, 1e-12)
+	require.InDelta(t, 12.5, *a.Variance, 1e-12)
+	require.InDelta(t, math.Sqrt(12.5), *a.StdDev, 1e-12)
+	require.Equal(t, 1.0, *a.Min)
+	require.Equal(t, 2.0, *a.Q1)
+	require.Equal(t, 3.0, *a.Median)
+	require.Equal(t, 4.0, *a.Q3)
+	require.Equal(t, 10.0, *a.Max)
+	require.Greater(t, *a.Skewness, 0.0)
+	require.NotNil(t, a.Kurtosis)
+	require.Equal(t, 1.0, *a.Mode)
+	require.Equal(t, 1, a.ModeCount)
+
+	// a constant column has no spread, so no skewness nor kurtosis.
+	b := res[1]
+	require.Equal(t, 4, b.Count)
+	require.Equal(t, 1, b.Missing)
+	require.Equal(t, 0.0, *b.Variance)
+	require.Nil(t, b.Skewness)
+	require.Nil(t, b.Kurtosis)
+	require.Equal(t, 7.0, *b.Mode)
+	require.Equal(t, 4, b.ModeCount)
+
+	// a single observed value has no variance.
+	c := res[2]
+	require.Equal(t, 1, c.Count)
+	require.Equal(t, 4, c.Missing)
+	require.Equal(t, 2.0, *c.Mean)
+	require.Nil(t, c.Variance)
+	require.Nil(t, c.StdDev)
+	require.Equal(t, 2.0, *c.Q1)
+	require.Equal(t, 2.0, *c.Q3)
+
+	// default names and mismatched names.
+	res, err = Describe(m, nil)
+	require.NoError(t, err)
+	require.Equal(t, "col_2", res[2].Name)
+
+	_, err = Describe(m, []string{"a"})
+	require.Error(t, err)
+}
+
+func TestQuantile(t *testing.T) {
+	x := []float64{1, 2, 3, 4}
+	require.Equal(t, 1.0, quantile(x, 0))
+	require.Equal(t, 1.75, quantile(x, 0.25))
+	require.Equal(t, 2.5, quantile(x, 0.5))
+	require.Equal(t, 3.25, quantile(x, 0.75))
+	require.Equal(t, 4.0, quantile(x, 1))
+}
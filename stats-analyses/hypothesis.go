@@ -0,0 +1,369 @@
+package statsanal
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/gonum/stat"
+	"gonum.org/v1/gonum/stat/distuv"
+)
+
+// Alternative is the alternative hypothesis of a test.
+type Alternative string
+
+const (
+	// TwoSided tests whether the parameter differs from the null value.
+	TwoSided Alternative = "two_sided"
+	// Less tests whether the parameter is less than the null value.
+	Less Alternative = "less"
+	// Greater tests whether the parameter is greater than the null value.
+	Greater Alternative = "greater"
+)
+
+// TTestOptions configures a t-test.
+type TTestOptions struct {
+	// Mu is the mean, or mean difference, under the null hypothesis.
+	Mu float64
+	// Alternative defaults to TwoSided.
+	Alternative Alternative
+	// Confidence is the confidence level of the interval, in the open
+	// interval (0, 1). Defaults to DefaultConfidence.
+	Confidence float64
+}
+
+// TestResult holds the outcome of a hypothesis test. Values which are
+// undefined, like the statistic of constant samples, are null.
+type TestResult struct {
+	Test        string      `json:"test"`
+	Alternative Alternative `json:"alternative,omitempty"`
+	Statistic   *float64    `json:"statistic"`
+	// DF holds the degrees of freedom of the statistic's distribution, the
+	// numerator then the denominator ones for an F statistic.
+	DF     []float64 `json:"df,omitempty"`
+	PValue *float64  `json:"p_value"`
+	// Method is `exact` or `asymptotic` for tests computing the p-value
+	// either way.
+	Method string `json:"method,omitempty"`
+	// EffectSize is Cohen's d for t-tests, Cramér's V for chi-square tests,
+	// eta squared for ANOVA, the rank-biserial correlation for Mann-Whitney
+	// and Wilcoxon tests and epsilon squared for Kruskal-Wallis tests, as
+	// named by EffectSizeName.
+	EffectSize     *float64 `json:"effect_size"`
+	EffectSizeName string   `json:"effect_size_name"`
+	// Estimate is the mean, or mean difference, of t-tests, with its
+	// confidence interval.
+	Estimate   *float64 `json:"estimate,omitempty"`
+	Confidence float64  `json:"confidence,omitempty"`
+	ConfLower  *float64 `json:"conf_lower,omitempty"`
+	ConfUpper  *float64 `json:"conf_upper,omitempty"`
+	// Lags is the number of lagged differences of augmented Dickey-Fuller
+	// tests.
+	Lags *int `json:"lags,omitempty"`
+	// Observations is the number of observed values of each sample, pair
+	// or group, or the total count of a contingency table.
+	Observations []int `json:"observations"`
+	// Expected counts of a contingency table under independence.
+	Expected [][]float64 `json:"expected,omitempty"`
+}
+
+// OneSampleTTest tests whether the mean of `x` is `opts.Mu`. Missing values
+// are ignored.
+//
+// Returns a non-nil error if the options are invalid or `x` has less than 2
+// observed values.
+func OneSampleTTest(x []float64, opts TTestOptions) (res TestResult, err error) {
+	if opts, err = checkTTestOptions(opts); err != nil {
+		return
+	}
+	x = observed(x)
+	if len(x) < 2 {
+		err = fmt.Errorf("Need at least 2 observations, got %d.", len(x))
+		return
+	}
+
+	m, sd := stat.MeanStdDev(x, nil)
+	n := float64(len(x))
+	res = tTest(m, opts.Mu, sd/math.Sqrt(n), n-1, opts)
+	res.Test = "one_sample"
+	res.EffectSize = finite((m - opts.Mu) / sd)
+	res.Observations = []int{len(x)}
+	return
+}
+
+// WelchTTest tests whether the difference of the means of `x` and `y` is
+// `opts.Mu`, without assuming equal variances. The degrees of freedom follow
+// the Welch–Satterthwaite equation, and the effect size is Cohen's d using
+// the average of the variances. Missing values are ignored.
+//
+// Returns a non-nil error if the options are invalid or a sample has less
+// than 2 observed values, or an error wrapping ErrNoVariance if both samples
+// are constant, which leaves the degrees of freedom undefined.
+func WelchTTest(x, y []float64, opts TTestOptions) (res TestResult, err error) {
+	if opts, err = checkTTestOptions(opts); err != nil {
+		return
+	}
+	x, y = observed(x), observed(y)
+	if len(x) < 2 || len(y) < 2 {
+		err = fmt.Errorf(
+			"Need at least 2 observations per sample, got %d and %d.", len(x), len(y))
+		return
+	}
+
+	mx, vx := stat.MeanVariance(x, nil)
+	my, vy := stat.MeanVariance(y, nil)
+	nx, ny := float64(len(x)), float64(len(y))
+	ax, ay := vx/nx, vy/ny
+	if ax+ay == 0 {
+		err = fmt.Errorf("%w: both samples are constant.", ErrNoVariance)
+		return
+	}
+	df := (ax + ay) * (ax + ay) / (ax*ax/(nx-1) + ay*ay/(ny-1))
+
+	res = tTest(mx-my, opts.Mu, math.Sqrt(ax+ay), df, opts)
+	res.Test = "welch"
+	res.EffectSize = finite((mx - my - opts.Mu) / math.Sqrt((vx+vy)/2))
+	res.Observations = []int{len(x), len(y)}
+	return
+}
+
+// PairedTTest tests whether the mean of the differences `x - y` is
+// `opts.Mu`. Pairs with a missing value are ignored.
+//
+// Returns a non-nil error if the options are invalid, the samples have
+// different lengths, or there are less than 2 complete pairs.
+func PairedTTest(x, y []float64, opts TTestOptions) (res TestResult, err error) {
+	if len(x) != len(y) {
+		err = fmt.Errorf("Samples have different lengths %d and %d.", len(x), len(y))
+		return
+	}
+	x, y = completePairs(x, y)
+	diff := make([]float64, len(x))
+	for i := range x {
+		diff[i] = x[i] - y[i]
+	}
+
+	if res, err = OneSampleTTest(diff, opts); err != nil {
+		return
+	}
+	res.Test = "paired"
+	return
+}
+
+// checkTTestOptions validates `opts` and fills in the defaults.
+func checkTTestOptions(opts TTestOptions) (TTestOptions, error) {
+	if opts.Alternative == "" {
+		opts.Alternative = TwoSided
+	}
+	switch opts.Alternative {
+	case TwoSided, Less, Greater:
+	default:
+		return opts, fmt.Errorf("Unknown alternative %q.", opts.Alternative)
+	}
+	if opts.Confidence == 0 {
+		opts.Confidence = DefaultConfidence
+	}
+	if opts.Confidence <= 0 || opts.Confidence >= 1 {
+		return opts, fmt.Errorf(
+			"Confidence level should be in (0, 1), got %v.", opts.Confidence)
+	}
+	return opts, nil
+}
+
+// tTest tests the `estimate` with standard error `se` against `mu`, with `df`
+// degrees of freedom.
+func tTest(estimate, mu, se, df float64, opts TTestOptions) (res TestResult) {
+	dist := distuv.StudentsT{Mu: 0, Sigma: 1, Nu: df}
+	t := (estimate - mu) / se
+
+	res.Alternative = opts.Alternative
+	res.Statistic = finite(t)
+	res.DF = []float64{df}
+	res.EffectSizeName = "cohens_d"
+	res.Estimate = &estimate
+	res.Confidence = opts.Confidence
+
+	var p, lower, upper float64
+	switch opts.Alternative {
+	case Less:
+		p = dist.CDF(t)
+		lower, upper = math.Inf(-1), estimate+dist.Quantile(opts.Confidence)*se
+	case Greater:
+		p = dist.Survival(t)
+		lower, upper = estimate-dist.Quantile(opts.Confidence)*se, math.Inf(1)
+	default:
+		p = 2 * dist.Survival(math.Abs(t))
+		half := dist.Quantile(1-(1-opts.Confidence)/2) * se
+		lower, upper = estimate-half, estimate+half
+	}
+	res.PValue = finite(p)
+	res.ConfLower, res.ConfUpper = finite(lower), finite(upper)
+	return
+}
+
+// ChiSquareTest tests the independence of the rows and columns of the
+// contingency table `table` with Pearson's chi-square test. The effect size
+// is Cramér's V.
+//
+// Returns a non-nil error if the table has less than 2 rows or columns, a
+// negative or missing count, or a row or column without counts.
+func ChiSquareTest(table mat.Matrix) (res TestResult, err error) {
+	r, c := table.Dims()
+	if r < 2 || c < 2 {
+		err = fmt.Errorf("Need at least a 2x2 table, got %dx%d.", r, c)
+		return
+	}
+
+	rowSums := make([]float64, r)
+	colSums := make([]float64, c)
+	var total float64
+	for i := 0; i < r; i++ {
+		for j := 0; j < c; j++ {
+			v := table.At(i, j)
+			if math.IsNaN(v) || v < 0 {
+				err = fmt.Errorf("Count at (%d, %d) should be non-negative, got %v.", i, j, v)
+				return
+			}
+			rowSums[i] += v
+			colSums[j] += v
+			total += v
+		}
+	}
+	for i, s := range rowSums {
+		if s == 0 {
+			err = fmt.Errorf("Row %d has no counts.", i)
+			return
+		}
+	}
+	for j, s := range colSums {
+		if s == 0 {
+			err = fmt.Errorf("Column %d has no counts.", j)
+			return
+		}
+	}
+
+	var chi2 float64
+	res.Expected = make([][]float64, r)
+	for i := 0; i < r; i++ {
+		res.Expected[i] = make([]float64, c)
+		for j := 0; j < c; j++ {
+			e := rowSums[i] * colSums[j] / total
+			d := table.At(i, j) - e
+			chi2 += d * d / e
+			res.Expected[i][j] = e
+		}
+	}
+	df := float64((r - 1) * (c - 1))
+
+	res.Test = "chi_square"
+	res.Statistic = finite(chi2)
+	res.DF = []float64{df}
+	res.PValue = finite(distuv.ChiSquared{K: df}.Survival(chi2))
+	res.EffectSize = finite(math.Sqrt(chi2 / (total * float64(min(r, c)-1))))
+	res.EffectSizeName = "cramers_v"
+	res.Observations = []int{int(total)}
+	return
+}
+
+// Crosstab counts the pairs of values of `x` and `y` into a contingency
+// table, whose rows are the sorted distinct values `rows` of `x` and columns
+// the sorted distinct values `cols` of `y`. Pairs with a missing value are
+// ignored.
+//
+// Returns a non-nil error if there is no complete pair.
+func Crosstab(x, y []float64) (table *mat.Dense, rows, cols []float64, err error) {
+	x, y = completePairs(x, y)
+	if len(x) == 0 {
+		err = fmt.Errorf("No pair of observed values.")
+		return
+	}
+	rows, cols = levels(x), levels(y)
+
+	table = mat.NewDense(len(rows), len(cols), nil)
+	for k := range x {
+		i := sort.SearchFloat64s(rows, x[k])
+		j := sort.SearchFloat64s(cols, y[k])
+		table.Set(i, j, table.At(i, j)+1)
+	}
+	return
+}
+
+// OneWayANOVA tests whether the means of the `groups` are equal, assuming
+// equal variances. The effect size is eta squared, the share of the variance
+// explained by the groups. Missing values are ignored.
+//
+// Returns a non-nil error if there are less than 2 groups, an empty group,
+// or no more observations than groups.
+func OneWayANOVA(groups [][]float64) (res TestResult, err error) {
+	if len(groups) < 2 {
+		err = fmt.Errorf("Need at least 2 groups, got %d.", len(groups))
+		return
+	}
+
+	var all []float64
+	observedGroups := make([][]float64, len(groups))
+	res.Observations = make([]int, len(groups))
+	for g, group := range groups {
+		observedGroups[g] = observed(group)
+		if len(observedGroups[g]) == 0 {
+			err = fmt.Errorf("Group %d has no observations.", g)
+			return
+		}
+		res.Observations[g] = len(observedGroups[g])
+		all = append(all, observedGroups[g]...)
+	}
+	k, n := float64(len(groups)), float64(len(all))
+	if n <= k {
+		err = fmt.Errorf("Need more observations than groups, got %v.", n)
+		return
+	}
+
+	grandMean := stat.Mean(all, nil)
+	var between, within float64
+	for _, group := range observedGroups {
+		m := stat.Mean(group, nil)
+		between += float64(len(group)) * (m - grandMean) * (m - grandMean)
+		for _, v := range group {
+			within += (v - m) * (v - m)
+		}
+	}
+	df1, df2 := k-1, n-k
+	f := (between / df1) / (within / df2)
+
+	res.Test = "anova"
+	res.Statistic = finite(f)
+	res.DF = []float64{df1, df2}
+	res.PValue = finite(distuv.F{D1: df1, D2: df2}.Survival(f))
+	res.EffectSize = finite(between / (between + within))
+	res.EffectSizeName = "eta_squared"
+	return
+}
+
+// GroupBy splits the `values` by the matching `labels`, into one group per
+// sorted distinct label. Values with a missing value or label are ignored.
+func GroupBy(values, labels []float64) (groups [][]float64, names []float64) {
+	values, labels = completePairs(values, labels)
+	names = levels(labels)
+
+	groups = make([][]float64, len(names))
+	for k, v := range values {
+		g := sort.SearchFloat64s(names, labels[k])
+		groups[g] = append(groups[g], v)
+	}
+	return
+}
+
+// levels returns the sorted distinct values of `x`.
+func levels(x []float64) []float64 {
+	sorted := append([]float64(nil), x...)
+	sort.Float64s(sorted)
+
+	var distinct []float64
+	for i, v := range sorted {
+		if i == 0 || v != sorted[i-1] {
+			distinct = append(distinct, v)
+		}
+	}
+	return distinct
+}
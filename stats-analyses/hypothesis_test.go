@@ -0,0 +1,165 @@
+package statsanal
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gonum.org/v1/gonum/mat"
+)
+
+// extra hours of sleep of 10 patients with 2 drugs, R's `sleep` dataset.
+var (
+	sleepDrug1 = []float64{0.7, -1.6, -0.2, -1.2, -0.1, 3.4, 3.7, 0.8, 0.0, 2.0}
+	sleepDrug2 = []float64{1.9, 0.8, 1.1, 0.1, -0.1, 4.4, 5.5, 1.6, 4.6, 3.4}
+)
+
+func TestOneSampleTTest(t *testing.T) {
+	// t.test(sleep$extra[1:10])
+	res, err := OneSampleTTest(sleepDrug1, TTestOptions{})
+	require.NoError(t, err)
+	require.Equal(t, "one_sample", res.Test)
+	require.Equal(t, TwoSided, res.Alternative)
+	require.InDelta(t, 1.3257, *res.Statistic, 1e-4)
+	require.Equal(t, []float64{9}, res.DF)
+	require.InDelta(t, 0.2176, *res.PValue, 1e-4)
+	require.InDelta(t, 0.75, *res.Estimate, 1e-12)
+	require.InDelta(t, -0.5297804, *res.ConfLower, 1e-6)
+	require.InDelta(t, 2.0297804, *res.ConfUpper, 1e-6)
+	require.Equal(t, "cohens_d", res.EffectSizeName)
+	require.InDelta(t, 1.3257/math.Sqrt(10), *res.EffectSize, 1e-4)
+	require.Equal(t, []int{10}, res.Observations)
+
+	// one-sided p-values split the two-sided one.
+	greater, err := OneSampleTTest(sleepDrug1, TTestOptions{Alternative: Greater})
+	require.NoError(t, err)
+	require.InDelta(t, *res.PValue/2, *greater.PValue, 1e-12)
+	require.Nil(t, greater.ConfUpper)
+	less, err := OneSampleTTest(sleepDrug1, TTestOptions{Alternative: Less})
+	require.NoError(t, err)
+	require.InDelta(t, 1, *less.PValue+*greater.PValue, 1e-12)
+	require.Nil(t, less.ConfLower)
+
+	// missing values are ignored.
+	withMissing := append([]float64{math.NaN()}, sleepDrug1...)
+	again, err := OneSampleTTest(withMissing, TTestOptions{})
+	require.NoError(t, err)
+	require.Equal(t, res, again)
+
+	_, err = OneSampleTTest([]float64{1}, TTestOptions{})
+	require.Error(t, err)
+	_, err = OneSampleTTest(sleepDrug1, TTestOptions{Alternative: "both"})
+	require.Error(t, err)
+	_, err = OneSampleTTest(sleepDrug1, TTestOptions{Confidence: 1})
+	require.Error(t, err)
+}
+
+func TestWelchTTest(t *testing.T) {
+	// t.test(extra ~ group, data = sleep)
+	res, err := WelchTTest(sleepDrug1, sleepDrug2, TTestOptions{})
+	require.NoError(t, err)
+	require.Equal(t, "welch", res.Test)
+	require.InDelta(t, -1.8608, *res.Statistic, 1e-4)
+	require.InDelta(t, 17.776, res.DF[0], 1e-3)
+	require.InDelta(t, 0.07939, *res.PValue, 1e-5)
+	require.InDelta(t, -1.58, *res.Estimate, 1e-12)
+	require.InDelta(t, -3.3654832, *res.ConfLower, 1e-6)
+	require.InDelta(t, 0.2054832, *res.ConfUpper, 1e-6)
+	require.Equal(t, []int{10, 10}, res.Observations)
+
+	_, err = WelchTTest(sleepDrug1, []float64{1}, TTestOptions{})
+	require.Error(t, err)
+
+	// a single constant sample still has degrees of freedom.
+	res, err = WelchTTest(sleepDrug1, []float64{2, 2, 2}, TTestOptions{})
+	require.NoError(t, err)
+	require.InDelta(t, 9, res.DF[0], 1e-12)
+
+	_, err = WelchTTest([]float64{1, 1, 1}, []float64{2, 2, 2}, TTestOptions{})
+	require.ErrorIs(t, err, ErrNoVariance)
+}
+
+func TestPairedTTest(t *testing.T) {
+	// t.test(sleep$extra[1:10], sleep$extra[11:20], paired = TRUE)
+	res, err := PairedTTest(sleepDrug1, sleepDrug2, TTestOptions{Confidence: 0.95})
+	require.NoError(t, err)
+	require.Equal(t, "paired", res.Test)
+	require.InDelta(t, -4.0621, *res.Statistic, 1e-4)
+	require.Equal(t, []float64{9}, res.DF)
+	require.InDelta(t, 0.002833, *res.PValue, 1e-6)
+	require.InDelta(t, -2.4598858, *res.ConfLower, 1e-6)
+	require.InDelta(t, -0.7001142, *res.ConfUpper, 1e-6)
+
+	_, err = PairedTTest(sleepDrug1, sleepDrug2[1:], TTestOptions{})
+	require.Error(t, err)
+}
+
+func TestChiSquareTest(t *testing.T) {
+	// party identification by gender, the example of R's chisq.test.
+	table := mat.NewDense(2, 3, []float64{762, 327, 468, 484, 239, 477})
+
+	res, err := ChiSquareTest(table)
+	require.NoError(t, err)
+	require.Equal(t, "chi_square", res.Test)
+	require.InDelta(t, 30.07, *res.Statistic, 1e-2)
+	require.Equal(t, []float64{2}, res.DF)
+	require.InDelta(t, 2.954e-07, *res.PValue, 1e-10)
+	require.Equal(t, "cramers_v", res.EffectSizeName)
+	require.InDelta(t, math.Sqrt(*res.Statistic/2757), *res.EffectSize, 1e-12)
+	require.Equal(t, []int{2757}, res.Observations)
+	require.InDelta(t, 1557*1246/2757.0, res.Expected[0][0], 1e-9)
+
+	_, err = ChiSquareTest(mat.NewDense(1, 2, []float64{1, 2}))
+	require.Error(t, err)
+	_, err = ChiSquareTest(mat.NewDense(2, 2, []float64{1, 0, 2, 0}))
+	require.Error(t, err)
+	_, err = ChiSquareTest(mat.NewDense(2, 2, []float64{1, -1, 2, 3}))
+	require.Error(t, err)
+}
+
+func TestCrosstab(t *testing.T) {
+	x := []float64{1, 2, 1, 2, 1, math.NaN()}
+	y := []float64{0, 0, 1, 1, 1, 0}
+
+	table, rows, cols, err := Crosstab(x, y)
+	require.NoError(t, err)
+	require.Equal(t, []float64{1, 2}, rows)
+	require.Equal(t, []float64{0, 1}, cols)
+	require.Equal(t, []float64{1, 2, 1, 1}, table.RawMatrix().Data)
+
+	_, _, _, err = Crosstab([]float64{math.NaN()}, []float64{1})
+	require.Error(t, err)
+}
+
+func TestOneWayANOVA(t *testing.T) {
+	// summary(aov(weight ~ group, data = PlantGrowth))
+	ctrl := []float64{4.17, 5.58, 5.18, 6.11, 4.50, 4.61, 5.17, 4.53, 5.33, 5.14}
+	trt1 := []float64{4.81, 4.17, 4.41, 3.59, 5.87, 3.83, 6.03, 4.89, 4.32, 4.69}
+	trt2 := []float64{6.31, 5.12, 5.54, 5.50, 5.37, 5.29, 4.92, 6.15, 5.80, 5.26}
+
+	res, err := OneWayANOVA([][]float64{ctrl, trt1, trt2})
+	require.NoError(t, err)
+	require.Equal(t, "anova", res.Test)
+	require.InDelta(t, 4.846, *res.Statistic, 1e-3)
+	require.Equal(t, []float64{2, 27}, res.DF)
+	require.InDelta(t, 0.01591, *res.PValue, 1e-5)
+	require.Equal(t, "eta_squared", res.EffectSizeName)
+	require.InDelta(t, 3.766/(3.766+10.492), *res.EffectSize, 1e-3)
+	require.Equal(t, []int{10, 10, 10}, res.Observations)
+
+	// the same groups in long format.
+	values := append(append(append([]float64{}, ctrl...), trt1...), trt2...)
+	labels := make([]float64, len(values))
+	for i := range labels {
+		labels[i] = float64(2 - i/10)
+	}
+	groups, names := GroupBy(values, labels)
+	require.Equal(t, []float64{0, 1, 2}, names)
+	require.Equal(t, trt2, groups[0])
+	require.Equal(t, ctrl, groups[2])
+
+	_, err = OneWayANOVA([][]float64{ctrl})
+	require.Error(t, err)
+	_, err = OneWayANOVA([][]float64{ctrl, {math.NaN()}})
+	require.Error(t, err)
+}
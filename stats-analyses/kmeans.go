@@ -0,0 +1,397 @@
+package statsanal
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"slices"
+
+	"gonum.org/v1/gonum/floats"
+	"gonum.org/v1/gonum/mat"
+)
+
+const (
+	// DefaultRestarts is the number of k-means runs from different initial
+	// centroids when none is given.
+	DefaultRestarts = 10
+	// DefaultKMeansIterations is the maximum number of iterations of a
+	// k-means run when none is given.
+	DefaultKMeansIterations = 300
+)
+
+// KMeansOptions configures a k-means clustering.
+type KMeansOptions struct {
+	// K is the number of clusters.
+	K int
+	// Restarts is the number of runs from different k-means++ initial
+	// centroids, the run with the smallest inertia is kept. Defaults to
+	// DefaultRestarts.
+	Restarts int
+	// MaxIterations bounds the iterations of each run. Defaults to
+	// DefaultKMeansIterations.
+	MaxIterations int
+	// Seed of the random choice of the initial centroids.
+	Seed int64
+	// Standardize scales the columns to unit variance before clustering, so
+	// every column weighs the same in the distances.
+	Standardize bool
+	// Names of the columns of the matrix. Defaults to ColumnNames.
+	Names []string
+}
+
+// KMeansResult holds the outcome of a k-means clustering. Clusters are
+// numbered from 0 in the order of their first row.
+type KMeansResult struct {
+	Names []string `json:"names"`
+	K     int      `json:"k"`
+	Seed  int64    `json:"seed"`
+	// Centroids holds the mean of each cluster, in the units of the columns
+	// ordered like `Names`.
+	Centroids [][]float64 `json:"centroids"`
+	// Labels holds the cluster of each row.
+	Labels []int `json:"labels"`
+	Sizes  []int `json:"sizes"`
+	// Inertia is the sum of the squared distances of the rows to their
+	// centroid, measured on the standardized columns when standardizing.
+	Inertia float64 `json:"inertia"`
+	// Silhouette is the mean silhouette coefficient of the rows, null with a
+	// single cluster or a cluster per row.
+	Silhouette *float64 `json:"silhouette"`
+	Iterations int      `json:"iterations"`
+	Converged  bool     `json:"converged"`
+}
+
+// ElbowPoint holds the inertia and silhouette of the k-means clustering in
+// K clusters.
+type ElbowPoint struct {
+	K          int      `json:"k"`
+	Inertia    float64  `json:"inertia"`
+	Silhouette *float64 `json:"silhouette"`
+}
+
+// KMeans clusters the rows of matrix `m` in `opts.K` clusters, minimizing
+// the sum of the squared distances of the rows to the mean of their cluster.
+// Each run starts from k-means++ initial centroids, then alternates between
+// assigning the rows to their closest centroid and moving the centroids to
+// the mean of their rows until no row changes cluster.
+//
+// Returns an error if the options or names are invalid, there are less
+// distinct rows than clusters, a column is constant while standardizing, or
+// `ctx` is done before the end.
+func KMeans(ctx context.Context, m mat.Matrix, opts KMeansOptions) (res KMeansResult, err error) {
+	data, opts, err := kmeansData(m, opts)
+	if err != nil {
+		return
+	}
+	r, _ := data.Dims()
+	if opts.K < 1 || opts.K > r {
+		err = fmt.Errorf("Number of clusters should be in [1, %d], got %d.", r, opts.K)
+		return
+	}
+
+	return kmeans(ctx, m, data, opts)
+}
+
+// KMeansElbow clusters the rows of matrix `m` with KMeans for every number of
+// clusters from `minK` to `maxK`, whose inertias trace the elbow curve.
+// `opts.K` is ignored.
+//
+// Returns an error if the range is invalid, or KMeans fails.
+func KMeansElbow(ctx context.Context, m mat.Matrix, minK, maxK int, opts KMeansOptions) (res []ElbowPoint, err error) {
+	data, opts, err := kmeansData(m, opts)
+	if err != nil {
+		return
+	}
+	r, _ := data.Dims()
+	if minK < 1 || maxK < minK || maxK > r {
+		err = fmt.Errorf(
+			"Range of clusters should be within [1, %d], got [%d, %d].", r, minK, maxK)
+		return
+	}
+
+	for k := minK; k <= maxK; k++ {
+		opts.K = k
+		var fit KMeansResult
+		if fit, err = kmeans(ctx, m, data, opts); err != nil {
+			return
+		}
+		res = append(res, ElbowPoint{K: k, Inertia: fit.Inertia, Silhouette: fit.Silhouette})
+	}
+	return
+}
+
+// kmeansData validates the options of a k-means clustering of matrix `m`
+// and fills in their defaults. Returns a copy of `m` to cluster, scaled when
+// standardizing.
+func kmeansData(m mat.Matrix, opts KMeansOptions) (*mat.Dense, KMeansOptions, error) {
+	r, c := m.Dims()
+	if r == 0 || c == 0 {
+		return nil, opts, fmt.Errorf("Need at least 1 row and 1 column, got %dx%d.", r, c)
+	}
+	if opts.Names == nil {
+		opts.Names = ColumnNames(c)
+	}
+	if len(opts.Names) != c {
+		return nil, opts, fmt.Errorf("Got %d names for %d columns.", len(opts.Names), c)
+	}
+	if opts.Restarts == 0 {
+		opts.Restarts = DefaultRestarts
+	}
+	if opts.Restarts < 1 {
+		return nil, opts, fmt.Errorf("Restarts should be at least 1, got %d.", opts.Restarts)
+	}
+	if opts.MaxIterations == 0 {
+		opts.MaxIterations = DefaultKMeansIterations
+	}
+	if opts.MaxIterations < 1 {
+		return nil, opts, fmt.Errorf(
+			"Maximum iterations should be at least 1, got %d.", opts.MaxIterations)
+	}
+
+	data := mat.DenseCopyOf(m)
+	if opts.Standardize {
+		if err := scaleColumns(data, opts.Names); err != nil {
+			return nil, opts, err
+		}
+	}
+	return data, opts, nil
+}
+
+// kmeans clusters the rows of `data`, the possibly scaled copy of matrix `m`,
+// keeping the best of `opts.Restarts` runs.
+//
+// Returns an error if there are less distinct rows than clusters, or `ctx`
+// is done before the end.
+func kmeans(ctx context.Context, m mat.Matrix, data *mat.Dense, opts KMeansOptions) (res KMeansResult, err error) {
+	rnd := rand.New(rand.NewSource(opts.Seed))
+
+	var best kmeansRun
+	for run := 0; run < opts.Restarts; run++ {
+		if err = ctx.Err(); err != nil {
+			return
+		}
+		var centroids [][]float64
+		if centroids, err = kmeansPlusPlus(data, opts.K, rnd); err != nil {
+			return
+		}
+		fit := lloyd(data, centroids, opts.MaxIterations)
+		if run == 0 || fit.inertia < best.inertia {
+			best = fit
+		}
+	}
+
+	res.Names = opts.Names
+	res.K = opts.K
+	res.Seed = opts.Seed
+	res.Inertia = best.inertia
+	res.Iterations = best.iterations
+	res.Converged = best.converged
+
+	// number the clusters in the order of their first row.
+	order := make([]int, opts.K)
+	for i := range order {
+		order[i] = -1
+	}
+	var next int
+	res.Labels = make([]int, len(best.labels))
+	for i, l := range best.labels {
+		if order[l] < 0 {
+			order[l] = next
+			next++
+		}
+		res.Labels[i] = order[l]
+	}
+
+	// report the centroids in the units of the columns.
+	r, c := m.Dims()
+	res.Sizes = make([]int, opts.K)
+	res.Centroids = make([][]float64, opts.K)
+	for l := range res.Centroids {
+		res.Centroids[l] = make([]float64, c)
+	}
+	for i := 0; i < r; i++ {
+		l := res.Labels[i]
+		res.Sizes[l]++
+		for j := 0; j < c; j++ {
+			res.Centroids[l][j] += m.At(i, j)
+		}
+	}
+	for l, centroid := range res.Centroids {
+		floats.Scale(1/float64(res.Sizes[l]), centroid)
+	}
+
+	res.Silhouette = silhouette(data, res.Labels, opts.K)
+	return
+}
+
+// kmeansRun holds the outcome of a single k-means run.
+type kmeansRun struct {
+	labels     []int
+	inertia    float64
+	iterations int
+	converged  bool
+}
+
+// kmeansPlusPlus chooses `k` initial centroids among the rows of `data`: the
+// first one uniformly, then each next one with a probability proportional
+// to the squared distance of the row to its closest chosen centroid.
+//
+// Returns an error if there are less than `k` distinct rows.
+func kmeansPlusPlus(data *mat.Dense, k int, rnd *rand.Rand) ([][]float64, error) {
+	r, _ := data.Dims()
+	centroids := [][]float64{mat.Row(nil, rnd.Intn(r), data)}
+
+	dist := make([]float64, r)
+	for i := range dist {
+		dist[i] = math.Inf(1)
+	}
+	for len(centroids) < k {
+		last := centroids[len(centroids)-1]
+		var total float64
+		for i := 0; i < r; i++ {
+			dist[i] = math.Min(dist[i], squaredDistance(data.RawRowView(i), last))
+			total += dist[i]
+		}
+		if total == 0 {
+			return nil, fmt.Errorf(
+				"Can't make %d clusters of %d distinct rows.", k, len(centroids))
+		}
+
+		target := rnd.Float64() * total
+		chosen := -1
+		for i, d := range dist {
+			if d == 0 {
+				continue
+			}
+			chosen = i
+			if target -= d; target < 0 {
+				break
+			}
+		}
+		centroids = append(centroids, mat.Row(nil, chosen, data))
+	}
+	return centroids, nil
+}
+
+// lloyd refines the `centroids` of the rows of `data` until no row changes
+// cluster, or `maxIterations` is reached. A cluster left empty takes the row
+// farthest from its centroid.
+func lloyd(data *mat.Dense, centroids [][]float64, maxIterations int) (run kmeansRun) {
+	r, c := data.Dims()
+	k := len(centroids)
+	run.labels = make([]int, r)
+	dist := make([]float64, r)
+
+	for run.iterations < maxIterations {
+		run.iterations++
+
+		changed := run.iterations == 1
+		for i := 0; i < r; i++ {
+			l, d := closest(data.RawRowView(i), centroids)
+			if l != run.labels[i] {
+				run.labels[i] = l
+				changed = true
+			}
+			dist[i] = d
+		}
+		if !changed {
+			run.converged = true
+			break
+		}
+
+		// refill the empty clusters with the rows farthest from their
+		// centroid.
+		sizes := make([]int, k)
+		for _, l := range run.labels {
+			sizes[l]++
+		}
+		for l := slices.Index(sizes, 0); l >= 0; l = slices.Index(sizes, 0) {
+			far := floats.MaxIdx(dist)
+			sizes[run.labels[far]]--
+			sizes[l]++
+			run.labels[far] = l
+			dist[far] = 0
+		}
+
+		for l := range centroids {
+			centroids[l] = make([]float64, c)
+		}
+		for i := 0; i < r; i++ {
+			floats.Add(centroids[run.labels[i]], data.RawRowView(i))
+		}
+		for l := range centroids {
+			floats.Scale(1/float64(sizes[l]), centroids[l])
+		}
+	}
+
+	for i := 0; i < r; i++ {
+		run.inertia += squaredDistance(data.RawRowView(i), centroids[run.labels[i]])
+	}
+	return
+}
+
+// closest finds the centroid closest to row `x`, along with their squared
+// distance.
+func closest(x []float64, centroids [][]float64) (int, float64) {
+	best, bestDist := 0, math.Inf(1)
+	for l, centroid := range centroids {
+		if d := squaredDistance(x, centroid); d < bestDist {
+			best, bestDist = l, d
+		}
+	}
+	return best, bestDist
+}
+
+// squaredDistance finds the squared euclidean distance between `x` and `y`.
+func squaredDistance(x, y []float64) float64 {
+	var d float64
+	for i := range x {
+		d += (x[i] - y[i]) * (x[i] - y[i])
+	}
+	return d
+}
+
+// silhouette finds the mean silhouette coefficient of the rows of `data` in
+// `k` clusters labelled by `labels`. The coefficient of a row alone in its
+// cluster is 0.
+//
+// Returns nil if there is a single cluster or a cluster per row.
+func silhouette(data *mat.Dense, labels []int, k int) *float64 {
+	r, _ := data.Dims()
+	if k < 2 || k >= r {
+		return nil
+	}
+
+	sizes := make([]int, k)
+	for _, l := range labels {
+		sizes[l]++
+	}
+
+	var total float64
+	sums := make([]float64, k)
+	for i := 0; i < r; i++ {
+		if sizes[labels[i]] == 1 {
+			continue
+		}
+		for l := range sums {
+			sums[l] = 0
+		}
+		for j := 0; j < r; j++ {
+			sums[labels[j]] += math.Sqrt(
+				squaredDistance(data.RawRowView(i), data.RawRowView(j)))
+		}
+
+		a := sums[labels[i]] / float64(sizes[labels[i]]-1)
+		b := math.Inf(1)
+		for l, s := range sums {
+			if l != labels[i] {
+				b = math.Min(b, s/float64(sizes[l]))
+			}
+		}
+		if s := math.Max(a, b); s > 0 {
+			total += (b - a) / s
+		}
+	}
+	return finite(total / float64(r))
+}
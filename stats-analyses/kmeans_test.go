@@ -0,0 +1,134 @@
+package statsanal
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gonum.org/v1/gonum/mat"
+)
+
+// blobs returns two well separated blobs of 4 rows each.
+func blobs() *mat.Dense {
+	return mat.NewDense(8, 2, []float64{
+		0, 0,
+		0, 1,
+		1, 0,
+		1, 1,
+		10, 10,
+		10, 11,
+		11, 10,
+		11, 11,
+	})
+}
+
+func TestKMeans(t *testing.T) {
+	m := blobs()
+
+	res, err := KMeans(context.Background(), m, KMeansOptions{K: 2, Seed: 7})
+	require.NoError(t, err)
+	require.Equal(t, []string{"col_0", "col_1"}, res.Names)
+	require.Equal(t, []int{0, 0, 0, 0, 1, 1, 1, 1}, res.Labels)
+	require.Equal(t, []int{4, 4}, res.Sizes)
+	require.InDeltaSlice(t, []float64{0.5, 0.5}, res.Centroids[0], 1e-12)
+	require.InDeltaSlice(t, []float64{10.5, 10.5}, res.Centroids[1], 1e-12)
+	require.InDelta(t, 4, res.Inertia, 1e-12)
+	require.InDelta(t, 0.919526, *res.Silhouette, 1e-6)
+	require.True(t, res.Converged)
+	require.Equal(t, int64(7), res.Seed)
+
+	// a single cluster has no silhouette.
+	res, err = KMeans(context.Background(), m, KMeansOptions{K: 1})
+	require.NoError(t, err)
+	require.InDelta(t, 404, res.Inertia, 1e-9)
+	require.Nil(t, res.Silhouette)
+
+	// the same seed gives the same clustering.
+	r := rand.New(rand.NewSource(1))
+	noisy := mat.NewDense(60, 3, nil)
+	for i := 0; i < 60; i++ {
+		for j := 0; j < 3; j++ {
+			noisy.Set(i, j, r.NormFloat64()+float64(i%3)*4)
+		}
+	}
+	first, err := KMeans(context.Background(), noisy, KMeansOptions{K: 4, Seed: 3, Restarts: 2})
+	require.NoError(t, err)
+	second, err := KMeans(context.Background(), noisy, KMeansOptions{K: 4, Seed: 3, Restarts: 2})
+	require.NoError(t, err)
+	require.Equal(t, first, second)
+	var rows int
+	for _, size := range first.Sizes {
+		rows += size
+	}
+	require.Equal(t, 60, rows)
+
+	// standardizing reports the centroids in the units of the columns.
+	scaled := mat.DenseCopyOf(m)
+	for i := 0; i < 8; i++ {
+		scaled.Set(i, 1, scaled.At(i, 1)*100)
+	}
+	res, err = KMeans(context.Background(), scaled, KMeansOptions{K: 2, Standardize: true})
+	require.NoError(t, err)
+	require.InDeltaSlice(t, []float64{10.5, 1050}, res.Centroids[1], 1e-9)
+
+	_, err = KMeans(context.Background(), m, KMeansOptions{K: 9})
+	require.Error(t, err)
+
+	_, err = KMeans(context.Background(), m, KMeansOptions{K: 2, Names: []string{"a"}})
+	require.Error(t, err)
+
+	_, err = KMeans(context.Background(), m, KMeansOptions{K: 2, Restarts: -1})
+	require.Error(t, err)
+
+	// 3 clusters of 2 distinct rows.
+	twice := mat.NewDense(4, 1, []float64{1, 1, 2, 2})
+	_, err = KMeans(context.Background(), twice, KMeansOptions{K: 3})
+	require.Error(t, err)
+
+	constant := mat.NewDense(3, 2, []float64{1, 1, 2, 1, 3, 1})
+	_, err = KMeans(context.Background(), constant, KMeansOptions{K: 2, Standardize: true})
+	require.Error(t, err)
+}
+
+func TestKMeansElbow(t *testing.T) {
+	res, err := KMeansElbow(context.Background(), blobs(), 1, 4, KMeansOptions{Seed: 1})
+	require.NoError(t, err)
+	require.Len(t, res, 4)
+	for i, p := range res {
+		require.Equal(t, i+1, p.K)
+		if i > 0 {
+			require.Less(t, p.Inertia, res[i-1].Inertia)
+		}
+	}
+	require.InDelta(t, 404, res[0].Inertia, 1e-9)
+	require.InDelta(t, 4, res[1].Inertia, 1e-9)
+	require.Nil(t, res[0].Silhouette)
+	require.NotNil(t, res[1].Silhouette)
+
+	_, err = KMeansElbow(context.Background(), blobs(), 3, 2, KMeansOptions{})
+	require.Error(t, err)
+
+	_, err = KMeansElbow(context.Background(), blobs(), 1, 9, KMeansOptions{})
+	require.Error(t, err)
+}
+
+func TestSilhouette(t *testing.T) {
+	// the coefficient of a row alone in its cluster is 0, the other rows
+	// are at distance 1 of their mate and 3 of the lone row.
+	m := mat.NewDense(3, 1, []float64{0, 1, 4})
+	s := silhouette(m, []int{0, 0, 1}, 2)
+	require.InDelta(t, (1-1/4.0+1-1/3.0)/3, *s, 1e-12)
+
+	require.Nil(t, silhouette(m, []int{0, 1, 2}, 3))
+}
+
+func TestLloydEmptyCluster(t *testing.T) {
+	// no row is closest to the second centroid, which takes the farthest
+	// row before converging.
+	m := mat.NewDense(4, 1, []float64{0, 1, 10, 11})
+	run := lloyd(m, [][]float64{{0}, {1000}}, DefaultKMeansIterations)
+	require.True(t, run.converged)
+	require.Equal(t, []int{0, 0, 1, 1}, run.labels)
+	require.InDelta(t, 1, run.inertia, 1e-12)
+}
@@ -0,0 +1,74 @@
+package statsanal
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// ErrRankDeficient is returned when the design matrix of a least squares
+// problem does not have full column rank, e.g. collinear predictors.
+var ErrRankDeficient = errors.New("design matrix is rank deficient")
+
+// lstsq holds the solution of a least squares problem.
+type lstsq struct {
+	beta *mat.Dense // solution, one row per column of the design matrix.
+	// covUnscaled is (XᵀX)⁻¹, or its pseudo-inverse if X is rank deficient.
+	covUnscaled *mat.Dense
+	rank        int
+	// cond is the 2-norm condition number of X over the retained singular
+	// values.
+	cond float64
+}
+
+// leastSquares solves the least squares problem `X * beta = Y` using the
+// singular value decomposition of `X`. Singular values smaller than
+// max(rows, cols) * eps * s_max are treated as zero when detecting the rank.
+//
+// If `X` is rank deficient, an error wrapping ErrRankDeficient is returned
+// unless `pinv` is set, in which case the minimum norm solution given by the
+// pseudo-inverse of `X` is returned.
+func leastSquares(X, Y mat.Matrix, pinv bool) (res lstsq, err error) {
+	r, c := X.Dims()
+
+	var svd mat.SVD
+	if ok := svd.Factorize(X, mat.SVDThin); !ok {
+		err = fmt.Errorf("Error factorizing design matrix.")
+		return
+	}
+
+	values := svd.Values(nil)
+	rcond := float64(max(r, c)) * eps
+	res.rank = svd.Rank(rcond)
+	if res.rank == 0 {
+		err = fmt.Errorf("%w: all singular values are zero.", ErrRankDeficient)
+		return
+	}
+	if res.rank < c && !pinv {
+		err = fmt.Errorf(
+			"%w: rank %d < %d columns, condition number %.5g.",
+			ErrRankDeficient, res.rank, c, svd.Cond())
+		return
+	}
+	res.cond = values[0] / values[res.rank-1]
+
+	res.beta = mat.NewDense(c, 1, nil)
+	svd.SolveTo(res.beta, Y, res.rank)
+
+	// (XᵀX)⁺ = V * S⁻² * Vᵀ over the retained singular values.
+	var v, vs mat.Dense
+	svd.VTo(&v)
+	vk := v.Slice(0, c, 0, res.rank)
+	vs.Apply(
+		func(i, j int, elem float64) float64 {
+			return elem / math.Pow(values[j], 2)
+		},
+		vk,
+	)
+	res.covUnscaled = mat.NewDense(c, c, nil)
+	res.covUnscaled.Mul(&vs, vk.T())
+
+	return
+}
@@ -1,80 +1,300 @@
 package statsanal
 
 import (
+	"errors"
 	"fmt"
 	"math"
 
 	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/gonum/stat/distuv"
 )
 
+// DefaultConfidence is the confidence level used for coefficients'
+// confidence intervals when none is given.
+const DefaultConfidence = 0.95
+
+// ErrNoVariance is returned when the target of a regression has no variance
+// to explain, or the predictors leave no residual variance.
+var ErrNoVariance = errors.New("no variance to explain")
+
+// RegressionOptions configures a linear regression.
+type RegressionOptions struct {
+	// Confidence is the confidence level of the coefficients' intervals,
+	// in the open interval (0, 1). Defaults to DefaultConfidence.
+	Confidence float64
+	// AllowRankDeficient falls back to the pseudo-inverse solution when
+	// the design matrix is rank deficient instead of returning an error
+	// wrapping ErrRankDeficient.
+	AllowRankDeficient bool
+	// NoIntercept fits the model through the origin.
+	NoIntercept bool
+	// Influence computes the leverage and Cook's distance of each
+	// observation.
+	Influence bool
+	// Names of the columns of the regressed matrix, used to label the
+	// coefficients. Defaults to ColumnNames.
+	Names []string
+}
+
+// RegressionResult holds the outcome of a linear regression. Every slice is
+// ordered like `Names`, with the first element being the intercept|bias
+// unless the model was fitted without an intercept.
+type RegressionResult struct {
+	Names        []string  `json:"names"`
+	Coefficients []float64 `json:"coefficients"`
+	StdErrors    []float64 `json:"standard_errors"`
+	TStats       []float64 `json:"t_statistics"`
+	PValues      []float64 `json:"p_values"`
+	ConfLower    []float64 `json:"conf_lower"`
+	ConfUpper    []float64 `json:"conf_upper"`
+
+	Confidence       float64 `json:"confidence"`
+	Observations     int     `json:"observations"`
+	ResidualDF       int     `json:"residual_df"`
+	ResidualStdError float64 `json:"residual_std_error"`
+	RSquared         float64 `json:"r_squared"`
+	AdjRSquared      float64 `json:"adj_r_squared"`
+	FStatistic       float64 `json:"f_statistic"`
+	FPValue          float64 `json:"f_p_value"`
+
+	// Rank of the design matrix, which is less than the number of
+	// coefficients when the pseudo-inverse fallback was used.
+	Rank            int     `json:"rank"`
+	RankDeficient   bool    `json:"rank_deficient"`
+	ConditionNumber float64 `json:"condition_number"`
+
+	// Influence holds the per-observation diagnostics, when asked for.
+	Influence *Influence `json:"influence,omitempty"`
+
+	// Covariance is the covariance matrix of the coefficients, kept to
+	// build a Model.
+	Covariance [][]float64 `json:"-"`
+}
+
+// Influence holds the influence of each observation on a linear regression,
+// ordered like the rows of the regressed matrix.
+type Influence struct {
+	Residuals []float64 `json:"residuals"`
+	// Leverage is the diagonal of the hat matrix X(XᵀX)⁻¹Xᵀ, in [0, 1],
+	// which sums to the rank of the design matrix.
+	Leverage []float64 `json:"leverage"`
+	// StudentizedResiduals divide the residuals by their standard error,
+	// and CooksDistance measures how much the fitted values move when an
+	// observation is dropped. Both are null for observations with a
+	// leverage of one or for exact fits.
+	StudentizedResiduals []*float64 `json:"studentized_residuals"`
+	CooksDistance        []*float64 `json:"cooks_distance"`
+	// LeverageCutoff is 2p/n and CooksCutoff 4/n, for p the rank of the
+	// design matrix and n the number of observations. Influential holds
+	// the observations above either one.
+	LeverageCutoff float64 `json:"leverage_cutoff"`
+	CooksCutoff    float64 `json:"cooks_cutoff"`
+	Influential    []int   `json:"influential"`
+}
+
+// Formatted renders the coefficients and the t-test statistics as python
+// formatted column vectors rounded to 5 decimal places, e.g.
+//
+//	[[199.60969], [11.66467], ...]
+func (res RegressionResult) Formatted() (coeffs, tstat string) {
+	coeffs = formatColumn(res.Coefficients)
+	tstat = formatColumn(res.TStats)
+	return
+}
+
 // LinearRegression computes the statistical multivariable linear regression
 // on the given matrix `m`, using the explanation found in:
 //
 //	https://developer.ibm.com/articles/linear-regression-from-scratch/
 //
 // The last column of the matrix is used as the target `Y` and the rest of the
-// columns is taken as the predictor `X`. Successful computation returns the
-// coefficients of regression and the t-test statistics for each columns, with
-// the first element being the intercept|bias.
+// columns is taken as the predictor `X`, see SelectColumns to pick other
+// columns. Successful computation returns the coefficients of regression,
+// their standard errors, t-test statistics, two-sided p-values and confidence
+// intervals, with the first element being the intercept|bias, along with the
+// usual goodness of fit summary.
 //
-// Returns a non-nil error if an error occured during computation.
-func LinearRegression(m *mat.Dense) (coeffs, tstat string, err error) {
-	// Calculate the regression coefficients.
-	var x, inv, xTransDotx, invDotxTrans, res mat.Dense
+// When `opts.Influence` is set, the leverage, studentized residual and Cook's
+// distance of each observation are computed from the design matrix.
+//
+// When `opts.NoIntercept` is set, R² and the F-test are computed against the
+// zero model rather than the mean model.
+//
+// The coefficients are found from the singular value decomposition of the
+// design matrix rather than by inverting XᵀX, so collinear or badly scaled
+// columns are detected instead of producing garbage.
+//
+// Returns a non-nil error if an error occured during computation, an error
+// wrapping ErrRankDeficient if the design matrix is rank deficient and
+// `opts.AllowRankDeficient` is not set, or an error wrapping ErrNoVariance if
+// the target is constant, or zero without an intercept, or fitted exactly.
+func LinearRegression(m *mat.Dense, opts RegressionOptions) (res RegressionResult, err error) {
+	if opts.Confidence == 0 {
+		opts.Confidence = DefaultConfidence
+	}
+	if opts.Confidence <= 0 || opts.Confidence >= 1 {
+		err = fmt.Errorf("Confidence level should be in (0, 1), got %v.", opts.Confidence)
+		return
+	}
 
 	r, c := m.Dims()
-	x.Stack(ones(1, r), m.Slice(0, r, 0, c-1).T())
-	X := x.T()
+	if c < 2 {
+		err = fmt.Errorf("Need a target and at least one predictor column.")
+		return
+	}
+	if opts.Names == nil {
+		opts.Names = ColumnNames(c)
+	}
+	if len(opts.Names) != c {
+		err = fmt.Errorf("Got %d names for %d columns.", len(opts.Names), c)
+		return
+	}
+
+	// one parameter for each predictor and the intercept.
+	p := c
+	if opts.NoIntercept {
+		p = c - 1
+	}
+	if r <= p {
+		err = fmt.Errorf(
+			"Not enough observations: %d rows for %d parameters.", r, p)
+		return
+	}
+
+	// Calculate the regression coefficients.
+	var X mat.Matrix = m.Slice(0, r, 0, c-1)
+	if !opts.NoIntercept {
+		var x mat.Dense
+		x.Stack(ones(1, r), X.T())
+		X = x.T()
+	}
 	Y := m.Slice(0, r, c-1, c)
 
-	xTransDotx.Mul(X.T(), X)
-	err = inv.Inverse(&xTransDotx)
+	sol, err := leastSquares(X, Y, opts.AllowRankDeficient)
 	if err != nil {
-		err = fmt.Errorf("Error calculating regression coefficients.\n%v\n", err)
+		err = fmt.Errorf("Error calculating regression coefficients.\n%w", err)
 		return
 	}
-	invDotxTrans.Mul(&inv, X.T())
-	res.Mul(&invDotxTrans, Y)
 
-	fr := mat.Formatted(&res, mat.FormatPython()) //, mat.Prefix("    "), mat.Squeeze())
-	coeffs = fmt.Sprintf("%.5f", fr)
+	// Calculate the residuals and the goodness of fit.
+	var yHat, residual mat.Dense
 
-	// Calculate t-statistics
-	var yHat, residual, residualSquare, coeffVariance mat.Dense
-	var coeffVarianceRoot, coeffVarianceDiag, tst mat.Dense
-
-	yHat.Mul(X, &res)
+	yHat.Mul(X, sol.beta)
 	residual.Sub(Y, &yHat)
-	residualSquare.Apply(
-		func(i, j int, elem float64) float64 {
-			return math.Pow(elem, 2)
-		},
-		&residual,
-	)
-
-	sigmaHat := mat.Sum(&residualSquare) / float64(r-c-2)
-
-	coeffVariance.Apply(
-		func(i, j int, elem float64) float64 {
-			return sigmaHat * elem
-		},
-		&inv,
-	)
-
-	diag := coeffVariance.DiagView()
-	dr, _ := diag.Dims()
-	coeffVarianceDiag.Mul(diag, ones(dr, 1))
-	coeffVarianceRoot.Apply(
-		func(i, j int, elem float64) float64 {
-			return math.Sqrt(elem)
-		},
-		&coeffVarianceDiag,
-	)
-
-	tst.DivElem(&res, &coeffVarianceRoot)
-
-	ft := mat.Formatted(&tst, mat.FormatPython()) //, mat.Prefix("    "), mat.Squeeze())
-	tstat = fmt.Sprintf("%.5f", ft)
+
+	dof := r - sol.rank
+	ssr := mat.Dot(residual.ColView(0), residual.ColView(0))
+	// the null model is the mean of Y, or zero without an intercept.
+	var yMean float64
+	modelDF, totalDF := sol.rank, r
+	if !opts.NoIntercept {
+		yMean = mat.Sum(Y) / float64(r)
+		modelDF, totalDF = sol.rank-1, r-1
+	}
+	var sst float64
+	for i := 0; i < r; i++ {
+		d := Y.At(i, 0) - yMean
+		sst += d * d
+	}
+	sigmaHat := ssr / float64(dof)
+	if sst == 0 {
+		err = fmt.Errorf("%w: the target column is constant.", ErrNoVariance)
+		return
+	}
+	if sigmaHat == 0 {
+		err = fmt.Errorf("%w: the predictors fit the target exactly.", ErrNoVariance)
+		return
+	}
+
+	res.Confidence = opts.Confidence
+	res.Observations = r
+	res.ResidualDF = dof
+	res.ResidualStdError = math.Sqrt(sigmaHat)
+	res.RSquared = 1 - ssr/sst
+	res.AdjRSquared = 1 - (1-res.RSquared)*float64(totalDF)/float64(dof)
+	res.FPValue = 1
+	if modelDF > 0 {
+		res.FStatistic = ((sst - ssr) / float64(modelDF)) / sigmaHat
+		res.FPValue = distuv.F{
+			D1: float64(modelDF),
+			D2: float64(dof),
+		}.Survival(res.FStatistic)
+	}
+	res.Rank = sol.rank
+	res.RankDeficient = sol.rank < p
+	res.ConditionNumber = sol.cond
+
+	// Calculate coefficients' statistics.
+	dist := distuv.StudentsT{Mu: 0, Sigma: 1, Nu: float64(dof)}
+	tCrit := dist.Quantile(1 - (1-opts.Confidence)/2)
+
+	res.Names = append([]string{}, opts.Names[:c-1]...)
+	if !opts.NoIntercept {
+		res.Names = append([]string{"intercept"}, res.Names...)
+	}
+	res.Coefficients = mat.Col(nil, 0, sol.beta)
+	res.StdErrors = make([]float64, p)
+	res.TStats = make([]float64, p)
+	res.PValues = make([]float64, p)
+	res.ConfLower = make([]float64, p)
+	res.ConfUpper = make([]float64, p)
+	res.Covariance = squareMatrix[float64](p)
+	for i := 0; i < p; i++ {
+		for j := 0; j < p; j++ {
+			res.Covariance[i][j] = sigmaHat * sol.covUnscaled.At(i, j)
+		}
+		res.StdErrors[i] = math.Sqrt(sigmaHat * sol.covUnscaled.At(i, i))
+		res.PValues[i] = 1
+		// coefficients aliased away by the pseudo-inverse have no spread.
+		if res.StdErrors[i] > 0 {
+			res.TStats[i] = res.Coefficients[i] / res.StdErrors[i]
+			res.PValues[i] = 2 * dist.Survival(math.Abs(res.TStats[i]))
+		}
+		res.ConfLower[i] = res.Coefficients[i] - tCrit*res.StdErrors[i]
+		res.ConfUpper[i] = res.Coefficients[i] + tCrit*res.StdErrors[i]
+	}
+
+	if opts.Influence {
+		res.Influence = influence(X, sol, mat.Col(nil, 0, &residual), sigmaHat)
+	}
 
 	return
 }
+
+// influence computes the influence of each observation of the fit `sol` of
+// the design matrix `X`, with residuals `residual` and residual variance
+// `sigmaHat`.
+func influence(X mat.Matrix, sol lstsq, residual []float64, sigmaHat float64) *Influence {
+	r, _ := X.Dims()
+	n, p := float64(r), float64(sol.rank)
+	res := &Influence{
+		Residuals:            residual,
+		Leverage:             make([]float64, r),
+		StudentizedResiduals: make([]*float64, r),
+		CooksDistance:        make([]*float64, r),
+		LeverageCutoff:       2 * p / n,
+		CooksCutoff:          4 / n,
+		Influential:          []int{},
+	}
+
+	var cx mat.VecDense
+	for i := 0; i < r; i++ {
+		x := mat.Row(nil, i, X)
+		cx.MulVec(sol.covUnscaled, mat.NewVecDense(len(x), x))
+		// rounding errors can push the leverage slightly out of [0, 1].
+		h := min(max(mat.Dot(mat.NewVecDense(len(x), x), &cx), 0), 1)
+		res.Leverage[i] = h
+
+		e := residual[i]
+		if 1-h > 1e-12 && sigmaHat > 0 {
+			res.StudentizedResiduals[i] = finite(e / math.Sqrt(sigmaHat*(1-h)))
+			res.CooksDistance[i] = finite(e * e * h / (p * sigmaHat * (1 - h) * (1 - h)))
+		}
+
+		cooks := res.CooksDistance[i]
+		if h > res.LeverageCutoff || (cooks != nil && *cooks > res.CooksCutoff) {
+			res.Influential = append(res.Influential, i)
+		}
+	}
+	return res
+}
@@ -1,6 +1,7 @@
 package statsanal
 
 import (
+	"math"
 	"strings"
 	"testing"
 
@@ -42,7 +43,7 @@ func TestLinearRegression(t *testing.T) {
     19.48,54.66,128,582,500,731,649,565,113
     19.5,54.66,131,582,500,731,649,565,113`
 
-	tstatGT := "[[0.36592], [2.00788], [2.24806], [-0.47590], [-0.39411], [-2.98670], [-1.20966], [0.87740], [-0.67826]]"
+	tstatGT := "[[0.38469], [2.11091], [2.36342], [-0.50032], [-0.41434], [-3.13997], [-1.27174], [0.92242], [-0.71306]]"
 	coeffsGT := "[[199.60969], [11.66467], [2.59601], [-0.12517], [-0.08513], [-0.48230], [-0.54345], [0.67493], [-0.33504]]"
 
 	reader := strings.NewReader(sampleCSV)
@@ -52,8 +53,192 @@ func TestLinearRegression(t *testing.T) {
 	require.Equal(t, 30, rows)
 
 	m := mat.NewDense(rows, cols, data)
-	coeffs, tstat, err := LinearRegression(m)
+	res, err := LinearRegression(m, RegressionOptions{})
 	require.NoError(t, err)
+	require.Len(t, res.Names, cols)
+	require.Equal(t, "intercept", res.Names[0])
+	require.Len(t, res.Coefficients, cols)
+	require.Len(t, res.StdErrors, cols)
+	require.Len(t, res.PValues, cols)
+	require.InDelta(t, 199.60969, res.Coefficients[0], 1e-5)
+	for i := range res.PValues {
+		require.GreaterOrEqual(t, res.PValues[i], 0.0)
+		require.LessOrEqual(t, res.PValues[i], 1.0)
+		require.Less(t, res.ConfLower[i], res.Coefficients[i])
+		require.Greater(t, res.ConfUpper[i], res.Coefficients[i])
+	}
+
+	require.Equal(t, DefaultConfidence, res.Confidence)
+	require.Equal(t, rows, res.Observations)
+	require.Equal(t, rows-cols, res.ResidualDF)
+	require.InDelta(t, 0.78321, res.ResidualStdError, 1e-5)
+	require.InDelta(t, 0.97567, res.RSquared, 1e-5)
+	require.InDelta(t, 0.96640, res.AdjRSquared, 1e-5)
+	require.InDelta(t, 105.27392, res.FStatistic, 1e-5)
+	require.Less(t, res.FPValue, 1e-10)
+	require.Equal(t, cols, res.Rank)
+	require.False(t, res.RankDeficient)
+	require.Greater(t, res.ConditionNumber, 1.0)
+
+	coeffs, tstat := res.Formatted()
 	require.Equal(t, coeffsGT, coeffs)
 	require.Equal(t, tstatGT, tstat)
 }
+
+func TestLinearRegressionOptions(t *testing.T) {
+	r, c := 30, 4
+	rows, cols, data, err := util.ParseCSVToFloatSlice(
+		strings.NewReader(util.RandomCSV(r, c)))
+	require.NoError(t, err)
+	m := mat.NewDense(rows, cols, data)
+
+	res95, err := LinearRegression(m, RegressionOptions{})
+	require.NoError(t, err)
+	res99, err := LinearRegression(m, RegressionOptions{Confidence: 0.99})
+	require.NoError(t, err)
+	require.Equal(t, 0.99, res99.Confidence)
+	for i := range res95.Coefficients {
+		require.Less(t, res99.ConfLower[i], res95.ConfLower[i])
+		require.Greater(t, res99.ConfUpper[i], res95.ConfUpper[i])
+	}
+
+	_, err = LinearRegression(m, RegressionOptions{Confidence: 1.5})
+	require.Error(t, err)
+
+	_, err = LinearRegression(m.Slice(0, c, 0, c).(*mat.Dense), RegressionOptions{})
+	require.Error(t, err)
+}
+
+func TestLinearRegressionRankDeficient(t *testing.T) {
+	r, c := 30, 4
+	rows, cols, data, err := util.ParseCSVToFloatSlice(
+		strings.NewReader(util.RandomCSV(r, c)))
+	require.NoError(t, err)
+	m := mat.NewDense(rows, cols, data)
+	// make the second predictor a multiple of the first.
+	for i := 0; i < rows; i++ {
+		m.Set(i, 1, 2*m.At(i, 0))
+	}
+
+	_, err = LinearRegression(m, RegressionOptions{})
+	require.ErrorIs(t, err, ErrRankDeficient)
+
+	res, err := LinearRegression(m, RegressionOptions{AllowRankDeficient: true})
+	require.NoError(t, err)
+	require.True(t, res.RankDeficient)
+	require.Equal(t, cols-1, res.Rank)
+	require.Equal(t, rows-res.Rank, res.ResidualDF)
+	// the minimum norm solution splits the effect between collinear columns.
+	require.InDelta(t, 2*res.Coefficients[1], res.Coefficients[2], 1e-8)
+}
+
+func TestLinearRegressionConstantTarget(t *testing.T) {
+	rows, cols, data, err := util.ParseCSVToFloatSlice(
+		strings.NewReader(util.RandomCSV(20, 3)))
+	require.NoError(t, err)
+	m := mat.NewDense(rows, cols, data)
+	for i := 0; i < rows; i++ {
+		m.Set(i, cols-1, 5)
+	}
+
+	_, err = LinearRegression(m, RegressionOptions{})
+	require.ErrorIs(t, err, ErrNoVariance)
+
+	// without an intercept only a zero target has no variance.
+	_, err = LinearRegression(m, RegressionOptions{NoIntercept: true})
+	require.NoError(t, err)
+	for i := 0; i < rows; i++ {
+		m.Set(i, cols-1, 0)
+	}
+	_, err = LinearRegression(m, RegressionOptions{NoIntercept: true})
+	require.ErrorIs(t, err, ErrNoVariance)
+}
+
+func TestLinearRegressionNoIntercept(t *testing.T) {
+	r := 30
+	x := make([]float64, 2*r)
+	for i := 0; i < r; i++ {
+		x[2*i] = float64(i + 1)
+		x[2*i+1] = 3 * float64(i+1)
+	}
+	m := mat.NewDense(r, 2, x)
+
+	res, err := LinearRegression(
+		m, RegressionOptions{NoIntercept: true, Names: []string{"x", "y"}})
+	require.NoError(t, err)
+	require.Equal(t, []string{"x"}, res.Names)
+	require.Len(t, res.Coefficients, 1)
+	require.InDelta(t, 3.0, res.Coefficients[0], 1e-10)
+	require.Equal(t, r-1, res.ResidualDF)
+	require.InDelta(t, 1.0, res.RSquared, 1e-10)
+}
+
+func TestLinearRegressionInfluence(t *testing.T) {
+	// a line with noise and a far away point off the line.
+	xs := []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 20}
+	noise := []float64{0.3, -0.2, 0.1, -0.4, 0.2, 0.5, -0.3, 0.1, -0.1, 0}
+	r := len(xs)
+	m := mat.NewDense(r, 2, nil)
+	for i, x := range xs {
+		m.Set(i, 0, x)
+		m.Set(i, 1, 1+2*x+noise[i])
+	}
+	m.Set(r-1, 1, 20)
+
+	res, err := LinearRegression(m, RegressionOptions{Influence: true})
+	require.NoError(t, err)
+	inf := res.Influence
+	require.NotNil(t, inf)
+	require.InDelta(t, 0.4, inf.LeverageCutoff, 1e-12)
+	require.InDelta(t, 0.4, inf.CooksCutoff, 1e-12)
+
+	// the leverage of a simple regression is 1/n + (x - mean)² / Sxx.
+	var mean, sxx, total float64
+	for _, x := range xs {
+		mean += x / float64(r)
+	}
+	for _, x := range xs {
+		sxx += (x - mean) * (x - mean)
+	}
+	for i, x := range xs {
+		require.InDelta(t, 1/float64(r)+(x-mean)*(x-mean)/sxx, inf.Leverage[i], 1e-10)
+		total += inf.Leverage[i]
+	}
+	require.InDelta(t, 2, total, 1e-10)
+
+	// Cook's distance is the shift of the fitted values when dropping an
+	// observation, scaled by p times the residual variance.
+	s2 := res.ResidualStdError * res.ResidualStdError
+	for _, i := range []int{0, 4, r - 1} {
+		rest := mat.NewDense(r-1, 2, nil)
+		for j, k := 0, 0; j < r; j++ {
+			if j != i {
+				rest.SetRow(k, mat.Row(nil, j, m))
+				k++
+			}
+		}
+		loo, err := LinearRegression(rest, RegressionOptions{})
+		require.NoError(t, err)
+		var shift float64
+		for j := 0; j < r; j++ {
+			d := (res.Coefficients[0] - loo.Coefficients[0]) +
+				(res.Coefficients[1]-loo.Coefficients[1])*m.At(j, 0)
+			shift += d * d
+		}
+		require.InDelta(t, shift/(2*s2), *inf.CooksDistance[i], 1e-9)
+		require.InDelta(t, inf.Residuals[i]/math.Sqrt(s2*(1-inf.Leverage[i])),
+			*inf.StudentizedResiduals[i], 1e-12)
+	}
+	require.Equal(t, []int{r - 1}, inf.Influential)
+
+	res, err = LinearRegression(m, RegressionOptions{})
+	require.NoError(t, err)
+	require.Nil(t, res.Influence)
+}
+
+func TestSelectColumns(t *testing.T) {
+	m := mat.NewDense(2, 3, []float64{1, 2, 3, 4, 5, 6})
+
+	sel := SelectColumns(m, []int{2, 0})
+	require.Equal(t, []float64{3, 1, 6, 4}, sel.RawMatrix().Data)
+}
@@ -0,0 +1,284 @@
+package statsanal
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/gonum/stat/distuv"
+)
+
+const (
+	// DefaultThreshold is the probability above which an observation is
+	// classified as positive when none is given.
+	DefaultThreshold = 0.5
+	// DefaultMaxIterations is the maximum number of iterations of an
+	// iterative fit when none is given.
+	DefaultMaxIterations = 25
+	// DefaultTolerance is the relative change in deviance under which an
+	// iterative fit has converged when none is given.
+	DefaultTolerance = 1e-8
+)
+
+var (
+	// ErrNotBinary is returned when the target of a logistic regression
+	// has values other than 0 and 1.
+	ErrNotBinary = errors.New("target is not binary")
+	// ErrNotConverged is returned when an iterative fit doesn't converge,
+	// e.g. when the classes are perfectly separated.
+	ErrNotConverged = errors.New("fit did not converge")
+)
+
+// LogisticOptions configures a logistic regression.
+type LogisticOptions struct {
+	// Threshold is the probability above which an observation is
+	// classified as positive in the confusion matrix, in the open interval
+	// (0, 1). Defaults to DefaultThreshold.
+	Threshold float64
+	// MaxIterations defaults to DefaultMaxIterations.
+	MaxIterations int
+	// Tolerance defaults to DefaultTolerance.
+	Tolerance float64
+	// NoIntercept fits the model without an intercept.
+	NoIntercept bool
+	// Names of the columns of the regressed matrix, used to label the
+	// coefficients. Defaults to ColumnNames.
+	Names []string
+}
+
+// ConfusionMatrix counts the observations of a binary classification by
+// actual and predicted class.
+type ConfusionMatrix struct {
+	TruePositive  int `json:"true_positive"`
+	FalsePositive int `json:"false_positive"`
+	TrueNegative  int `json:"true_negative"`
+	FalseNegative int `json:"false_negative"`
+}
+
+// Accuracy is the ratio of correctly classified observations.
+func (cm ConfusionMatrix) Accuracy() float64 {
+	correct := cm.TruePositive + cm.TrueNegative
+	return float64(correct) /
+		float64(correct+cm.FalsePositive+cm.FalseNegative)
+}
+
+// LogisticResult holds the outcome of a logistic regression. Every slice is
+// ordered like `Names`, with the first element being the intercept unless
+// the model was fitted without an intercept.
+type LogisticResult struct {
+	Names        []string  `json:"names"`
+	Coefficients []float64 `json:"coefficients"`
+	StdErrors    []float64 `json:"standard_errors"`
+	ZStats       []float64 `json:"z_statistics"`
+	PValues      []float64 `json:"p_values"`
+	// OddsRatios are null when they overflow, for badly scaled predictors.
+	OddsRatios []*float64 `json:"odds_ratios"`
+
+	Observations      int     `json:"observations"`
+	LogLikelihood     float64 `json:"log_likelihood"`
+	NullLogLikelihood float64 `json:"null_log_likelihood"`
+	// PseudoRSquared is McFadden's R², 1 - LogLikelihood / NullLogLikelihood.
+	PseudoRSquared float64 `json:"pseudo_r_squared"`
+	AIC            float64 `json:"aic"`
+	Iterations     int     `json:"iterations"`
+
+	Threshold float64         `json:"threshold"`
+	Confusion ConfusionMatrix `json:"confusion_matrix"`
+	Accuracy  float64         `json:"accuracy"`
+
+	// Covariance is the covariance matrix of the coefficients, kept to
+	// build a Model.
+	Covariance [][]float64 `json:"-"`
+}
+
+// LogisticRegression fits a logistic regression on the given matrix `m` by
+// iteratively reweighted least squares, i.e. Newton's method on the
+// log-likelihood. The last column of the matrix is used as the binary target
+// and the rest of the columns as the predictors, see SelectColumns to pick
+// other columns.
+//
+// The standard errors come from the inverse of the Fisher information, and
+// the p-values from the two-sided Wald z-tests.
+//
+// Returns a non-nil error if the options are invalid, an error wrapping
+// ErrNotBinary if the target has values other than 0 and 1, ErrRankDeficient
+// if the predictors are collinear, or ErrNotConverged if the fit doesn't
+// converge within `opts.MaxIterations`.
+func LogisticRegression(m *mat.Dense, opts LogisticOptions) (res LogisticResult, err error) {
+	if opts.Threshold == 0 {
+		opts.Threshold = DefaultThreshold
+	}
+	if opts.Threshold <= 0 || opts.Threshold >= 1 {
+		err = fmt.Errorf("Threshold should be in (0, 1), got %v.", opts.Threshold)
+		return
+	}
+	if opts.MaxIterations == 0 {
+		opts.MaxIterations = DefaultMaxIterations
+	}
+	if opts.Tolerance == 0 {
+		opts.Tolerance = DefaultTolerance
+	}
+
+	r, c := m.Dims()
+	if c < 2 {
+		err = fmt.Errorf("Need a target and at least one predictor column.")
+		return
+	}
+	if opts.Names == nil {
+		opts.Names = ColumnNames(c)
+	}
+	if len(opts.Names) != c {
+		err = fmt.Errorf("Got %d names for %d columns.", len(opts.Names), c)
+		return
+	}
+
+	p := c
+	if opts.NoIntercept {
+		p = c - 1
+	}
+	if r <= p {
+		err = fmt.Errorf(
+			"Not enough observations: %d rows for %d parameters.", r, p)
+		return
+	}
+
+	y := mat.Col(nil, c-1, m)
+	for _, v := range y {
+		if v != 0 && v != 1 {
+			err = fmt.Errorf("%w: found value %v.", ErrNotBinary, v)
+			return
+		}
+	}
+	if positives := sum(y...); positives == 0 || positives == float64(r) {
+		err = fmt.Errorf("%w: only one class is observed.", ErrNotBinary)
+		return
+	}
+
+	var X mat.Matrix = m.Slice(0, r, 0, c-1)
+	if !opts.NoIntercept {
+		var x mat.Dense
+		x.Stack(ones(1, r), X.T())
+		X = x.T()
+	}
+
+	// Fit the coefficients.
+	beta := mat.NewVecDense(p, nil)
+	eta := mat.NewVecDense(r, nil)
+	var sol lstsq
+	deviance := math.Inf(1)
+	converged := false
+	for res.Iterations < opts.MaxIterations && !converged {
+		res.Iterations++
+
+		// weighted least squares on the working response z.
+		Xw := mat.DenseCopyOf(X)
+		zw := mat.NewDense(r, 1, nil)
+		for i := 0; i < r; i++ {
+			mu := clampProbability(sigmoid(eta.AtVec(i)))
+			w := mu * (1 - mu)
+			sw := math.Sqrt(w)
+			zw.Set(i, 0, sw*(eta.AtVec(i)+(y[i]-mu)/w))
+			for j := 0; j < p; j++ {
+				Xw.Set(i, j, sw*Xw.At(i, j))
+			}
+		}
+
+		sol, err = leastSquares(Xw, zw, false)
+		if err != nil {
+			err = fmt.Errorf("Error calculating regression coefficients.\n%w", err)
+			return
+		}
+		beta.CopyVec(sol.beta.ColView(0))
+		eta.MulVec(X, beta)
+
+		newDeviance := -2 * logLikelihood(y, eta)
+		converged = math.Abs(newDeviance-deviance) <
+			opts.Tolerance*(math.Abs(newDeviance)+0.1)
+		deviance = newDeviance
+	}
+	if !converged {
+		err = fmt.Errorf("%w after %d iterations, the classes may be separated.",
+			ErrNotConverged, res.Iterations)
+		return
+	}
+
+	// Calculate the goodness of fit.
+	res.Observations = r
+	res.LogLikelihood = -deviance / 2
+	yMean := sum(y...) / float64(r)
+	if opts.NoIntercept {
+		// the null model predicts even odds.
+		res.NullLogLikelihood = float64(r) * math.Log(0.5)
+	} else {
+		for _, v := range y {
+			res.NullLogLikelihood += v*math.Log(yMean) + (1-v)*math.Log(1-yMean)
+		}
+	}
+	res.PseudoRSquared = 1 - res.LogLikelihood/res.NullLogLikelihood
+	res.AIC = -2*res.LogLikelihood + 2*float64(p)
+
+	res.Threshold = opts.Threshold
+	for i, v := range y {
+		predicted := sigmoid(eta.AtVec(i)) > opts.Threshold
+		switch {
+		case predicted && v == 1:
+			res.Confusion.TruePositive++
+		case predicted:
+			res.Confusion.FalsePositive++
+		case v == 0:
+			res.Confusion.TrueNegative++
+		default:
+			res.Confusion.FalseNegative++
+		}
+	}
+	res.Accuracy = res.Confusion.Accuracy()
+
+	// Calculate coefficients' statistics.
+	res.Names = append([]string{}, opts.Names[:c-1]...)
+	if !opts.NoIntercept {
+		res.Names = append([]string{"intercept"}, res.Names...)
+	}
+	res.Coefficients = mat.Col(nil, 0, beta)
+	res.StdErrors = make([]float64, p)
+	res.ZStats = make([]float64, p)
+	res.PValues = make([]float64, p)
+	res.OddsRatios = make([]*float64, p)
+	res.Covariance = squareMatrix[float64](p)
+	for i := 0; i < p; i++ {
+		for j := 0; j < p; j++ {
+			res.Covariance[i][j] = sol.covUnscaled.At(i, j)
+		}
+		res.StdErrors[i] = math.Sqrt(sol.covUnscaled.At(i, i))
+		res.ZStats[i] = res.Coefficients[i] / res.StdErrors[i]
+		res.PValues[i] = 2 * distuv.UnitNormal.Survival(math.Abs(res.ZStats[i]))
+		res.OddsRatios[i] = finite(math.Exp(res.Coefficients[i]))
+	}
+
+	return
+}
+
+// sigmoid is the logistic function, the inverse of the logit.
+func sigmoid(x float64) float64 {
+	return 1 / (1 + math.Exp(-x))
+}
+
+// clampProbability keeps the fitted probability `p` away from 0 and 1 so
+// the weights of the fit stay positive.
+func clampProbability(p float64) float64 {
+	const limit = 1e-10
+	return math.Min(math.Max(p, limit), 1-limit)
+}
+
+// logLikelihood finds the log-likelihood of the binary outcomes `y` under
+// the linear predictor `eta`, i.e. Σ yη - log(1 + exp(η)).
+func logLikelihood(y []float64, eta mat.Vector) float64 {
+	var ll float64
+	for i, v := range y {
+		e := eta.AtVec(i)
+		// log(1 + exp(e)) without overflow.
+		softplus := math.Log1p(math.Exp(-math.Abs(e))) + math.Max(e, 0)
+		ll += v*e - softplus
+	}
+	return ll
+}
@@ -0,0 +1,94 @@
+package statsanal
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestLogisticRegression(t *testing.T) {
+	// with a single binary predictor the fit has a closed form: for x = 0,
+	// 3 of 8 are positive, and for x = 1, 6 of 8 are.
+	var data []float64
+	for i, x := range []float64{0, 1} {
+		positives := []int{3, 6}[i]
+		for j := 0; j < 8; j++ {
+			y := 0.0
+			if j < positives {
+				y = 1
+			}
+			data = append(data, x, y)
+		}
+	}
+	m := mat.NewDense(16, 2, data)
+
+	logit := func(p float64) float64 { return math.Log(p / (1 - p)) }
+
+	res, err := LogisticRegression(m, LogisticOptions{})
+	require.NoError(t, err)
+	require.Equal(t, []string{"intercept", "col_0"}, res.Names)
+	require.InDelta(t, logit(3.0/8), res.Coefficients[0], 1e-6)
+	require.InDelta(t, logit(6.0/8)-logit(3.0/8), res.Coefficients[1], 1e-6)
+	require.InDelta(t, math.Sqrt(1.0/3+1.0/5+1.0/6+1.0/2), res.StdErrors[1], 1e-6)
+	require.InDelta(t, math.Exp(res.Coefficients[1]), *res.OddsRatios[1], 1e-12)
+	require.InDelta(t, res.Coefficients[1]/res.StdErrors[1], res.ZStats[1], 1e-12)
+	require.Greater(t, res.PValues[1], 0.0)
+	require.Less(t, res.PValues[1], 1.0)
+
+	ll := 3*math.Log(3.0/8) + 5*math.Log(5.0/8) + 6*math.Log(6.0/8) + 2*math.Log(2.0/8)
+	require.InDelta(t, ll, res.LogLikelihood, 1e-6)
+	require.InDelta(t, 9*math.Log(9.0/16)+7*math.Log(7.0/16), res.NullLogLikelihood, 1e-9)
+	require.InDelta(t, -2*ll+4, res.AIC, 1e-6)
+	require.Equal(t, 16, res.Observations)
+
+	// x = 0 is predicted negative and x = 1 positive.
+	require.Equal(t, ConfusionMatrix{
+		TruePositive:  6,
+		FalsePositive: 2,
+		TrueNegative:  5,
+		FalseNegative: 3,
+	}, res.Confusion)
+	require.InDelta(t, 11.0/16, res.Accuracy, 1e-12)
+
+	// everything is positive with a low threshold.
+	res, err = LogisticRegression(m, LogisticOptions{Threshold: 0.3})
+	require.NoError(t, err)
+	require.Equal(t, 9, res.Confusion.TruePositive)
+	require.Equal(t, 7, res.Confusion.FalsePositive)
+
+	// a badly scaled predictor overflows its odds ratio.
+	scaled := mat.DenseCopyOf(m)
+	for i := 0; i < 16; i++ {
+		scaled.Set(i, 0, 1e-3*scaled.At(i, 0))
+	}
+	res, err = LogisticRegression(scaled, LogisticOptions{})
+	require.NoError(t, err)
+	require.InDelta(t, 1e3*(logit(6.0/8)-logit(3.0/8)), res.Coefficients[1], 1e-2)
+	require.NotNil(t, res.OddsRatios[0])
+	require.Nil(t, res.OddsRatios[1])
+}
+
+func TestLogisticRegressionErrors(t *testing.T) {
+	notBinary := mat.NewDense(4, 2, []float64{1, 0, 2, 1, 3, 2, 4, 1})
+	_, err := LogisticRegression(notBinary, LogisticOptions{})
+	require.ErrorIs(t, err, ErrNotBinary)
+
+	oneClass := mat.NewDense(4, 2, []float64{1, 1, 2, 1, 3, 1, 4, 1})
+	_, err = LogisticRegression(oneClass, LogisticOptions{})
+	require.ErrorIs(t, err, ErrNotBinary)
+
+	separated := mat.NewDense(6, 2, []float64{1, 0, 2, 0, 3, 0, 4, 1, 5, 1, 6, 1})
+	_, err = LogisticRegression(separated, LogisticOptions{})
+	require.ErrorIs(t, err, ErrNotConverged)
+
+	collinear := mat.NewDense(6, 3, []float64{
+		1, 2, 0, 2, 4, 1, 3, 6, 0, 4, 8, 1, 5, 10, 1, 6, 12, 0,
+	})
+	_, err = LogisticRegression(collinear, LogisticOptions{})
+	require.ErrorIs(t, err, ErrRankDeficient)
+
+	_, err = LogisticRegression(notBinary, LogisticOptions{Threshold: 1})
+	require.Error(t, err)
+}
@@ -0,0 +1,113 @@
+package statsanal
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/gonum/stat"
+)
+
+// MissingStrategy tells how missing values, stored as NaN, are handled
+// before an analysis.
+type MissingStrategy string
+
+const (
+	// MissingListwise drops every row with a missing value.
+	MissingListwise MissingStrategy = "listwise"
+	// MissingMean replaces missing values with the mean of their column.
+	MissingMean MissingStrategy = "mean"
+	// MissingMedian replaces missing values with the median of their column.
+	MissingMedian MissingStrategy = "median"
+	// MissingForwardFill replaces missing values with the last observed value
+	// of their column. Rows left with missing values at the start of the
+	// data are dropped.
+	MissingForwardFill MissingStrategy = "ffill"
+)
+
+// HandleMissing returns a copy of matrix `m` where missing values are handled
+// according to `strategy`, which defaults to MissingListwise. The second
+// value returned holds the indices in `m` of the kept rows.
+//
+// Returns an error if the strategy is unknown, if a column only has missing
+// values while imputing, or if no row is left.
+func HandleMissing(m mat.Matrix, strategy MissingStrategy) (*mat.Dense, []int, error) {
+	res := mat.DenseCopyOf(m)
+	r, c := res.Dims()
+
+	switch strategy {
+	case "", MissingListwise:
+	case MissingMean, MissingMedian:
+		for j := 0; j < c; j++ {
+			col := observed(mat.Col(nil, j, res))
+			if len(col) == 0 {
+				return nil, nil, fmt.Errorf(
+					"Column %d has no observed value to impute from.", j)
+			}
+
+			var fill float64
+			if strategy == MissingMean {
+				fill = stat.Mean(col, nil)
+			} else {
+				sort.Float64s(col)
+				fill = median(col)
+			}
+			for i := 0; i < r; i++ {
+				if math.IsNaN(res.At(i, j)) {
+					res.Set(i, j, fill)
+				}
+			}
+		}
+	case MissingForwardFill:
+		for j := 0; j < c; j++ {
+			last := math.NaN()
+			for i := 0; i < r; i++ {
+				if v := res.At(i, j); math.IsNaN(v) {
+					res.Set(i, j, last)
+				} else {
+					last = v
+				}
+			}
+		}
+	default:
+		return nil, nil, fmt.Errorf("Unknown missing value strategy %q.", strategy)
+	}
+
+	return dropMissing(res)
+}
+
+// dropMissing drops the rows of `m` holding a missing value.
+func dropMissing(m *mat.Dense) (*mat.Dense, []int, error) {
+	r, c := m.Dims()
+
+	var kept []int
+	for i := 0; i < r; i++ {
+		if len(observed(m.RawRowView(i))) == c {
+			kept = append(kept, i)
+		}
+	}
+	if len(kept) == 0 {
+		return nil, nil, fmt.Errorf("No row is left after dropping missing values.")
+	}
+	if len(kept) == r {
+		return m, kept, nil
+	}
+
+	res := mat.NewDense(len(kept), c, nil)
+	for i, row := range kept {
+		res.SetRow(i, m.RawRowView(row))
+	}
+	return res, kept, nil
+}
+
+// observed returns the non missing values of `x`.
+func observed(x []float64) []float64 {
+	res := make([]float64, 0, len(x))
+	for _, v := range x {
+		if !math.IsNaN(v) {
+			res = append(res, v)
+		}
+	}
+	return res
+}
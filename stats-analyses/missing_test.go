@@ -0,0 +1,91 @@
+package statsanal
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestHandleMissing(t *testing.T) {
+	nan := math.NaN()
+	m := mat.NewDense(4, 2, []float64{
+		nan, 1,
+		2, nan,
+		4, 3,
+		9, 8,
+	})
+
+	testCases := []struct {
+		name     string
+		strategy MissingStrategy
+		expected []float64
+		kept     []int
+		hasErr   bool
+	}{
+		{
+			name:     "DEFAULT",
+			expected: []float64{4, 3, 9, 8},
+			kept:     []int{2, 3},
+		},
+		{
+			name:     "LISTWISE",
+			strategy: MissingListwise,
+			expected: []float64{4, 3, 9, 8},
+			kept:     []int{2, 3},
+		},
+		{
+			name:     "MEAN",
+			strategy: MissingMean,
+			expected: []float64{5, 1, 2, 4, 4, 3, 9, 8},
+			kept:     []int{0, 1, 2, 3},
+		},
+		{
+			name:     "MEDIAN",
+			strategy: MissingMedian,
+			expected: []float64{4, 1, 2, 3, 4, 3, 9, 8},
+			kept:     []int{0, 1, 2, 3},
+		},
+		{
+			name:     "FORWARD FILL",
+			strategy: MissingForwardFill,
+			expected: []float64{2, 1, 4, 3, 9, 8},
+			kept:     []int{1, 2, 3},
+		},
+		{
+			name:     "UNKNOWN",
+			strategy: "drop",
+			hasErr:   true,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			res, kept, err := HandleMissing(m, tc.strategy)
+			if tc.hasErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, res.RawMatrix().Data)
+			require.Equal(t, tc.kept, kept)
+		})
+	}
+
+	// the input is left untouched.
+	require.True(t, math.IsNaN(m.At(0, 0)))
+}
+
+func TestHandleMissingEmpty(t *testing.T) {
+	nan := math.NaN()
+	m := mat.NewDense(2, 2, []float64{nan, 1, nan, 2})
+
+	_, _, err := HandleMissing(m, MissingListwise)
+	require.Error(t, err)
+
+	_, _, err = HandleMissing(m, MissingMean)
+	require.Error(t, err)
+}
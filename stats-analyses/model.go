@@ -0,0 +1,173 @@
+package statsanal
+
+import (
+	"fmt"
+	"math"
+
+	"gonum.org/v1/gonum/floats"
+	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/gonum/stat/distuv"
+)
+
+// ModelKind is the kind of a fitted model.
+type ModelKind string
+
+const (
+	// KindLinear is a model fitted by LinearRegression.
+	KindLinear ModelKind = "linear"
+	// KindLogistic is a model fitted by LogisticRegression.
+	KindLogistic ModelKind = "logistic"
+	// KindRegularized is a model fitted by RegularizedRegression.
+	KindRegularized ModelKind = "regularized"
+)
+
+// Model is a fitted regression model, which can be stored as json and used
+// to predict the target of new rows.
+type Model struct {
+	Kind       ModelKind `json:"kind"`
+	Target     string    `json:"target"`
+	Predictors []string  `json:"predictors"`
+	Intercept  bool      `json:"intercept"`
+	// Coefficients start with the intercept, if any, followed by one
+	// coefficient per predictor.
+	Coefficients []float64 `json:"coefficients"`
+	// Covariance is the covariance matrix of the coefficients, nil when the
+	// model has no intervals.
+	Covariance [][]float64 `json:"covariance,omitempty"`
+	// ResidualStdError and ResidualDF of a linear model.
+	ResidualStdError float64 `json:"residual_std_error,omitempty"`
+	ResidualDF       int     `json:"residual_df,omitempty"`
+}
+
+// Model returns the fitted linear model, whose predictor columns are named
+// `predictors` and target column `target`.
+func (res RegressionResult) Model(predictors []string, target string) Model {
+	return Model{
+		Kind:             KindLinear,
+		Target:           target,
+		Predictors:       predictors,
+		Intercept:        len(res.Coefficients) > len(predictors),
+		Coefficients:     res.Coefficients,
+		Covariance:       res.Covariance,
+		ResidualStdError: res.ResidualStdError,
+		ResidualDF:       res.ResidualDF,
+	}
+}
+
+// Model returns the fitted logistic model, whose predictor columns are named
+// `predictors` and target column `target`.
+func (res LogisticResult) Model(predictors []string, target string) Model {
+	return Model{
+		Kind:         KindLogistic,
+		Target:       target,
+		Predictors:   predictors,
+		Intercept:    len(res.Coefficients) > len(predictors),
+		Coefficients: res.Coefficients,
+		Covariance:   res.Covariance,
+	}
+}
+
+// Model returns the fitted regularized model, whose predictor columns are
+// named `predictors` and target column `target`. Penalized coefficients are
+// biased, so the model has no intervals.
+func (res RegularizedResult) Model(predictors []string, target string) Model {
+	return Model{
+		Kind:         KindRegularized,
+		Target:       target,
+		Predictors:   predictors,
+		Intercept:    true,
+		Coefficients: res.Coefficients,
+	}
+}
+
+// Prediction holds the predictions of a model for a set of rows. The
+// predictions of a logistic model are probabilities of the positive class.
+type Prediction struct {
+	Predictions []float64 `json:"predictions"`
+	// Interval is `prediction` for the intervals of new observations of a
+	// linear model, `confidence` for the intervals of the probabilities of
+	// a logistic model, or empty if the model has no intervals.
+	Interval   string    `json:"interval,omitempty"`
+	Confidence float64   `json:"confidence,omitempty"`
+	Lower      []float64 `json:"lower,omitempty"`
+	Upper      []float64 `json:"upper,omitempty"`
+}
+
+// Predict predicts the target of each row of matrix `m`, whose columns are
+// the model's predictors in order, along with intervals at the `confidence`
+// level, which defaults to DefaultConfidence.
+//
+// Returns an error if the number of columns doesn't match the predictors,
+// a value is missing, or the confidence level isn't in (0, 1).
+func (mdl Model) Predict(m mat.Matrix, confidence float64) (res Prediction, err error) {
+	if confidence == 0 {
+		confidence = DefaultConfidence
+	}
+	if confidence <= 0 || confidence >= 1 {
+		err = fmt.Errorf("Confidence level should be in (0, 1), got %v.", confidence)
+		return
+	}
+
+	r, c := m.Dims()
+	if c != len(mdl.Predictors) {
+		err = fmt.Errorf("Got %d columns for %d predictors.", c, len(mdl.Predictors))
+		return
+	}
+
+	withIntervals := mdl.Covariance != nil
+	var crit float64
+	switch {
+	case !withIntervals:
+	case mdl.Kind == KindLinear:
+		res.Interval = "prediction"
+		dist := distuv.StudentsT{Mu: 0, Sigma: 1, Nu: float64(mdl.ResidualDF)}
+		crit = dist.Quantile(1 - (1-confidence)/2)
+	default:
+		res.Interval = "confidence"
+		crit = distuv.UnitNormal.Quantile(1 - (1-confidence)/2)
+	}
+	if withIntervals {
+		res.Confidence = confidence
+		res.Lower = make([]float64, r)
+		res.Upper = make([]float64, r)
+	}
+
+	res.Predictions = make([]float64, r)
+	for i := 0; i < r; i++ {
+		x := mat.Row(nil, i, m)
+		if len(observed(x)) != c {
+			err = fmt.Errorf("Row %d has missing values.", i)
+			return
+		}
+		if mdl.Intercept {
+			x = append([]float64{1}, x...)
+		}
+
+		eta := floats.Dot(mdl.Coefficients, x)
+		if mdl.Kind == KindLogistic {
+			res.Predictions[i] = sigmoid(eta)
+		} else {
+			res.Predictions[i] = eta
+		}
+		if !withIntervals {
+			continue
+		}
+
+		// variance of the fitted value, xᵀ Cov x.
+		var v float64
+		for j := range x {
+			for k := range x {
+				v += x[j] * mdl.Covariance[j][k] * x[k]
+			}
+		}
+		if mdl.Kind == KindLinear {
+			half := crit * math.Sqrt(mdl.ResidualStdError*mdl.ResidualStdError+v)
+			res.Lower[i], res.Upper[i] = eta-half, eta+half
+		} else {
+			half := crit * math.Sqrt(v)
+			res.Lower[i], res.Upper[i] = sigmoid(eta-half), sigmoid(eta+half)
+		}
+	}
+
+	return
+}
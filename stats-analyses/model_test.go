@@ -0,0 +1,87 @@
+package statsanal
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/gonum/stat/distuv"
+)
+
+func TestLinearModelPredict(t *testing.T) {
+	x := []float64{1, 2, 3, 4, 5, 6}
+	y := []float64{3.1, 4.9, 7.2, 8.8, 11.1, 13.0}
+	m := mat.NewDense(6, 2, nil)
+	m.SetCol(0, x)
+	m.SetCol(1, y)
+
+	res, err := LinearRegression(m, RegressionOptions{})
+	require.NoError(t, err)
+
+	// the model survives a json round trip.
+	encoded, err := json.Marshal(res.Model([]string{"x"}, "y"))
+	require.NoError(t, err)
+	var model Model
+	require.NoError(t, json.Unmarshal(encoded, &model))
+	require.Equal(t, KindLinear, model.Kind)
+	require.True(t, model.Intercept)
+
+	pred, err := model.Predict(mat.NewDense(2, 1, []float64{3.5, 10}), 0.9)
+	require.NoError(t, err)
+	require.Equal(t, "prediction", pred.Interval)
+	require.Equal(t, 0.9, pred.Confidence)
+
+	// s * sqrt(1 + 1/n + (x0 - x̄)² / Sxx) for a simple regression.
+	tCrit := distuv.StudentsT{Mu: 0, Sigma: 1, Nu: 4}.Quantile(0.95)
+	for i, x0 := range []float64{3.5, 10} {
+		fit := res.Coefficients[0] + res.Coefficients[1]*x0
+		half := tCrit * res.ResidualStdError *
+			math.Sqrt(1+1.0/6+(x0-3.5)*(x0-3.5)/17.5)
+		require.InDelta(t, fit, pred.Predictions[i], 1e-9)
+		require.InDelta(t, fit-half, pred.Lower[i], 1e-9)
+		require.InDelta(t, fit+half, pred.Upper[i], 1e-9)
+	}
+
+	_, err = model.Predict(mat.NewDense(1, 2, []float64{1, 2}), 0)
+	require.Error(t, err)
+
+	_, err = model.Predict(mat.NewDense(1, 1, []float64{math.NaN()}), 0)
+	require.Error(t, err)
+
+	_, err = model.Predict(mat.NewDense(1, 1, []float64{1}), 1)
+	require.Error(t, err)
+}
+
+func TestLogisticModelPredict(t *testing.T) {
+	m := mat.NewDense(8, 2, []float64{
+		1, 0, 2, 0, 3, 1, 4, 0, 5, 1, 6, 0, 7, 1, 8, 1,
+	})
+	res, err := LogisticRegression(m, LogisticOptions{})
+	require.NoError(t, err)
+
+	pred, err := res.Model([]string{"x"}, "y").Predict(mat.NewDense(1, 1, []float64{4.5}), 0)
+	require.NoError(t, err)
+	require.Equal(t, "confidence", pred.Interval)
+	p := sigmoid(res.Coefficients[0] + 4.5*res.Coefficients[1])
+	require.InDelta(t, p, pred.Predictions[0], 1e-12)
+	require.Less(t, pred.Lower[0], p)
+	require.Greater(t, pred.Upper[0], p)
+	require.Greater(t, pred.Lower[0], 0.0)
+	require.Less(t, pred.Upper[0], 1.0)
+}
+
+func TestRegularizedModelPredict(t *testing.T) {
+	m := randomRegression(20, 2)
+	res, err := RegularizedRegression(m, RegularizedOptions{Penalty: Lasso, Lambda: 0.01})
+	require.NoError(t, err)
+
+	pred, err := res.Model([]string{"a", "b"}, "y").Predict(mat.NewDense(1, 2, []float64{1, 1}), 0)
+	require.NoError(t, err)
+	require.Empty(t, pred.Interval)
+	require.Nil(t, pred.Lower)
+	require.InDelta(t,
+		res.Coefficients[0]+res.Coefficients[1]+res.Coefficients[2],
+		pred.Predictions[0], 1e-12)
+}
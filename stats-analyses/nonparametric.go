@@ -0,0 +1,645 @@
+package statsanal
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/gonum/stat/distuv"
+)
+
+// PValueMethod is how the p-value of a non-parametric test is computed.
+type PValueMethod string
+
+const (
+	// Auto uses the exact distribution of samples without ties of less than
+	// autoExactSize values, and the asymptotic one otherwise.
+	Auto PValueMethod = ""
+	// Exact uses the exact distribution of the statistic, and isn't
+	// available with ties.
+	Exact PValueMethod = "exact"
+	// Asymptotic uses the normal approximation of rank statistics, with a
+	// continuity and tie correction, or the Kolmogorov distribution.
+	Asymptotic PValueMethod = "asymptotic"
+)
+
+const (
+	// autoExactSize is the sample size below which Auto uses the exact
+	// distribution of every test: from there the asymptotic distributions
+	// are close to the exact ones, which grow costly to compute.
+	autoExactSize = 50
+	// maxExactSize is the largest sample size of exact p-values.
+	maxExactSize = 100
+)
+
+// NonParametricOptions configures a non-parametric test.
+type NonParametricOptions struct {
+	// Mu is the location shift, or median difference, under the null
+	// hypothesis of rank tests.
+	Mu float64
+	// Alternative defaults to TwoSided.
+	Alternative Alternative
+	// Method defaults to Auto.
+	Method PValueMethod
+}
+
+// MannWhitneyU tests whether the values of `x` tend to differ from the values
+// of `y` shifted by `opts.Mu`. The statistic is the U of `x`, the number of
+// pairs where its value is the greater, counting ties as half. The effect
+// size is the rank-biserial correlation, positive when `x` tends to be
+// greater. Missing values are ignored.
+//
+// Returns a non-nil error if the options are invalid, a sample is empty, or
+// an exact p-value is requested with ties or a sample larger than 100.
+func MannWhitneyU(x, y []float64, opts NonParametricOptions) (res TestResult, err error) {
+	if opts, err = checkNonParametricOptions(opts); err != nil {
+		return
+	}
+	x, y = observed(x), observed(y)
+	if len(x) == 0 || len(y) == 0 {
+		err = fmt.Errorf("Need observations in both samples, got %d and %d.", len(x), len(y))
+		return
+	}
+
+	pooled := make([]float64, 0, len(x)+len(y))
+	for _, v := range x {
+		pooled = append(pooled, v-opts.Mu)
+	}
+	pooled = append(pooled, y...)
+	ranks := rank(pooled)
+	ties := tieCorrection(pooled)
+
+	nx, ny := float64(len(x)), float64(len(y))
+	n := nx + ny
+	var rankSum float64
+	for _, r := range ranks[:len(x)] {
+		rankSum += r
+	}
+	u := rankSum - nx*(nx+1)/2
+
+	exact, err := useExact(opts.Method, ties != 0, len(x), len(y))
+	if err != nil {
+		return
+	}
+	if exact {
+		res.Method = string(Exact)
+		res.PValue = finite(exactPValue(rankSumCounts(len(x), len(y)), u, opts.Alternative))
+	} else {
+		res.Method = string(Asymptotic)
+		sd := math.Sqrt(nx * ny / 12 * ((n + 1) - ties/(n*(n-1))))
+		res.PValue = finite(normalPValue(u-nx*ny/2, sd, opts.Alternative))
+	}
+
+	res.Test = "mann_whitney"
+	res.Alternative = opts.Alternative
+	res.Statistic = finite(u)
+	res.EffectSize = finite(2*u/(nx*ny) - 1)
+	res.EffectSizeName = "rank_biserial"
+	res.Observations = []int{len(x), len(y)}
+	return
+}
+
+// WilcoxonSignedRank tests whether the median of the differences `x - y` is
+// `opts.Mu`, or the median of `x` when `y` is nil. Zero differences are
+// dropped. The statistic is the sum of the ranks of the positive differences,
+// and the effect size the matched-pairs rank-biserial correlation. Pairs with
+// a missing value are ignored.
+//
+// Returns a non-nil error if the options are invalid, the samples have
+// different lengths, no difference is left, or an exact p-value is requested
+// with ties or more than 100 differences.
+func WilcoxonSignedRank(x, y []float64, opts NonParametricOptions) (res TestResult, err error) {
+	if opts, err = checkNonParametricOptions(opts); err != nil {
+		return
+	}
+	if y == nil {
+		y = make([]float64, len(x))
+	}
+	if len(x) != len(y) {
+		err = fmt.Errorf("Samples have different lengths %d and %d.", len(x), len(y))
+		return
+	}
+	x, y = completePairs(x, y)
+
+	var diff, abs []float64
+	var zeros bool
+	for i := range x {
+		d := x[i] - y[i] - opts.Mu
+		if d == 0 {
+			zeros = true
+			continue
+		}
+		diff = append(diff, d)
+		abs = append(abs, math.Abs(d))
+	}
+	if len(diff) == 0 {
+		err = fmt.Errorf("No non-zero difference.")
+		return
+	}
+
+	ranks := rank(abs)
+	ties := tieCorrection(abs)
+	n := float64(len(diff))
+	var v float64
+	for i, d := range diff {
+		if d > 0 {
+			v += ranks[i]
+		}
+	}
+	total := n * (n + 1) / 2
+
+	exact, err := useExact(opts.Method, ties != 0 || zeros, len(diff))
+	if err != nil {
+		return
+	}
+	if exact {
+		res.Method = string(Exact)
+		res.PValue = finite(exactPValue(signedRankCounts(len(diff)), v, opts.Alternative))
+	} else {
+		res.Method = string(Asymptotic)
+		sd := math.Sqrt(n*(n+1)*(2*n+1)/24 - ties/48)
+		res.PValue = finite(normalPValue(v-total/2, sd, opts.Alternative))
+	}
+
+	res.Test = "wilcoxon"
+	res.Alternative = opts.Alternative
+	res.Statistic = finite(v)
+	res.EffectSize = finite((2*v - total) / total)
+	res.EffectSizeName = "rank_biserial"
+	res.Observations = []int{len(diff)}
+	return
+}
+
+// KruskalWallis tests whether the `groups` come from the same distribution,
+// with the tie corrected H statistic and its asymptotic chi-square
+// distribution. The effect size is epsilon squared. Missing values are
+// ignored.
+//
+// Returns a non-nil error if there are less than 2 groups, an empty group,
+// or every value is tied.
+func KruskalWallis(groups [][]float64) (res TestResult, err error) {
+	if len(groups) < 2 {
+		err = fmt.Errorf("Need at least 2 groups, got %d.", len(groups))
+		return
+	}
+
+	var pooled []float64
+	res.Observations = make([]int, len(groups))
+	for g, group := range groups {
+		group = observed(group)
+		if len(group) == 0 {
+			err = fmt.Errorf("Group %d has no observations.", g)
+			return
+		}
+		res.Observations[g] = len(group)
+		pooled = append(pooled, group...)
+	}
+	n := float64(len(pooled))
+	ties := tieCorrection(pooled)
+	if ties == n*n*n-n {
+		err = fmt.Errorf("Every value is tied.")
+		return
+	}
+
+	ranks := rank(pooled)
+	var h float64
+	var start int
+	for _, size := range res.Observations {
+		var rankSum float64
+		for _, r := range ranks[start : start+size] {
+			rankSum += r
+		}
+		h += rankSum * rankSum / float64(size)
+		start += size
+	}
+	h = (12/(n*(n+1))*h - 3*(n+1)) / (1 - ties/(n*n*n-n))
+	df := float64(len(groups) - 1)
+
+	res.Test = "kruskal_wallis"
+	res.Statistic = finite(h)
+	res.DF = []float64{df}
+	res.PValue = finite(distuv.ChiSquared{K: df}.Survival(h))
+	res.Method = string(Asymptotic)
+	res.EffectSize = finite(h / (n - 1))
+	res.EffectSizeName = "epsilon_squared"
+	return
+}
+
+// OneSampleKS tests whether `x` follows the distribution with cumulative
+// distribution function `cdf` with the Kolmogorov-Smirnov test. The `Less`
+// and `Greater` alternatives state that the empirical distribution function
+// of `x` lies below or above `cdf`. Missing values are ignored.
+//
+// Returns a non-nil error if the options are invalid, `x` is empty, or an
+// exact p-value is requested with ties or more than 100 values.
+func OneSampleKS(x []float64, cdf func(float64) float64, opts NonParametricOptions) (res TestResult, err error) {
+	if opts, err = checkNonParametricOptions(opts); err != nil {
+		return
+	}
+	x = append([]float64(nil), observed(x)...)
+	if len(x) == 0 {
+		err = fmt.Errorf("Need at least 1 observation.")
+		return
+	}
+	sort.Float64s(x)
+
+	n := float64(len(x))
+	var above, below float64
+	for i, v := range x {
+		f := cdf(v)
+		above = math.Max(above, float64(i+1)/n-f)
+		below = math.Max(below, f-float64(i)/n)
+	}
+	d := ksStatistic(above, below, opts.Alternative)
+
+	exact, err := useExact(opts.Method, len(levels(x)) < len(x), len(x))
+	if err != nil {
+		return
+	}
+	var p float64
+	switch {
+	case exact && opts.Alternative == TwoSided:
+		p = 1 - kolmogorovCDF(len(x), d)
+	case exact:
+		p = smirnovSurvival(len(x), d)
+	case opts.Alternative == TwoSided:
+		p = kolmogorovSurvival(math.Sqrt(n) * d)
+	default:
+		p = math.Exp(-2 * n * d * d)
+	}
+	res.Method = string(Asymptotic)
+	if exact {
+		res.Method = string(Exact)
+	}
+
+	res.Test = "ks_one_sample"
+	res.Alternative = opts.Alternative
+	res.Statistic = finite(d)
+	res.PValue = finite(math.Min(1, math.Max(0, p)))
+	res.Observations = []int{len(x)}
+	return
+}
+
+// TwoSampleKS tests whether `x` and `y` follow the same distribution with the
+// Kolmogorov-Smirnov test. The `Less` and `Greater` alternatives state that
+// the empirical distribution function of `x` lies below or above the one of
+// `y`. Missing values are ignored.
+//
+// Returns a non-nil error if the options are invalid, a sample is empty, or
+// an exact p-value is requested with ties or a sample larger than 100.
+func TwoSampleKS(x, y []float64, opts NonParametricOptions) (res TestResult, err error) {
+	if opts, err = checkNonParametricOptions(opts); err != nil {
+		return
+	}
+	x = append([]float64(nil), observed(x)...)
+	y = append([]float64(nil), observed(y)...)
+	if len(x) == 0 || len(y) == 0 {
+		err = fmt.Errorf("Need observations in both samples, got %d and %d.", len(x), len(y))
+		return
+	}
+	sort.Float64s(x)
+	sort.Float64s(y)
+
+	nx, ny := float64(len(x)), float64(len(y))
+	var above, below float64
+	var i, j int
+	for i < len(x) || j < len(y) {
+		// step both empirical functions past the next value.
+		var v float64
+		switch {
+		case j == len(y) || (i < len(x) && x[i] <= y[j]):
+			v = x[i]
+		default:
+			v = y[j]
+		}
+		for i < len(x) && x[i] == v {
+			i++
+		}
+		for j < len(y) && y[j] == v {
+			j++
+		}
+		diff := float64(i)/nx - float64(j)/ny
+		above = math.Max(above, diff)
+		below = math.Max(below, -diff)
+	}
+	d := ksStatistic(above, below, opts.Alternative)
+
+	pooled := append(append([]float64(nil), x...), y...)
+	exact, err := useExact(opts.Method, len(levels(pooled)) < len(pooled), len(x), len(y))
+	if err != nil {
+		return
+	}
+	var p float64
+	switch {
+	case exact:
+		p = 1 - smirnovCDF(len(x), len(y), d, opts.Alternative)
+	case opts.Alternative == TwoSided:
+		p = kolmogorovSurvival(math.Sqrt(nx*ny/(nx+ny)) * d)
+	default:
+		p = math.Exp(-2 * nx * ny / (nx + ny) * d * d)
+	}
+	res.Method = string(Asymptotic)
+	if exact {
+		res.Method = string(Exact)
+	}
+
+	res.Test = "ks_two_sample"
+	res.Alternative = opts.Alternative
+	res.Statistic = finite(d)
+	res.PValue = finite(math.Min(1, math.Max(0, p)))
+	res.Observations = []int{len(x), len(y)}
+	return
+}
+
+// checkNonParametricOptions validates `opts` and fills in the defaults.
+func checkNonParametricOptions(opts NonParametricOptions) (NonParametricOptions, error) {
+	if opts.Alternative == "" {
+		opts.Alternative = TwoSided
+	}
+	switch opts.Alternative {
+	case TwoSided, Less, Greater:
+	default:
+		return opts, fmt.Errorf("Unknown alternative %q.", opts.Alternative)
+	}
+	switch opts.Method {
+	case Auto, Exact, Asymptotic:
+	default:
+		return opts, fmt.Errorf("Unknown p-value method %q.", opts.Method)
+	}
+	return opts, nil
+}
+
+// useExact reports whether the exact distribution of a statistic on samples
+// of the given `sizes` is used with `method`.
+//
+// Returns a non-nil error if the exact distribution is requested with
+// `ties`, or a sample larger than maxExactSize.
+func useExact(method PValueMethod, ties bool, sizes ...int) (bool, error) {
+	largest := 0
+	for _, size := range sizes {
+		largest = max(largest, size)
+	}
+
+	switch method {
+	case Exact:
+		if ties {
+			return false, fmt.Errorf("Exact p-values aren't available with ties.")
+		}
+		if largest > maxExactSize {
+			return false, fmt.Errorf(
+				"Exact p-values are limited to samples of %d values, got %d.",
+				maxExactSize, largest)
+		}
+		return true, nil
+	case Asymptotic:
+		return false, nil
+	default:
+		return !ties && largest < autoExactSize, nil
+	}
+}
+
+// tieCorrection returns the sum of t³ - t over the groups of `t` tied values
+// of `x`.
+func tieCorrection(x []float64) float64 {
+	sorted := append([]float64(nil), x...)
+	sort.Float64s(sorted)
+
+	var sum float64
+	for i := 0; i < len(sorted); {
+		j := i
+		for j < len(sorted) && sorted[j] == sorted[i] {
+			j++
+		}
+		t := float64(j - i)
+		sum += t*t*t - t
+		i = j
+	}
+	return sum
+}
+
+// normalPValue returns the p-value of the centered statistic `dev` with
+// standard deviation `sd` under the normal approximation, with a continuity
+// correction.
+func normalPValue(dev, sd float64, alt Alternative) float64 {
+	switch alt {
+	case Less:
+		return distuv.UnitNormal.CDF((dev + 0.5) / sd)
+	case Greater:
+		return distuv.UnitNormal.Survival((dev - 0.5) / sd)
+	default:
+		z := (math.Abs(dev) - 0.5) / sd
+		return math.Min(1, 2*distuv.UnitNormal.Survival(z))
+	}
+}
+
+// exactPValue returns the p-value of the statistic `stat`, from `counts`
+// holding the number of arrangements giving each integer value of the
+// statistic, starting at 0.
+func exactPValue(counts []float64, stat float64, alt Alternative) float64 {
+	var total, below, above float64
+	for v, c := range counts {
+		total += c
+		if float64(v) <= stat {
+			below += c
+		}
+		if float64(v) >= stat {
+			above += c
+		}
+	}
+
+	switch alt {
+	case Less:
+		return below / total
+	case Greater:
+		return above / total
+	default:
+		return math.Min(1, 2*math.Min(below, above)/total)
+	}
+}
+
+// rankSumCounts returns the number of ways each value of the Mann-Whitney U
+// statistic arises from samples of `nx` and `ny` values without ties.
+func rankSumCounts(nx, ny int) []float64 {
+	n := nx + ny
+	// counts[k][s] is the number of k-subsets of the ranks seen so far
+	// summing to s.
+	maxSum := nx * (2*n - nx + 1) / 2
+	counts := make([][]float64, nx+1)
+	for k := range counts {
+		counts[k] = make([]float64, maxSum+1)
+	}
+	counts[0][0] = 1
+	for r := 1; r <= n; r++ {
+		for k := min(r, nx); k >= 1; k-- {
+			for s := maxSum; s >= r; s-- {
+				counts[k][s] += counts[k-1][s-r]
+			}
+		}
+	}
+
+	// U is the rank sum shifted by its minimum.
+	return counts[nx][nx*(nx+1)/2:]
+}
+
+// signedRankCounts returns the number of ways each value of the Wilcoxon
+// signed-rank statistic arises from `n` differences without ties.
+func signedRankCounts(n int) []float64 {
+	counts := make([]float64, n*(n+1)/2+1)
+	counts[0] = 1
+	for r := 1; r <= n; r++ {
+		for s := len(counts) - 1; s >= r; s-- {
+			counts[s] += counts[s-r]
+		}
+	}
+	return counts
+}
+
+// ksStatistic returns the Kolmogorov-Smirnov statistic of the `alt`
+// alternative from the largest deviations `above` and `below` of the
+// empirical distribution function.
+func ksStatistic(above, below float64, alt Alternative) float64 {
+	switch alt {
+	case Less:
+		return below
+	case Greater:
+		return above
+	default:
+		return math.Max(above, below)
+	}
+}
+
+// kolmogorovSurvival returns the probability that the limiting Kolmogorov
+// distribution exceeds `x`.
+func kolmogorovSurvival(x float64) float64 {
+	if x <= 0 {
+		return 1
+	}
+	if x < 1 {
+		// the series of the distribution function converges faster for
+		// small values.
+		var cdf float64
+		for k := 1; k <= 100; k++ {
+			cdf += math.Exp(-float64((2*k-1)*(2*k-1)) * math.Pi * math.Pi / (8 * x * x))
+		}
+		return 1 - math.Sqrt(2*math.Pi)/x*cdf
+	}
+
+	var p float64
+	for k := 1; k <= 100; k++ {
+		sign := float64(1 - 2*((k-1)%2))
+		p += sign * math.Exp(-2*float64(k*k)*x*x)
+	}
+	return 2 * p
+}
+
+// kolmogorovCDF returns the probability that the two-sided one-sample
+// statistic of `n` values is less than `d`, following Marsaglia, Tsang and
+// Wang (2003).
+func kolmogorovCDF(n int, d float64) float64 {
+	nd := float64(n) * d
+	k := int(nd) + 1
+	m := 2*k - 1
+	h := float64(k) - nd
+
+	H := mat.NewDense(m, m, nil)
+	for i := 0; i < m; i++ {
+		for j := 0; j < m; j++ {
+			if i-j+1 >= 0 {
+				H.Set(i, j, 1)
+			}
+		}
+	}
+	for i := 0; i < m; i++ {
+		H.Set(i, 0, H.At(i, 0)-math.Pow(h, float64(i+1)))
+		H.Set(m-1, i, H.At(m-1, i)-math.Pow(h, float64(m-i)))
+	}
+	if 2*h-1 > 0 {
+		H.Set(m-1, 0, H.At(m-1, 0)+math.Pow(2*h-1, float64(m)))
+	}
+	for i := 0; i < m; i++ {
+		for j := 0; j < m; j++ {
+			if i-j+1 > 0 {
+				H.Set(i, j, H.At(i, j)/math.Gamma(float64(i-j+2)))
+			}
+		}
+	}
+
+	// scale each factor by e to keep the power in range, n!/nⁿ ~ e⁻ⁿ.
+	H.Scale(math.E, H)
+	var power mat.Dense
+	power.Pow(H, n)
+	lgammaN, _ := math.Lgamma(float64(n + 1))
+	return power.At(k-1, k-1) *
+		math.Exp(lgammaN-float64(n)*math.Log(float64(n))-float64(n))
+}
+
+// smirnovSurvival returns the probability that the one-sided one-sample
+// statistic of `n` values is at least `d`, following Birnbaum and Tingey
+// (1951).
+func smirnovSurvival(n int, d float64) float64 {
+	if d <= 0 {
+		return 1
+	}
+	if d >= 1 {
+		return 0
+	}
+
+	fn := float64(n)
+	lgammaN, _ := math.Lgamma(fn + 1)
+	var p float64
+	for j := 0; j <= int(fn*(1-d)); j++ {
+		fj := float64(j)
+		lgammaJ, _ := math.Lgamma(fj + 1)
+		lgammaNJ, _ := math.Lgamma(fn - fj + 1)
+		logTerm := lgammaN - lgammaJ - lgammaNJ +
+			(fn-fj)*math.Log(1-d-fj/fn) + (fj-1)*math.Log(d+fj/fn)
+		p += math.Exp(logTerm)
+	}
+	return d * p
+}
+
+// smirnovCDF returns the probability that the two-sample statistic of
+// samples of `nx` and `ny` values without ties is less than `d`, counting the
+// paths of the empirical distribution functions which stay within it.
+func smirnovCDF(nx, ny int, d float64, alt Alternative) float64 {
+	mx, my := float64(nx), float64(ny)
+	// the attainable values of the statistic are multiples of 1/(nx*ny).
+	q := (0.5 + math.Floor(d*mx*my-1e-7)) / (mx * my)
+	outside := func(i, j int) bool {
+		diff := float64(i)/mx - float64(j)/my
+		switch alt {
+		case Less:
+			return -diff > q
+		case Greater:
+			return diff > q
+		default:
+			return math.Abs(diff) > q
+		}
+	}
+
+	// u[j] is the probability of the paths reaching (i, j) without leaving
+	// the band, normalized as they go.
+	u := make([]float64, ny+1)
+	for j := range u {
+		if !outside(0, j) && (j == 0 || u[j-1] != 0) {
+			u[j] = 1
+		}
+	}
+	for i := 1; i <= nx; i++ {
+		w := float64(i) / float64(i+ny)
+		if outside(i, 0) {
+			u[0] = 0
+		} else {
+			u[0] *= w
+		}
+		for j := 1; j <= ny; j++ {
+			if outside(i, j) {
+				u[j] = 0
+			} else {
+				u[j] = w*u[j] + u[j-1]
+			}
+		}
+	}
+	return u[ny]
+}
@@ -0,0 +1,216 @@
+package statsanal
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gonum.org/v1/gonum/stat/distuv"
+)
+
+func TestMannWhitneyU(t *testing.T) {
+	// wilcox.test(x, y, alternative = "g"), from R's documentation.
+	x := []float64{0.80, 0.83, 1.89, 1.04, 1.45, 1.38, 1.91, 1.64, 0.73, 1.46}
+	y := []float64{1.15, 0.88, 0.90, 0.74, 1.21}
+
+	res, err := MannWhitneyU(x, y, NonParametricOptions{Alternative: Greater})
+	require.NoError(t, err)
+	require.Equal(t, "mann_whitney", res.Test)
+	require.Equal(t, "exact", res.Method)
+	require.Equal(t, 35.0, *res.Statistic)
+	require.InDelta(t, 0.1272, *res.PValue, 1e-4)
+	require.Equal(t, "rank_biserial", res.EffectSizeName)
+	require.InDelta(t, 2*35.0/50-1, *res.EffectSize, 1e-12)
+	require.Equal(t, []int{10, 5}, res.Observations)
+
+	// U has mean 25 and variance 50 * 16 / 12 without ties.
+	res, err = MannWhitneyU(x, y, NonParametricOptions{
+		Alternative: Greater,
+		Method:      Asymptotic,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "asymptotic", res.Method)
+	z := (35 - 25 - 0.5) / math.Sqrt(50*16/12.0)
+	require.InDelta(t, distuv.UnitNormal.Survival(z), *res.PValue, 1e-12)
+
+	// ties rule out the exact distribution.
+	tied := []float64{1, 1, 2, 3}
+	res, err = MannWhitneyU(tied, y, NonParametricOptions{})
+	require.NoError(t, err)
+	require.Equal(t, "asymptotic", res.Method)
+	_, err = MannWhitneyU(tied, y, NonParametricOptions{Method: Exact})
+	require.Error(t, err)
+
+	_, err = MannWhitneyU(x, nil, NonParametricOptions{})
+	require.Error(t, err)
+	_, err = MannWhitneyU(x, y, NonParametricOptions{Method: "bootstrap"})
+	require.Error(t, err)
+}
+
+func TestRankSumCounts(t *testing.T) {
+	// U of 2 values among 4 takes 0, 1, 2, 2, 3, 4 over the 6 arrangements.
+	require.Equal(t, []float64{1, 1, 2, 1, 1}, rankSumCounts(2, 2))
+
+	// the counts add up to the number of arrangements.
+	counts := rankSumCounts(7, 9)
+	var total float64
+	for _, c := range counts {
+		total += c
+	}
+	require.Equal(t, 11440.0, total)
+	require.Len(t, counts, 7*9+1)
+}
+
+func TestWilcoxonSignedRank(t *testing.T) {
+	// wilcox.test(x, y, paired = TRUE, alternative = "greater"), from R's
+	// documentation.
+	x := []float64{1.83, 0.50, 1.62, 2.48, 1.68, 1.88, 1.55, 3.06, 1.30}
+	y := []float64{0.878, 0.647, 0.598, 2.05, 1.06, 1.29, 1.06, 3.14, 1.29}
+
+	res, err := WilcoxonSignedRank(x, y, NonParametricOptions{Alternative: Greater})
+	require.NoError(t, err)
+	require.Equal(t, "wilcoxon", res.Test)
+	require.Equal(t, "exact", res.Method)
+	require.Equal(t, 40.0, *res.Statistic)
+	require.InDelta(t, 0.01953, *res.PValue, 1e-5)
+	require.InDelta(t, (80-45)/45.0, *res.EffectSize, 1e-12)
+
+	// the one-sample test on the differences is the same test.
+	diff := make([]float64, len(x))
+	for i := range x {
+		diff[i] = x[i] - y[i]
+	}
+	again, err := WilcoxonSignedRank(diff, nil, NonParametricOptions{Alternative: Greater})
+	require.NoError(t, err)
+	require.Equal(t, res, again)
+
+	// zero differences are dropped, and rule out the exact distribution.
+	res, err = WilcoxonSignedRank([]float64{0, 1, 2, -3, 4}, nil, NonParametricOptions{})
+	require.NoError(t, err)
+	require.Equal(t, []int{4}, res.Observations)
+	require.Equal(t, "asymptotic", res.Method)
+
+	_, err = WilcoxonSignedRank([]float64{0, 0}, nil, NonParametricOptions{})
+	require.Error(t, err)
+	_, err = WilcoxonSignedRank(x, y[1:], NonParametricOptions{})
+	require.Error(t, err)
+}
+
+func TestSignedRankCounts(t *testing.T) {
+	// subsets of {1, 2, 3} by sum.
+	require.Equal(t, []float64{1, 1, 1, 2, 1, 1, 1}, signedRankCounts(3))
+}
+
+func TestKruskalWallis(t *testing.T) {
+	// kruskal.test(list(x, y, z)), from R's documentation.
+	x := []float64{2.9, 3.0, 2.5, 2.6, 3.2}
+	y := []float64{3.8, 2.7, 4.0, 2.4}
+	z := []float64{2.8, 3.4, 3.7, 2.2, 2.0}
+
+	res, err := KruskalWallis([][]float64{x, y, z})
+	require.NoError(t, err)
+	require.Equal(t, "kruskal_wallis", res.Test)
+	require.InDelta(t, 0.77143, *res.Statistic, 1e-5)
+	require.Equal(t, []float64{2}, res.DF)
+	require.InDelta(t, 0.68, *res.PValue, 1e-2)
+	require.Equal(t, []int{5, 4, 5}, res.Observations)
+	require.InDelta(t, 0.77143/13, *res.EffectSize, 1e-5)
+
+	_, err = KruskalWallis([][]float64{x})
+	require.Error(t, err)
+	_, err = KruskalWallis([][]float64{{1, 1}, {1}})
+	require.Error(t, err)
+}
+
+func TestKolmogorovDistributions(t *testing.T) {
+	// the example of Marsaglia, Tsang and Wang.
+	require.InDelta(t, 0.6284796154565043, kolmogorovCDF(10, 0.274), 1e-12)
+	// D of a single value is max(F, 1 - F), uniform on [0.5, 1].
+	require.InDelta(t, 0.5, kolmogorovCDF(1, 0.75), 1e-12)
+	// the exact distribution approaches the limiting one, with Stephens'
+	// correction of the sample size.
+	sqrtN := math.Sqrt(90) + 0.12 + 0.11/math.Sqrt(90)
+	require.InDelta(t, kolmogorovSurvival(sqrtN*0.1), 1-kolmogorovCDF(90, 0.1), 0.005)
+	// both series of the limiting distribution agree.
+	require.InDelta(t, 0.27, kolmogorovSurvival(1), 1e-2)
+	require.InDelta(t, kolmogorovSurvival(0.9999999), kolmogorovSurvival(1.0000001), 1e-6)
+
+	// D⁺ of a single value is 1 - U.
+	require.InDelta(t, 0.7, smirnovSurvival(1, 0.3), 1e-12)
+
+	// D of 2 values against 2 is 1 for 2 of the 6 arrangements.
+	require.InDelta(t, 4.0/6, smirnovCDF(2, 2, 1, TwoSided), 1e-12)
+	require.InDelta(t, 5.0/6, smirnovCDF(2, 2, 1, Greater), 1e-12)
+}
+
+func TestOneSampleKS(t *testing.T) {
+	x := []float64{-1.2, -0.4, 0.1, 0.3, 0.9, 1.6, -0.7, 0.05, 2.1, -1.9}
+
+	res, err := OneSampleKS(x, distuv.UnitNormal.CDF, NonParametricOptions{})
+	require.NoError(t, err)
+	require.Equal(t, "ks_one_sample", res.Test)
+	require.Equal(t, "exact", res.Method)
+	// the largest gap is below the second largest value.
+	require.InDelta(t, distuv.UnitNormal.CDF(1.6)-0.8, *res.Statistic, 1e-12)
+	require.Greater(t, *res.PValue, 0.5)
+
+	// a shifted sample is rejected.
+	shifted := make([]float64, len(x))
+	for i, v := range x {
+		shifted[i] = v + 3
+	}
+	res, err = OneSampleKS(shifted, distuv.UnitNormal.CDF, NonParametricOptions{})
+	require.NoError(t, err)
+	require.Less(t, *res.PValue, 0.001)
+
+	// its empirical distribution lies below the normal one.
+	less, err := OneSampleKS(shifted, distuv.UnitNormal.CDF,
+		NonParametricOptions{Alternative: Less})
+	require.NoError(t, err)
+	require.Less(t, *less.PValue, 0.001)
+	greater, err := OneSampleKS(shifted, distuv.UnitNormal.CDF,
+		NonParametricOptions{Alternative: Greater, Method: Asymptotic})
+	require.NoError(t, err)
+	require.Equal(t, "asymptotic", greater.Method)
+	require.InDelta(t, 1, *greater.PValue, 1e-9)
+
+	_, err = OneSampleKS([]float64{1, 1, 2}, distuv.UnitNormal.CDF,
+		NonParametricOptions{Method: Exact})
+	require.Error(t, err)
+	_, err = OneSampleKS(nil, distuv.UnitNormal.CDF, NonParametricOptions{})
+	require.Error(t, err)
+}
+
+func TestTwoSampleKS(t *testing.T) {
+	x := []float64{0.61, 0.29, 0.06, 0.59, -1.73, -0.74, 0.51, -0.56, 0.39, 1.64}
+	y := []float64{2.2, 3.1, 1.9, 2.7, 1.2, 2.4, 3.3, 0.9}
+
+	res, err := TwoSampleKS(x, y, NonParametricOptions{})
+	require.NoError(t, err)
+	require.Equal(t, "ks_two_sample", res.Test)
+	require.Equal(t, "exact", res.Method)
+	// every value of x but 1.64 is below every value of y.
+	require.InDelta(t, 0.9, *res.Statistic, 1e-12)
+	require.Less(t, *res.PValue, 0.001)
+
+	// x's empirical distribution lies above y's.
+	greater, err := TwoSampleKS(x, y, NonParametricOptions{Alternative: Greater})
+	require.NoError(t, err)
+	require.InDelta(t, 0.9, *greater.Statistic, 1e-12)
+	less, err := TwoSampleKS(x, y, NonParametricOptions{Alternative: Less})
+	require.NoError(t, err)
+	require.Equal(t, 0.0, *less.Statistic)
+	require.Equal(t, 1.0, *less.PValue)
+
+	// the exact and asymptotic p-values are close.
+	asymptotic, err := TwoSampleKS(x, y, NonParametricOptions{Method: Asymptotic})
+	require.NoError(t, err)
+	require.InDelta(t, *res.PValue, *asymptotic.PValue, 0.01)
+
+	// a sample against itself.
+	res, err = TwoSampleKS(x, x, NonParametricOptions{})
+	require.NoError(t, err)
+	require.Equal(t, 0.0, *res.Statistic)
+	require.Equal(t, "asymptotic", res.Method)
+	require.Equal(t, 1.0, *res.PValue)
+}
@@ -0,0 +1,400 @@
+package statsanal
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"slices"
+
+	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/gonum/stat"
+	"gonum.org/v1/gonum/stat/distuv"
+)
+
+// OutlierMethod is the way an outlier detection scores the rows.
+type OutlierMethod string
+
+const (
+	// ZScore scores each value by its distance to the mean of its column in
+	// standard deviations.
+	ZScore OutlierMethod = "zscore"
+	// ModifiedZScore scores each value by its distance to the median of its
+	// column in median absolute deviations, scaled to match the standard
+	// deviation of normal values, following Iglewicz & Hoaglin (1993).
+	ModifiedZScore OutlierMethod = "modified_zscore"
+	// IQRFences scores each value by its distance beyond the quartiles of
+	// its column in interquartile ranges, zero between the quartiles.
+	IQRFences OutlierMethod = "iqr"
+	// Mahalanobis scores each row by its squared Mahalanobis distance to the
+	// mean of the rows, accounting for the correlation of the columns.
+	Mahalanobis OutlierMethod = "mahalanobis"
+	// IsolationForest scores each row by how quickly random splits of the
+	// columns isolate it, in (0, 1), following Liu, Ting & Zhou (2008),
+	// "Isolation forest".
+	IsolationForest OutlierMethod = "isolation_forest"
+)
+
+const (
+	// DefaultTrees is the number of trees of an isolation forest when none
+	// is given.
+	DefaultTrees = 100
+	// DefaultSampleSize is the largest number of rows each tree of an
+	// isolation forest is grown on when none is given.
+	DefaultSampleSize = 256
+	// DefaultOutlierConfidence is the chi-squared quantile of the default
+	// Mahalanobis threshold.
+	DefaultOutlierConfidence = 0.975
+)
+
+// defaultThresholds are the scores above which values are flagged, for the
+// methods with a fixed default.
+var defaultThresholds = map[OutlierMethod]float64{
+	ZScore:          3,
+	ModifiedZScore:  3.5,
+	IQRFences:       1.5,
+	IsolationForest: 0.6,
+}
+
+// OutlierOptions configures an outlier detection.
+type OutlierOptions struct {
+	// Method defaults to ZScore.
+	Method OutlierMethod
+	// Threshold is the score above which a row is flagged. Defaults to 3
+	// for ZScore, 3.5 for ModifiedZScore, 1.5 for IQRFences, 0.6 for
+	// IsolationForest, and for Mahalanobis to the DefaultOutlierConfidence
+	// quantile of the chi-squared distribution with a degree of freedom per
+	// column.
+	Threshold float64
+	// Trees and SampleSize are the number of trees of an isolation forest
+	// and the number of rows, drawn without replacement, each one is grown
+	// on. They default to DefaultTrees and to the smallest of
+	// DefaultSampleSize and the number of rows.
+	Trees      int
+	SampleSize int
+	// Seed of the random samples and splits of an isolation forest.
+	Seed int64
+	// Names of the columns of the matrix. Defaults to ColumnNames.
+	Names []string
+}
+
+// OutlierResult holds the scores of the rows of an outlier detection.
+type OutlierResult struct {
+	Method    OutlierMethod `json:"method"`
+	Names     []string      `json:"names"`
+	Threshold float64       `json:"threshold"`
+	// Scores holds the score of each row, for the methods scoring each
+	// value the largest absolute score of its values. ColumnScores holds
+	// the signed score of each value of these methods, one slice per column
+	// ordered like `Names`.
+	Scores       []float64   `json:"scores"`
+	ColumnScores [][]float64 `json:"column_scores,omitempty"`
+	// Flags tells whether each row scores above the threshold, and Outliers
+	// holds the flagged rows.
+	Flags    []bool `json:"flags"`
+	Outliers []int  `json:"outliers"`
+}
+
+// Outliers scores the rows of matrix `m` by how much they stand out from
+// the others with the method `opts.Method`, and flags those scoring above
+// the threshold. The z-scores, modified z-scores and IQR fences score each
+// column on its own, a row being flagged if any of its values is, while the
+// Mahalanobis distance and the isolation forest score the rows as a whole.
+//
+// Returns an error if the options or names are invalid, the matrix has
+// missing values or less than 3 rows, a column has no spread, the covariance
+// of the columns is singular for the Mahalanobis distance, or `ctx` is done
+// before the end.
+func Outliers(ctx context.Context, m mat.Matrix, opts OutlierOptions) (res OutlierResult, err error) {
+	if opts.Method == "" {
+		opts.Method = ZScore
+	}
+	if opts.Threshold < 0 || math.IsNaN(opts.Threshold) {
+		err = fmt.Errorf("Threshold should not be negative, got %v.", opts.Threshold)
+		return
+	}
+	r, c := m.Dims()
+	if opts.Names == nil {
+		opts.Names = ColumnNames(c)
+	}
+	if len(opts.Names) != c {
+		err = fmt.Errorf("Got %d names for %d columns.", len(opts.Names), c)
+		return
+	}
+	if r < 3 {
+		err = fmt.Errorf("Need at least 3 rows, got %d.", r)
+		return
+	}
+	for i := 0; i < r; i++ {
+		for j := 0; j < c; j++ {
+			if v := m.At(i, j); math.IsNaN(v) || math.IsInf(v, 0) {
+				err = fmt.Errorf("Column %q has missing or infinite values.", opts.Names[j])
+				return
+			}
+		}
+	}
+	if opts.Threshold == 0 {
+		opts.Threshold = defaultThresholds[opts.Method]
+		if opts.Method == Mahalanobis {
+			opts.Threshold = distuv.ChiSquared{K: float64(c)}.Quantile(DefaultOutlierConfidence)
+		}
+	}
+
+	res.Method = opts.Method
+	res.Names = append([]string{}, opts.Names...)
+	res.Threshold = opts.Threshold
+	switch opts.Method {
+	case ZScore, ModifiedZScore, IQRFences:
+		res.ColumnScores = make([][]float64, c)
+		res.Scores = make([]float64, r)
+		for j := 0; j < c; j++ {
+			if res.ColumnScores[j], err = columnScores(
+				mat.Col(nil, j, m), opts.Method, opts.Names[j]); err != nil {
+				return
+			}
+			for i, s := range res.ColumnScores[j] {
+				res.Scores[i] = max(res.Scores[i], math.Abs(s))
+			}
+		}
+	case Mahalanobis:
+		if res.Scores, err = mahalanobis(m); err != nil {
+			return
+		}
+	case IsolationForest:
+		if opts.Trees == 0 {
+			opts.Trees = DefaultTrees
+		}
+		if opts.SampleSize == 0 {
+			opts.SampleSize = min(DefaultSampleSize, r)
+		}
+		if opts.Trees < 1 {
+			err = fmt.Errorf("Number of trees should be positive, got %d.", opts.Trees)
+			return
+		}
+		if opts.SampleSize < 2 || opts.SampleSize > r {
+			err = fmt.Errorf(
+				"Sample size should be in [2, %d], got %d.", r, opts.SampleSize)
+			return
+		}
+		if res.Scores, err = isolationForest(
+			ctx, m, opts.Trees, opts.SampleSize, opts.Seed); err != nil {
+			return
+		}
+	default:
+		err = fmt.Errorf("Unknown outlier method %q.", opts.Method)
+		return
+	}
+
+	res.Flags = make([]bool, r)
+	res.Outliers = []int{}
+	for i, s := range res.Scores {
+		if s > opts.Threshold {
+			res.Flags[i] = true
+			res.Outliers = append(res.Outliers, i)
+		}
+	}
+	return
+}
+
+// columnScores returns the signed score of each value of the column `x`,
+// named `name`, with the univariate method `method`.
+//
+// Returns an error if the column has no spread.
+func columnScores(x []float64, method OutlierMethod, name string) ([]float64, error) {
+	sorted := slices.Clone(x)
+	slices.Sort(sorted)
+	scores := make([]float64, len(x))
+
+	switch method {
+	case ZScore:
+		mu, sigma := stat.MeanStdDev(x, nil)
+		if !(sigma > 0) {
+			return nil, fmt.Errorf("Column %q is constant.", name)
+		}
+		for i, v := range x {
+			scores[i] = (v - mu) / sigma
+		}
+	case ModifiedZScore:
+		center := median(sorted)
+		devs := make([]float64, len(x))
+		for i, v := range sorted {
+			devs[i] = math.Abs(v - center)
+		}
+		slices.Sort(devs)
+		// the deviation of normal values is 1.4826 MAD, or 1.2533 times
+		// their mean absolute deviation, used when most values are equal.
+		scale := median(devs) / 0.6744897501960817
+		if !(scale > 0) {
+			scale = stat.Mean(devs, nil) * math.Sqrt(math.Pi/2)
+		}
+		if !(scale > 0) {
+			return nil, fmt.Errorf("Column %q is constant.", name)
+		}
+		for i, v := range x {
+			scores[i] = (v - center) / scale
+		}
+	case IQRFences:
+		q1, q3 := quantile(sorted, 0.25), quantile(sorted, 0.75)
+		iqr := q3 - q1
+		if !(iqr > 0) {
+			return nil, fmt.Errorf("Column %q has a zero interquartile range.", name)
+		}
+		for i, v := range x {
+			switch {
+			case v < q1:
+				scores[i] = (v - q1) / iqr
+			case v > q3:
+				scores[i] = (v - q3) / iqr
+			}
+		}
+	}
+	return scores, nil
+}
+
+// mahalanobis returns the squared Mahalanobis distance of each row of matrix
+// `m` to the mean of its rows.
+//
+// Returns an error if the covariance of the columns is singular.
+func mahalanobis(m mat.Matrix) ([]float64, error) {
+	r, c := m.Dims()
+	var cov mat.SymDense
+	stat.CovarianceMatrix(&cov, m, nil)
+	var chol mat.Cholesky
+	if ok := chol.Factorize(&cov); !ok {
+		return nil, fmt.Errorf("Covariance of the columns is singular.")
+	}
+
+	means := mean(m)
+	dists := make([]float64, r)
+	dev := mat.NewVecDense(c, nil)
+	var solved mat.VecDense
+	for i := 0; i < r; i++ {
+		for j := 0; j < c; j++ {
+			dev.SetVec(j, m.At(i, j)-means[j])
+		}
+		if err := chol.SolveVecTo(&solved, dev); err != nil {
+			return nil, fmt.Errorf("Covariance of the columns is singular.\n%w", err)
+		}
+		dists[i] = mat.Dot(dev, &solved)
+	}
+	return dists, nil
+}
+
+// isolationTree is a node of a tree of an isolation forest, a leaf when it
+// has no children.
+type isolationTree struct {
+	column      int
+	split       float64
+	left, right *isolationTree
+	// size is the number of sample rows reaching a leaf.
+	size int
+}
+
+// isolationForest returns the anomaly score of each row of matrix `m` from
+// `trees` random trees grown on `sampleSize` rows each, with the random
+// generator seeded by `seed`.
+//
+// Returns the error of `ctx` if it is done before the end.
+func isolationForest(
+	ctx context.Context, m mat.Matrix, trees, sampleSize int, seed int64,
+) ([]float64, error) {
+	r, _ := m.Dims()
+	rnd := rand.New(rand.NewSource(seed))
+	// trees stop growing at the average depth of an unsuccessful search.
+	limit := int(math.Ceil(math.Log2(float64(sampleSize))))
+
+	depths := make([]float64, r)
+	for t := 0; t < trees; t++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		sample := rnd.Perm(r)[:sampleSize]
+		tree := growIsolationTree(m, sample, 0, limit, rnd)
+		for i := 0; i < r; i++ {
+			depths[i] += tree.pathLength(m, i)
+		}
+	}
+
+	scores := make([]float64, r)
+	norm := averagePathLength(sampleSize)
+	for i := range scores {
+		scores[i] = math.Pow(2, -depths[i]/float64(trees)/norm)
+	}
+	return scores, nil
+}
+
+// growIsolationTree grows a tree on the rows `rows` of matrix `m`, at depth
+// `depth`, splitting a random column with spread at a uniform random value
+// until the rows are isolated or the depth reaches `limit`.
+func growIsolationTree(
+	m mat.Matrix, rows []int, depth, limit int, rnd *rand.Rand,
+) *isolationTree {
+	leaf := &isolationTree{size: len(rows)}
+	if len(rows) <= 1 || depth >= limit {
+		return leaf
+	}
+
+	_, c := m.Dims()
+	lows, highs := make([]float64, c), make([]float64, c)
+	var spread []int
+	for j := 0; j < c; j++ {
+		lows[j], highs[j] = math.Inf(1), math.Inf(-1)
+		for _, i := range rows {
+			lows[j] = min(lows[j], m.At(i, j))
+			highs[j] = max(highs[j], m.At(i, j))
+		}
+		if highs[j] > lows[j] {
+			spread = append(spread, j)
+		}
+	}
+	if len(spread) == 0 {
+		return leaf
+	}
+
+	j := spread[rnd.Intn(len(spread))]
+	split := lows[j] + rnd.Float64()*(highs[j]-lows[j])
+	var left, right []int
+	for _, i := range rows {
+		if m.At(i, j) < split {
+			left = append(left, i)
+		} else {
+			right = append(right, i)
+		}
+	}
+	return &isolationTree{
+		column: j,
+		split:  split,
+		left:   growIsolationTree(m, left, depth+1, limit, rnd),
+		right:  growIsolationTree(m, right, depth+1, limit, rnd),
+	}
+}
+
+// pathLength returns the depth of the leaf row `i` of matrix `m` falls in,
+// plus the average depth the rows of the leaf would have been isolated at.
+func (tree *isolationTree) pathLength(m mat.Matrix, i int) float64 {
+	var depth float64
+	for tree.left != nil {
+		if m.At(i, tree.column) < tree.split {
+			tree = tree.left
+		} else {
+			tree = tree.right
+		}
+		depth++
+	}
+	return depth + averagePathLength(tree.size)
+}
+
+// averagePathLength returns the average depth of an unsuccessful search in a
+// binary search tree of `n` values, 2H(n-1) - 2(n-1)/n with H the harmonic
+// numbers.
+func averagePathLength(n int) float64 {
+	if n <= 1 {
+		return 0
+	}
+	if n == 2 {
+		return 1
+	}
+	k := float64(n - 1)
+	harmonic := math.Log(k) + 0.5772156649015329
+	return 2*harmonic - 2*k/float64(n)
+}
@@ -0,0 +1,187 @@
+package statsanal
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/gonum/stat/distuv"
+)
+
+func TestUnivariateOutliers(t *testing.T) {
+	x := []float64{2, 4, 4, 4, 5, 5, 7, 9, 30}
+	m := mat.NewDense(len(x), 1, x)
+
+	// the mean is 70/9 and the sample standard deviation about 8.54, too
+	// inflated by the outlier for it to score above 3.
+	res, err := Outliers(context.Background(), m, OutlierOptions{Names: []string{"x"}})
+	require.NoError(t, err)
+	require.Equal(t, ZScore, res.Method)
+	require.Equal(t, []string{"x"}, res.Names)
+	require.Equal(t, 3.0, res.Threshold)
+	mu := 70.0 / 9
+	var ss float64
+	for _, v := range x {
+		ss += (v - mu) * (v - mu)
+	}
+	sd := math.Sqrt(ss / 8)
+	require.InDelta(t, (2-mu)/sd, res.ColumnScores[0][0], 1e-12)
+	require.InDelta(t, (30-mu)/sd, res.Scores[8], 1e-12)
+	require.Empty(t, res.Outliers)
+	res, err = Outliers(context.Background(), m, OutlierOptions{Threshold: 2})
+	require.NoError(t, err)
+	require.Equal(t, []int{8}, res.Outliers)
+	require.True(t, res.Flags[8])
+	require.False(t, res.Flags[0])
+
+	// the median is 5 and the deviations 3 1 1 1 0 0 2 4 25 have median 1.
+	res, err = Outliers(context.Background(), m, OutlierOptions{Method: ModifiedZScore})
+	require.NoError(t, err)
+	require.Equal(t, 3.5, res.Threshold)
+	require.InDelta(t, 25*0.6744897501960817, res.Scores[8], 1e-12)
+	require.InDelta(t, -3*0.6744897501960817, res.ColumnScores[0][0], 1e-12)
+	require.Equal(t, []int{8}, res.Outliers)
+
+	// the quartiles are 4 and 7.
+	res, err = Outliers(context.Background(), m, OutlierOptions{Method: IQRFences})
+	require.NoError(t, err)
+	require.Equal(t, 1.5, res.Threshold)
+	require.InDelta(t, 23.0/3, res.Scores[8], 1e-12)
+	require.InDelta(t, -2.0/3, res.ColumnScores[0][0], 1e-12)
+	require.InDelta(t, 2.0/3, res.Scores[7], 1e-12)
+	require.Zero(t, res.Scores[4])
+	require.Equal(t, []int{8}, res.Outliers)
+
+	// most equal values fall back to the mean absolute deviation.
+	y := mat.NewDense(5, 1, []float64{1, 1, 1, 1, 6})
+	res, err = Outliers(context.Background(), y, OutlierOptions{Method: ModifiedZScore})
+	require.NoError(t, err)
+	require.InDelta(t, 5/(math.Sqrt(math.Pi/2)), res.Scores[4], 1e-12)
+
+	// a row is flagged when any of its values is.
+	two := mat.NewDense(6, 2, []float64{1, 10, 2, 11, 3, 12, 2, 11, 1, 50, 2, 12})
+	res, err = Outliers(context.Background(), two, OutlierOptions{Method: IQRFences})
+	require.NoError(t, err)
+	require.Len(t, res.ColumnScores, 2)
+	require.Equal(t, []int{4}, res.Outliers)
+
+	_, err = Outliers(context.Background(), y, OutlierOptions{Method: IQRFences})
+	require.Error(t, err)
+
+	_, err = Outliers(context.Background(), mat.NewDense(3, 1, []float64{2, 2, 2}), OutlierOptions{})
+	require.Error(t, err)
+
+	_, err = Outliers(context.Background(), mat.NewDense(3, 1, []float64{1, math.NaN(), 2}), OutlierOptions{})
+	require.Error(t, err)
+
+	_, err = Outliers(context.Background(), mat.NewDense(2, 1, []float64{1, 2}), OutlierOptions{})
+	require.Error(t, err)
+
+	_, err = Outliers(context.Background(), m, OutlierOptions{Method: "grubbs"})
+	require.Error(t, err)
+
+	_, err = Outliers(context.Background(), m, OutlierOptions{Threshold: -1})
+	require.Error(t, err)
+
+	_, err = Outliers(context.Background(), m, OutlierOptions{Names: []string{"a", "b"}})
+	require.Error(t, err)
+}
+
+// correlatedRows returns `r` rows of two strongly correlated normal columns,
+// the last one lying off their line while each of its values is typical.
+func correlatedRows(rnd *rand.Rand, r int) *mat.Dense {
+	m := mat.NewDense(r, 2, nil)
+	for i := 0; i < r; i++ {
+		x := rnd.NormFloat64()
+		m.Set(i, 0, x)
+		m.Set(i, 1, x+0.1*rnd.NormFloat64())
+	}
+	m.Set(r-1, 0, 1.5)
+	m.Set(r-1, 1, -1.5)
+	return m
+}
+
+func TestMahalanobisOutliers(t *testing.T) {
+	m := correlatedRows(rand.New(rand.NewSource(2)), 200)
+
+	res, err := Outliers(context.Background(), m, OutlierOptions{Method: Mahalanobis})
+	require.NoError(t, err)
+	require.InDelta(t, distuv.ChiSquared{K: 2}.Quantile(0.975), res.Threshold, 1e-12)
+	require.Nil(t, res.ColumnScores)
+	require.True(t, res.Flags[199])
+	require.Greater(t, res.Scores[199], 100.0)
+
+	// the squared distances of the rows average (n - 1) p / n.
+	var total float64
+	for _, s := range res.Scores {
+		total += s
+	}
+	require.InDelta(t, 2*199.0/200, total/200, 1e-9)
+
+	// the values of the outlying row are typical of their columns.
+	res, err = Outliers(context.Background(), m, OutlierOptions{})
+	require.NoError(t, err)
+	require.False(t, res.Flags[199])
+
+	_, err = Outliers(
+		context.Background(),
+		mat.NewDense(3, 2, []float64{1, 2, 2, 4, 3, 6}),
+		OutlierOptions{Method: Mahalanobis},
+	)
+	require.Error(t, err)
+}
+
+func TestIsolationForest(t *testing.T) {
+	rnd := rand.New(rand.NewSource(3))
+	m := mat.NewDense(300, 2, nil)
+	for i := 0; i < 300; i++ {
+		m.Set(i, 0, rnd.NormFloat64())
+		m.Set(i, 1, rnd.NormFloat64())
+	}
+	m.Set(0, 0, 8)
+	m.Set(0, 1, -8)
+
+	res, err := Outliers(context.Background(), m, OutlierOptions{Method: IsolationForest, Seed: 5})
+	require.NoError(t, err)
+	require.Equal(t, 0.6, res.Threshold)
+	require.Contains(t, res.Outliers, 0)
+	require.Greater(t, res.Scores[0], 0.7)
+	var total float64
+	for i, s := range res.Scores {
+		require.Greater(t, s, 0.0)
+		require.Less(t, s, 1.0)
+		if i > 0 {
+			require.Less(t, s, res.Scores[0])
+		}
+		total += s
+	}
+	require.Less(t, total/300, 0.5)
+
+	// the same seed grows the same trees.
+	again, err := Outliers(context.Background(), m, OutlierOptions{Method: IsolationForest, Seed: 5})
+	require.NoError(t, err)
+	require.Equal(t, res.Scores, again.Scores)
+
+	small, err := Outliers(context.Background(), m, OutlierOptions{
+		Method:     IsolationForest,
+		Trees:      50,
+		SampleSize: 64,
+		Seed:       1,
+	})
+	require.NoError(t, err)
+	require.Contains(t, small.Outliers, 0)
+
+	_, err = Outliers(context.Background(), m, OutlierOptions{Method: IsolationForest, SampleSize: 301})
+	require.Error(t, err)
+
+	_, err = Outliers(context.Background(), m, OutlierOptions{Method: IsolationForest, Trees: -1})
+	require.Error(t, err)
+
+	require.Zero(t, averagePathLength(1))
+	require.Equal(t, 1.0, averagePathLength(2))
+	require.InDelta(t, 2*(math.Log(255)+0.5772156649015329)-2*255.0/256,
+		averagePathLength(256), 1e-12)
+}
@@ -0,0 +1,152 @@
+package statsanal
+
+import (
+	"fmt"
+	"math"
+
+	"gonum.org/v1/gonum/floats"
+	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/gonum/stat"
+)
+
+// PCAOptions configures a principal component analysis.
+type PCAOptions struct {
+	// Standardize scales the columns to unit variance before the analysis,
+	// so it is performed on the correlation matrix rather than on the
+	// covariance matrix.
+	Standardize bool
+	// Components is the number of components whose loadings and scores are
+	// returned. Defaults to every component.
+	Components int
+	// Scores also projects the rows on the components.
+	Scores bool
+	// Names of the columns of the matrix. Defaults to ColumnNames.
+	Names []string
+}
+
+// PCAResult holds the outcome of a principal component analysis. Components
+// are ordered by decreasing variance.
+type PCAResult struct {
+	Names []string `json:"names"`
+	// Eigenvalues are the variances of every component.
+	Eigenvalues     []float64 `json:"eigenvalues"`
+	ExplainedRatio  []float64 `json:"explained_variance_ratio"`
+	CumulativeRatio []float64 `json:"cumulative_variance_ratio"`
+	// Loadings holds the weights of each column, ordered like `Names`, in
+	// each of the first components.
+	Loadings [][]float64 `json:"loadings"`
+	// Scores holds the projection of each row on the first components.
+	Scores [][]float64 `json:"scores,omitempty"`
+}
+
+// PCA performs the principal component analysis of the columns of matrix
+// `m`, using the singular value decomposition of the centered data.
+//
+// The sign of a component is arbitrary, it is chosen so that the largest
+// loading of each component is positive.
+//
+// Returns an error if there are less than 2 rows or columns, the number of
+// components or names is invalid, every column is constant, or a column is
+// constant while standardizing.
+func PCA(m mat.Matrix, opts PCAOptions) (res PCAResult, err error) {
+	r, c := m.Dims()
+	if r < 2 || c < 2 {
+		err = fmt.Errorf("Need at least 2 rows and 2 columns, got %dx%d.", r, c)
+		return
+	}
+	if opts.Names == nil {
+		opts.Names = ColumnNames(c)
+	}
+	if len(opts.Names) != c {
+		err = fmt.Errorf("Got %d names for %d columns.", len(opts.Names), c)
+		return
+	}
+
+	// center, and maybe scale, the columns.
+	data := mat.DenseCopyOf(m)
+	means := mean(data)
+	variances := variance(data)
+	if sum(variances...) == 0 {
+		err = fmt.Errorf("Every column is constant, there is no variance to explain.")
+		return
+	}
+	for j := 0; j < c; j++ {
+		scale := 1.0
+		if opts.Standardize {
+			if variances[j] == 0 {
+				err = fmt.Errorf("Can't standardize constant column %q.", opts.Names[j])
+				return
+			}
+			scale = math.Sqrt(variances[j])
+		}
+		for i := 0; i < r; i++ {
+			data.Set(i, j, (data.At(i, j)-means[j])/scale)
+		}
+	}
+
+	var pc stat.PC
+	if ok := pc.PrincipalComponents(data, nil); !ok {
+		err = fmt.Errorf("Error computing principal components.")
+		return
+	}
+	var vecs mat.Dense
+	pc.VectorsTo(&vecs)
+	res.Eigenvalues = pc.VarsTo(nil)
+
+	k := len(res.Eigenvalues)
+	if opts.Components < 0 || opts.Components > k {
+		err = fmt.Errorf("Number of components should be in [1, %d], got %d.",
+			k, opts.Components)
+		return
+	}
+	if opts.Components > 0 {
+		k = opts.Components
+	}
+
+	total := sum(res.Eigenvalues...)
+	res.ExplainedRatio = make([]float64, len(res.Eigenvalues))
+	res.CumulativeRatio = make([]float64, len(res.Eigenvalues))
+	var cumulative float64
+	for i, v := range res.Eigenvalues {
+		res.ExplainedRatio[i] = v / total
+		cumulative += res.ExplainedRatio[i]
+		res.CumulativeRatio[i] = cumulative
+	}
+
+	res.Names = opts.Names
+	res.Loadings = make([][]float64, k)
+	for j := 0; j < k; j++ {
+		loadings := mat.Col(nil, j, &vecs)
+		if loadings[maxAbsIndex(loadings)] < 0 {
+			for i := range loadings {
+				loadings[i] = -loadings[i]
+			}
+		}
+		res.Loadings[j] = loadings
+	}
+
+	if opts.Scores {
+		res.Scores = make([][]float64, r)
+		for i := 0; i < r; i++ {
+			row := data.RawRowView(i)
+			res.Scores[i] = make([]float64, k)
+			for j, loadings := range res.Loadings {
+				res.Scores[i][j] = floats.Dot(row, loadings)
+			}
+		}
+	}
+
+	return
+}
+
+// maxAbsIndex finds the index of the value of `x` with the largest absolute
+// value.
+func maxAbsIndex(x []float64) int {
+	var idx int
+	for i, v := range x {
+		if math.Abs(v) > math.Abs(x[idx]) {
+			idx = i
+		}
+	}
+	return idx
+}
@@ -0,0 +1,66 @@
+package statsanal
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestPCA(t *testing.T) {
+	// the second column is twice the first, so a single component holds all
+	// the variance.
+	m := mat.NewDense(5, 2, []float64{
+		1, 2,
+		2, 4,
+		3, 6,
+		4, 8,
+		5, 10,
+	})
+
+	res, err := PCA(m, PCAOptions{Scores: true})
+	require.NoError(t, err)
+	require.Equal(t, []string{"col_0", "col_1"}, res.Names)
+	require.Len(t, res.Eigenvalues, 2)
+	// total variance is 2.5 + 10.
+	require.InDelta(t, 12.5, res.Eigenvalues[0], 1e-9)
+	require.InDelta(t, 0, res.Eigenvalues[1], 1e-9)
+	require.InDelta(t, 1, res.ExplainedRatio[0], 1e-9)
+	require.InDelta(t, 1, res.CumulativeRatio[1], 1e-9)
+	require.Len(t, res.Loadings, 2)
+	require.InDelta(t, 1/math.Sqrt(5), res.Loadings[0][0], 1e-9)
+	require.InDelta(t, 2/math.Sqrt(5), res.Loadings[0][1], 1e-9)
+	require.Len(t, res.Scores, 5)
+	require.InDelta(t, -2*math.Sqrt(5), res.Scores[0][0], 1e-9)
+	require.InDelta(t, 0, res.Scores[2][0], 1e-9)
+
+	// standardized columns weigh the same.
+	res, err = PCA(m, PCAOptions{Standardize: true, Components: 1})
+	require.NoError(t, err)
+	require.InDelta(t, 2, res.Eigenvalues[0], 1e-9)
+	require.Len(t, res.Loadings, 1)
+	require.InDelta(t, 1/math.Sqrt(2), res.Loadings[0][0], 1e-9)
+	require.InDelta(t, 1/math.Sqrt(2), res.Loadings[0][1], 1e-9)
+	require.Nil(t, res.Scores)
+
+	_, err = PCA(m, PCAOptions{Components: 3})
+	require.Error(t, err)
+
+	_, err = PCA(m, PCAOptions{Names: []string{"a"}})
+	require.Error(t, err)
+
+	constant := mat.NewDense(3, 2, []float64{1, 1, 2, 1, 3, 1})
+	_, err = PCA(constant, PCAOptions{Standardize: true})
+	require.Error(t, err)
+	// a single constant column still leaves variance to explain.
+	_, err = PCA(constant, PCAOptions{})
+	require.NoError(t, err)
+
+	flat := mat.NewDense(3, 2, []float64{1, 5, 1, 5, 1, 5})
+	_, err = PCA(flat, PCAOptions{})
+	require.Error(t, err)
+
+	_, err = PCA(mat.NewDense(1, 2, []float64{1, 2}), PCAOptions{})
+	require.Error(t, err)
+}
@@ -0,0 +1,478 @@
+package statsanal
+
+import (
+	"fmt"
+	"math"
+
+	"gonum.org/v1/gonum/floats"
+	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/gonum/stat"
+)
+
+// Penalty is the penalty of a regularized regression.
+type Penalty string
+
+const (
+	// Ridge penalizes the squared L2 norm of the coefficients.
+	Ridge Penalty = "ridge"
+	// Lasso penalizes the L1 norm of the coefficients.
+	Lasso Penalty = "lasso"
+	// ElasticNet penalizes a mix of the L1 and squared L2 norms.
+	ElasticNet Penalty = "elastic_net"
+)
+
+const (
+	// DefaultAlpha is the L1 share of the elastic net penalty when none is
+	// given.
+	DefaultAlpha = 0.5
+	// DefaultPathLength is the number of penalties in a regularization
+	// path when none is given.
+	DefaultPathLength = 50
+	// DefaultFolds is the number of cross-validation folds when none is
+	// given.
+	DefaultFolds = 5
+
+	// pathRatio is the ratio of the smallest to the largest penalty of a
+	// regularization path.
+	pathRatio = 1e-3
+	// maxSweeps and sweepTolerance bound the coordinate descent.
+	maxSweeps      = 10000
+	sweepTolerance = 1e-9
+)
+
+// RegularizedOptions configures a regularized regression.
+type RegularizedOptions struct {
+	// Penalty defaults to Ridge.
+	Penalty Penalty
+	// Alpha is the L1 share of the ElasticNet penalty, in the open interval
+	// (0, 1). Defaults to DefaultAlpha, and is 0 for Ridge and 1 for Lasso.
+	Alpha float64
+	// Lambda is the strength of the penalty. When zero, it is chosen along
+	// the regularization path by cross-validation.
+	Lambda float64
+	// PathLength is the number of penalties of the regularization path.
+	// Defaults to DefaultPathLength.
+	PathLength int
+	// Folds is the number of cross-validation folds. Defaults to
+	// DefaultFolds.
+	Folds int
+	// NoStandardize fits the model on the raw predictors instead of scaling
+	// them to unit variance. The coefficients are always reported on the
+	// original scale.
+	NoStandardize bool
+	// Names of the columns of the regressed matrix, used to label the
+	// coefficients. Defaults to ColumnNames.
+	Names []string
+}
+
+// PathStep holds the fit at one penalty of a regularization path.
+type PathStep struct {
+	Lambda       float64   `json:"lambda"`
+	Coefficients []float64 `json:"coefficients"`
+	NonZero      int       `json:"non_zero"`
+	// CVError is the mean cross-validated squared error and CVStdError its
+	// standard error, when the penalty was cross-validated.
+	CVError    float64 `json:"cv_error,omitempty"`
+	CVStdError float64 `json:"cv_std_error,omitempty"`
+}
+
+// RegularizedResult holds the outcome of a regularized regression. Every
+// coefficients slice is ordered like `Names`, with the first element being
+// the intercept.
+type RegularizedResult struct {
+	Names   []string `json:"names"`
+	Penalty string   `json:"penalty"`
+	Alpha   float64  `json:"alpha"`
+	// Lambda is the penalty of the reported coefficients.
+	Lambda       float64   `json:"lambda"`
+	Coefficients []float64 `json:"coefficients"`
+	NonZero      int       `json:"non_zero"`
+	Observations int       `json:"observations"`
+	RSquared     float64   `json:"r_squared"`
+
+	// CrossValidated is set when Lambda was chosen by cross-validation,
+	// as LambdaMin, the penalty with the smallest error. Lambda1SE is the
+	// largest penalty within one standard error of it.
+	CrossValidated bool       `json:"cross_validated"`
+	LambdaMin      float64    `json:"lambda_min,omitempty"`
+	Lambda1SE      float64    `json:"lambda_1se,omitempty"`
+	Path           []PathStep `json:"path"`
+}
+
+// RegularizedRegression fits a penalized linear regression on the given
+// matrix `m`, minimizing
+//
+//	1/(2n) * ||y - Xb||² + λ * ((1-α)/2 * ||b||² + α * ||b||₁)
+//
+// like glmnet. The last column of the matrix is used as the target and the
+// rest of the columns as the predictors, see SelectColumns to pick other
+// columns. The intercept is not penalized.
+//
+// Ridge is solved in closed form from the singular value decomposition of
+// the predictors, and lasso and elastic net by coordinate descent with warm
+// starts along the regularization path, so unlike LinearRegression the fit
+// works with collinear predictors or more predictors than rows.
+//
+// The path runs over `opts.PathLength` penalties, log-spaced from the
+// smallest one zeroing every coefficient down to a thousandth of it. When
+// `opts.Lambda` isn't given, the penalty with the smallest k-fold
+// cross-validated error is used, the folds interleaving the rows.
+//
+// Returns a non-nil error if the options are invalid, there are too few
+// rows for the folds, or the coordinate descent doesn't converge.
+func RegularizedRegression(m *mat.Dense, opts RegularizedOptions) (res RegularizedResult, err error) {
+	if opts.Penalty == "" {
+		opts.Penalty = Ridge
+	}
+	switch opts.Penalty {
+	case Ridge:
+		opts.Alpha = 0
+	case Lasso:
+		opts.Alpha = 1
+	case ElasticNet:
+		if opts.Alpha == 0 {
+			opts.Alpha = DefaultAlpha
+		}
+		if opts.Alpha <= 0 || opts.Alpha >= 1 {
+			err = fmt.Errorf("Alpha should be in (0, 1), got %v.", opts.Alpha)
+			return
+		}
+	default:
+		err = fmt.Errorf("Unknown penalty %q.", opts.Penalty)
+		return
+	}
+	if opts.Lambda < 0 {
+		err = fmt.Errorf("Lambda should be positive, got %v.", opts.Lambda)
+		return
+	}
+	if opts.PathLength == 0 {
+		opts.PathLength = DefaultPathLength
+	}
+	if opts.Folds == 0 {
+		opts.Folds = DefaultFolds
+	}
+
+	r, c := m.Dims()
+	if c < 2 {
+		err = fmt.Errorf("Need a target and at least one predictor column.")
+		return
+	}
+	if opts.Names == nil {
+		opts.Names = ColumnNames(c)
+	}
+	if len(opts.Names) != c {
+		err = fmt.Errorf("Got %d names for %d columns.", len(opts.Names), c)
+		return
+	}
+	if r < 2 {
+		err = fmt.Errorf("Not enough observations: %d rows.", r)
+		return
+	}
+	if opts.Lambda == 0 && opts.Folds < 2 {
+		err = fmt.Errorf("Folds should be at least 2, got %d.", opts.Folds)
+		return
+	}
+	if opts.Lambda == 0 && opts.Folds > r {
+		err = fmt.Errorf(
+			"Can't split %d rows into %d folds.\n%w", r, opts.Folds, ErrEmptySplit)
+		return
+	}
+
+	X := m.Slice(0, r, 0, c-1)
+	y := mat.Col(nil, c-1, m)
+
+	full := standardize(X, y, !opts.NoStandardize)
+	lambdas := regularizationPath(full, opts.Alpha, opts.PathLength)
+
+	path, err := fitPath(full, opts.Alpha, lambdas)
+	if err != nil {
+		return
+	}
+	res.Path = make([]PathStep, len(lambdas))
+	for i, lambda := range lambdas {
+		coeffs := full.unscale(path[i])
+		res.Path[i] = PathStep{
+			Lambda:       lambda,
+			Coefficients: coeffs,
+			NonZero:      nonZero(coeffs[1:]),
+		}
+	}
+
+	// Pick the penalty.
+	if opts.Lambda > 0 {
+		var b [][]float64
+		b, err = fitPath(full, opts.Alpha, []float64{opts.Lambda})
+		if err != nil {
+			return
+		}
+		res.Lambda = opts.Lambda
+		res.Coefficients = full.unscale(b[0])
+	} else {
+		err = crossValidatePath(X, y, opts, lambdas, res.Path)
+		if err != nil {
+			return
+		}
+
+		best := 0
+		for i, step := range res.Path {
+			if step.CVError < res.Path[best].CVError {
+				best = i
+			}
+		}
+		// the path runs from the largest penalty down.
+		oneSE := best
+		limit := res.Path[best].CVError + res.Path[best].CVStdError
+		for oneSE > 0 && res.Path[oneSE-1].CVError <= limit {
+			oneSE--
+		}
+
+		res.CrossValidated = true
+		res.LambdaMin = res.Path[best].Lambda
+		res.Lambda1SE = res.Path[oneSE].Lambda
+		res.Lambda = res.LambdaMin
+		res.Coefficients = res.Path[best].Coefficients
+	}
+
+	res.Names = append([]string{"intercept"}, opts.Names[:c-1]...)
+	res.Penalty = string(opts.Penalty)
+	res.Alpha = opts.Alpha
+	res.NonZero = nonZero(res.Coefficients[1:])
+	res.Observations = r
+
+	var sse, sst float64
+	yMean := stat.Mean(y, nil)
+	for i := 0; i < r; i++ {
+		d := y[i] - predictRow(res.Coefficients, mat.Row(nil, i, X))
+		sse += d * d
+		sst += (y[i] - yMean) * (y[i] - yMean)
+	}
+	res.RSquared = 1 - sse/sst
+
+	return
+}
+
+// crossValidatePath sets the cross-validated error of each step of `path`,
+// fitted on the penalties `lambdas`.
+func crossValidatePath(
+	X mat.Matrix, y []float64, opts RegularizedOptions,
+	lambdas []float64, path []PathStep,
+) error {
+	r, c := X.Dims()
+
+	errs := make([][]float64, len(lambdas))
+	for f := 0; f < opts.Folds; f++ {
+		var train, test []int
+		for i := 0; i < r; i++ {
+			if i%opts.Folds == f {
+				test = append(test, i)
+			} else {
+				train = append(train, i)
+			}
+		}
+
+		trainX := mat.NewDense(len(train), c, nil)
+		trainY := make([]float64, len(train))
+		for k, i := range train {
+			trainX.SetRow(k, mat.Row(nil, i, X))
+			trainY[k] = y[i]
+		}
+
+		fold := standardize(trainX, trainY, !opts.NoStandardize)
+		b, err := fitPath(fold, opts.Alpha, lambdas)
+		if err != nil {
+			return fmt.Errorf("Error fitting fold %d.\n%w", f, err)
+		}
+		for l := range lambdas {
+			coeffs := fold.unscale(b[l])
+			var sse float64
+			for _, i := range test {
+				d := y[i] - predictRow(coeffs, mat.Row(nil, i, X))
+				sse += d * d
+			}
+			errs[l] = append(errs[l], sse/float64(len(test)))
+		}
+	}
+
+	for l := range lambdas {
+		mse, std := stat.MeanStdDev(errs[l], nil)
+		path[l].CVError = mse
+		path[l].CVStdError = std / math.Sqrt(float64(opts.Folds))
+	}
+	return nil
+}
+
+// standardized holds centered, and maybe scaled, predictors and target.
+type standardized struct {
+	x      *mat.Dense
+	y      []float64
+	xMean  []float64
+	xScale []float64
+	yMean  float64
+	// xSq holds the mean of the squares of each column of x.
+	xSq []float64
+}
+
+// standardize centers the columns of `X` and `y`, and scales the columns of
+// `X` to unit variance if `scale` is set. Constant columns are left
+// unscaled.
+func standardize(X mat.Matrix, y []float64, scale bool) standardized {
+	r, c := X.Dims()
+	s := standardized{
+		x:      mat.DenseCopyOf(X),
+		y:      make([]float64, r),
+		xMean:  make([]float64, c),
+		xScale: make([]float64, c),
+		xSq:    make([]float64, c),
+		yMean:  stat.Mean(y, nil),
+	}
+	for i, v := range y {
+		s.y[i] = v - s.yMean
+	}
+
+	for j := 0; j < c; j++ {
+		col := mat.Col(nil, j, s.x)
+		s.xMean[j] = stat.Mean(col, nil)
+		floats.AddConst(-s.xMean[j], col)
+
+		s.xScale[j] = 1
+		if sd := math.Sqrt(floats.Dot(col, col) / float64(r)); scale && sd > 0 {
+			s.xScale[j] = sd
+			floats.Scale(1/sd, col)
+		}
+		s.xSq[j] = floats.Dot(col, col) / float64(r)
+		s.x.SetCol(j, col)
+	}
+	return s
+}
+
+// unscale returns the intercept followed by the coefficients `b`, fitted on
+// the standardized data, on the original scale.
+func (s standardized) unscale(b []float64) []float64 {
+	res := make([]float64, len(b)+1)
+	res[0] = s.yMean
+	for j, v := range b {
+		res[j+1] = v / s.xScale[j]
+		res[0] -= res[j+1] * s.xMean[j]
+	}
+	return res
+}
+
+// regularizationPath returns `n` log-spaced penalties from the smallest one
+// zeroing every coefficient down to pathRatio of it. Ridge never zeroes the
+// coefficients, so its path starts like an elastic net mostly made of ridge.
+func regularizationPath(s standardized, alpha float64, n int) []float64 {
+	r, c := s.x.Dims()
+	var top float64
+	for j := 0; j < c; j++ {
+		top = math.Max(top, math.Abs(floats.Dot(mat.Col(nil, j, s.x), s.y)))
+	}
+	top /= float64(r) * math.Max(alpha, 1e-3)
+	if top == 0 {
+		top = 1
+	}
+
+	lambdas := make([]float64, n)
+	floats.LogSpan(lambdas, top, top*pathRatio)
+	return lambdas
+}
+
+// fitPath fits the standardized data `s` on each of the decreasing penalties
+// `lambdas`, and returns the coefficients on the standardized scale.
+func fitPath(s standardized, alpha float64, lambdas []float64) ([][]float64, error) {
+	if alpha == 0 {
+		return ridgePath(s, lambdas)
+	}
+
+	r, c := s.x.Dims()
+	b := make([]float64, c)
+	residual := append([]float64{}, s.y...)
+	cols := make([][]float64, c)
+	for j := range cols {
+		cols[j] = mat.Col(nil, j, s.x)
+	}
+
+	res := make([][]float64, len(lambdas))
+	for l, lambda := range lambdas {
+		converged := false
+		for sweep := 0; sweep < maxSweeps && !converged; sweep++ {
+			var maxChange float64
+			for j := 0; j < c; j++ {
+				if s.xSq[j] == 0 {
+					continue
+				}
+				z := floats.Dot(cols[j], residual)/float64(r) + s.xSq[j]*b[j]
+				bj := softThreshold(z, lambda*alpha) / (s.xSq[j] + lambda*(1-alpha))
+				if d := bj - b[j]; d != 0 {
+					floats.AddScaled(residual, -d, cols[j])
+					maxChange = math.Max(maxChange, s.xSq[j]*d*d)
+					b[j] = bj
+				}
+			}
+			converged = maxChange < sweepTolerance
+		}
+		if !converged {
+			return nil, fmt.Errorf("%w: coordinate descent at lambda %.5g.",
+				ErrNotConverged, lambda)
+		}
+		res[l] = append([]float64{}, b...)
+	}
+	return res, nil
+}
+
+// ridgePath solves the ridge problem on each penalty of `lambdas` from the
+// singular value decomposition X = U S Vᵀ, as b = V (S² + nλ)⁻¹ S Uᵀ y.
+func ridgePath(s standardized, lambdas []float64) ([][]float64, error) {
+	r, c := s.x.Dims()
+
+	var svd mat.SVD
+	if ok := svd.Factorize(s.x, mat.SVDThin); !ok {
+		return nil, fmt.Errorf("Error factorizing predictors.")
+	}
+	var u, v mat.Dense
+	svd.UTo(&u)
+	svd.VTo(&v)
+	values := svd.Values(nil)
+
+	var uty mat.VecDense
+	uty.MulVec(u.T(), mat.NewVecDense(r, s.y))
+
+	res := make([][]float64, len(lambdas))
+	for l, lambda := range lambdas {
+		d := mat.NewVecDense(len(values), nil)
+		for k, sv := range values {
+			d.SetVec(k, sv/(sv*sv+float64(r)*lambda)*uty.AtVec(k))
+		}
+		b := mat.NewVecDense(c, nil)
+		b.MulVec(&v, d)
+		res[l] = mat.Col(nil, 0, b)
+	}
+	return res, nil
+}
+
+// softThreshold shrinks `z` towards zero by `gamma`.
+func softThreshold(z, gamma float64) float64 {
+	switch {
+	case z > gamma:
+		return z - gamma
+	case z < -gamma:
+		return z + gamma
+	}
+	return 0
+}
+
+// predictRow predicts the target of the predictors `x` from the intercept
+// followed by the coefficients `coeffs`.
+func predictRow(coeffs, x []float64) float64 {
+	return coeffs[0] + floats.Dot(coeffs[1:], x)
+}
+
+// nonZero counts the non zero values of `x`.
+func nonZero(x []float64) int {
+	var n int
+	for _, v := range x {
+		if v != 0 {
+			n++
+		}
+	}
+	return n
+}
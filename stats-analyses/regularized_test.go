@@ -0,0 +1,140 @@
+package statsanal
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gonum.org/v1/gonum/mat"
+)
+
+// randomRegression generates `r` rows of `c` predictors followed by a target
+// depending on the first 2 predictors only.
+func randomRegression(r, c int) *mat.Dense {
+	rng := rand.New(rand.NewSource(42))
+	m := mat.NewDense(r, c+1, nil)
+	for i := 0; i < r; i++ {
+		for j := 0; j < c; j++ {
+			m.Set(i, j, rng.NormFloat64())
+		}
+		m.Set(i, c, 3+2*m.At(i, 0)-m.At(i, 1)+0.1*rng.NormFloat64())
+	}
+	return m
+}
+
+func TestRegularizedRegression(t *testing.T) {
+	m := randomRegression(40, 4)
+	ols, err := LinearRegression(m, RegressionOptions{})
+	require.NoError(t, err)
+
+	// a negligible penalty gives back the least squares fit.
+	for _, penalty := range []Penalty{Ridge, Lasso, ElasticNet} {
+		res, err := RegularizedRegression(m, RegularizedOptions{Penalty: penalty, Lambda: 1e-9})
+		require.NoError(t, err, penalty)
+		require.Equal(t, string(penalty), res.Penalty)
+		require.Equal(t, ols.Names, res.Names)
+		require.InDeltaSlice(t, ols.Coefficients, res.Coefficients, 1e-5, penalty)
+		require.InDelta(t, ols.RSquared, res.RSquared, 1e-6)
+		require.Len(t, res.Path, DefaultPathLength)
+		require.False(t, res.CrossValidated)
+	}
+
+	// the path of the lasso starts with every coefficient zeroed and ends
+	// close to least squares.
+	res, err := RegularizedRegression(m, RegularizedOptions{Penalty: Lasso, Lambda: 0.5})
+	require.NoError(t, err)
+	require.Equal(t, 0, res.Path[0].NonZero)
+	require.Equal(t, 4, res.Path[len(res.Path)-1].NonZero)
+	// a large penalty keeps the relevant predictors only.
+	require.Equal(t, 2, res.NonZero)
+	require.Zero(t, res.Coefficients[3])
+	require.Zero(t, res.Coefficients[4])
+
+	// ridge shrinks the coefficients without zeroing them.
+	res, err = RegularizedRegression(m, RegularizedOptions{Penalty: Ridge, Lambda: 1})
+	require.NoError(t, err)
+	require.Equal(t, 4, res.NonZero)
+	require.Less(t, res.Coefficients[1], ols.Coefficients[1])
+}
+
+func TestRidgeClosedForm(t *testing.T) {
+	m := randomRegression(20, 3)
+	r, c := m.Dims()
+	lambda := 0.3
+
+	res, err := RegularizedRegression(m, RegularizedOptions{
+		Penalty:       Ridge,
+		Lambda:        lambda,
+		NoStandardize: true,
+	})
+	require.NoError(t, err)
+
+	// b = (XcᵀXc + nλI)⁻¹ Xcᵀyc on the centered data.
+	s := standardize(m.Slice(0, r, 0, c-1), mat.Col(nil, c-1, m), false)
+	var a mat.Dense
+	a.Mul(s.x.T(), s.x)
+	for j := 0; j < c-1; j++ {
+		a.Set(j, j, a.At(j, j)+float64(r)*lambda)
+	}
+	var xty, b mat.VecDense
+	xty.MulVec(s.x.T(), mat.NewVecDense(r, s.y))
+	require.NoError(t, b.SolveVec(&a, &xty))
+
+	require.InDeltaSlice(t, mat.Col(nil, 0, &b), res.Coefficients[1:], 1e-9)
+}
+
+func TestRegularizedRegressionWide(t *testing.T) {
+	// more predictors than rows.
+	m := randomRegression(8, 12)
+
+	for _, penalty := range []Penalty{Ridge, Lasso, ElasticNet} {
+		res, err := RegularizedRegression(m, RegularizedOptions{Penalty: penalty, Lambda: 0.1})
+		require.NoError(t, err, penalty)
+		require.Len(t, res.Coefficients, 13)
+	}
+}
+
+func TestRegularizedRegressionCV(t *testing.T) {
+	m := randomRegression(40, 6)
+
+	res, err := RegularizedRegression(m, RegularizedOptions{
+		Penalty:    Lasso,
+		PathLength: 20,
+		Folds:      4,
+	})
+	require.NoError(t, err)
+	require.True(t, res.CrossValidated)
+	require.Len(t, res.Path, 20)
+	require.Equal(t, res.LambdaMin, res.Lambda)
+	require.GreaterOrEqual(t, res.Lambda1SE, res.LambdaMin)
+	for _, step := range res.Path {
+		require.Greater(t, step.CVError, 0.0)
+	}
+	require.InDelta(t, 3, res.Coefficients[0], 0.2)
+	require.InDelta(t, 2, res.Coefficients[1], 0.2)
+	require.InDelta(t, -1, res.Coefficients[2], 0.2)
+}
+
+func TestRegularizedRegressionErrors(t *testing.T) {
+	m := randomRegression(10, 2)
+
+	testCases := []struct {
+		name string
+		opts RegularizedOptions
+	}{
+		{name: "UNKNOWN PENALTY", opts: RegularizedOptions{Penalty: "l0"}},
+		{name: "INVALID ALPHA", opts: RegularizedOptions{Penalty: ElasticNet, Alpha: 1}},
+		{name: "NEGATIVE LAMBDA", opts: RegularizedOptions{Lambda: -1}},
+		{name: "TOO MANY FOLDS", opts: RegularizedOptions{Folds: 11}},
+		{name: "INVALID NAMES", opts: RegularizedOptions{Names: []string{"a"}}},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := RegularizedRegression(m, tc.opts)
+			require.Error(t, err)
+		})
+	}
+}
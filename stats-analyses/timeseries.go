@@ -0,0 +1,446 @@
+package statsanal
+
+import (
+	"fmt"
+	"math"
+
+	"gonum.org/v1/gonum/optimize"
+	"gonum.org/v1/gonum/stat/distuv"
+)
+
+// Seasonality is the seasonal component of an exponential smoothing or a
+// seasonal decomposition.
+type Seasonality string
+
+const (
+	// NoSeasonality smooths a series without seasonal component.
+	NoSeasonality Seasonality = ""
+	// AdditiveSeasonality adds a seasonal component of constant size.
+	AdditiveSeasonality Seasonality = "additive"
+	// MultiplicativeSeasonality scales the series by a seasonal component
+	// whose size grows with the level.
+	MultiplicativeSeasonality Seasonality = "multiplicative"
+)
+
+// MovingAverageResult holds a series smoothed with a moving average.
+type MovingAverageResult struct {
+	Window  int       `json:"window"`
+	Weights []float64 `json:"weights,omitempty"`
+	// Values holds the average of the window ending at each value of the
+	// series, null for the first values without a full window.
+	Values []*float64 `json:"values"`
+}
+
+// MovingAverage smooths the series `x` with the mean of the last `window`
+// values, weighted by `weights`, oldest value first, when given.
+//
+// Returns an error if the series has missing values, the window is not in
+// [1, len(x)], or the weights don't match the window or aren't positive.
+func MovingAverage(x []float64, window int, weights []float64) (res MovingAverageResult, err error) {
+	if err = checkSeries(x, 1); err != nil {
+		return
+	}
+	if window < 1 || window > len(x) {
+		err = fmt.Errorf("Window should be in [1, %d], got %d.", len(x), window)
+		return
+	}
+	if weights != nil && len(weights) != window {
+		err = fmt.Errorf("Got %d weights for a window of %d.", len(weights), window)
+		return
+	}
+
+	w := weights
+	if w == nil {
+		w = make([]float64, window)
+		for i := range w {
+			w[i] = 1
+		}
+	}
+	var total float64
+	for _, v := range w {
+		if v < 0 || math.IsNaN(v) {
+			err = fmt.Errorf("Weights should not be negative, got %v.", weights)
+			return
+		}
+		total += v
+	}
+	if !(total > 0) || math.IsInf(total, 1) {
+		err = fmt.Errorf("Weights should have a positive sum, got %v.", weights)
+		return
+	}
+
+	res.Window = window
+	res.Weights = weights
+	res.Values = make([]*float64, len(x))
+	for t := window - 1; t < len(x); t++ {
+		var v float64
+		for i, wi := range w {
+			v += wi * x[t-window+1+i]
+		}
+		res.Values[t] = finite(v / total)
+	}
+	return
+}
+
+// checkSeries checks that the series `x` has at least `n` values and none of
+// them is missing.
+func checkSeries(x []float64, n int) error {
+	if len(x) < n {
+		return fmt.Errorf("Need at least %d values, got %d.", n, len(x))
+	}
+	for _, v := range x {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return fmt.Errorf("Series has missing or infinite values.")
+		}
+	}
+	return nil
+}
+
+// SmoothingOptions configures an exponential smoothing.
+type SmoothingOptions struct {
+	// Trend adds a linear trend to the level, Holt's double exponential
+	// smoothing.
+	Trend bool
+	// Seasonality adds a seasonal component of Period values, Holt-Winters'
+	// triple exponential smoothing along with Trend.
+	Seasonality Seasonality
+	Period      int
+	// Alpha, Beta and Gamma are the smoothing parameters of the level, trend
+	// and seasonal component, in (0, 1]. Each one left at zero is fitted by
+	// minimizing the sum of the squared one-step errors.
+	Alpha float64
+	Beta  float64
+	Gamma float64
+	// Horizon is the number of values forecast after the series.
+	Horizon int
+	// Confidence level of the forecast intervals. Defaults to
+	// DefaultConfidence.
+	Confidence float64
+}
+
+// SmoothingResult holds the outcome of an exponential smoothing.
+type SmoothingResult struct {
+	// Method is `simple`, `double` or `holt_winters`.
+	Method      string      `json:"method"`
+	Seasonality Seasonality `json:"seasonality,omitempty"`
+	Period      int         `json:"period,omitempty"`
+	Alpha       float64     `json:"alpha"`
+	Beta        *float64    `json:"beta,omitempty"`
+	Gamma       *float64    `json:"gamma,omitempty"`
+	// Level, Trend and Seasonals are the components after the last value,
+	// the seasonals ordered from the value following the series.
+	Level     float64   `json:"level"`
+	Trend     *float64  `json:"trend,omitempty"`
+	Seasonals []float64 `json:"seasonals,omitempty"`
+	// Fitted holds the one-step forecast of each value, null for the first
+	// values used to initialize the components.
+	Fitted []*float64 `json:"fitted"`
+	// SSE is the sum of the squared one-step errors, and RMSE their root
+	// mean square.
+	SSE  float64 `json:"sse"`
+	RMSE float64 `json:"rmse"`
+	// Forecast holds the values forecast after the series, within the
+	// bounds Lower and Upper at the Confidence level.
+	Confidence float64   `json:"confidence,omitempty"`
+	Forecast   []float64 `json:"forecast,omitempty"`
+	Lower      []float64 `json:"lower,omitempty"`
+	Upper      []float64 `json:"upper,omitempty"`
+}
+
+// ExponentialSmoothing smooths the series `x` by updating a level, and
+// optionally a trend and a seasonal component, as a weighted average of
+// their previous estimate and the new value. The level and trend start from
+// the first two values, or from the means of the first two periods with a
+// seasonal component, whose starting values are the mean deviations of these
+// periods from that trend.
+//
+// The forecast intervals follow from the equivalent state space model with
+// additive errors. With a multiplicative seasonal component, they are an
+// approximation ignoring the seasonal scaling of the errors.
+//
+// Returns an error if the options are invalid, the series is too short or
+// has missing values, or has non-positive values with a multiplicative
+// seasonal component.
+func ExponentialSmoothing(x []float64, opts SmoothingOptions) (res SmoothingResult, err error) {
+	s := smoother{x: x, trend: opts.Trend, season: opts.Seasonality, m: opts.Period}
+	if err = s.check(); err != nil {
+		return
+	}
+	for _, p := range []float64{opts.Alpha, opts.Beta, opts.Gamma} {
+		if p < 0 || p > 1 || math.IsNaN(p) {
+			err = fmt.Errorf("Smoothing parameters should be in (0, 1], got %v.", p)
+			return
+		}
+	}
+	if opts.Horizon < 0 {
+		err = fmt.Errorf("Horizon should not be negative, got %d.", opts.Horizon)
+		return
+	}
+	if opts.Confidence == 0 {
+		opts.Confidence = DefaultConfidence
+	}
+	if opts.Confidence <= 0 || opts.Confidence >= 1 {
+		err = fmt.Errorf("Confidence level should be in (0, 1), got %v.", opts.Confidence)
+		return
+	}
+
+	params := [3]float64{opts.Alpha, opts.Beta, opts.Gamma}
+	if !s.trend {
+		params[1] = 0
+	}
+	if s.season == NoSeasonality {
+		params[2] = 0
+	}
+	if params, err = s.fit(params); err != nil {
+		return
+	}
+	fit := s.run(params)
+
+	res.Method = "simple"
+	res.Alpha = params[0]
+	if s.trend {
+		res.Method = "double"
+		res.Beta = &params[1]
+		res.Trend = &fit.trend
+	}
+	if s.season != NoSeasonality {
+		res.Method = "holt_winters"
+		res.Seasonality = s.season
+		res.Period = s.m
+		res.Gamma = &params[2]
+		res.Seasonals = make([]float64, s.m)
+		for h := range res.Seasonals {
+			res.Seasonals[h] = fit.seasonals[(len(x)+h)%s.m]
+		}
+	}
+	res.Level = fit.level
+	res.Fitted = make([]*float64, len(x))
+	for t := s.start(); t < len(x); t++ {
+		res.Fitted[t] = finite(fit.fitted[t])
+	}
+	steps := float64(len(x) - s.start())
+	res.SSE = fit.sse
+	res.RMSE = math.Sqrt(fit.sse / steps)
+
+	if opts.Horizon == 0 {
+		return
+	}
+	z := distuv.UnitNormal.Quantile((1 + opts.Confidence) / 2)
+	res.Confidence = opts.Confidence
+	res.Forecast = make([]float64, opts.Horizon)
+	res.Lower = make([]float64, opts.Horizon)
+	res.Upper = make([]float64, opts.Horizon)
+	// the variance of the h-step error grows by the square of the effect
+	// c_j of an error on the forecast j steps later.
+	variance := fit.sse / steps
+	for h := 1; h <= opts.Horizon; h++ {
+		f := fit.level
+		if s.trend {
+			f += float64(h) * fit.trend
+		}
+		switch s.season {
+		case AdditiveSeasonality:
+			f += fit.seasonals[(len(x)-1+h)%s.m]
+		case MultiplicativeSeasonality:
+			f *= fit.seasonals[(len(x)-1+h)%s.m]
+		}
+		if h > 1 {
+			j := float64(h - 1)
+			c := params[0] * (1 + params[1]*j)
+			if s.season != NoSeasonality && (h-1)%s.m == 0 {
+				c += params[2] * (1 - params[0])
+			}
+			variance += fit.sse / steps * c * c
+		}
+		half := z * math.Sqrt(variance)
+		res.Forecast[h-1] = f
+		res.Lower[h-1], res.Upper[h-1] = f-half, f+half
+	}
+	return
+}
+
+// smoother runs the exponential smoothing of a series.
+type smoother struct {
+	x      []float64
+	trend  bool
+	season Seasonality
+	// m is the period of the seasonal component.
+	m int
+}
+
+// smoothingRun holds the outcome of running a smoother.
+type smoothingRun struct {
+	level, trend float64
+	// seasonals holds the last seasonal component of each position in the
+	// period, the component of value t at t%m.
+	seasonals []float64
+	fitted    []float64
+	sse       float64
+}
+
+// check validates the smoother's settings against its series.
+func (s smoother) check() error {
+	switch s.season {
+	case NoSeasonality:
+		if s.m != 0 {
+			return fmt.Errorf("Period %d given without seasonality.", s.m)
+		}
+	case AdditiveSeasonality, MultiplicativeSeasonality:
+		if s.m < 2 {
+			return fmt.Errorf("Seasonal period should be at least 2, got %d.", s.m)
+		}
+	default:
+		return fmt.Errorf("Unknown seasonality %q.", s.season)
+	}
+	if err := checkSeries(s.x, s.start()+1); err != nil {
+		return err
+	}
+	if s.season == MultiplicativeSeasonality {
+		for _, v := range s.x {
+			if v <= 0 {
+				return fmt.Errorf("Multiplicative seasonality needs positive values, got %v.", v)
+			}
+		}
+	}
+	return nil
+}
+
+// start returns the index of the first value forecast, the previous ones
+// initialize the components.
+func (s smoother) start() int {
+	switch {
+	case s.season != NoSeasonality:
+		return 2 * s.m
+	case s.trend:
+		return 2
+	}
+	return 1
+}
+
+// run smooths the series with the smoothing parameters `params` of the
+// level, trend and seasonal component.
+func (s smoother) run(params [3]float64) (run smoothingRun) {
+	alpha, beta, gamma := params[0], params[1], params[2]
+	x, m := s.x, s.m
+	start := s.start()
+
+	switch {
+	case s.season != NoSeasonality:
+		// the means of the first two periods give the trend, the level at
+		// the end of the second period, and the seasonal components.
+		var first, second float64
+		for i := 0; i < m; i++ {
+			first += x[i] / float64(m)
+			second += x[m+i] / float64(m)
+		}
+		if s.trend {
+			run.trend = (second - first) / float64(m)
+		}
+		run.level = first + run.trend*(float64(2*m-1)-float64(m-1)/2)
+		run.seasonals = make([]float64, m)
+		for i := 0; i < 2*m; i++ {
+			base := first + run.trend*(float64(i)-float64(m-1)/2)
+			if s.season == AdditiveSeasonality {
+				run.seasonals[i%m] += (x[i] - base) / 2
+			} else {
+				run.seasonals[i%m] += x[i] / base / 2
+			}
+		}
+	case s.trend:
+		run.level = x[1]
+		run.trend = x[1] - x[0]
+	default:
+		run.level = x[0]
+	}
+
+	run.fitted = make([]float64, len(x))
+	for t := range run.fitted[:start] {
+		run.fitted[t] = math.NaN()
+	}
+	for t := start; t < len(x); t++ {
+		prev := run.level
+		base := run.level + run.trend
+
+		var deseasoned float64
+		switch s.season {
+		case NoSeasonality:
+			run.fitted[t] = base
+			deseasoned = x[t]
+		case AdditiveSeasonality:
+			run.fitted[t] = base + run.seasonals[t%m]
+			deseasoned = x[t] - run.seasonals[t%m]
+		case MultiplicativeSeasonality:
+			run.fitted[t] = base * run.seasonals[t%m]
+			deseasoned = x[t] / run.seasonals[t%m]
+		}
+		e := x[t] - run.fitted[t]
+		run.sse += e * e
+
+		run.level = alpha*deseasoned + (1-alpha)*base
+		if s.trend {
+			run.trend = beta*(run.level-prev) + (1-beta)*run.trend
+		}
+		switch s.season {
+		case AdditiveSeasonality:
+			run.seasonals[t%m] = gamma*(x[t]-run.level) + (1-gamma)*run.seasonals[t%m]
+		case MultiplicativeSeasonality:
+			run.seasonals[t%m] = gamma*x[t]/run.level + (1-gamma)*run.seasonals[t%m]
+		}
+	}
+	return
+}
+
+// fit fills in the smoothing parameters of `params` left at zero that the
+// smoother uses, minimizing the sum of the squared one-step errors with the
+// Nelder-Mead method over their logits.
+//
+// Returns an error if the minimization fails.
+func (s smoother) fit(params [3]float64) ([3]float64, error) {
+	var free []int
+	if params[0] == 0 {
+		free = append(free, 0)
+	}
+	if s.trend && params[1] == 0 {
+		free = append(free, 1)
+	}
+	if s.season != NoSeasonality && params[2] == 0 {
+		free = append(free, 2)
+	}
+	if len(free) == 0 {
+		return params, nil
+	}
+
+	set := func(logits []float64) [3]float64 {
+		p := params
+		for k, i := range free {
+			p[i] = sigmoid(logits[k])
+		}
+		return p
+	}
+	problem := optimize.Problem{
+		Func: func(logits []float64) float64 {
+			sse := s.run(set(logits)).sse
+			if math.IsNaN(sse) {
+				return math.Inf(1)
+			}
+			return sse
+		},
+	}
+
+	// start from both weak and strong smoothing, keeping the best fit.
+	var best *optimize.Result
+	for _, p := range []float64{0.2, 0.8} {
+		init := make([]float64, len(free))
+		for k := range init {
+			init[k] = math.Log(p / (1 - p))
+		}
+		result, err := optimize.Minimize(problem, init, nil, &optimize.NelderMead{})
+		if err != nil {
+			return params, fmt.Errorf("Error fitting the smoothing parameters.\n%w", err)
+		}
+		if best == nil || result.F < best.F {
+			best = result
+		}
+	}
+	return set(best.X), nil
+}
@@ -0,0 +1,202 @@
+package statsanal
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gonum.org/v1/gonum/stat/distuv"
+)
+
+func TestMovingAverage(t *testing.T) {
+	x := []float64{1, 2, 3, 4, 5}
+
+	res, err := MovingAverage(x, 3, nil)
+	require.NoError(t, err)
+	require.Equal(t, 3, res.Window)
+	require.Nil(t, res.Values[0])
+	require.Nil(t, res.Values[1])
+	require.InDeltaSlice(t, []float64{2, 3, 4}, derefs(res.Values[2:]), 1e-12)
+
+	// the newest value weighs the most.
+	res, err = MovingAverage(x, 3, []float64{1, 2, 3})
+	require.NoError(t, err)
+	require.InDelta(t, 14.0/6, *res.Values[2], 1e-12)
+	require.InDelta(t, 26.0/6, *res.Values[4], 1e-12)
+	require.Equal(t, []float64{1, 2, 3}, res.Weights)
+
+	res, err = MovingAverage(x, 1, nil)
+	require.NoError(t, err)
+	require.InDeltaSlice(t, x, derefs(res.Values), 1e-12)
+
+	_, err = MovingAverage(x, 6, nil)
+	require.Error(t, err)
+
+	_, err = MovingAverage(x, 2, []float64{1})
+	require.Error(t, err)
+
+	_, err = MovingAverage(x, 2, []float64{1, -1})
+	require.Error(t, err)
+
+	_, err = MovingAverage(x, 2, []float64{0, 0})
+	require.Error(t, err)
+
+	_, err = MovingAverage([]float64{1, math.NaN(), 3}, 2, nil)
+	require.Error(t, err)
+}
+
+func TestExponentialSmoothing(t *testing.T) {
+	// simple smoothing with alpha 0.5 worked by hand.
+	x := []float64{3, 5, 9, 20, 12}
+	res, err := ExponentialSmoothing(x, SmoothingOptions{Alpha: 0.5, Horizon: 2})
+	require.NoError(t, err)
+	require.Equal(t, "simple", res.Method)
+	require.Nil(t, res.Beta)
+	require.Nil(t, res.Fitted[0])
+	require.InDeltaSlice(t, []float64{3, 4, 6.5, 13.25}, derefs(res.Fitted[1:]), 1e-12)
+	require.InDelta(t, 212.8125, res.SSE, 1e-9)
+	require.InDelta(t, math.Sqrt(212.8125/4), res.RMSE, 1e-9)
+	require.InDelta(t, 12.625, res.Level, 1e-12)
+	require.Equal(t, []float64{12.625, 12.625}, res.Forecast)
+	z := distuv.UnitNormal.Quantile(0.975)
+	sigma2 := 212.8125 / 4
+	require.InDelta(t, 12.625-z*math.Sqrt(sigma2), res.Lower[0], 1e-9)
+	require.InDelta(t, 12.625+z*math.Sqrt(sigma2*(1+0.25)), res.Upper[1], 1e-9)
+	require.Equal(t, DefaultConfidence, res.Confidence)
+
+	// the fitted alpha is no worse than any other.
+	res, err = ExponentialSmoothing(x, SmoothingOptions{})
+	require.NoError(t, err)
+	for _, alpha := range []float64{0.05, 0.25, 0.5, 0.75, 0.95, 1} {
+		other, err := ExponentialSmoothing(x, SmoothingOptions{Alpha: alpha})
+		require.NoError(t, err)
+		require.LessOrEqual(t, res.SSE, other.SSE+1e-6)
+	}
+	require.Nil(t, res.Forecast)
+
+	_, err = ExponentialSmoothing(x, SmoothingOptions{Alpha: 1.5})
+	require.Error(t, err)
+
+	_, err = ExponentialSmoothing(x, SmoothingOptions{Horizon: -1})
+	require.Error(t, err)
+
+	_, err = ExponentialSmoothing(x, SmoothingOptions{Confidence: 1})
+	require.Error(t, err)
+
+	_, err = ExponentialSmoothing(x[:1], SmoothingOptions{})
+	require.Error(t, err)
+
+	_, err = ExponentialSmoothing(x, SmoothingOptions{Period: 2})
+	require.Error(t, err)
+}
+
+func TestHolt(t *testing.T) {
+	// a line is forecast exactly.
+	x := []float64{1, 3, 5, 7, 9, 11}
+	res, err := ExponentialSmoothing(x, SmoothingOptions{Trend: true, Horizon: 3})
+	require.NoError(t, err)
+	require.Equal(t, "double", res.Method)
+	require.NotNil(t, res.Beta)
+	require.InDelta(t, 0, res.SSE, 1e-12)
+	require.InDelta(t, 2, *res.Trend, 1e-12)
+	require.InDeltaSlice(t, []float64{13, 15, 17}, res.Forecast, 1e-9)
+	require.InDeltaSlice(t, res.Forecast, res.Lower, 1e-9)
+	require.Nil(t, res.Fitted[1])
+	require.InDelta(t, 5, *res.Fitted[2], 1e-12)
+
+	// with fixed parameters, the h-step variance grows by (alpha(1+beta j))^2.
+	x = []float64{2, 4, 5, 9, 10, 14, 13, 18}
+	res, err = ExponentialSmoothing(
+		x, SmoothingOptions{Trend: true, Alpha: 0.6, Beta: 0.3, Horizon: 3})
+	require.NoError(t, err)
+	sigma2 := res.SSE / 6
+	z := distuv.UnitNormal.Quantile(0.975)
+	c1, c2 := 0.6*(1+0.3), 0.6*(1+0.6)
+	require.InDelta(t,
+		z*math.Sqrt(sigma2*(1+c1*c1+c2*c2)), res.Upper[2]-res.Forecast[2], 1e-9)
+	require.InDelta(t, res.Level+3**res.Trend, res.Forecast[2], 1e-12)
+}
+
+func TestHoltWinters(t *testing.T) {
+	// a trend plus a seasonal pattern of period 4 is forecast exactly.
+	pattern := []float64{3, -1, -4, 2}
+	x := make([]float64, 16)
+	for i := range x {
+		x[i] = 10 + 0.5*float64(i) + pattern[i%4]
+	}
+	res, err := ExponentialSmoothing(x, SmoothingOptions{
+		Trend:       true,
+		Seasonality: AdditiveSeasonality,
+		Period:      4,
+		Horizon:     6,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "holt_winters", res.Method)
+	require.Equal(t, 4, res.Period)
+	require.NotNil(t, res.Gamma)
+	require.InDelta(t, 0, res.SSE, 1e-9)
+	require.InDeltaSlice(t, pattern, res.Seasonals, 1e-9)
+	for h := 1; h <= 6; h++ {
+		i := 15 + h
+		require.InDelta(t, 10+0.5*float64(i)+pattern[i%4], res.Forecast[h-1], 1e-9)
+	}
+	require.Nil(t, res.Fitted[7])
+	require.NotNil(t, res.Fitted[8])
+
+	// a growing seasonal pattern is multiplicative.
+	factors := []float64{1.2, 0.9, 0.7, 1.2}
+	for i := range x {
+		x[i] = (10 + float64(i)) * factors[i%4]
+	}
+	mult, err := ExponentialSmoothing(x, SmoothingOptions{
+		Trend:       true,
+		Seasonality: MultiplicativeSeasonality,
+		Period:      4,
+	})
+	require.NoError(t, err)
+	add, err := ExponentialSmoothing(x, SmoothingOptions{
+		Trend:       true,
+		Seasonality: AdditiveSeasonality,
+		Period:      4,
+	})
+	require.NoError(t, err)
+	require.Less(t, mult.SSE, add.SSE)
+
+	// seasonal smoothing without trend.
+	res, err = ExponentialSmoothing(x, SmoothingOptions{
+		Seasonality: AdditiveSeasonality,
+		Period:      4,
+		Horizon:     1,
+	})
+	require.NoError(t, err)
+	require.Nil(t, res.Trend)
+	require.Len(t, res.Forecast, 1)
+
+	_, err = ExponentialSmoothing(x, SmoothingOptions{Seasonality: AdditiveSeasonality})
+	require.Error(t, err)
+
+	_, err = ExponentialSmoothing(x[:8], SmoothingOptions{
+		Seasonality: AdditiveSeasonality,
+		Period:      4,
+	})
+	require.Error(t, err)
+
+	_, err = ExponentialSmoothing(x, SmoothingOptions{Seasonality: "cyclic", Period: 4})
+	require.Error(t, err)
+
+	x[3] = -1
+	_, err = ExponentialSmoothing(x, SmoothingOptions{
+		Seasonality: MultiplicativeSeasonality,
+		Period:      4,
+	})
+	require.Error(t, err)
+}
+
+// derefs dereferences the values of `x`.
+func derefs(x []*float64) []float64 {
+	res := make([]float64, len(x))
+	for i, v := range x {
+		res[i] = *v
+	}
+	return res
+}
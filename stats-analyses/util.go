@@ -1,10 +1,16 @@
 package statsanal
 
 import (
+	"fmt"
+	"math"
+
 	"gonum.org/v1/gonum/mat"
 	"gonum.org/v1/gonum/stat"
 )
 
+// eps is the machine epsilon for float64.
+const eps = 0x1p-52
+
 // ones generates a slice of floats filled with 1
 func ones(rows, cols int) mat.Matrix {
 	f := make([]float64, rows*cols)
@@ -26,30 +32,106 @@ func sum(elem ...float64) float64 {
 	return s
 }
 
-// mean finds the mean of each column of matrix `m`
+// mean finds the mean of each column of matrix `m`, ignoring missing values.
+// The mean of a column without observed values is NaN.
 func mean(m mat.Matrix) []float64 {
 	var means []float64
 
 	_, c := m.Dims()
 
 	for i := 0; i < c; i++ {
-		col := mat.Col(nil, i, m)
+		col := observed(mat.Col(nil, i, m))
 		means = append(means, stat.Mean(col, nil))
 	}
 
 	return means
 }
 
-// varianc finds the variance of each column of matrix `m`
+// variance finds the unbiased variance of each column of matrix `m`,
+// ignoring missing values. The variance of a column with less than 2 observed
+// values is NaN.
 func variance(m mat.Matrix) []float64 {
 	var v []float64
 
 	_, c := m.Dims()
 
 	for i := 0; i < c; i++ {
-		col := mat.Col(nil, i, m)
+		col := observed(mat.Col(nil, i, m))
 		v = append(v, stat.Variance(col, nil))
 	}
 
 	return v
 }
+
+// ColumnNames generates positional names for `n` columns, `col_0`, `col_1`...
+func ColumnNames(n int) []string {
+	names := make([]string, n)
+	for i := range names {
+		names[i] = fmt.Sprintf("col_%d", i)
+	}
+
+	return names
+}
+
+// formatColumn renders slice `v` as a python formatted column vector with
+// 5 decimal places.
+func formatColumn(v []float64) string {
+	if len(v) == 0 {
+		return "[]"
+	}
+	m := mat.NewDense(len(v), 1, v)
+	return fmt.Sprintf("%.5f", mat.Formatted(m, mat.FormatPython()))
+}
+
+// SelectColumns copies the columns of matrix `m` at indices `cols`, in the
+// given order, into a new matrix.
+func SelectColumns(m mat.Matrix, cols []int) *mat.Dense {
+	r, _ := m.Dims()
+	res := mat.NewDense(r, len(cols), nil)
+	for j, col := range cols {
+		res.SetCol(j, mat.Col(nil, col, m))
+	}
+
+	return res
+}
+
+// median finds the median of the sorted slice `x`.
+func median(x []float64) float64 {
+	n := len(x)
+	if n%2 == 1 {
+		return x[n/2]
+	}
+	return (x[n/2-1] + x[n/2]) / 2
+}
+
+// quantile finds the `p` quantile of the sorted slice `x`, linearly
+// interpolating between the closest ranks.
+func quantile(x []float64, p float64) float64 {
+	h := float64(len(x)-1) * p
+	lo := math.Floor(h)
+	i := int(lo)
+	if i+1 >= len(x) {
+		return x[len(x)-1]
+	}
+	return x[i] + (h-lo)*(x[i+1]-x[i])
+}
+
+// scaleColumns scales the columns of matrix `m`, named `names`, in place to
+// zero mean and unit variance.
+//
+// Returns an error if a column is constant.
+func scaleColumns(m *mat.Dense, names []string) error {
+	r, c := m.Dims()
+	means := mean(m)
+	variances := variance(m)
+	for j := 0; j < c; j++ {
+		if !(variances[j] > 0) {
+			return fmt.Errorf("Can't standardize constant column %q.", names[j])
+		}
+		scale := math.Sqrt(variances[j])
+		for i := 0; i < r; i++ {
+			m.Set(i, j, (m.At(i, j)-means[j])/scale)
+		}
+	}
+	return nil
+}
@@ -22,6 +22,13 @@ type Config struct {
 	ServerAddr          string        `mapstructure:"SERVER_ADDRESS"`
 	TokenSymmetricKey   string        `mapstructure:"TOKEN_SYMMETRIC_KEY"`
 	AccessTokenDuration time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"`
+	// BlobStorage is where the files' data is kept: `postgres` or `fs`.
+	BlobStorage  string `mapstructure:"BLOB_STORAGE"`
+	BlobDir      string `mapstructure:"BLOB_DIR"`
+	BlobCompress bool   `mapstructure:"BLOB_COMPRESS"`
+	// Workers is the number of analysis jobs run concurrently.
+	Workers         int           `mapstructure:"WORKERS"`
+	JobPollInterval time.Duration `mapstructure:"JOB_POLL_INTERVAL"`
 }
 
 func LoadConfig(path string) (config Config, err error) {
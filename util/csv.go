@@ -4,66 +4,226 @@ import (
 	"encoding/csv"
 	"fmt"
 	"io"
+	"math"
 	"strconv"
 	"strings"
 )
 
-// ParseCSVToFloat parses a csv file containing numerical values in the supplied
-// reader. Returns a slice of floats containing the numerical values. The data are
-// stored in row major order. The numbers of rows and columns of the file is also
-// returned.
+// DefaultMissingTokens are the cell values, besides empty cells, read as
+// missing values when no tokens are given.
+var DefaultMissingTokens = []string{"NA", "NaN", "null"}
+
+// HeaderMode tells the csv parser whether the first row holds column names.
+type HeaderMode string
+
+const (
+	// HeaderAuto treats the first row as a header if any of its fields is
+	// not a number.
+	HeaderAuto    HeaderMode = "auto"
+	HeaderPresent HeaderMode = "true"
+	HeaderAbsent  HeaderMode = "false"
+)
+
+// ParseHeaderMode parses the header mode `s`, an empty string is HeaderAuto.
+// Returns an error for an unknown mode.
+func ParseHeaderMode(s string) (HeaderMode, error) {
+	switch mode := HeaderMode(strings.ToLower(strings.TrimSpace(s))); mode {
+	case "":
+		return HeaderAuto, nil
+	case HeaderAuto, HeaderPresent, HeaderAbsent:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("Unknown header mode %q, expected auto, true or false.", s)
+	}
+}
+
+// ParseMissingTokens parses a comma separated list of missing value tokens,
+// an empty string gives DefaultMissingTokens.
+func ParseMissingTokens(s string) []string {
+	if strings.TrimSpace(s) == "" {
+		return DefaultMissingTokens
+	}
+
+	var tokens []string
+	for _, token := range strings.Split(s, ",") {
+		if token = strings.TrimSpace(token); token != "" {
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens
+}
+
+// CSVOptions configures ParseCSV.
+type CSVOptions struct {
+	Header HeaderMode
+	// MissingTokens are the cell values, matched case insensitively, read
+	// as missing values. Empty cells are always missing values.
+	// Defaults to DefaultMissingTokens.
+	MissingTokens []string
+}
+
+// CSVData holds a parsed csv file. `Data` is stored in row major order, with
+// missing values stored as NaN, and `Names` holds the column names when the
+// file has a header, or is empty. `Missing` holds the number of missing values
+// in each column.
+type CSVData struct {
+	Rows    int
+	Cols    int
+	Names   []string
+	Data    []float64
+	Missing []int32
+}
+
+// ParseCSV parses a csv file containing numerical values, and optionally a
+// header row, in the supplied reader.
 //
-// An error, empty slice,  zero rows and columns are returned if an error occured
-// while parsing the csv string contained in the reader.
-func ParseCSVToFloatSlice(r io.Reader) (rows, cols int, data []float64, err error) {
-	reader := csv.NewReader(r)
+// An error is returned if an error occured while parsing the csv string
+// contained in the reader, or if the header has empty or repeated names.
+func ParseCSV(r io.Reader, opts CSVOptions) (res CSVData, err error) {
+	if opts.Header == "" {
+		opts.Header = HeaderAuto
+	}
+	if opts.MissingTokens == nil {
+		opts.MissingTokens = DefaultMissingTokens
+	}
+	missing := missingSet(opts.MissingTokens)
 
-	var record []string
+	reader := csv.NewReader(r)
+	res.Names = []string{}
+	res.Missing = []int32{}
 
 	for {
-		record, err = reader.Read()
-
+		record, err := reader.Read()
 		if err == io.EOF {
-			err = nil
-			return
+			return res, nil
+		}
+		if err != nil {
+			return CSVData{}, fmt.Errorf("Error parsing file.\n%v\n", err)
 		}
 
 		if len(record) == 0 {
 			continue
 		}
 
-		if (err != nil) && (err != io.EOF) {
-			return 0, 0, []float64{}, fmt.Errorf("Error parsing file.\n%v\n", err)
-		}
-
-		if (cols != 0) && (len(record) != cols) {
+		if (res.Cols != 0) && (len(record) != res.Cols) {
 			err = fmt.Errorf("All rows should have same length!!!")
-			return 0, 0, []float64{}, err
-		} else if cols == 0 {
-			cols = len(record)
+			return CSVData{}, err
+		} else if res.Cols == 0 {
+			res.Cols = len(record)
+			res.Missing = make([]int32, res.Cols)
+
+			if isHeader(record, opts.Header, missing) {
+				res.Names, err = parseHeader(record)
+				if err != nil {
+					return CSVData{}, err
+				}
+				continue
+			}
 		}
 
-		data, err = appendFloat(data, record)
+		res.Data, err = appendFloat(res.Data, record, missing, res.Missing)
 		if err != nil {
-			return 0, 0, []float64{}, err
+			return CSVData{}, fmt.Errorf("Error parsing row %d.\n%w", res.Rows+1, err)
+		}
+
+		res.Rows += 1
+	}
+}
+
+// ParseCSVToFloat parses a csv file containing numerical values in the supplied
+// reader. Returns a slice of floats containing the numerical values. The data are
+// stored in row major order, with missing values stored as NaN. The numbers of
+// rows and columns of the file is also returned.
+//
+// An error, empty slice,  zero rows and columns are returned if an error occured
+// while parsing the csv string contained in the reader.
+func ParseCSVToFloatSlice(r io.Reader) (rows, cols int, data []float64, err error) {
+	res, err := ParseCSV(r, CSVOptions{Header: HeaderAbsent})
+	if err != nil {
+		return 0, 0, []float64{}, err
+	}
+
+	return res.Rows, res.Cols, res.Data, nil
+}
+
+// missingSet builds a lookup set of the lower cased missing value `tokens`.
+func missingSet(tokens []string) map[string]bool {
+	set := map[string]bool{"": true}
+	for _, token := range tokens {
+		set[strings.ToLower(strings.TrimSpace(token))] = true
+	}
+	return set
+}
+
+// isHeader reports whether the first `record` of a file is a header.
+// Missing values are not taken as a hint of a header.
+func isHeader(record []string, mode HeaderMode, missing map[string]bool) bool {
+	switch mode {
+	case HeaderPresent:
+		return true
+	case HeaderAbsent:
+		return false
+	}
+
+	for _, elem := range record {
+		elem = strings.TrimSpace(elem)
+		if missing[strings.ToLower(elem)] {
+			continue
 		}
+		if _, err := strconv.ParseFloat(elem, 64); err != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// parseHeader returns the trimmed column names in `record`.
+//
+// An error is returned if a name is empty or repeated.
+func parseHeader(record []string) ([]string, error) {
+	names := make([]string, len(record))
+	seen := make(map[string]bool, len(record))
 
-		rows += 1
+	for i, elem := range record {
+		name := strings.TrimSpace(elem)
+		if name == "" {
+			return nil, fmt.Errorf("Header has an empty name at column %d.", i)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("Header has repeated name %q.", name)
+		}
+		seen[name] = true
+		names[i] = name
 	}
-	return
+	return names, nil
 }
 
 // appendFloat converts the record/row elements to float and appends
 // to the given slice. A slice is returned which might have underlying memory
 // as the passed slice if the passed slice has enough capacity for new elements.
+// Elements found in the `missing` set are appended as NaN and counted in
+// `counts`.
 //
-// An error is returned if the conversion fails.
-func appendFloat(data []float64, record []string) ([]float64, error) {
-	for _, elem := range record {
-		f, err := strconv.ParseFloat(strings.TrimSpace(elem), 64)
+// An error is returned if the conversion fails or gives an infinite or NaN
+// value.
+func appendFloat(
+	data []float64, record []string, missing map[string]bool, counts []int32,
+) ([]float64, error) {
+	for i, elem := range record {
+		elem = strings.TrimSpace(elem)
+		if missing[strings.ToLower(elem)] {
+			data = append(data, math.NaN())
+			counts[i]++
+			continue
+		}
+
+		f, err := strconv.ParseFloat(elem, 64)
 		if err != nil {
 			return data, err
 		}
+		if math.IsInf(f, 0) || math.IsNaN(f) {
+			return data, fmt.Errorf("Non-finite value %q at column %d.", elem, i)
+		}
 		data = append(data, f)
 	}
 	return data, nil
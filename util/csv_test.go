@@ -1,6 +1,7 @@
 package util
 
 import (
+	"math"
 	"strings"
 	"testing"
 
@@ -20,3 +21,128 @@ func TestParseCSVToFloatSlice(t *testing.T) {
 	require.Equal(t, c, cols)
 	require.Equal(t, r*c, len(data))
 }
+
+func TestParseCSVHeader(t *testing.T) {
+	r, c := 5, 3
+	body := RandomCSV(r, c)
+
+	testCases := []struct {
+		name   string
+		text   string
+		header HeaderMode
+		names  []string
+		hasErr bool
+	}{
+		{
+			name:   "AUTO WITH HEADER",
+			text:   "a, b ,c\n" + body,
+			header: HeaderAuto,
+			names:  []string{"a", "b", "c"},
+		},
+		{
+			name:   "AUTO WITHOUT HEADER",
+			text:   body,
+			header: HeaderAuto,
+			names:  []string{},
+		},
+		{
+			name:   "NUMERIC HEADER",
+			text:   "1,2,3\n" + body,
+			header: HeaderPresent,
+			names:  []string{"1", "2", "3"},
+		},
+		{
+			name:   "ABSENT HEADER",
+			text:   "a,b,c\n" + body,
+			header: HeaderAbsent,
+			hasErr: true,
+		},
+		{
+			name:   "REPEATED NAME",
+			text:   "a,b,a\n" + body,
+			header: HeaderAuto,
+			hasErr: true,
+		},
+		{
+			name:   "EMPTY NAME",
+			text:   "a,,c\n" + body,
+			header: HeaderAuto,
+			hasErr: true,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := ParseCSV(strings.NewReader(tc.text), CSVOptions{Header: tc.header})
+			if tc.hasErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.names, res.Names)
+			require.Equal(t, r, res.Rows)
+			require.Equal(t, c, res.Cols)
+			require.Len(t, res.Data, r*c)
+		})
+	}
+}
+
+func TestParseHeaderMode(t *testing.T) {
+	mode, err := ParseHeaderMode("")
+	require.NoError(t, err)
+	require.Equal(t, HeaderAuto, mode)
+
+	mode, err = ParseHeaderMode("True")
+	require.NoError(t, err)
+	require.Equal(t, HeaderPresent, mode)
+
+	_, err = ParseHeaderMode("yes")
+	require.Error(t, err)
+}
+
+func TestParseCSVMissing(t *testing.T) {
+	text := "a,b,c\n1,NA,3\n,5,null\n7,nan,9\n"
+
+	res, err := ParseCSV(strings.NewReader(text), CSVOptions{})
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "b", "c"}, res.Names)
+	require.Equal(t, []int32{1, 2, 1}, res.Missing)
+	require.True(t, math.IsNaN(res.Data[1]))
+	require.True(t, math.IsNaN(res.Data[3]))
+	require.True(t, math.IsNaN(res.Data[5]))
+	require.True(t, math.IsNaN(res.Data[7]))
+
+	text = "1,?,3\n4,NA,6\n"
+	_, err = ParseCSV(strings.NewReader(text), CSVOptions{Header: HeaderAbsent})
+	require.Error(t, err)
+
+	res, err = ParseCSV(
+		strings.NewReader(text),
+		CSVOptions{MissingTokens: ParseMissingTokens("na, ?")},
+	)
+	require.NoError(t, err)
+	require.Equal(t, []string{}, res.Names)
+	require.Equal(t, []int32{0, 2, 0}, res.Missing)
+	require.Equal(t, 2, res.Rows)
+}
+
+func TestParseCSVNonFinite(t *testing.T) {
+	opts := CSVOptions{Header: HeaderAbsent, MissingTokens: []string{"NA"}}
+
+	_, err := ParseCSV(strings.NewReader("1,NaN,3\n"), opts)
+	require.Error(t, err)
+
+	for _, token := range []string{"Inf", "+Infinity", "-inf"} {
+		_, err = ParseCSV(strings.NewReader("1,"+token+",3\n"), opts)
+		require.Error(t, err)
+	}
+
+	opts.MissingTokens = []string{"NaN", "Inf"}
+	res, err := ParseCSV(strings.NewReader("1,NaN,Inf\n"), opts)
+	require.NoError(t, err)
+	require.Equal(t, []int32{0, 1, 1}, res.Missing)
+	require.True(t, math.IsNaN(res.Data[1]))
+	require.True(t, math.IsNaN(res.Data[2]))
+}
@@ -1,7 +1,6 @@
 package util
 
 import (
-	"encoding/base64"
 	"fmt"
 	"math/rand"
 	"strconv"
@@ -75,25 +74,21 @@ func RandomCSV(rows, cols int) string {
 	return sampleCSV
 }
 
-// RandomData generate encoded random csv data.
+// RandomData generate binary encoded random csv data.
 //
-// Returns an empty string and an error if an error occurred while
+// Returns a nil slice and an error if an error occurred while
 // parsing randomly generated csv strings, or if an error occurred
 // while encoding the matrix gotten from the csv string.
-func RandomData() (string, error) {
+func RandomData() ([]byte, error) {
 	sample_text := RandomCSV(csvRows, csvCols)
 
 	reader := strings.NewReader(sample_text)
 
 	rows, cols, data, err := ParseCSVToFloatSlice(reader)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	m := mat.NewDense(rows, cols, data)
-	bytes, err := m.MarshalBinary()
-	if err != nil {
-		return "", err
-	}
-	return base64.StdEncoding.EncodeToString(bytes), nil
+	return m.MarshalBinary()
 }
@@ -0,0 +1,202 @@
+// Package worker runs the queued analysis jobs in the background.
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	db "github.com/yodeman/analyses-api/dbase/sqlc"
+)
+
+// Job statuses.
+const (
+	StatusQueued    = "queued"
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+	StatusCancelled = "cancelled"
+)
+
+const (
+	// DefaultSize is the number of workers when none is given.
+	DefaultSize = 4
+	// DefaultPollInterval is how often idle workers look for queued jobs
+	// when none is given.
+	DefaultPollInterval = 5 * time.Second
+)
+
+// RunFunc runs `job` and returns its json encoded result. `ctx` is cancelled
+// when the job is cancelled.
+type RunFunc func(ctx context.Context, job db.Job) (json.RawMessage, error)
+
+// Pool is a pool of workers running the jobs queued in the database. Jobs
+// are claimed from the database, so queued jobs survive restarts.
+type Pool struct {
+	store        db.Store
+	run          RunFunc
+	size         int
+	pollInterval time.Duration
+	wake         chan struct{}
+
+	mu      sync.Mutex
+	running map[int64]context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+// NewPool creates a pool of `size` workers running jobs with `run`. Idle
+// workers look for queued jobs every `pollInterval`, and whenever Notify is
+// called.
+func NewPool(store db.Store, size int, pollInterval time.Duration, run RunFunc) *Pool {
+	if size <= 0 {
+		size = DefaultSize
+	}
+	if pollInterval <= 0 {
+		pollInterval = DefaultPollInterval
+	}
+
+	return &Pool{
+		store:        store,
+		run:          run,
+		size:         size,
+		pollInterval: pollInterval,
+		wake:         make(chan struct{}, size),
+		running:      make(map[int64]context.CancelFunc),
+	}
+}
+
+// Start requeues the jobs left running by a previous run of the server and
+// starts the workers, which stop once `ctx` is done.
+func (pool *Pool) Start(ctx context.Context) error {
+	if _, err := pool.store.RequeueRunningJobs(ctx); err != nil {
+		return fmt.Errorf("Error requeuing running jobs.\n%w", err)
+	}
+
+	for i := 0; i < pool.size; i++ {
+		pool.wg.Add(1)
+		go func() {
+			defer pool.wg.Done()
+			pool.work(ctx)
+		}()
+	}
+	return nil
+}
+
+// Wait waits for the workers to stop.
+func (pool *Pool) Wait() {
+	pool.wg.Wait()
+}
+
+// Notify wakes an idle worker up to run a newly queued job.
+func (pool *Pool) Notify() {
+	select {
+	case pool.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Cancel stops the job with id `id` if it is running in this pool, and
+// reports whether it was.
+func (pool *Pool) Cancel(id int64) bool {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	cancel, ok := pool.running[id]
+	if ok {
+		cancel()
+	}
+	return ok
+}
+
+// work runs queued jobs until `ctx` is done.
+func (pool *Pool) work(ctx context.Context) {
+	ticker := time.NewTicker(pool.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		// run jobs until the queue is empty, then wait.
+		for ctx.Err() == nil {
+			ran, err := pool.RunNext(ctx)
+			if err != nil {
+				log.Printf("worker: %v\n", err)
+			}
+			if !ran {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-pool.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunNext claims the oldest queued job and runs it, and reports whether a
+// job was found.
+//
+// Returns an error if the job couldn't be claimed or its outcome saved.
+func (pool *Pool) RunNext(ctx context.Context) (bool, error) {
+	job, err := pool.store.ClaimJob(ctx)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, fmt.Errorf("Error claiming job.\n%w", err)
+	}
+
+	jobCtx, cancel := context.WithCancel(ctx)
+	pool.mu.Lock()
+	pool.running[job.ID] = cancel
+	pool.mu.Unlock()
+
+	result, runErr := pool.safeRun(jobCtx, job)
+
+	pool.mu.Lock()
+	delete(pool.running, job.ID)
+	pool.mu.Unlock()
+	cancel()
+
+	if ctx.Err() != nil {
+		// the server is stopping, the job is requeued on the next start.
+		return true, nil
+	}
+
+	params := db.FinishJobParams{
+		ID:     job.ID,
+		Status: StatusSucceeded,
+		Result: result,
+	}
+	if result == nil {
+		params.Result = json.RawMessage("null")
+	}
+	if runErr != nil {
+		params.Status = StatusFailed
+		params.Result = json.RawMessage("null")
+		params.Error = runErr.Error()
+	}
+
+	_, err = pool.store.FinishJob(ctx, params)
+	// a job cancelled while running is no longer running.
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return true, fmt.Errorf("Error saving job %d outcome.\n%w", job.ID, err)
+	}
+	return true, nil
+}
+
+// safeRun runs `job`, turning a panic into an error.
+func (pool *Pool) safeRun(ctx context.Context, job db.Job) (res json.RawMessage, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("Job panicked: %v", r)
+		}
+	}()
+	return pool.run(ctx, job)
+}
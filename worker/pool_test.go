@@ -0,0 +1,208 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	gomock "go.uber.org/mock/gomock"
+
+	mockdb "github.com/yodeman/analyses-api/dbase/mock"
+	db "github.com/yodeman/analyses-api/dbase/sqlc"
+)
+
+func TestRunNext(t *testing.T) {
+	job := db.Job{ID: 7, Username: "itachi", Kind: "regression", Status: StatusRunning}
+
+	testCases := []struct {
+		name       string
+		run        RunFunc
+		buildStubs func(store *mockdb.MockStore)
+		ran        bool
+		wantErr    bool
+	}{
+		{
+			name: "EMPTY QUEUE",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ClaimJob(gomock.Any()).
+					Times(1).
+					Return(db.Job{}, sql.ErrNoRows)
+				store.EXPECT().
+					FinishJob(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+		},
+		{
+			name: "CLAIM ERROR",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ClaimJob(gomock.Any()).
+					Times(1).
+					Return(db.Job{}, sql.ErrConnDone)
+			},
+			wantErr: true,
+		},
+		{
+			name: "SUCCEEDED",
+			run: func(ctx context.Context, job db.Job) (json.RawMessage, error) {
+				return json.RawMessage(`{"ok": true}`), nil
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ClaimJob(gomock.Any()).
+					Times(1).
+					Return(job, nil)
+				store.EXPECT().
+					FinishJob(gomock.Any(), gomock.Eq(db.FinishJobParams{
+						ID:     job.ID,
+						Status: StatusSucceeded,
+						Result: json.RawMessage(`{"ok": true}`),
+					})).
+					Times(1).
+					Return(db.Job{}, nil)
+			},
+			ran: true,
+		},
+		{
+			name: "FAILED",
+			run: func(ctx context.Context, job db.Job) (json.RawMessage, error) {
+				return nil, errors.New("bad data")
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ClaimJob(gomock.Any()).
+					Times(1).
+					Return(job, nil)
+				store.EXPECT().
+					FinishJob(gomock.Any(), gomock.Eq(db.FinishJobParams{
+						ID:     job.ID,
+						Status: StatusFailed,
+						Result: json.RawMessage("null"),
+						Error:  "bad data",
+					})).
+					Times(1).
+					Return(db.Job{}, nil)
+			},
+			ran: true,
+		},
+		{
+			name: "PANICKED",
+			run: func(ctx context.Context, job db.Job) (json.RawMessage, error) {
+				panic("index out of range")
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ClaimJob(gomock.Any()).
+					Times(1).
+					Return(job, nil)
+				store.EXPECT().
+					FinishJob(gomock.Any(), gomock.Eq(db.FinishJobParams{
+						ID:     job.ID,
+						Status: StatusFailed,
+						Result: json.RawMessage("null"),
+						Error:  "Job panicked: index out of range",
+					})).
+					Times(1).
+					Return(db.Job{}, nil)
+			},
+			ran: true,
+		},
+		{
+			name: "CANCELLED WHILE RUNNING",
+			run: func(ctx context.Context, job db.Job) (json.RawMessage, error) {
+				return nil, context.Canceled
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ClaimJob(gomock.Any()).
+					Times(1).
+					Return(job, nil)
+				store.EXPECT().
+					FinishJob(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Job{}, sql.ErrNoRows)
+			},
+			ran: true,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			pool := NewPool(store, 1, time.Minute, tc.run)
+			ran, err := pool.RunNext(context.Background())
+			require.Equal(t, tc.ran, ran)
+			if tc.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestPoolCancel(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	job := db.Job{ID: 3, Username: "itachi", Kind: "regression", Status: StatusRunning}
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		RequeueRunningJobs(gomock.Any()).
+		Times(1).
+		Return(int64(0), nil)
+	store.EXPECT().
+		ClaimJob(gomock.Any()).
+		Return(job, nil).
+		Times(1)
+	store.EXPECT().
+		ClaimJob(gomock.Any()).
+		Return(db.Job{}, sql.ErrNoRows).
+		AnyTimes()
+
+	started := make(chan struct{})
+	finished := make(chan error, 1)
+	store.EXPECT().
+		FinishJob(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(ctx context.Context, arg db.FinishJobParams) (db.Job, error) {
+			finished <- errors.New(arg.Error)
+			return db.Job{}, sql.ErrNoRows
+		})
+
+	pool := NewPool(store, 1, time.Minute, func(ctx context.Context, job db.Job) (json.RawMessage, error) {
+		close(started)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+
+	ctx, stop := context.WithCancel(context.Background())
+	require.NoError(t, pool.Start(ctx))
+
+	<-started
+	require.False(t, pool.Cancel(job.ID+1))
+	require.True(t, pool.Cancel(job.ID))
+
+	select {
+	case err := <-finished:
+		require.EqualError(t, err, context.Canceled.Error())
+	case <-time.After(time.Second):
+		t.Fatal("cancelled job did not stop")
+	}
+
+	stop()
+	pool.Wait()
+	require.False(t, pool.Cancel(job.ID))
+}